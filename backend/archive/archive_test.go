@@ -0,0 +1,145 @@
+package archive_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/archive"
+)
+
+type record struct {
+	N int `json:"n"`
+}
+
+func TestWriteRead(t *testing.T) {
+	ctx := context.Background()
+	storage, err := archive.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := archive.NewWriter(storage, "test", archive.WithMaxRecords(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 records spread over two hourly partitions
+	base := time.Date(2024, 3, 1, 11, 50, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		if err := writer.Write(base.Add(time.Duration(i)*2*time.Minute), &record{N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := storage.List(ctx, "test/2024/03/01/12/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 {
+		t.Fatal("expected files in the 12h partition")
+	}
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".ndjson.zst") && !strings.HasSuffix(key, ".manifest.json") {
+			t.Fatalf("unexpected key %v", key)
+		}
+	}
+
+	reader := archive.NewReader(storage, "test")
+	manifests, err := reader.Manifests(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, m := range manifests {
+		if m.Records > 4 {
+			t.Fatalf("file %v has %v records, more than the maximum", m.Key, m.Records)
+		}
+		total += m.Records
+	}
+	if total != 10 {
+		t.Fatalf("expected 10 archived records, got %v", total)
+	}
+
+	seen := make([]int, 0)
+	from := base.Add(4 * time.Minute)
+	to := base.Add(14 * time.Minute)
+	err = archive.Read(ctx, reader, from, to, func(ts time.Time, r *record) error {
+		seen = append(seen, r.N)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{2, 3, 4, 5, 6}
+	if len(seen) != len(expected) {
+		t.Fatalf("expected records %v, got %v", expected, seen)
+	}
+	for i := range expected {
+		if seen[i] != expected[i] {
+			t.Fatalf("expected records %v, got %v", expected, seen)
+		}
+	}
+}
+
+// Storage that fails uploads while fail is set
+type failingStorage struct {
+	archive.Storage
+	fail atomic.Bool
+}
+
+func (s *failingStorage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if s.fail.Load() {
+		return errors.New("unavailable")
+	}
+	return s.Storage.Put(ctx, key, r, size)
+}
+
+func TestWriterDropsOldestPending(t *testing.T) {
+	ctx := context.Background()
+	local, err := archive.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &failingStorage{Storage: local}
+	storage.fail.Store(true)
+
+	// every file is over the maximum pending size so only the newest one is kept
+	writer, err := archive.NewWriter(storage, "test", archive.WithMaxRecords(1), archive.WithMaxPendingSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		if err := writer.Write(base.Add(time.Duration(i)*time.Minute), &record{N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(ctx); err == nil {
+		t.Fatal("expected the upload to fail")
+	}
+
+	storage.fail.Store(false)
+	if err := writer.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make([]int, 0)
+	err = archive.Read(ctx, archive.NewReader(storage, "test"), time.Time{}, time.Time{}, func(ts time.Time, r *record) error {
+		seen = append(seen, r.N)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0] != 9 {
+		t.Fatalf("expected only the newest record to be uploaded, got %v", seen)
+	}
+}
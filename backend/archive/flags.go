@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	FLAG_ARCHIVE_DIR = &cli.StringFlag{
+		Name:    "archive-dir",
+		Usage:   "local directory used to archive raw telemetry",
+		EnvVars: []string{"ARCHIVE_DIR"},
+	}
+
+	FLAG_ARCHIVE_S3_ENDPOINT = &cli.StringFlag{
+		Name:    "archive-s3-endpoint",
+		Usage:   "host[:port] of the S3 compatible endpoint used to archive raw telemetry",
+		EnvVars: []string{"ARCHIVE_S3_ENDPOINT"},
+	}
+
+	FLAG_ARCHIVE_S3_BUCKET = &cli.StringFlag{
+		Name:    "archive-s3-bucket",
+		Usage:   "S3 bucket used to archive raw telemetry, created if missing",
+		EnvVars: []string{"ARCHIVE_S3_BUCKET"},
+		Value:   "telemetry-archive",
+	}
+
+	FLAG_ARCHIVE_S3_PREFIX = &cli.StringFlag{
+		Name:    "archive-s3-prefix",
+		Usage:   "prefix prepended to every archived object key",
+		EnvVars: []string{"ARCHIVE_S3_PREFIX"},
+	}
+
+	FLAG_ARCHIVE_S3_REGION = &cli.StringFlag{
+		Name:    "archive-s3-region",
+		EnvVars: []string{"ARCHIVE_S3_REGION"},
+	}
+
+	FLAG_ARCHIVE_S3_ACCESS_KEY = &cli.StringFlag{
+		Name:    "archive-s3-access-key",
+		EnvVars: []string{"ARCHIVE_S3_ACCESS_KEY"},
+	}
+
+	FLAG_ARCHIVE_S3_SECRET_KEY = &cli.StringFlag{
+		Name:    "archive-s3-secret-key",
+		EnvVars: []string{"ARCHIVE_S3_SECRET_KEY"},
+	}
+
+	FLAG_ARCHIVE_S3_INSECURE = &cli.BoolFlag{
+		Name:    "archive-s3-insecure",
+		Usage:   "use plain http to connect to the S3 endpoint",
+		EnvVars: []string{"ARCHIVE_S3_INSECURE"},
+	}
+
+	FLAG_ARCHIVE_MAX_RECORDS = &cli.IntFlag{
+		Name:    "archive-max-records",
+		Usage:   "maximum number of records in a single archive file",
+		EnvVars: []string{"ARCHIVE_MAX_RECORDS"},
+		Value:   DEFAULT_MAX_RECORDS,
+	}
+
+	FLAG_ARCHIVE_ROLLOVER_INTERVAL = &cli.DurationFlag{
+		Name:    "archive-rollover-interval",
+		Usage:   "maximum amount of time an archive file is kept open before being uploaded",
+		EnvVars: []string{"ARCHIVE_ROLLOVER_INTERVAL"},
+		Value:   DEFAULT_ROLLOVER_INTERVAL,
+	}
+
+	FLAG_ARCHIVE_MAX_PENDING_SIZE = &cli.Int64Flag{
+		Name:    "archive-max-pending-size",
+		Usage:   "maximum size in bytes of the archive files kept in memory while waiting to be uploaded, the oldest are dropped past it",
+		EnvVars: []string{"ARCHIVE_MAX_PENDING_SIZE"},
+		Value:   DEFAULT_MAX_PENDING_SIZE,
+	}
+
+	FLAG_ARCHIVE_UPLOAD_TIMEOUT = &cli.DurationFlag{
+		Name:    "archive-upload-timeout",
+		Usage:   "maximum amount of time spent uploading a single archive file",
+		EnvVars: []string{"ARCHIVE_UPLOAD_TIMEOUT"},
+		Value:   DEFAULT_UPLOAD_TIMEOUT,
+	}
+
+	// Flags used to select the archive storage
+	StorageFlags = []cli.Flag{
+		FLAG_ARCHIVE_DIR,
+		FLAG_ARCHIVE_S3_ENDPOINT,
+		FLAG_ARCHIVE_S3_BUCKET,
+		FLAG_ARCHIVE_S3_PREFIX,
+		FLAG_ARCHIVE_S3_REGION,
+		FLAG_ARCHIVE_S3_ACCESS_KEY,
+		FLAG_ARCHIVE_S3_SECRET_KEY,
+		FLAG_ARCHIVE_S3_INSECURE,
+	}
+
+	// Flags used to configure an archive writer
+	WriterFlags = []cli.Flag{
+		FLAG_ARCHIVE_MAX_RECORDS,
+		FLAG_ARCHIVE_ROLLOVER_INTERVAL,
+		FLAG_ARCHIVE_MAX_PENDING_SIZE,
+		FLAG_ARCHIVE_UPLOAD_TIMEOUT,
+	}
+)
+
+// Create the storage selected by the archive flags.
+// Returns nil if archiving is not enabled.
+func StorageFromFlags(c *cli.Context) (Storage, error) {
+	dir := c.String(FLAG_ARCHIVE_DIR.Name)
+	endpoint := c.String(FLAG_ARCHIVE_S3_ENDPOINT.Name)
+
+	switch {
+	case dir != "" && endpoint != "":
+		return nil, fmt.Errorf("only one of --%s and --%s can be set", FLAG_ARCHIVE_DIR.Name, FLAG_ARCHIVE_S3_ENDPOINT.Name)
+	case dir != "":
+		return NewLocalStorage(dir)
+	case endpoint != "":
+		return NewS3Storage(c.Context, S3Config{
+			Endpoint:  endpoint,
+			Bucket:    c.String(FLAG_ARCHIVE_S3_BUCKET.Name),
+			AccessKey: c.String(FLAG_ARCHIVE_S3_ACCESS_KEY.Name),
+			SecretKey: c.String(FLAG_ARCHIVE_S3_SECRET_KEY.Name),
+			Region:    c.String(FLAG_ARCHIVE_S3_REGION.Name),
+			Insecure:  c.Bool(FLAG_ARCHIVE_S3_INSECURE.Name),
+			Prefix:    c.String(FLAG_ARCHIVE_S3_PREFIX.Name),
+		})
+	default:
+		return nil, nil
+	}
+}
+
+// Writer options from the archive flags
+func WriterOptionsFromFlags(c *cli.Context) []Option {
+	return []Option{
+		WithMaxRecords(c.Int(FLAG_ARCHIVE_MAX_RECORDS.Name)),
+		WithRolloverInterval(c.Duration(FLAG_ARCHIVE_ROLLOVER_INTERVAL.Name)),
+		WithMaxPendingSize(c.Int64(FLAG_ARCHIVE_MAX_PENDING_SIZE.Name)),
+		WithUploadTimeout(c.Duration(FLAG_ARCHIVE_UPLOAD_TIMEOUT.Name)),
+	}
+}
@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	FormatNdjsonZstd = "ndjson+zstd"
+
+	ManifestVersion = 1
+
+	manifestSuffix   = ".manifest.json"
+	ndjsonZstdSuffix = ".ndjson.zst"
+)
+
+// Manifest describes a single archived data file.
+// It is written after the data file so a data file without a manifest is incomplete and ignored by readers.
+type Manifest struct {
+	Version int    `json:"version"`
+	Dataset string `json:"dataset"`
+	Format  string `json:"format"`
+	// Key of the data file this manifest refers to
+	Key string `json:"key"`
+	// Lowercase hex encoded sha256 of the data file
+	Sha256            string    `json:"sha256"`
+	Size              int64     `json:"size"`
+	UncompressedSize  int64     `json:"uncompressed_size"`
+	Records           int       `json:"records"`
+	MinTimestamp      time.Time `json:"min_timestamp"`
+	MaxTimestamp      time.Time `json:"max_timestamp"`
+	CreatedAt         time.Time `json:"created_at"`
+	PartitionInterval string    `json:"partition_interval"`
+}
+
+// Returns true if any of the records in this file could have a timestamp in [from, to).
+// A zero from or to leaves that side of the range unbounded.
+func (m *Manifest) Overlaps(from, to time.Time) bool {
+	if !to.IsZero() && !m.MinTimestamp.Before(to) {
+		return false
+	}
+	if !from.IsZero() && m.MaxTimestamp.Before(from) {
+		return false
+	}
+	return true
+}
+
+// Key prefix for all files of a dataset in the partition containing t.
+func partitionPrefix(dataset string, t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s/%04d/%02d/%02d/%02d/", dataset, t.Year(), t.Month(), t.Day(), t.Hour())
+}
+
+func dayPrefix(dataset string, t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s/%04d/%02d/%02d/", dataset, t.Year(), t.Month(), t.Day())
+}
+
+func manifestKey(dataKey string) string {
+	return strings.TrimSuffix(dataKey, ndjsonZstdSuffix) + manifestSuffix
+}
+
+func isManifestKey(key string) bool {
+	return strings.HasSuffix(key, manifestSuffix)
+}
@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DEFAULT_MAX_RECORDS       = 10_000
+	DEFAULT_MAX_SIZE          = 256 * 1024 * 1024
+	DEFAULT_ROLLOVER_INTERVAL = time.Minute * 15
+	DEFAULT_MAX_PENDING_SIZE  = 1024 * 1024 * 1024
+	DEFAULT_UPLOAD_TIMEOUT    = time.Minute * 2
+)
+
+type Option func(*options) error
+
+type options struct {
+	// Maximum number of records in a single file
+	maxRecords int
+	// Maximum uncompressed size of a single file
+	maxSize int64
+	// Maximum amount of time a file is kept open before being uploaded
+	rolloverInterval time.Duration
+	// Maximum compressed size of the files waiting to be uploaded
+	maxPendingSize int64
+	// Maximum amount of time spent uploading a single file
+	uploadTimeout time.Duration
+	logger        *zap.Logger
+}
+
+func defaults() *options {
+	return &options{
+		maxRecords:       DEFAULT_MAX_RECORDS,
+		maxSize:          DEFAULT_MAX_SIZE,
+		rolloverInterval: DEFAULT_ROLLOVER_INTERVAL,
+		maxPendingSize:   DEFAULT_MAX_PENDING_SIZE,
+		uploadTimeout:    DEFAULT_UPLOAD_TIMEOUT,
+		logger:           zap.NewNop(),
+	}
+}
+
+func apply(opts *options, o ...Option) error {
+	for _, opt := range o {
+		if err := opt(opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WithMaxRecords(n int) Option {
+	return func(o *options) error {
+		if n <= 0 {
+			return fmt.Errorf("max records must be positive")
+		}
+		o.maxRecords = n
+		return nil
+	}
+}
+
+func WithMaxSize(size int64) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("max size must be positive")
+		}
+		o.maxSize = size
+		return nil
+	}
+}
+
+func WithRolloverInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return fmt.Errorf("rollover interval must be positive")
+		}
+		o.rolloverInterval = interval
+		return nil
+	}
+}
+
+func WithMaxPendingSize(size int64) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("max pending size must be positive")
+		}
+		o.maxPendingSize = size
+		return nil
+	}
+}
+
+func WithUploadTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		if timeout <= 0 {
+			return fmt.Errorf("upload timeout must be positive")
+		}
+		o.uploadTimeout = timeout
+		return nil
+	}
+}
+
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) error {
+		o.logger = l
+		return nil
+	}
+}
@@ -0,0 +1,203 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Maximum size of a single archived record
+const maxLineSize = 64 * 1024 * 1024
+
+// Reader reads the files of a dataset written by a Writer.
+type Reader struct {
+	storage Storage
+	dataset string
+}
+
+func NewReader(storage Storage, dataset string) *Reader {
+	return &Reader{storage: storage, dataset: dataset}
+}
+
+// List the manifests of all files that may contain records in [from, to), sorted by their minimum timestamp.
+// A zero from or to leaves that side of the range unbounded.
+func (r *Reader) Manifests(ctx context.Context, from, to time.Time) ([]Manifest, error) {
+	prefixes := []string{r.dataset + "/"}
+	if !from.IsZero() && !to.IsZero() {
+		prefixes = prefixes[:0]
+		// files are partitioned by the timestamp of their records so listing
+		// the days in the range is enough to find every candidate file
+		for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.Add(24 * time.Hour) {
+			prefixes = append(prefixes, dayPrefix(r.dataset, day))
+		}
+	}
+
+	manifests := make([]Manifest, 0)
+	for _, prefix := range prefixes {
+		keys, err := r.storage.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if !isManifestKey(key) {
+				continue
+			}
+			m, err := r.manifest(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if m.Overlaps(from, to) {
+				manifests = append(manifests, m)
+			}
+		}
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifests[i].MinTimestamp.Before(manifests[j].MinTimestamp)
+	})
+	return manifests, nil
+}
+
+// Iterate over the records of the file described by the manifest.
+// The file is verified against the manifest checksum before any record is returned.
+func (r *Reader) Open(ctx context.Context, m Manifest) (*Records, error) {
+	if m.Format != FormatNdjsonZstd {
+		return nil, fmt.Errorf("unsupported archive format %q in %v", m.Format, m.Key)
+	}
+
+	obj, err := r.storage.Get(ctx, m.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(data)
+	if hex.EncodeToString(digest[:]) != m.Sha256 {
+		return nil, fmt.Errorf("checksum mismatch for archive file %v", m.Key)
+	}
+
+	dec, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(dec)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Records{dec: dec, scanner: scanner}, nil
+}
+
+func (r *Reader) manifest(ctx context.Context, key string) (Manifest, error) {
+	obj, err := r.storage.Get(ctx, key)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer obj.Close()
+
+	m := Manifest{}
+	if err := json.NewDecoder(obj).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest %v: %w", key, err)
+	}
+	if m.Version > ManifestVersion {
+		return Manifest{}, fmt.Errorf("manifest %v has unsupported version %v", key, m.Version)
+	}
+	return m, nil
+}
+
+// Records is an iterator over the records of an archived file.
+type Records struct {
+	dec     *zstd.Decoder
+	scanner *bufio.Scanner
+	current line
+	err     error
+}
+
+// Advance to the next record. Returns false when there are no more records or an error occurred.
+func (r *Records) Next() bool {
+	if r.err != nil || !r.scanner.Scan() {
+		return false
+	}
+	r.current = line{}
+	if err := json.Unmarshal(r.scanner.Bytes(), &r.current); err != nil {
+		r.err = err
+		return false
+	}
+	return true
+}
+
+// Timestamp of the current record
+func (r *Records) Timestamp() time.Time {
+	return r.current.Timestamp
+}
+
+// Raw JSON of the current record
+func (r *Records) Bytes() []byte {
+	return r.current.Record
+}
+
+// Decode the current record into v
+func (r *Records) Decode(v any) error {
+	return json.Unmarshal(r.current.Record, v)
+}
+
+func (r *Records) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.scanner.Err()
+}
+
+func (r *Records) Close() {
+	r.dec.Close()
+}
+
+// Read every record of the dataset with a timestamp in [from, to), in file order, and call fn with each one.
+// A zero from or to leaves that side of the range unbounded.
+func Read[T any](ctx context.Context, r *Reader, from, to time.Time, fn func(time.Time, *T) error) error {
+	manifests, err := r.Manifests(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range manifests {
+		records, err := r.Open(ctx, m)
+		if err != nil {
+			return err
+		}
+		for records.Next() {
+			ts := records.Timestamp()
+			if (!from.IsZero() && ts.Before(from)) || (!to.IsZero() && !ts.Before(to)) {
+				continue
+			}
+			value := new(T)
+			if err := records.Decode(value); err != nil {
+				records.Close()
+				return fmt.Errorf("invalid record in %v: %w", m.Key, err)
+			}
+			if err := fn(ts, value); err != nil {
+				records.Close()
+				return err
+			}
+		}
+		err = records.Err()
+		records.Close()
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ (Storage) = (*S3Storage)(nil)
+
+type S3Config struct {
+	// host[:port] of the S3 compatible endpoint, without scheme
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string
+	// Use plain http instead of https
+	Insecure bool
+	// Prefix prepended to every key
+	Prefix string
+}
+
+// S3Storage stores objects in a bucket of an S3 compatible endpoint, like MinIO.
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// Create a new S3 storage, creating the bucket if it does not already exist.
+func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: !cfg.Insecure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3Storage{client: client, bucket: cfg.Bucket, prefix: prefix}, nil
+}
+
+// Put implements Storage
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.prefix+key, r, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	return err
+}
+
+// Get implements Storage
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.prefix+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy, Stat forces the request so missing objects are reported here
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// List implements Storage
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.prefix + prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, strings.TrimPrefix(obj.Key, s.prefix))
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
@@ -0,0 +1,292 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+)
+
+const partitionInterval = time.Hour
+
+// A single line of an archived data file.
+type line struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Record    json.RawMessage `json:"record"`
+}
+
+type openFile struct {
+	key          string
+	partition    time.Time
+	createdAt    time.Time
+	buf          bytes.Buffer
+	enc          *zstd.Encoder
+	records      int
+	uncompressed int64
+	minTs        time.Time
+	maxTs        time.Time
+}
+
+type closedFile struct {
+	data     []byte
+	manifest Manifest
+}
+
+// Writer archives timestamped records of a dataset into time partitioned, zstd compressed, ndjson files.
+// Files are closed when they reach the maximum number of records, the maximum size,
+// the rollover interval or when a record belonging to a different partition is written.
+// Closed files are uploaded to the storage by a background goroutine, writing a record never waits on the storage.
+type Writer struct {
+	mu      sync.Mutex
+	logger  *zap.Logger
+	storage Storage
+	dataset string
+	opts    *options
+
+	current *openFile
+	// closed files waiting to be uploaded, the oldest are dropped once their size exceeds the maximum
+	pending     []*closedFile
+	pendingSize int64
+
+	// serializes uploads between the background goroutine and Flush
+	uploadMu sync.Mutex
+	notify   chan struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewWriter(storage Storage, dataset string, o ...Option) (*Writer, error) {
+	opts := defaults()
+	if err := apply(opts, o...); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Writer{
+		logger:  opts.logger,
+		storage: storage,
+		dataset: dataset,
+		opts:    opts,
+		notify:  make(chan struct{}, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go w.run(ctx)
+	return w, nil
+}
+
+// Write a record with the given timestamp. The record is encoded as json and appended to the current file in memory.
+func (w *Writer) Write(timestamp time.Time, record any) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	marshaled, err := json.Marshal(&line{Timestamp: timestamp.UTC(), Record: encoded})
+	if err != nil {
+		return err
+	}
+	marshaled = append(marshaled, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	partition := timestamp.UTC().Truncate(partitionInterval)
+	if w.current != nil && !w.current.partition.Equal(partition) {
+		w.rollover()
+	}
+	if w.current == nil {
+		f, err := w.newFile(partition)
+		if err != nil {
+			return err
+		}
+		w.current = f
+	}
+
+	f := w.current
+	if _, err := f.enc.Write(marshaled); err != nil {
+		return err
+	}
+	f.records += 1
+	f.uncompressed += int64(len(marshaled))
+	if f.minTs.IsZero() || timestamp.Before(f.minTs) {
+		f.minTs = timestamp.UTC()
+	}
+	if timestamp.After(f.maxTs) {
+		f.maxTs = timestamp.UTC()
+	}
+
+	if f.records >= w.opts.maxRecords || f.uncompressed >= w.opts.maxSize {
+		w.rollover()
+	}
+	return nil
+}
+
+// Close the current file and upload all pending files.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	w.rollover()
+	w.mu.Unlock()
+	return w.upload(ctx)
+}
+
+// Stop the writer and flush any buffered records.
+func (w *Writer) Close(ctx context.Context) error {
+	w.cancel()
+	<-w.done
+	return w.Flush(ctx)
+}
+
+func (w *Writer) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.rolloverInterval / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.current != nil && time.Since(w.current.createdAt) >= w.opts.rolloverInterval {
+				w.rollover()
+			}
+			w.mu.Unlock()
+		case <-w.notify:
+		}
+
+		if err := w.upload(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Warn("failed to upload archive files", zap.String("dataset", w.dataset), zap.Error(err))
+		}
+	}
+}
+
+func (w *Writer) newFile(partition time.Time) (*openFile, error) {
+	f := &openFile{
+		partition: partition,
+		createdAt: time.Now().UTC(),
+	}
+	enc, err := zstd.NewWriter(&f.buf, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	f.enc = enc
+	f.key = fmt.Sprintf("%s%d-%s%s", partitionPrefix(w.dataset, partition), f.createdAt.UnixNano(), hex.EncodeToString(suffix), ndjsonZstdSuffix)
+	return f, nil
+}
+
+// Close the current file, if any, move it to the pending upload list and wake up the background goroutine.
+// Must be called with the lock held.
+func (w *Writer) rollover() {
+	f := w.current
+	if f == nil {
+		return
+	}
+	w.current = nil
+
+	if err := f.enc.Close(); err != nil {
+		w.logger.Error("failed to finish zstd stream, dropping archive file", zap.String("key", f.key), zap.Int("records", f.records), zap.Error(err))
+		return
+	}
+
+	data := f.buf.Bytes()
+	digest := sha256.Sum256(data)
+	w.pending = append(w.pending, &closedFile{
+		data: data,
+		manifest: Manifest{
+			Version:           ManifestVersion,
+			Dataset:           w.dataset,
+			Format:            FormatNdjsonZstd,
+			Key:               f.key,
+			Sha256:            hex.EncodeToString(digest[:]),
+			Size:              int64(len(data)),
+			UncompressedSize:  f.uncompressed,
+			Records:           f.records,
+			MinTimestamp:      f.minTs,
+			MaxTimestamp:      f.maxTs,
+			CreatedAt:         f.createdAt,
+			PartitionInterval: partitionInterval.String(),
+		},
+	})
+	w.pendingSize += int64(len(data))
+
+	for w.pendingSize > w.opts.maxPendingSize && len(w.pending) > 1 {
+		dropped := w.pending[0]
+		w.removePending(dropped)
+		w.logger.Error("too many archive files waiting to be uploaded, dropping the oldest",
+			zap.String("key", dropped.manifest.Key),
+			zap.Int("records", dropped.manifest.Records),
+			zap.Int64("max-pending-size", w.opts.maxPendingSize))
+	}
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Must be called with the lock held.
+func (w *Writer) removePending(f *closedFile) {
+	for i, p := range w.pending {
+		if p == f {
+			w.pending = append(w.pending[:i], w.pending[i+1:]...)
+			w.pendingSize -= int64(len(f.data))
+			return
+		}
+	}
+}
+
+// Upload all pending files, each one with at most the upload timeout. Files that fail to upload are kept for the next attempt.
+// The lock is only held to pick the next file so writes are not blocked by the storage.
+func (w *Writer) upload(ctx context.Context) error {
+	w.uploadMu.Lock()
+	defer w.uploadMu.Unlock()
+
+	for {
+		w.mu.Lock()
+		if len(w.pending) == 0 {
+			w.mu.Unlock()
+			return nil
+		}
+		f := w.pending[0]
+		w.mu.Unlock()
+
+		if err := w.put(ctx, f); err != nil {
+			return err
+		}
+		w.logger.Info("uploaded archive file",
+			zap.String("key", f.manifest.Key),
+			zap.Int("records", f.manifest.Records),
+			zap.Int64("size", f.manifest.Size))
+
+		w.mu.Lock()
+		w.removePending(f)
+		w.mu.Unlock()
+	}
+}
+
+func (w *Writer) put(ctx context.Context, f *closedFile) error {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.uploadTimeout)
+	defer cancel()
+
+	if err := w.storage.Put(ctx, f.manifest.Key, bytes.NewReader(f.data), int64(len(f.data))); err != nil {
+		return err
+	}
+	manifest, err := json.Marshal(&f.manifest)
+	if err != nil {
+		return err
+	}
+	return w.storage.Put(ctx, manifestKey(f.manifest.Key), bytes.NewReader(manifest), int64(len(manifest)))
+}
@@ -2,10 +2,12 @@ package backend
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
 	"github.com/jackc/pgx/v5"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
@@ -41,9 +43,21 @@ func ServiceSetup(c *cli.Context, name string) *zap.Logger {
 	runtime.Start(runtime.WithMeterProvider(provider))
 	otel.SetMeterProvider(provider)
 
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", serviceHealth.liveness)
+	mux.HandleFunc("/readyz", serviceHealth.readiness)
+	server := &http.Server{Addr: c.String(Flag_PrometheusAddress.Name), Handler: mux}
 	go func() {
-		http.Handle("/metrics", promhttp.Handler())
-		logger.Fatal("failed to create prometheus http server", zap.Error(http.ListenAndServe(c.String(Flag_PrometheusAddress.Name), nil)))
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("failed to create prometheus http server", zap.Error(err))
+		}
+	}()
+	// the context is canceled on SIGTERM, the service stops being ready while it finishes its work
+	go func() {
+		<-c.Context.Done()
+		serviceHealth.stopping.Store(true)
+		logger.Info("shutting down")
 	}()
 
 	return logger
@@ -52,13 +66,33 @@ func ServiceSetup(c *cli.Context, name string) *zap.Logger {
 func NatsClient(logger *zap.Logger, c *cli.Context) *nats.Conn {
 	natsUrl := c.String(Flag_NatsUrl.Name)
 	logger.Info("connecting to nats", zap.String("url", natsUrl))
-	nc, err := nats.Connect(natsUrl)
+	// once connected, keep reconnecting instead of giving up on the connection
+	nc, err := nats.Connect(natsUrl,
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn("disconnected from nats", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			logger.Info("reconnected to nats")
+		}),
+	)
 	if err != nil {
 		logger.Fatal("failed to connect to nats at "+natsUrl, zap.Error(err))
 	}
+	AddNatsReadinessCheck(nc)
 	return nc
 }
 
+// Report the nats connection in the readiness endpoint, the service is not ready while it is reconnecting
+func AddNatsReadinessCheck(nc *nats.Conn) {
+	AddReadinessCheck("nats", func(ctx context.Context) error {
+		if status := nc.Status(); status != nats.CONNECTED {
+			return fmt.Errorf("connection is %v", status)
+		}
+		return nil
+	})
+}
+
 func NatsJetstream(logger *zap.Logger, nc *nats.Conn) jetstream.JetStream {
 	js, err := jetstream.New(nc)
 	if err != nil {
@@ -67,38 +101,69 @@ func NatsJetstream(logger *zap.Logger, nc *nats.Conn) jetstream.JetStream {
 	return js
 }
 
-func NatsPublishJson(logger *zap.Logger, nc *nats.Conn, subject string, value any) {
-	serialized, err := json.Marshal(value)
-	FatalOnError(logger, err, "failed to serialize json message to publish on nats", zap.String("subject", subject), zap.Any("value", value))
-	err = nc.Publish(subject, serialized)
-	FatalOnError(logger, err, "failed to publish message to nats", zap.String("subject", subject))
+func NatsEncoding(logger *zap.Logger, c *cli.Context) Encoding {
+	encoding, err := ParseEncoding(c.String(Flag_NatsEncoding.Name))
+	FatalOnError(logger, err, "invalid nats encoding")
+	return encoding
+}
+
+func NatsPublish(nc *nats.Conn, encoding Encoding, subject string, value any) error {
+	msg, err := NatsMsg(encoding, subject, value)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message to publish on %v: %w", subject, err)
+	}
+	if err := nc.PublishMsg(msg); err != nil {
+		return Retryable(fmt.Errorf("failed to publish message on %v: %w", subject, err))
+	}
+	return nil
 }
 
-func NatsJetstreamDecodeJson[T any](logger *zap.Logger, msg jetstream.Msg) *T {
+// Decode a jetstream message, a message that can not be decoded is poison
+func NatsJetstreamDecode[T any](msg jetstream.Msg) (*T, error) {
 	value := new(T)
-	err := json.Unmarshal(msg.Data(), value)
-	FatalOnError(logger, err, "failed to decode nats jetstream json message",
-		zap.String("subject", msg.Subject()), zap.Int("length", len(msg.Data())))
-	return value
+	if err := NatsDecode(msg.Headers(), msg.Data(), value); err != nil {
+		return nil, Poison(fmt.Errorf("failed to decode message on %v with content type %q: %w", msg.Subject(), msg.Headers().Get(HeaderContentType), err))
+	}
+	return value, nil
 }
 
+// Get a durable consumer declared in the streams package, exits if it is missing or misconfigured
 func NatsConsumer(ctx context.Context, logger *zap.Logger, js jetstream.JetStream, stream, consumer string) jetstream.Consumer {
-	c, err := js.Consumer(ctx, stream, consumer)
-	if err != nil {
-		logger.Fatal("failed to create stream consumer", zap.Error(err))
-	}
+	c, err := streams.Consumer(ctx, js, stream, consumer)
+	FatalOnError(logger, err, "failed to get stream consumer", zap.String("stream", stream), zap.String("consumer", consumer))
 	return c
 }
 
+// Exit if the stream is missing or misconfigured
+func NatsValidateStream(ctx context.Context, logger *zap.Logger, js jetstream.JetStream, stream string) {
+	err := streams.ValidateStream(ctx, js, stream)
+	FatalOnError(logger, err, "invalid stream", zap.String("stream", stream))
+}
+
 func PostgresClient(logger *zap.Logger, c *cli.Context) *pgx.Conn {
-	databaseUrl := c.String(Flag_PostgresUrl.Name)
-	conn, err := pgx.Connect(c.Context, databaseUrl)
+	conn, err := PostgresConnect(c)
 	if err != nil {
-		logger.Fatal("failed to connect to database", zap.String("url", databaseUrl))
+		logger.Fatal("failed to connect to database", zap.String("url", c.String(Flag_PostgresUrl.Name)), zap.Error(err))
 	}
 	return conn
 }
 
+// Connect to the database and report the connection in the readiness endpoint, replacing any previous connection
+func PostgresConnect(c *cli.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(c.Context, c.String(Flag_PostgresUrl.Name))
+	if err != nil {
+		return nil, err
+	}
+	AddReadinessCheck("postgres", func(ctx context.Context) error {
+		if conn.IsClosed() {
+			return errors.New("connection is closed")
+		}
+		return nil
+	})
+	return conn, nil
+}
+
+// Only meant for startup, errors while processing messages should be handled by a MsgHandler
 func FatalOnError(logger *zap.Logger, err error, msg string, fields ...zap.Field) {
 	if err == nil {
 		return
@@ -0,0 +1,136 @@
+package clickhouse_exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client of the ClickHouse HTTP interface
+type Client struct {
+	url      string
+	database string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func NewClient(url, database, user, password string) *Client {
+	return &Client{
+		url:      url,
+		database: database,
+		user:     user,
+		password: password,
+		http:     &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (c *Client) Database() string {
+	return c.database
+}
+
+// Create the database of the client if it does not exist
+func (c *Client) CreateDatabase(ctx context.Context) error {
+	_, err := c.do(ctx, "", fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", c.database), nil)
+	return err
+}
+
+// Run a statement that returns no rows. Params are bound to {name:Type} placeholders.
+func (c *Client) Exec(ctx context.Context, query string, params map[string]string) error {
+	_, err := c.do(ctx, c.database, query, params)
+	return err
+}
+
+// Run a query and return its output, the format is part of the query
+func (c *Client) Query(ctx context.Context, query string, params map[string]string) ([]byte, error) {
+	return c.do(ctx, c.database, query, params)
+}
+
+// Insert the rows into table as JSONEachRow
+func (c *Client) Insert(ctx context.Context, table string, rows []any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	body := bytes.Buffer{}
+	encoder := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode %v row: %w", table, err)
+		}
+	}
+
+	values := c.values(c.database, nil)
+	values.Set("query", fmt.Sprintf("INSERT INTO `%s` FORMAT JSONEachRow", table))
+	if _, err := c.post(ctx, values, &body); err != nil {
+		return fmt.Errorf("failed to insert into %v: %w", table, err)
+	}
+	return nil
+}
+
+// Check that the server answers, the /ping handler needs no credentials
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/ping", nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("clickhouse returned status code %v", res.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, database string, query string, params map[string]string) ([]byte, error) {
+	return c.post(ctx, c.values(database, params), bytes.NewBufferString(query))
+}
+
+func (c *Client) values(database string, params map[string]string) url.Values {
+	values := url.Values{}
+	if database != "" {
+		values.Set("database", database)
+	}
+	// accept RFC3339 timestamps in inserted rows
+	values.Set("date_time_input_format", "best_effort")
+	for name, value := range params {
+		values.Set("param_"+name, value)
+	}
+	return values
+}
+
+func (c *Client) post(ctx context.Context, values url.Values, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/?"+values.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+	}
+	if c.password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	output, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("clickhouse returned status code %v: %s", res.StatusCode, bytes.TrimSpace(output))
+	}
+	return output, nil
+}
@@ -0,0 +1,376 @@
+package clickhouse_exporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	"github.com/diogo464/ipfs-telemetry/backend/enrich"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	TableCrawl            = "crawler_crawl"
+	TablePeer             = "crawler_peer"
+	TableEdge             = "crawler_edge"
+	TableError            = "crawler_error"
+	TableEvent            = "crawler_event"
+	TableMonitorEvent     = "monitor_event"
+	TableMonitorBandwidth = "monitor_bandwidth"
+	TableMonitorProperty  = "monitor_property"
+)
+
+type crawlRow struct {
+	CrawlId   string    `json:"crawl_id"`
+	Kind      string    `json:"kind"`
+	Seqn      uint64    `json:"seqn"`
+	Timestamp time.Time `json:"timestamp"`
+	Mode      string    `json:"mode"`
+}
+
+type peerRow struct {
+	CrawlId           string    `json:"crawl_id"`
+	Seqn              uint64    `json:"seqn"`
+	ObservedAt        time.Time `json:"observed_at"`
+	Network           string    `json:"network"`
+	PeerId            string    `json:"peer_id"`
+	Agent             string    `json:"agent"`
+	Implementation    string    `json:"implementation"`
+	Version           string    `json:"version"`
+	Addresses         []string  `json:"addresses"`
+	Protocols         []string  `json:"protocols"`
+	Buckets           int       `json:"buckets"`
+	ConnectDurationMs float64   `json:"connect_duration_ms"`
+	Ip                string    `json:"ip"`
+	Asn               uint      `json:"asn"`
+	AsnOrg            string    `json:"asn_org"`
+	CountryCode       string    `json:"country_code"`
+	City              string    `json:"city"`
+	HostingProvider   string    `json:"hosting_provider"`
+}
+
+type edgeRow struct {
+	CrawlId     string    `json:"crawl_id"`
+	ObservedAt  time.Time `json:"observed_at"`
+	Network     string    `json:"network"`
+	PeerId      string    `json:"peer_id"`
+	NeighbourId string    `json:"neighbour_id"`
+}
+
+type errorRow struct {
+	CrawlId    string    `json:"crawl_id"`
+	Seqn       uint64    `json:"seqn"`
+	ObservedAt time.Time `json:"observed_at"`
+	Network    string    `json:"network"`
+	PeerId     string    `json:"peer_id"`
+	Addresses  []string  `json:"addresses"`
+	Stage      string    `json:"stage"`
+	Reason     string    `json:"reason"`
+	Error      string    `json:"error"`
+}
+
+type peerEventRow struct {
+	CrawlId           string    `json:"crawl_id"`
+	Seqn              uint64    `json:"seqn"`
+	ObservedAt        time.Time `json:"observed_at"`
+	Network           string    `json:"network"`
+	PeerId            string    `json:"peer_id"`
+	Kind              string    `json:"kind"`
+	Addresses         []string  `json:"addresses"`
+	PreviousAddresses []string  `json:"previous_addresses"`
+	SessionLengthMs   int64     `json:"session_length_ms"`
+}
+
+type eventRow struct {
+	PeerId    string    `json:"peer_id"`
+	Session   string    `json:"session"`
+	Scope     string    `json:"scope"`
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+	Payload   string    `json:"payload"`
+}
+
+type bandwidthRow struct {
+	PeerId             string    `json:"peer_id"`
+	Session            *string   `json:"session"`
+	ObservedAt         time.Time `json:"observed_at"`
+	UploadRate         uint64    `json:"upload_rate"`
+	DownloadRate       uint64    `json:"download_rate"`
+	RttUs              int64     `json:"rtt_us"`
+	JitterUs           int64     `json:"jitter_us"`
+	UploadBytes        uint64    `json:"upload_bytes"`
+	UploadStreams      uint32    `json:"upload_streams"`
+	UploadDurationMs   int64     `json:"upload_duration_ms"`
+	UploadPeakRate     uint64    `json:"upload_peak_rate"`
+	DownloadBytes      uint64    `json:"download_bytes"`
+	DownloadStreams    uint32    `json:"download_streams"`
+	DownloadDurationMs int64     `json:"download_duration_ms"`
+	DownloadPeakRate   uint64    `json:"download_peak_rate"`
+}
+
+type propertyRow struct {
+	PeerId       string    `json:"peer_id"`
+	Session      string    `json:"session"`
+	Scope        string    `json:"scope"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	ObservedAt   time.Time `json:"observed_at"`
+	ValueString  *string   `json:"value_string"`
+	ValueInteger *int64    `json:"value_integer"`
+}
+
+// Prefix of the identity given to crawls published before crawls had one, followed by the sequence number of their begin
+const legacyCrawlPrefix = "seqn-"
+
+// Exporter buffers rows of crawler and monitor messages and inserts them in batches.
+// Batches are not atomic, the tables inserted before a failure keep their rows and get them again when the messages are redelivered.
+// Every table is a ReplacingMergeTree so the duplicates are only removed when parts are merged, queries that must not count them use FINAL.
+type Exporter struct {
+	client   *Client
+	enricher *enrich.Enricher
+	rows     map[string][]any
+	messages int
+	// identity of the current crawl for messages published before crawls had one
+	legacyCrawl string
+}
+
+// The enricher is optional, peers are not located without it
+func NewExporter(client *Client, enricher *enrich.Enricher) *Exporter {
+	return &Exporter{
+		client:   client,
+		enricher: enricher,
+		rows:     make(map[string][]any),
+	}
+}
+
+// Number of messages added since the last flush
+func (e *Exporter) Pending() int {
+	return e.messages
+}
+
+// Recover the identity of the last crawl published before crawls had one.
+// Its messages can be redelivered after a restart without the crawl begin that named it.
+func (e *Exporter) Recover(ctx context.Context) error {
+	output, err := e.client.Query(ctx, `SELECT crawl_id FROM crawler_crawl
+		WHERE kind = {kind:String} AND startsWith(crawl_id, {prefix:String})
+		ORDER BY seqn DESC
+		LIMIT 1
+		FORMAT TabSeparated`, map[string]string{"kind": crawler.KindCrawlBegin, "prefix": legacyCrawlPrefix})
+	if err != nil {
+		return fmt.Errorf("failed to read the last legacy crawl: %w", err)
+	}
+	e.legacyCrawl = strings.TrimSpace(string(output))
+	return nil
+}
+
+// Add a crawler message received with the given stream sequence number
+func (e *Exporter) AddCrawler(seqn uint64, cmsg *crawler.NatsMessage) error {
+	crawl := cmsg.Crawl
+	if crawl == "" {
+		if cmsg.Kind == crawler.KindCrawlBegin {
+			e.legacyCrawl = fmt.Sprintf("%v%d", legacyCrawlPrefix, seqn)
+		}
+		crawl = e.legacyCrawl
+	}
+
+	switch cmsg.Kind {
+	case crawler.KindCrawlBegin, crawler.KindCrawlEnd:
+		e.add(TableCrawl, crawlRow{CrawlId: crawl, Kind: cmsg.Kind, Seqn: seqn, Timestamp: cmsg.Timestamp.UTC(), Mode: cmsg.CrawlMode()})
+	case crawler.KindPeer:
+		if cmsg.Peer == nil {
+			return backend.Poison(fmt.Errorf("crawler message %v of kind %v has no peer", seqn, cmsg.Kind))
+		}
+		e.add(TablePeer, e.peerRow(crawl, seqn, cmsg))
+		p := cmsg.Peer
+		for _, bucket := range p.Buckets {
+			e.add(TableEdge, edgeRow{
+				CrawlId:     crawl,
+				ObservedAt:  cmsg.Timestamp.UTC(),
+				Network:     crawler.NetworkOrDefault(p.Network),
+				PeerId:      p.ID.String(),
+				NeighbourId: bucket.ID.String(),
+			})
+		}
+	case crawler.KindError:
+		if cmsg.Error == nil {
+			return backend.Poison(fmt.Errorf("crawler message %v of kind %v has no error", seqn, cmsg.Kind))
+		}
+		werr := cmsg.Error
+		msg := ""
+		if werr.Err != nil {
+			msg = werr.Err.Error()
+		}
+		e.add(TableError, errorRow{
+			CrawlId:    crawl,
+			Seqn:       seqn,
+			ObservedAt: werr.Time.UTC(),
+			Network:    crawler.NetworkOrDefault(werr.Network),
+			PeerId:     werr.ID.String(),
+			Addresses:  addrStrings(werr.Addresses),
+			Stage:      string(werr.Stage),
+			Reason:     string(werr.Reason),
+			Error:      msg,
+		})
+	case crawler.KindEvent:
+		if cmsg.Event == nil {
+			return backend.Poison(fmt.Errorf("crawler message %v of kind %v has no event", seqn, cmsg.Kind))
+		}
+		event := cmsg.Event
+		e.add(TableEvent, peerEventRow{
+			CrawlId:           crawl,
+			Seqn:              seqn,
+			ObservedAt:        event.Time.UTC(),
+			Network:           crawler.NetworkOrDefault(event.Network),
+			PeerId:            event.ID.String(),
+			Kind:              string(event.Kind),
+			Addresses:         addrStrings(event.Addresses),
+			PreviousAddresses: addrStrings(event.PreviousAddresses),
+			SessionLengthMs:   event.SessionLength.Milliseconds(),
+		})
+	default:
+		return backend.Poison(fmt.Errorf("unknown crawler message kind %q", cmsg.Kind))
+	}
+	e.messages += 1
+	return nil
+}
+
+// Add the events, bandwidth and properties of a monitor export
+func (e *Exporter) AddMonitor(exp *monitor.Export) error {
+	peerId := exp.Peer.String()
+	session := exp.Session.String()
+	observedAt := exp.ObservedAt.UTC()
+
+	// properties are validated first so a rejected export adds no rows
+	properties := make([]any, 0, len(exp.Properties))
+	for _, property := range exp.Properties {
+		valueString, valueInteger, err := monitor.PropertyValue(property.Value)
+		if err != nil {
+			return backend.Poison(fmt.Errorf("property %v: %w", property.Name, err))
+		}
+		properties = append(properties, propertyRow{
+			PeerId:       peerId,
+			Session:      session,
+			Scope:        property.Scope.Name,
+			Name:         property.Name,
+			Description:  property.Description,
+			ObservedAt:   observedAt,
+			ValueString:  valueString,
+			ValueInteger: valueInteger,
+		})
+	}
+	e.rows[TableMonitorProperty] = append(e.rows[TableMonitorProperty], properties...)
+
+	if b := exp.Bandwidth; b != nil {
+		// bandwidth tests of a peer whose telemetry was never collected have no session
+		var bandwidthSession *string
+		if exp.Session != telemetry.InvalidSession {
+			bandwidthSession = &session
+		}
+		e.add(TableMonitorBandwidth, bandwidthRow{
+			PeerId:             peerId,
+			Session:            bandwidthSession,
+			ObservedAt:         observedAt,
+			UploadRate:         b.UploadRate,
+			DownloadRate:       b.DownloadRate,
+			RttUs:              b.Rtt.Microseconds(),
+			JitterUs:           b.Jitter.Microseconds(),
+			UploadBytes:        b.Upload.Bytes,
+			UploadStreams:      b.Upload.Streams,
+			UploadDurationMs:   b.Upload.Duration.Milliseconds(),
+			UploadPeakRate:     b.Upload.PeakRate,
+			DownloadBytes:      b.Download.Bytes,
+			DownloadStreams:    b.Download.Streams,
+			DownloadDurationMs: b.Download.Duration.Milliseconds(),
+			DownloadPeakRate:   b.Download.PeakRate,
+		})
+	}
+
+	for _, events := range exp.Events {
+		d := events.Descriptor
+		for _, event := range events.Events {
+			e.add(TableMonitorEvent, eventRow{
+				PeerId:    peerId,
+				Session:   session,
+				Scope:     d.Scope.Name,
+				Name:      d.Name,
+				Timestamp: event.Timestamp.UTC(),
+				Payload:   string(monitor.EventPayload(event.Data)),
+			})
+		}
+	}
+	e.messages += 1
+	return nil
+}
+
+// Insert the buffered rows one table at a time.
+// If an insert fails the tables inserted before it are not rolled back, the rows not inserted are kept so the flush can be retried.
+func (e *Exporter) Flush(ctx context.Context) error {
+	for table, rows := range e.rows {
+		if err := e.client.Insert(ctx, table, rows); err != nil {
+			return err
+		}
+		delete(e.rows, table)
+	}
+	e.messages = 0
+	return nil
+}
+
+// Drop the buffered rows, ex: when their messages will be redelivered
+func (e *Exporter) Discard() {
+	clear(e.rows)
+	e.messages = 0
+}
+
+func (e *Exporter) add(table string, row any) {
+	e.rows[table] = append(e.rows[table], row)
+}
+
+func (e *Exporter) peerRow(crawl string, seqn uint64, cmsg *crawler.NatsMessage) peerRow {
+	p := cmsg.Peer
+	protocols := make([]string, len(p.Protocols))
+	for i, proto := range p.Protocols {
+		protocols[i] = string(proto)
+	}
+	row := peerRow{
+		CrawlId:           crawl,
+		Seqn:              seqn,
+		ObservedAt:        cmsg.Timestamp.UTC(),
+		Network:           crawler.NetworkOrDefault(p.Network),
+		PeerId:            p.ID.String(),
+		Agent:             p.Agent,
+		Implementation:    string(p.Fingerprint.Implementation),
+		Version:           p.Fingerprint.Version,
+		Addresses:         addrStrings(p.Addresses),
+		Protocols:         protocols,
+		Buckets:           len(p.Buckets),
+		ConnectDurationMs: float64(p.ConnectDuration) / float64(time.Millisecond),
+	}
+
+	if e.enricher != nil {
+		if locations := e.enricher.LookupAddrs(p.Addresses); len(locations) > 0 {
+			first := locations[0]
+			row.Ip = first.IP
+			row.Asn = first.ASN
+			row.AsnOrg = first.ASNOrg
+			row.CountryCode = first.CountryCode
+			row.City = first.City
+			if first.Hosting() {
+				row.HostingProvider = first.HostingProvider
+			}
+		}
+	}
+	return row
+}
+
+func addrStrings(maddrs []multiaddr.Multiaddr) []string {
+	addrs := make([]string, len(maddrs))
+	for i, maddr := range maddrs {
+		addrs[i] = maddr.String()
+	}
+	return addrs
+}
@@ -0,0 +1,309 @@
+package clickhouse_exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	tcrawler "github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"go.uber.org/zap"
+)
+
+// In memory stand-in for the ClickHouse http interface, it records statements and inserted rows
+type standIn struct {
+	mu         sync.Mutex
+	statements []string
+	rows       map[string][]map[string]any
+	version    int
+	fail       bool
+	// answer to the query of the last legacy crawl
+	legacyCrawl string
+}
+
+func newStandIn(t *testing.T) (*standIn, *Client) {
+	s := &standIn{rows: make(map[string][]map[string]any)}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, NewClient(server.URL, "telemetry", "default", "")
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		http.Error(w, "Code: 241. DB::Exception: Memory limit exceeded", http.StatusInternalServerError)
+		return
+	}
+
+	if query := r.URL.Query().Get("query"); query != "" {
+		table := strings.Trim(strings.Fields(query)[2], "`")
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			row := make(map[string]any)
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.rows[table] = append(s.rows[table], row)
+		}
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	statement := string(body)
+	switch {
+	case strings.HasPrefix(statement, "INSERT INTO schema_migration"):
+		version, _ := strconv.Atoi(r.URL.Query().Get("param_version"))
+		if r.URL.Query().Get("param_applied") == "1" {
+			s.version = version
+		} else {
+			s.version = version - 1
+		}
+	case strings.HasPrefix(statement, "SELECT version FROM schema_migration"):
+		if s.version > 0 {
+			io.WriteString(w, strconv.Itoa(s.version)+"\n")
+		}
+	case strings.HasPrefix(statement, "SELECT crawl_id FROM crawler_crawl"):
+		if s.legacyCrawl != "" {
+			io.WriteString(w, s.legacyCrawl+"\n")
+		}
+	default:
+		s.statements = append(s.statements, statement)
+	}
+}
+
+func (s *standIn) count(prefix string) int {
+	n := 0
+	for _, statement := range s.statements {
+		if strings.HasPrefix(statement, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSetupSchema(t *testing.T) {
+	s, client := newStandIn(t)
+	component, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetupSchema(context.Background(), zap.NewNop(), client, false); err != nil {
+		t.Fatal(err)
+	}
+	if s.version != int(component.Latest()) {
+		t.Fatalf("expected version %v, got %v", component.Latest(), s.version)
+	}
+	if n := s.count("CREATE TABLE IF NOT EXISTS crawler_") + s.count("CREATE TABLE IF NOT EXISTS monitor_"); n != 8 {
+		t.Fatalf("expected 8 tables to be created, got %v", n)
+	}
+
+	s.statements = nil
+	if err := SetupSchema(context.Background(), zap.NewNop(), client, false); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.count("CREATE TABLE IF NOT EXISTS crawler_"); n != 0 {
+		t.Fatalf("migrations were applied twice")
+	}
+
+	if err := SetupSchema(context.Background(), zap.NewNop(), client, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.count("DROP TABLE"); n != 8 {
+		t.Fatalf("expected 8 tables to be dropped, got %v", n)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	sql := "-- comment;\nCREATE TABLE a(\n    x String\n);\n\nDROP TABLE b;\nSELECT 1"
+	statements := statements(sql)
+	if len(statements) != 3 || statements[0] != "CREATE TABLE a(\n    x String\n)" || statements[1] != "DROP TABLE b" || statements[2] != "SELECT 1" {
+		t.Fatalf("unexpected statements %q", statements)
+	}
+}
+
+func testPeer(t *testing.T) *walker.Peer {
+	return &walker.Peer{
+		ID:        peer.ID("peer-a"),
+		Network:   "amino",
+		Addresses: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/1.2.3.4/tcp/4001")},
+		Agent:     "kubo/0.25.0",
+		Buckets: []walker.BucketEntry{
+			{ID: peer.ID("peer-b")},
+			{ID: peer.ID("peer-c")},
+		},
+		ConnectDuration: 1500 * time.Microsecond,
+		Fingerprint:     walker.Fingerprint{Implementation: "kubo", Agent: "kubo", Version: "0.25.0"},
+	}
+}
+
+func TestExporterFlush(t *testing.T) {
+	s, client := newStandIn(t)
+	exporter := NewExporter(client, nil)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	messages := []crawler.NatsMessage{
+		{Kind: crawler.KindCrawlBegin, Timestamp: now},
+		{Kind: crawler.KindPeer, Timestamp: now, Peer: testPeer(t)},
+		{Kind: crawler.KindError, Timestamp: now, Error: &walker.Error{ID: peer.ID("peer-d"), Time: now, Stage: walker.Stage("connect")}},
+		{Kind: crawler.KindCrawlEnd, Timestamp: now},
+		{Kind: crawler.KindEvent, Timestamp: now, Event: &tcrawler.Event{Kind: tcrawler.EventLeave, ID: peer.ID("peer-a"), Time: now, SessionLength: time.Minute}},
+	}
+	for i := range messages {
+		if err := exporter.AddCrawler(uint64(10+i), &messages[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exporter.AddCrawler(20, &crawler.NatsMessage{Kind: crawler.KindPeer}); err == nil {
+		t.Fatalf("expected an error for a peer message without a peer")
+	}
+
+	export := &monitor.Export{
+		ObservedAt: now,
+		Peer:       peer.ID("peer-a"),
+		Session:    telemetry.Session(uuid.New()),
+		Properties: []monitor.ExportProperty{{Name: "process.runtime.numcpu", Value: float64(8)}},
+		Bandwidth:  &monitor.ExportBandwidth{UploadRate: 100, Rtt: 2 * time.Millisecond},
+		Events: []monitor.ExportEvents{{
+			Descriptor: telemetry.EventDescriptor{Name: "libp2p.network.addresses"},
+			Events:     []telemetry.Event{{Timestamp: now, Data: []byte(`["/ip4/1.2.3.4/tcp/4001"]`)}, {Timestamp: now, Data: []byte("raw")}},
+		}},
+	}
+	if err := exporter.AddMonitor(export); err != nil {
+		t.Fatal(err)
+	}
+	if exporter.Pending() != 6 {
+		t.Fatalf("expected 6 pending messages, got %v", exporter.Pending())
+	}
+
+	if err := exporter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if exporter.Pending() != 0 {
+		t.Fatalf("messages still pending after flush")
+	}
+
+	expected := map[string]int{
+		TableCrawl:            2,
+		TablePeer:             1,
+		TableEdge:             2,
+		TableError:            1,
+		TableEvent:            1,
+		TableMonitorProperty:  1,
+		TableMonitorBandwidth: 1,
+		TableMonitorEvent:     2,
+	}
+	for table, n := range expected {
+		if len(s.rows[table]) != n {
+			t.Fatalf("expected %v rows in %v, got %v", n, table, len(s.rows[table]))
+		}
+	}
+
+	crawl := s.rows[TableCrawl][0]["crawl_id"]
+	if crawl != "seqn-10" || s.rows[TablePeer][0]["crawl_id"] != crawl || s.rows[TableCrawl][1]["crawl_id"] != crawl {
+		t.Fatalf("messages without a crawl identity must use the sequence number of the crawl begin, got %v", crawl)
+	}
+	peerRow := s.rows[TablePeer][0]
+	if peerRow["implementation"] != "kubo" || peerRow["connect_duration_ms"] != 1.5 || peerRow["buckets"] != float64(2) {
+		t.Fatalf("unexpected peer row %v", peerRow)
+	}
+	if s.rows[TableEdge][1]["neighbour_id"] != peer.ID("peer-c").String() {
+		t.Fatalf("unexpected edge row %v", s.rows[TableEdge][1])
+	}
+	if event := s.rows[TableEvent][0]; event["kind"] != "leave" || event["session_length_ms"] != float64(60000) || event["crawl_id"] != crawl {
+		t.Fatalf("unexpected event row %v", event)
+	}
+	if s.rows[TableMonitorProperty][0]["value_integer"] != float64(8) || s.rows[TableMonitorProperty][0]["value_string"] != nil {
+		t.Fatalf("unexpected property row %v", s.rows[TableMonitorProperty][0])
+	}
+	if bandwidth := s.rows[TableMonitorBandwidth][0]; bandwidth["rtt_us"] != float64(2000) || bandwidth["session"] != export.Session.String() {
+		t.Fatalf("unexpected bandwidth row %v", s.rows[TableMonitorBandwidth][0])
+	}
+	if s.rows[TableMonitorEvent][1]["payload"] != `"raw"` {
+		t.Fatalf("non json payloads must be stored as json strings, got %v", s.rows[TableMonitorEvent][1]["payload"])
+	}
+}
+
+func TestRecoverLegacyCrawl(t *testing.T) {
+	s, client := newStandIn(t)
+	s.legacyCrawl = "seqn-10"
+	exporter := NewExporter(client, nil)
+	if err := exporter.Recover(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.AddCrawler(12, &crawler.NatsMessage{Kind: crawler.KindPeer, Peer: testPeer(t)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if crawl := s.rows[TablePeer][0]["crawl_id"]; crawl != "seqn-10" {
+		t.Fatalf("redelivered messages without a crawl identity must belong to the recovered crawl, got %v", crawl)
+	}
+}
+
+func TestBandwidthWithoutSession(t *testing.T) {
+	s, client := newStandIn(t)
+	exporter := NewExporter(client, nil)
+	export := &monitor.Export{
+		ObservedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Peer:       peer.ID("peer-a"),
+		Bandwidth:  &monitor.ExportBandwidth{UploadRate: 100},
+	}
+	if err := exporter.AddMonitor(export); err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if row := s.rows[TableMonitorBandwidth][0]; row["session"] != nil {
+		t.Fatalf("bandwidth of an export without a session must have a null session, got %v", row["session"])
+	}
+}
+
+func TestExporterFlushFailure(t *testing.T) {
+	s, client := newStandIn(t)
+	exporter := NewExporter(client, nil)
+	if err := exporter.AddCrawler(1, &crawler.NatsMessage{Kind: crawler.KindPeer, Peer: testPeer(t)}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.fail = true
+	if err := exporter.Flush(context.Background()); err == nil || !strings.Contains(err.Error(), "Memory limit exceeded") {
+		t.Fatalf("expected the clickhouse exception, got %v", err)
+	}
+	if exporter.Pending() != 1 {
+		t.Fatalf("rows must be kept after a failed flush")
+	}
+
+	s.fail = false
+	if err := exporter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.rows[TablePeer]) != 1 || len(s.rows[TableEdge]) != 2 {
+		t.Fatalf("unexpected rows after retrying the flush %v", s.rows)
+	}
+
+	exporter.AddCrawler(2, &crawler.NatsMessage{Kind: crawler.KindPeer, Peer: testPeer(t)})
+	exporter.Discard()
+	if exporter.Pending() != 0 {
+		t.Fatalf("rows must be dropped after discard")
+	}
+}
@@ -0,0 +1,201 @@
+package clickhouse_exporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	"github.com/diogo464/ipfs-telemetry/backend/enrich"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+var FlagUrl *cli.StringFlag = &cli.StringFlag{
+	Name:    "clickhouse-url",
+	Usage:   "url of the ClickHouse http interface",
+	EnvVars: []string{"CLICKHOUSE_URL"},
+	Value:   "http://localhost:8123",
+}
+
+var FlagDatabase *cli.StringFlag = &cli.StringFlag{
+	Name:    "clickhouse-database",
+	Usage:   "ClickHouse database the tables are created in",
+	EnvVars: []string{"CLICKHOUSE_DATABASE"},
+	Value:   "telemetry",
+}
+
+var FlagUser *cli.StringFlag = &cli.StringFlag{
+	Name:    "clickhouse-user",
+	Usage:   "ClickHouse user",
+	EnvVars: []string{"CLICKHOUSE_USER"},
+	Value:   "default",
+}
+
+var FlagPassword *cli.StringFlag = &cli.StringFlag{
+	Name:    "clickhouse-password",
+	Usage:   "ClickHouse password",
+	EnvVars: []string{"CLICKHOUSE_PASSWORD"},
+}
+
+var FlagRecreate *cli.BoolFlag = &cli.BoolFlag{
+	Name:  "recreate",
+	Usage: "revert every migration of the clickhouse schema and apply them again, dropping all data",
+	Value: false,
+}
+
+var FlagBatchSize *cli.IntFlag = &cli.IntFlag{
+	Name:  "batch-size",
+	Usage: "maximum number of messages of each stream inserted into clickhouse at once",
+	Value: 10000,
+}
+
+var FlagBatchWait *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "batch-wait",
+	Usage: "how long to wait for a batch to fill before inserting it",
+	Value: 5 * time.Second,
+}
+
+var FlagNakDelay *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "nak-delay",
+	Usage: "how long nats waits before redelivering a batch that could not be inserted",
+	Value: time.Minute,
+}
+
+var Command *cli.Command = &cli.Command{
+	Name:        "clickhouse-exporter",
+	Description: "export crawler and monitor information to clickhouse",
+	Flags: []cli.Flag{
+		FlagUrl,
+		FlagDatabase,
+		FlagUser,
+		FlagPassword,
+		FlagRecreate,
+		FlagBatchSize,
+		FlagBatchWait,
+		FlagNakDelay,
+	},
+	Action: main,
+}
+
+func main(c *cli.Context) error {
+	logger := backend.ServiceSetup(c, "clickhouse-exporter")
+
+	client := ClientFromFlags(c)
+	nc := backend.NatsClient(logger, c)
+	js := backend.NatsJetstream(logger, nc)
+
+	enricher, err := enrich.FromFlags(logger.Named("enrich"), c)
+	backend.FatalOnError(logger, err, "failed to open geoip databases")
+	defer enricher.Close()
+
+	err = SetupSchema(c.Context, logger, client, c.Bool(FlagRecreate.Name))
+	backend.FatalOnError(logger, err, "failed to setup clickhouse schema")
+	backend.AddReadinessCheck("clickhouse", client.Ping)
+
+	// rows are deduplicated by clickhouse so durable consumers can redeliver anything that was not acked
+	crawlerConsumer, err := streams.Consumer(c.Context, js, streams.StreamCrawler, streams.ConsumerCrawlerClickhouse)
+	backend.FatalOnError(logger, err, "failed to get crawler consumer")
+	monitorConsumer, err := streams.Consumer(c.Context, js, streams.StreamMonitor, streams.ConsumerMonitorClickhouse)
+	backend.FatalOnError(logger, err, "failed to get monitor consumer")
+
+	loop := &batchLoop{
+		size: c.Int(FlagBatchSize.Name),
+		wait: c.Duration(FlagBatchWait.Name),
+	}
+	nakDelay := c.Duration(FlagNakDelay.Name)
+	crawlerExporter := NewExporter(client, enricher)
+	err = crawlerExporter.Recover(c.Context)
+	backend.FatalOnError(logger, err, "failed to recover crawler exporter state")
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		handler := backend.NewMsgHandler(logger.Named("crawler"), js, streams.ConsumerCrawlerClickhouse, nakDelay)
+		loop.run(c.Context, logger.Named("crawler"), crawlerConsumer, handler, crawlerExporter, func(seqn uint64, msg jetstream.Msg) error {
+			cmsg, err := backend.NatsJetstreamDecode[crawler.NatsMessage](msg)
+			if err != nil {
+				return err
+			}
+			return crawlerExporter.AddCrawler(seqn, cmsg)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		exporter := NewExporter(client, enricher)
+		handler := backend.NewMsgHandler(logger.Named("monitor"), js, streams.ConsumerMonitorClickhouse, nakDelay)
+		loop.run(c.Context, logger.Named("monitor"), monitorConsumer, handler, exporter, func(seqn uint64, msg jetstream.Msg) error {
+			export, err := backend.NatsJetstreamDecode[monitor.Export](msg)
+			if err != nil {
+				return err
+			}
+			return exporter.AddMonitor(export)
+		})
+	}()
+	wg.Wait()
+
+	return nc.Drain()
+}
+
+func ClientFromFlags(c *cli.Context) *Client {
+	return NewClient(c.String(FlagUrl.Name), c.String(FlagDatabase.Name), c.String(FlagUser.Name), c.String(FlagPassword.Name))
+}
+
+type batchLoop struct {
+	size int
+	wait time.Duration
+}
+
+// Fetch batches of messages, add them to the exporter and ack them once they are inserted.
+// Messages that can not be added are dead lettered, batches that fail to insert are redelivered after a delay.
+// A batch that is being inserted when the context is canceled is handed back to nats.
+func (l *batchLoop) run(ctx context.Context, logger *zap.Logger, consumer jetstream.Consumer, handler *backend.MsgHandler, exporter *Exporter, add func(uint64, jetstream.Msg) error) {
+	for ctx.Err() == nil {
+		batch, err := consumer.Fetch(l.size, jetstream.FetchMaxWait(l.wait))
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("failed to fetch messages", zap.Error(err))
+				time.Sleep(l.wait)
+			}
+			continue
+		}
+
+		msgs := make([]jetstream.Msg, 0, l.size)
+		for msg := range batch.Messages() {
+			meta, _ := msg.Metadata()
+			if err := add(meta.Sequence.Stream, msg); err != nil {
+				handler.DeadLetter(ctx, msg, err)
+				continue
+			}
+			msgs = append(msgs, msg)
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) && ctx.Err() == nil {
+			logger.Warn("failed to fetch messages", zap.Error(err))
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+
+		pending := exporter.Pending()
+		if err := exporter.Flush(ctx); err != nil {
+			logger.Error("failed to insert batch, handing it back to nats", zap.Int("messages", len(msgs)), zap.Error(err))
+			// the rows are inserted again when the messages are redelivered
+			exporter.Discard()
+			for _, msg := range msgs {
+				handler.Retry(ctx, msg)
+			}
+			continue
+		}
+		logger.Info("inserted batch", zap.Int("messages", pending))
+		for _, msg := range msgs {
+			msg.Ack()
+		}
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS monitor_property;
+DROP TABLE IF EXISTS monitor_bandwidth;
+DROP TABLE IF EXISTS monitor_event;
+DROP TABLE IF EXISTS crawler_error;
+DROP TABLE IF EXISTS crawler_edge;
+DROP TABLE IF EXISTS crawler_peer;
+DROP TABLE IF EXISTS crawler_crawl;
@@ -0,0 +1,101 @@
+-- rows are deduplicated by their sorting key when parts are merged, redelivered messages insert identical rows
+CREATE TABLE IF NOT EXISTS crawler_crawl(
+    crawl_id        String,
+    kind            LowCardinality(String),
+    seqn            UInt64,
+    timestamp       DateTime64(3, 'UTC')
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (crawl_id, kind);
+
+CREATE TABLE IF NOT EXISTS crawler_peer(
+    crawl_id            String,
+    seqn                UInt64,
+    observed_at         DateTime64(3, 'UTC'),
+    network             LowCardinality(String),
+    peer_id             String,
+    agent               String,
+    implementation      LowCardinality(String),
+    version             LowCardinality(String),
+    addresses           Array(String),
+    protocols           Array(LowCardinality(String)),
+    buckets             UInt32,
+    connect_duration_ms Float64,
+    ip                  String,
+    asn                 UInt32,
+    asn_org             String,
+    country_code        LowCardinality(String),
+    city                String,
+    hosting_provider    LowCardinality(String)
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(observed_at)
+ORDER BY (network, peer_id, observed_at, seqn);
+
+-- one row per routing table entry of a crawled peer
+CREATE TABLE IF NOT EXISTS crawler_edge(
+    crawl_id        String,
+    observed_at     DateTime64(3, 'UTC'),
+    network         LowCardinality(String),
+    peer_id         String,
+    neighbour_id    String
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(observed_at)
+ORDER BY (network, crawl_id, peer_id, neighbour_id);
+
+CREATE TABLE IF NOT EXISTS crawler_error(
+    crawl_id        String,
+    seqn            UInt64,
+    observed_at     DateTime64(3, 'UTC'),
+    network         LowCardinality(String),
+    peer_id         String,
+    addresses       Array(String),
+    stage           LowCardinality(String),
+    reason          LowCardinality(String),
+    error           String
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(observed_at)
+ORDER BY (network, peer_id, observed_at, seqn);
+
+CREATE TABLE IF NOT EXISTS monitor_event(
+    peer_id         String,
+    session         UUID,
+    scope           LowCardinality(String),
+    name            LowCardinality(String),
+    timestamp       DateTime64(3, 'UTC'),
+    payload         String
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(timestamp)
+ORDER BY (name, peer_id, timestamp, cityHash64(payload));
+
+CREATE TABLE IF NOT EXISTS monitor_bandwidth(
+    peer_id             String,
+    session             UUID,
+    observed_at         DateTime64(3, 'UTC'),
+    upload_rate         UInt64,
+    download_rate       UInt64,
+    rtt_us              Int64,
+    jitter_us           Int64,
+    upload_bytes        UInt64,
+    upload_streams      UInt32,
+    upload_duration_ms  Int64,
+    upload_peak_rate    UInt64,
+    download_bytes      UInt64,
+    download_streams    UInt32,
+    download_duration_ms Int64,
+    download_peak_rate  UInt64
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(observed_at)
+ORDER BY (peer_id, observed_at);
+
+-- the last observed value of each property is kept
+CREATE TABLE IF NOT EXISTS monitor_property(
+    peer_id         String,
+    session         UUID,
+    scope           LowCardinality(String),
+    name            LowCardinality(String),
+    description     String,
+    observed_at     DateTime64(3, 'UTC'),
+    value_string    Nullable(String),
+    value_integer   Nullable(Int64)
+) ENGINE = ReplacingMergeTree(observed_at)
+ORDER BY (peer_id, session, scope, name);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS crawler_event;
@@ -0,0 +1,15 @@
+-- joins, leaves and address changes of the live peer set of continuous crawlers
+CREATE TABLE IF NOT EXISTS crawler_event(
+    crawl_id            String,
+    seqn                UInt64,
+    observed_at         DateTime64(3, 'UTC'),
+    network             LowCardinality(String),
+    peer_id             String,
+    kind                LowCardinality(String),
+    addresses           Array(String),
+    previous_addresses  Array(String),
+    -- only set for leaves
+    session_length_ms   Int64
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(observed_at)
+ORDER BY (network, peer_id, observed_at, seqn);
@@ -0,0 +1 @@
+ALTER TABLE crawler_crawl DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,2 @@
+-- how the peers of the crawl were found: active, continuous or passive. crawls stored before were all active
+ALTER TABLE crawler_crawl ADD COLUMN IF NOT EXISTS mode LowCardinality(String) DEFAULT 'active';
@@ -0,0 +1,2 @@
+ALTER TABLE monitor_bandwidth DELETE WHERE session IS NULL;
+ALTER TABLE monitor_bandwidth MODIFY COLUMN session UUID;
@@ -0,0 +1,2 @@
+-- bandwidth tests of peers whose telemetry was never collected have no session
+ALTER TABLE monitor_bandwidth MODIFY COLUMN session Nullable(UUID);
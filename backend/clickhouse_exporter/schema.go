@@ -0,0 +1,151 @@
+package clickhouse_exporter
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diogo464/ipfs-telemetry/backend/pgmigrate"
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Applied and reverted migrations are appended, the latest row of each version tells if it is applied.
+// ClickHouse has no transactions or advisory locks so concurrent migrations of the same database must be avoided.
+const versionTable = `CREATE TABLE IF NOT EXISTS schema_migration(
+    component   String,
+    version     UInt32,
+    name        String,
+    applied     UInt8,
+    changed_at  DateTime64(6, 'UTC') DEFAULT now64(6)
+) ENGINE = MergeTree
+ORDER BY (component, version, changed_at)`
+
+// Migrations of the clickhouse schema, the file layout is the same as the postgres migrations
+func Migrations() (*pgmigrate.Component, error) {
+	migrations, err := pgmigrate.Load(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &pgmigrate.Component{Name: "clickhouse", Migrations: migrations}, nil
+}
+
+// Migrate the schema to the latest version. If recreate is true every migration is reverted first, dropping all data.
+func SetupSchema(ctx context.Context, logger *zap.Logger, client *Client, recreate bool) error {
+	component, err := Migrations()
+	if err != nil {
+		return err
+	}
+	if err := client.CreateDatabase(ctx); err != nil {
+		return fmt.Errorf("failed to create database %v: %w", client.Database(), err)
+	}
+	if recreate {
+		if err := MigrateTo(ctx, logger, client, component, 0); err != nil {
+			return err
+		}
+	}
+	return MigrateTo(ctx, logger, client, component, component.Latest())
+}
+
+// Version of the last migration applied to the component, 0 if none was applied
+func CurrentVersion(ctx context.Context, client *Client, component string) (uint, error) {
+	if err := client.Exec(ctx, versionTable, nil); err != nil {
+		return 0, fmt.Errorf("failed to create migration table: %w", err)
+	}
+	output, err := client.Query(ctx, `SELECT version FROM schema_migration
+		WHERE component = {component:String}
+		GROUP BY version
+		HAVING argMax(applied, changed_at) = 1
+		ORDER BY version DESC
+		LIMIT 1
+		FORMAT TabSeparated`, map[string]string{"component": component})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read version of %v: %w", component, err)
+	}
+	line := strings.TrimSpace(string(output))
+	if line == "" {
+		return 0, nil
+	}
+	version, err := strconv.ParseUint(line, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version of %v: %q", component, line)
+	}
+	return uint(version), nil
+}
+
+// Migrate the component up or down to the target version, 0 removes every migration
+func MigrateTo(ctx context.Context, logger *zap.Logger, client *Client, component *pgmigrate.Component, target uint) error {
+	if target > component.Latest() {
+		return fmt.Errorf("invalid target version %v, the latest version of %v is %v", target, component.Name, component.Latest())
+	}
+	current, err := CurrentVersion(ctx, client, component.Name)
+	if err != nil {
+		return err
+	}
+	if current > component.Latest() {
+		return fmt.Errorf("database version %v of %v is newer than the latest known version %v", current, component.Name, component.Latest())
+	}
+
+	for current < target {
+		m := component.Migrations[current]
+		logger.Info("applying migration", zap.String("component", component.Name), zap.Uint("version", m.Version), zap.String("name", m.Name))
+		if err := apply(ctx, client, component.Name, m, m.Up, 1); err != nil {
+			return fmt.Errorf("failed to apply migration %v_%v of %v: %w", m.Version, m.Name, component.Name, err)
+		}
+		current += 1
+	}
+	for current > target {
+		m := component.Migrations[current-1]
+		logger.Info("reverting migration", zap.String("component", component.Name), zap.Uint("version", m.Version), zap.String("name", m.Name))
+		if err := apply(ctx, client, component.Name, m, m.Down, 0); err != nil {
+			return fmt.Errorf("failed to revert migration %v_%v of %v: %w", m.Version, m.Name, component.Name, err)
+		}
+		current -= 1
+	}
+	return nil
+}
+
+// Run every statement of the migration and record it, the http interface accepts a single statement per request
+func apply(ctx context.Context, client *Client, component string, m pgmigrate.Migration, sql string, applied int) error {
+	for _, statement := range statements(sql) {
+		if err := client.Exec(ctx, statement, nil); err != nil {
+			return err
+		}
+	}
+	return client.Exec(ctx,
+		"INSERT INTO schema_migration(component, version, name, applied) VALUES ({component:String}, {version:UInt32}, {name:String}, {applied:UInt8})",
+		map[string]string{
+			"component": component,
+			"version":   strconv.FormatUint(uint64(m.Version), 10),
+			"name":      m.Name,
+			"applied":   strconv.Itoa(applied),
+		})
+}
+
+// Split a migration file into its statements, statements end with a semicolon at the end of a line
+func statements(sql string) []string {
+	result := make([]string, 0)
+	current := strings.Builder{}
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+		if strings.HasSuffix(trimmed, ";") {
+			if statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";"); statement != "" {
+				result = append(result, statement)
+			}
+			current.Reset()
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		result = append(result, statement)
+	}
+	return result
+}
@@ -1,13 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/clickhouse_exporter"
+	"github.com/diogo464/ipfs-telemetry/backend/config"
+	"github.com/diogo464/ipfs-telemetry/backend/crawldiff"
 	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	"github.com/diogo464/ipfs-telemetry/backend/migrate"
 	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/natssetup"
 	"github.com/diogo464/ipfs-telemetry/backend/pg_crawler_exporter"
 	"github.com/diogo464/ipfs-telemetry/backend/pg_monitor_exporter"
+	"github.com/diogo464/ipfs-telemetry/backend/replay"
 	"github.com/diogo464/ipfs-telemetry/backend/vm_otlp_exporter"
 	"github.com/urfave/cli/v2"
 )
@@ -15,10 +26,17 @@ import (
 func main() {
 	app := &cli.App{
 		Flags: []cli.Flag{
+			backend.Flag_Config,
 			backend.Flag_PrometheusAddress,
 			backend.Flag_VmUrl,
 			backend.Flag_NatsUrl,
+			backend.Flag_NatsEncoding,
 			backend.Flag_PostgresUrl,
+			backend.Flag_GeoIPCity,
+			backend.Flag_GeoIPAsn,
+			backend.Flag_HostingAsns,
+			backend.Flag_GeoIPCacheSize,
+			backend.Flag_GeoIPReloadInterval,
 		},
 		Commands: []*cli.Command{
 			crawler.Command,
@@ -26,10 +44,20 @@ func main() {
 			vm_otlp_exporter.Command,
 			pg_crawler_exporter.Command,
 			pg_monitor_exporter.Command,
+			clickhouse_exporter.Command,
+			replay.Command,
+			crawldiff.Command,
+			migrate.Command,
+			natssetup.Command,
+			config.Command,
 		},
 	}
+	config.Install(app)
 
-	if err := app.Run(os.Args); err != nil {
-		panic(err)
+	// services stop consuming and finish their in flight work when the context is canceled
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := app.RunContext(ctx, os.Args); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,289 @@
+// Package config loads the configuration file of the backend.
+//
+// The file has a section per command, named after it, and a global section with the flags shared by every command:
+//
+//	global:
+//	  nats-url: nats://nats:4222
+//	  geoip-city: /data/GeoLite2-City.mmdb
+//	crawler:
+//	  concurrency: 64
+//	  network: [amino=/ipfs/kad/1.0.0]
+//	clickhouse-exporter:
+//	  batch-wait: 10s
+//
+// Keys are the long names of the flags and every flag documents its default value.
+// Flags given on the command line or through their environment variables take precedence over the file.
+// YAML and TOML files are accepted, the format is picked from the file extension.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Section of the flags of the application itself
+const GlobalSection = "global"
+
+// Name of the command urfave/cli adds to every application
+const helpCommand = "help"
+
+const (
+	FormatYaml = "yaml"
+	FormatToml = "toml"
+)
+
+// Values of a configuration file by section and flag name.
+// Every value is kept as the string it would be given as on the command line, lists have one string per element.
+type File map[string]map[string][]string
+
+// Load the file at path, the format is picked from its extension
+func Load(path string) (File, error) {
+	format, err := formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %v: %w", path, err)
+	}
+	return file, nil
+}
+
+func Parse(data []byte, format string) (File, error) {
+	raw := make(map[string]any)
+	switch format {
+	case FormatYaml:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case FormatToml:
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q, expected %q or %q", format, FormatYaml, FormatToml)
+	}
+
+	file := make(File)
+	for section, value := range raw {
+		keys, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("section %v must be a table of flags", section)
+		}
+		file[section] = make(map[string][]string)
+		for key, value := range keys {
+			values, err := flagValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("%v.%v: %w", section, key, err)
+			}
+			file[section][key] = values
+		}
+	}
+	return file, nil
+}
+
+// Check that every section is a command of the app, every key is a flag of its section and every value parses
+func (f File) Validate(app *cli.App) error {
+	for _, section := range sortedKeys(f) {
+		flags, ok := sectionFlags(app, section)
+		if !ok {
+			return fmt.Errorf("unknown config section %q, expected one of %v", section, strings.Join(sections(app), ", "))
+		}
+		fs := flag.NewFlagSet(section, flag.ContinueOnError)
+		for _, fl := range flags {
+			if err := fl.Apply(fs); err != nil {
+				return err
+			}
+		}
+		for _, key := range sortedKeys(f[section]) {
+			if fl := findFlag(flags, key); fl == nil || ignored(fl) {
+				return fmt.Errorf("unknown config key %v.%v, see `%v --help`", section, key, strings.TrimSuffix(app.Name+" "+strings.TrimPrefix(section, GlobalSection), " "))
+			}
+			for _, value := range f[section][key] {
+				if err := fs.Set(key, value); err != nil {
+					return fmt.Errorf("invalid config value for %v.%v: %w", section, key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Chain the Before of every command of the app with one that applies the config file given with backend.Flag_Config
+func Install(app *cli.App) {
+	for _, command := range app.Commands {
+		before := command.Before
+		command.Before = func(c *cli.Context) error {
+			if err := Apply(c); err != nil {
+				return err
+			}
+			if before != nil {
+				return before(c)
+			}
+			return nil
+		}
+	}
+}
+
+// Set the flags of the command and the global flags that were not given on the command line or the environment
+func Apply(c *cli.Context) error {
+	path := c.String(backend.Flag_Config.Name)
+	if path == "" {
+		return nil
+	}
+	file, err := Load(path)
+	if err != nil {
+		return err
+	}
+	app := rootContext(c).App
+	if err := file.Validate(app); err != nil {
+		return fmt.Errorf("invalid config file %v: %w", path, err)
+	}
+
+	if err := apply(rootContext(c), app.Flags, file[GlobalSection]); err != nil {
+		return err
+	}
+	if c.Command != nil {
+		return apply(c, c.Command.Flags, file[c.Command.Name])
+	}
+	return nil
+}
+
+func apply(c *cli.Context, flags []cli.Flag, values map[string][]string) error {
+	for _, key := range sortedKeys(values) {
+		fl := findFlag(flags, key)
+		name := fl.Names()[0]
+		if c.IsSet(name) {
+			continue
+		}
+		for _, value := range values[key] {
+			if err := c.Set(name, value); err != nil {
+				return fmt.Errorf("invalid config value for %v: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Context of the application, commands with subcommands run them in an application of their own
+func rootContext(c *cli.Context) *cli.Context {
+	root := c
+	for _, ctx := range c.Lineage() {
+		if ctx.App != nil {
+			root = ctx
+		}
+	}
+	return root
+}
+
+func formatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYaml, nil
+	case ".toml":
+		return FormatToml, nil
+	default:
+		return "", fmt.Errorf("unknown config file extension of %v, expected .yaml, .yml or .toml", path)
+	}
+}
+
+// Convert a decoded value to the strings it would be given as on the command line
+func flagValues(value any) ([]string, error) {
+	if list, ok := value.([]any); ok {
+		values := make([]string, 0, len(list))
+		for _, element := range list {
+			v, err := flagValue(element)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	v, err := flagValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+func flagValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v of type %T", value, value)
+	}
+}
+
+func sectionFlags(app *cli.App, section string) ([]cli.Flag, bool) {
+	if section == GlobalSection {
+		return app.Flags, true
+	}
+	for _, command := range app.Commands {
+		if command.Name == section && command.Name != helpCommand {
+			return command.Flags, true
+		}
+	}
+	return nil, false
+}
+
+func sections(app *cli.App) []string {
+	names := []string{GlobalSection}
+	for _, command := range app.Commands {
+		if command.Name != helpCommand {
+			names = append(names, command.Name)
+		}
+	}
+	return names
+}
+
+// Flags that can not be given in the file
+func ignored(fl cli.Flag) bool {
+	return fl == cli.HelpFlag || fl == backend.Flag_Config
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, fl := range flags {
+		if slices.Contains(fl.Names(), name) {
+			return fl
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const testYaml = `
+global:
+  nats-url: nats://config:4222
+service:
+  workers: 8
+  wait: 10s
+  network: [amino, other]
+  name: from-config
+`
+
+const testToml = `
+[global]
+nats-url = "nats://config:4222"
+
+[service]
+workers = 8
+wait = "10s"
+network = ["amino", "other"]
+name = "from-config"
+`
+
+type result struct {
+	natsUrl string
+	workers int
+	wait    time.Duration
+	network []string
+	name    string
+}
+
+func testApp(r *result) *cli.App {
+	app := &cli.App{
+		Name:  "backend",
+		Flags: []cli.Flag{backend.Flag_Config, &cli.StringFlag{Name: "nats-url", Value: "nats://localhost:4222"}},
+		Commands: []*cli.Command{{
+			Name: "service",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "workers", Value: 1},
+				&cli.DurationFlag{Name: "wait", Value: time.Second},
+				&cli.StringSliceFlag{Name: "network"},
+				&cli.StringFlag{Name: "name", EnvVars: []string{"CONFIG_TEST_NAME"}},
+				&cli.StringFlag{Name: "database", DefaultText: "./database.mmdb"},
+			},
+			Action: func(c *cli.Context) error {
+				r.natsUrl = c.String("nats-url")
+				r.workers = c.Int("workers")
+				r.wait = c.Duration("wait")
+				r.network = c.StringSlice("network")
+				r.name = c.String("name")
+				return nil
+			},
+		}},
+	}
+	Install(app)
+	return app
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApply(t *testing.T) {
+	for name, content := range map[string]string{"backend.yaml": testYaml, "backend.toml": testToml} {
+		path := writeConfig(t, name, content)
+		r := &result{}
+		if err := testApp(r).RunContext(context.Background(), []string{"backend", "--config", path, "service", "--workers", "2"}); err != nil {
+			t.Fatal(err)
+		}
+		if r.natsUrl != "nats://config:4222" || r.wait != 10*time.Second || strings.Join(r.network, ",") != "amino,other" || r.name != "from-config" {
+			t.Fatalf("%v: config values were not applied %+v", name, r)
+		}
+		if r.workers != 2 {
+			t.Fatalf("%v: command line flags must take precedence over the config, got %v", name, r.workers)
+		}
+	}
+}
+
+func TestApplyEnvPrecedence(t *testing.T) {
+	t.Setenv("CONFIG_TEST_NAME", "from-env")
+	path := writeConfig(t, "backend.yaml", testYaml)
+	r := &result{}
+	if err := testApp(r).RunContext(context.Background(), []string{"backend", "--config", path, "service"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.name != "from-env" || r.workers != 8 {
+		t.Fatalf("environment variables must take precedence over the config, got %+v", r)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	app := testApp(&result{})
+	app.Setup()
+	for content, expected := range map[string]string{
+		"services:\n  workers: 1\n":   `unknown config section "services"`,
+		"service:\n  worker: 1\n":     "unknown config key service.worker",
+		"service:\n  workers: many\n": "invalid config value for service.workers",
+		"global:\n  config: a.yaml\n": "unknown config key global.config",
+		"service: 1\n":                "section service must be a table of flags",
+	} {
+		file, err := Parse([]byte(content), FormatYaml)
+		if err == nil {
+			err = file.Validate(app)
+		}
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error containing %q for %q, got %v", expected, content, err)
+		}
+	}
+	if _, err := Load("backend.json"); err == nil {
+		t.Fatalf("expected an error for an unknown extension")
+	}
+}
+
+func TestEffective(t *testing.T) {
+	t.Setenv("CONFIG_TEST_NAME", "from-env")
+	app := testApp(&result{})
+	app.Setup()
+	file, err := Parse([]byte(testYaml), FormatYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := Effective(app, file, "service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := yaml.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"workers: 8 # config",
+		"wait: 10s # config",
+		"network: [amino, other] # config",
+		"name: from-env # env CONFIG_TEST_NAME",
+		`database: "" # default: ./database.mmdb`,
+	} {
+		if !strings.Contains(string(out), line) {
+			t.Fatalf("expected %q in\n%s", line, out)
+		}
+	}
+	if strings.Contains(string(out), "global") {
+		t.Fatalf("only the selected sections must be printed\n%s", out)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if redact("clickhouse-password", "hunter2") != "<redacted>" {
+		t.Fatalf("passwords must be redacted")
+	}
+	if redact("postgres-url", "postgres://user:hunter2@db:5432/telemetry") != "postgres://user:xxxxx@db:5432/telemetry" {
+		t.Fatalf("url passwords must be redacted")
+	}
+	if redact("clickhouse-password", "") != "" {
+		t.Fatalf("empty values must be kept")
+	}
+}
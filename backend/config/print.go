@@ -0,0 +1,194 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var FLAG_SECTION = &cli.StringSliceFlag{
+	Name:  "section",
+	Usage: "only print the given sections, ex: global or crawler. defaults to every section",
+}
+
+var Command *cli.Command = &cli.Command{
+	Name:        "config",
+	Description: "inspect the configuration of the backend",
+	Subcommands: []*cli.Command{
+		{
+			Name:        "print",
+			Description: "print the effective configuration of every command as yaml, the source of each value is written next to it",
+			Flags: []cli.Flag{
+				FLAG_SECTION,
+			},
+			Action: printConfig,
+		},
+	},
+}
+
+func printConfig(c *cli.Context) error {
+	app := rootContext(c).App
+	file := make(File)
+	if path := c.String(backend.Flag_Config.Name); path != "" {
+		loaded, err := Load(path)
+		if err != nil {
+			return err
+		}
+		if err := loaded.Validate(app); err != nil {
+			return fmt.Errorf("invalid config file %v: %w", path, err)
+		}
+		file = loaded
+	}
+
+	selected := c.StringSlice(FLAG_SECTION.Name)
+	for _, section := range selected {
+		if _, ok := sectionFlags(app, section); !ok {
+			return fmt.Errorf("unknown config section %q, expected one of %v", section, strings.Join(sections(app), ", "))
+		}
+	}
+
+	document, err := Effective(app, file, selected...)
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+	return encoder.Encode(document)
+}
+
+// Effective configuration of the sections as a yaml document, every section if none is given.
+// Values come from the environment, the file or the flag defaults, in that order, and secrets are redacted.
+func Effective(app *cli.App, file File, only ...string) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, section := range sections(app) {
+		if len(only) > 0 && !slices.Contains(only, section) {
+			continue
+		}
+		flags, _ := sectionFlags(app, section)
+		node, err := effectiveSection(flags, file[section])
+		if err != nil {
+			return nil, fmt.Errorf("section %v: %w", section, err)
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, node)
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, nil
+}
+
+func effectiveSection(flags []cli.Flag, values map[string][]string) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, fl := range flags {
+		if err := fl.Apply(fs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, fl := range flags {
+		name := fl.Names()[0]
+		if ignored(fl) {
+			continue
+		}
+
+		source := defaultSource(fl)
+		if env, ok := envOf(fl); ok {
+			source = "env " + env
+		} else if vs, ok := fileValues(fl, values); ok {
+			source = "config"
+			for _, v := range vs {
+				if err := fs.Set(name, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		value := &yaml.Node{}
+		if err := value.Encode(printable(name, fs.Lookup(name).Value)); err != nil {
+			return nil, err
+		}
+		if value.Kind == yaml.SequenceNode {
+			// keeps the source comment on the line of the key
+			value.Style = yaml.FlowStyle
+		}
+		value.LineComment = source
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+	return node, nil
+}
+
+// Flags without a value whose default is picked by the command document it in their default text
+func defaultSource(fl cli.Flag) string {
+	if doc, ok := fl.(cli.DocGenerationFlag); ok && doc.TakesValue() && doc.GetValue() == "" && doc.GetDefaultText() != "" {
+		return "default: " + doc.GetDefaultText()
+	}
+	return "default"
+}
+
+// First environment variable of the flag that is set
+func envOf(fl cli.Flag) (string, bool) {
+	doc, ok := fl.(cli.DocGenerationFlag)
+	if !ok {
+		return "", false
+	}
+	for _, env := range doc.GetEnvVars() {
+		if _, ok := os.LookupEnv(env); ok {
+			return env, true
+		}
+	}
+	return "", false
+}
+
+func fileValues(fl cli.Flag, values map[string][]string) ([]string, bool) {
+	for _, name := range fl.Names() {
+		if vs, ok := values[name]; ok {
+			return vs, true
+		}
+	}
+	return nil, false
+}
+
+// Value of a flag as it is written in the config file
+func printable(name string, value flag.Value) any {
+	var v any = value.String()
+	switch typed := value.(type) {
+	case interface{ Value() []string }:
+		v = typed.Value()
+	case *cli.Timestamp:
+		if t := typed.Value(); t != nil {
+			v = t.Format(time.RFC3339)
+		} else {
+			v = ""
+		}
+	case flag.Getter:
+		switch got := typed.Get().(type) {
+		case bool, int, int64, uint, uint64, float64:
+			v = got
+		}
+	}
+	return redact(name, v)
+}
+
+func redact(name string, value any) any {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return value
+	}
+	if strings.Contains(name, "password") || strings.Contains(name, "secret") {
+		return "<redacted>"
+	}
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
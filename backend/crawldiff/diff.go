@@ -0,0 +1,226 @@
+// Package crawldiff compares the peers found by two crawls
+package crawldiff
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/diogo464/telemetry/walker"
+)
+
+type AgentChangeKind string
+
+const (
+	AgentUpgrade   AgentChangeKind = "upgrade"
+	AgentDowngrade AgentChangeKind = "downgrade"
+	// Different agent name or versions that can not be compared
+	AgentOther AgentChangeKind = "other"
+)
+
+type AgentChange struct {
+	Key
+	Kind   AgentChangeKind `json:"kind"`
+	Before string          `json:"before"`
+	After  string          `json:"after"`
+}
+
+type AddressChange struct {
+	Key
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Number of peers with some property in the old and the new crawl
+type Shift struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+func (s Shift) Delta() int {
+	return s.After - s.Before
+}
+
+// Differences between two crawls
+type Diff struct {
+	New            []*Peer         `json:"new"`
+	Vanished       []*Peer         `json:"vanished"`
+	AgentChanges   []AgentChange   `json:"agent_changes"`
+	AddressChanges []AddressChange `json:"address_changes"`
+	Summary        Summary         `json:"summary"`
+}
+
+// Aggregates of a diff, small enough to be published with every crawl
+type Summary struct {
+	Peers           int `json:"peers"`
+	PreviousPeers   int `json:"previous_peers"`
+	New             int `json:"new"`
+	Vanished        int `json:"vanished"`
+	AgentUpgrades   int `json:"agent_upgrades"`
+	AgentDowngrades int `json:"agent_downgrades"`
+	AgentChanges    int `json:"agent_changes"`
+	AddressChanges  int `json:"address_changes"`
+	// Number of peers supporting each protocol
+	Protocols map[string]Shift `json:"protocols"`
+	// Number of peers in each autonomous system and country, peers that could not be located are not counted
+	ASNs      map[uint]Shift   `json:"asns"`
+	Countries map[string]Shift `json:"countries"`
+}
+
+// Compare two crawls. The old snapshot can be empty, every peer is then new.
+func Compare(old, new *Snapshot) *Diff {
+	d := &Diff{
+		New:            []*Peer{},
+		Vanished:       []*Peer{},
+		AgentChanges:   []AgentChange{},
+		AddressChanges: []AddressChange{},
+		Summary: Summary{
+			Peers:         len(new.Peers),
+			PreviousPeers: len(old.Peers),
+			Protocols:     make(map[string]Shift),
+			ASNs:          make(map[uint]Shift),
+			Countries:     make(map[string]Shift),
+		},
+	}
+
+	for key, np := range new.Peers {
+		op, ok := old.Peers[key]
+		if !ok {
+			d.New = append(d.New, np)
+			continue
+		}
+		if op.Agent != np.Agent {
+			d.AgentChanges = append(d.AgentChanges, AgentChange{
+				Key:    key,
+				Kind:   agentChangeKind(op.Agent, np.Agent),
+				Before: op.Agent,
+				After:  np.Agent,
+			})
+		}
+		added, removed := setDiff(op.Addresses, np.Addresses)
+		if len(added) > 0 || len(removed) > 0 {
+			d.AddressChanges = append(d.AddressChanges, AddressChange{Key: key, Added: added, Removed: removed})
+		}
+	}
+	for key, op := range old.Peers {
+		if _, ok := new.Peers[key]; !ok {
+			d.Vanished = append(d.Vanished, op)
+		}
+	}
+
+	count(old, func(s Shift) Shift { s.Before += 1; return s }, &d.Summary)
+	count(new, func(s Shift) Shift { s.After += 1; return s }, &d.Summary)
+
+	d.Summary.New = len(d.New)
+	d.Summary.Vanished = len(d.Vanished)
+	d.Summary.AddressChanges = len(d.AddressChanges)
+	for _, change := range d.AgentChanges {
+		switch change.Kind {
+		case AgentUpgrade:
+			d.Summary.AgentUpgrades += 1
+		case AgentDowngrade:
+			d.Summary.AgentDowngrades += 1
+		default:
+			d.Summary.AgentChanges += 1
+		}
+	}
+
+	sortPeers(d.New)
+	sortPeers(d.Vanished)
+	slices.SortFunc(d.AgentChanges, func(a, b AgentChange) int { return compareKeys(a.Key, b.Key) })
+	slices.SortFunc(d.AddressChanges, func(a, b AddressChange) int { return compareKeys(a.Key, b.Key) })
+	return d
+}
+
+func count(s *Snapshot, inc func(Shift) Shift, summary *Summary) {
+	for _, p := range s.Peers {
+		for _, proto := range p.Protocols {
+			summary.Protocols[proto] = inc(summary.Protocols[proto])
+		}
+		if p.ASN != 0 {
+			summary.ASNs[p.ASN] = inc(summary.ASNs[p.ASN])
+		}
+		if p.Country != "" {
+			summary.Countries[p.Country] = inc(summary.Countries[p.Country])
+		}
+	}
+}
+
+// Classify an agent change, versions are only compared when the agent name is the same
+func agentChangeKind(before, after string) AgentChangeKind {
+	fb := walker.Classify(before, nil)
+	fa := walker.Classify(after, nil)
+	if fb.Agent != fa.Agent || fb.Version == "" || fa.Version == "" {
+		return AgentOther
+	}
+	switch c, ok := compareVersions(fb.Version, fa.Version); {
+	case !ok:
+		return AgentOther
+	case c < 0:
+		return AgentUpgrade
+	case c > 0:
+		return AgentDowngrade
+	default:
+		// same version, different commit or suffix
+		return AgentOther
+	}
+}
+
+// Compare dotted numeric versions like 0.25.0, any suffix after a '-' or '+' is ignored
+func compareVersions(a, b string) (int, bool) {
+	pa, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	pb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	return slices.Compare(pa, pb), true
+}
+
+func parseVersion(v string) ([]int, bool) {
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// Elements only in b and elements only in a, both inputs must be sorted
+func setDiff(a, b []string) ([]string, []string) {
+	added := []string{}
+	removed := []string{}
+	for _, v := range b {
+		if _, found := slices.BinarySearch(a, v); !found {
+			added = append(added, v)
+		}
+	}
+	for _, v := range a {
+		if _, found := slices.BinarySearch(b, v); !found {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+func sortPeers(peers []*Peer) {
+	slices.SortFunc(peers, func(a, b *Peer) int {
+		return compareKeys(Key{a.Network, a.ID}, Key{b.Network, b.ID})
+	})
+}
+
+func compareKeys(a, b Key) int {
+	if c := strings.Compare(a.Network, b.Network); c != 0 {
+		return c
+	}
+	return strings.Compare(string(a.ID), string(b.ID))
+}
@@ -0,0 +1,62 @@
+package crawldiff
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestCompare(t *testing.T) {
+	old := NewSnapshot()
+	old.Add(&Peer{Network: "amino", ID: peer.ID("a"), Agent: "kubo/0.24.0/", Addresses: []string{"/ip4/1.1.1.1/tcp/4001"}, Protocols: []string{"/ipfs/kad/1.0.0"}, Country: "Portugal"})
+	old.Add(&Peer{Network: "amino", ID: peer.ID("b"), Agent: "kubo/0.25.0/", Protocols: []string{"/ipfs/kad/1.0.0"}, ASN: 13335})
+	old.Add(&Peer{Network: "amino", ID: peer.ID("c"), Agent: "kubo/0.25.0/"})
+
+	new := NewSnapshot()
+	new.Add(&Peer{Network: "amino", ID: peer.ID("a"), Agent: "kubo/0.25.0/", Addresses: []string{"/ip4/1.1.1.2/tcp/4001"}, Protocols: []string{"/ipfs/kad/1.0.0", "/ipfs/bitswap/1.2.0"}, Country: "Spain"})
+	new.Add(&Peer{Network: "amino", ID: peer.ID("b"), Agent: "kubo/0.23.0/", Protocols: []string{"/ipfs/kad/1.0.0"}, ASN: 13335})
+	new.Add(&Peer{Network: "lan", ID: peer.ID("c"), Agent: "kubo/0.25.0/"})
+
+	d := Compare(old, new)
+	if len(d.New) != 1 || d.New[0].Network != "lan" {
+		t.Fatalf("unexpected new peers: %+v", d.New)
+	}
+	if len(d.Vanished) != 1 || d.Vanished[0].ID != peer.ID("c") || d.Vanished[0].Network != "amino" {
+		t.Fatalf("unexpected vanished peers: %+v", d.Vanished)
+	}
+	if len(d.AgentChanges) != 2 || d.AgentChanges[0].Kind != AgentUpgrade || d.AgentChanges[1].Kind != AgentDowngrade {
+		t.Fatalf("unexpected agent changes: %+v", d.AgentChanges)
+	}
+	if len(d.AddressChanges) != 1 || d.AddressChanges[0].Added[0] != "/ip4/1.1.1.2/tcp/4001" || d.AddressChanges[0].Removed[0] != "/ip4/1.1.1.1/tcp/4001" {
+		t.Fatalf("unexpected address changes: %+v", d.AddressChanges)
+	}
+
+	s := d.Summary
+	if s.Peers != 3 || s.PreviousPeers != 3 || s.New != 1 || s.Vanished != 1 || s.AgentUpgrades != 1 || s.AgentDowngrades != 1 || s.AddressChanges != 1 {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	if s.Protocols["/ipfs/bitswap/1.2.0"] != (Shift{Before: 0, After: 1}) || s.Protocols["/ipfs/kad/1.0.0"] != (Shift{Before: 2, After: 2}) {
+		t.Fatalf("unexpected protocol shifts: %+v", s.Protocols)
+	}
+	if s.Countries["Portugal"].Delta() != -1 || s.Countries["Spain"].Delta() != 1 || s.ASNs[13335].Delta() != 0 {
+		t.Fatalf("unexpected location shifts: %+v %+v", s.Countries, s.ASNs)
+	}
+}
+
+func TestAgentChangeKind(t *testing.T) {
+	cases := []struct {
+		before, after string
+		kind          AgentChangeKind
+	}{
+		{"kubo/0.9.1/", "kubo/0.10.0/", AgentUpgrade},
+		{"kubo/0.25.0-rc1/", "kubo/0.24.0/", AgentDowngrade},
+		{"kubo/0.25.0/abc", "kubo/0.25.0/def", AgentOther},
+		{"go-ipfs/0.12.0/", "kubo/0.25.0/", AgentOther},
+		{"", "kubo/0.25.0/", AgentOther},
+	}
+	for _, c := range cases {
+		if kind := agentChangeKind(c.before, c.after); kind != c.kind {
+			t.Fatalf("expected %v for %q -> %q, got %v", c.kind, c.before, c.after, kind)
+		}
+	}
+}
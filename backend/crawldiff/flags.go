@@ -0,0 +1,36 @@
+package crawldiff
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+var (
+	FLAG_FROM = &cli.IntFlag{
+		Name:  "from",
+		Usage: "id of the old crawl, defaults to the second to last crawl",
+	}
+
+	FLAG_TO = &cli.IntFlag{
+		Name:  "to",
+		Usage: "id of the new crawl, defaults to the last crawl",
+	}
+
+	FLAG_FORMAT = &cli.StringFlag{
+		Name:  "format",
+		Usage: fmt.Sprintf("output format, %s or %s", FormatText, FormatJson),
+		Value: FormatText,
+	}
+
+	FLAG_TOP = &cli.IntFlag{
+		Name:  "top",
+		Usage: "number of protocols, autonomous systems and countries with the largest shifts shown in the text format",
+		Value: 10,
+	}
+)
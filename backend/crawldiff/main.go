@@ -0,0 +1,128 @@
+package crawldiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+var Command *cli.Command = &cli.Command{
+	Name:        "crawl-diff",
+	Description: "compare the peers of two crawls stored in postgres",
+	Flags: []cli.Flag{
+		FLAG_FROM,
+		FLAG_TO,
+		FLAG_FORMAT,
+		FLAG_TOP,
+	},
+	Action: main,
+}
+
+func main(c *cli.Context) error {
+	logger := backend.ServiceSetup(c, "crawl-diff")
+	format := c.String(FLAG_FORMAT.Name)
+	if format != FormatText && format != FormatJson {
+		return fmt.Errorf("invalid format %q", format)
+	}
+
+	conn := backend.PostgresClient(logger, c)
+	defer conn.Close(c.Context)
+
+	from, to := c.Int(FLAG_FROM.Name), c.Int(FLAG_TO.Name)
+	if !c.IsSet(FLAG_FROM.Name) || !c.IsSet(FLAG_TO.Name) {
+		latest, err := LatestCrawls(c.Context, conn, 2)
+		if err != nil {
+			return err
+		}
+		if len(latest) < 2 {
+			return fmt.Errorf("at least two crawls are required, found %v", len(latest))
+		}
+		if !c.IsSet(FLAG_FROM.Name) {
+			from = latest[0]
+		}
+		if !c.IsSet(FLAG_TO.Name) {
+			to = latest[1]
+		}
+	}
+
+	logger.Info("comparing crawls", zap.Int("from", from), zap.Int("to", to))
+	old, err := LoadCrawl(c.Context, conn, from)
+	if err != nil {
+		return err
+	}
+	new, err := LoadCrawl(c.Context, conn, to)
+	if err != nil {
+		return err
+	}
+
+	diff := Compare(old, new)
+	if format == FormatJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	WriteText(os.Stdout, diff, c.Int(FLAG_TOP.Name))
+	return nil
+}
+
+// Write a human readable report of a diff, only the top largest shifts of each category are included
+func WriteText(w io.Writer, d *Diff, top int) {
+	s := d.Summary
+	fmt.Fprintf(w, "peers:            %v -> %v (%+d)\n", s.PreviousPeers, s.Peers, s.Peers-s.PreviousPeers)
+	fmt.Fprintf(w, "new:              %v\n", s.New)
+	fmt.Fprintf(w, "vanished:         %v\n", s.Vanished)
+	fmt.Fprintf(w, "agent upgrades:   %v\n", s.AgentUpgrades)
+	fmt.Fprintf(w, "agent downgrades: %v\n", s.AgentDowngrades)
+	fmt.Fprintf(w, "agent changes:    %v\n", s.AgentChanges)
+	fmt.Fprintf(w, "address changes:  %v\n", s.AddressChanges)
+
+	writeShifts(w, "protocols", s.Protocols, top)
+	asns := make(map[string]Shift, len(s.ASNs))
+	for asn, shift := range s.ASNs {
+		asns["AS"+strconv.FormatUint(uint64(asn), 10)] = shift
+	}
+	writeShifts(w, "autonomous systems", asns, top)
+	writeShifts(w, "countries", s.Countries, top)
+}
+
+func writeShifts(w io.Writer, title string, shifts map[string]Shift, top int) {
+	names := make([]string, 0, len(shifts))
+	for name, shift := range shifts {
+		if shift.Delta() != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := abs(shifts[b].Delta()) - abs(shifts[a].Delta()); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if len(names) > top {
+		names = names[:top]
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, name := range names {
+		shift := shifts[name]
+		fmt.Fprintf(w, "  %-48s %6v -> %6v (%+d)\n", name, shift.Before, shift.After, shift.Delta())
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
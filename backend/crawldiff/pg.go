@@ -0,0 +1,60 @@
+package crawldiff
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Load the peers of a crawl stored by the postgres crawler exporter
+func LoadCrawl(ctx context.Context, conn *pgx.Conn, crawl int) (*Snapshot, error) {
+	rows, err := conn.Query(ctx, "SELECT network, peer_id, agent, addresses, protocols, asn, country FROM crawler.peer WHERE crawl = $1", crawl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query peers of crawl %v: %w", crawl, err)
+	}
+	defer rows.Close()
+
+	s := NewSnapshot()
+	for rows.Next() {
+		var pidStr string
+		var asn *int64
+		var country *string
+		p := &Peer{}
+		if err := rows.Scan(&p.Network, &pidStr, &p.Agent, &p.Addresses, &p.Protocols, &asn, &country); err != nil {
+			return nil, fmt.Errorf("failed to scan peer of crawl %v: %w", crawl, err)
+		}
+		pid, err := peer.Decode(pidStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer id %q in crawl %v: %w", pidStr, crawl, err)
+		}
+		p.ID = pid
+		if asn != nil {
+			p.ASN = uint(*asn)
+		}
+		if country != nil {
+			p.Country = *country
+		}
+		s.Add(p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read peers of crawl %v: %w", crawl, err)
+	}
+	return s, nil
+}
+
+// Ids of the last n crawls, oldest first
+func LatestCrawls(ctx context.Context, conn *pgx.Conn, n int) ([]int, error) {
+	rows, err := conn.Query(ctx, "SELECT id FROM crawler.crawl WHERE timestamp_end IS NOT NULL ORDER BY timestamp_end DESC LIMIT $1", n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest crawls: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latest crawls: %w", err)
+	}
+	slices.Reverse(ids)
+	return ids, nil
+}
@@ -0,0 +1,82 @@
+package crawldiff
+
+import (
+	"net"
+	"slices"
+
+	"github.com/diogo464/ipfs-telemetry/backend/enrich"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// A peer is identified by its id and the network it was found in
+type Key struct {
+	Network string  `json:"network"`
+	ID      peer.ID `json:"id"`
+}
+
+// State of a peer in a single crawl
+type Peer struct {
+	Network   string   `json:"network"`
+	ID        peer.ID  `json:"id"`
+	Agent     string   `json:"agent"`
+	Addresses []string `json:"addresses"`
+	Protocols []string `json:"protocols"`
+	// Zero and empty if unknown
+	ASN     uint   `json:"asn,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// Every peer found in a crawl
+type Snapshot struct {
+	Peers map[Key]*Peer
+}
+
+func NewSnapshot() *Snapshot {
+	return &Snapshot{Peers: make(map[Key]*Peer)}
+}
+
+// Locates the autonomous system and country of an ip
+type Locator interface {
+	Locate(ip net.IP) (asn uint, country string, ok bool)
+}
+
+// Add a peer to the snapshot, replacing any previous entry with the same key
+func (s *Snapshot) Add(p *Peer) {
+	slices.Sort(p.Addresses)
+	slices.Sort(p.Protocols)
+	s.Peers[Key{p.Network, p.ID}] = p
+}
+
+// Add a walked peer to the snapshot, the locator can be nil
+func (s *Snapshot) AddWalkerPeer(p *walker.Peer, locator Locator) {
+	network := p.Network
+	if network == "" {
+		network = walker.NetworkNameAmino
+	}
+	addrs := make([]string, len(p.Addresses))
+	for i, addr := range p.Addresses {
+		addrs[i] = addr.String()
+	}
+	protocols := make([]string, len(p.Protocols))
+	for i, proto := range p.Protocols {
+		protocols[i] = string(proto)
+	}
+
+	sp := &Peer{
+		Network:   network,
+		ID:        p.ID,
+		Agent:     p.Agent,
+		Addresses: addrs,
+		Protocols: protocols,
+	}
+	if locator != nil {
+		if ips := enrich.PublicIPs(p.Addresses); len(ips) > 0 {
+			if asn, country, ok := locator.Locate(ips[0]); ok {
+				sp.ASN = asn
+				sp.Country = country
+			}
+		}
+	}
+	s.Add(sp)
+}
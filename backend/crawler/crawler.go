@@ -3,20 +3,61 @@ package crawler
 import (
 	"time"
 
+	"github.com/diogo464/ipfs-telemetry/backend/crawldiff"
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
+	"github.com/diogo464/telemetry/crawler"
 	"github.com/diogo464/telemetry/walker"
 )
 
 const (
-	SubjectCrawler = "crawler"
-	StreamCrawler  = "crawler"
+	SubjectCrawler = streams.SubjectCrawler
+	StreamCrawler  = streams.StreamCrawler
 
 	KindPeer       = "peer"
 	KindCrawlBegin = "crawl_begin"
 	KindCrawlEnd   = "crawl_end"
+	KindError      = "error"
+	// Join, leave or address change of a peer, only published by continuous crawlers
+	KindEvent = "event"
+)
+
+// How the peers of a crawl were found
+const (
+	// Walked the buckets of the dht, messages published by older crawlers have no mode and are active crawls
+	ModeActive = "active"
+	// Walked the dht and kept querying the peers found to publish events
+	ModeContinuous = "continuous"
+	// Recorded the peers that contacted a dht server node, a crawl is a round of the node
+	ModePassive = "passive"
 )
 
 type NatsMessage struct {
-	Kind      string       `json:"kind"`
-	Timestamp time.Time    `json:"timestamp"`
-	Peer      *walker.Peer `json:"peer,omitempty"`
+	Kind      string    `json:"kind"`
+	Timestamp time.Time `json:"timestamp"`
+	// Identity of the crawl the message belongs to, unique across crawlers.
+	// Messages published by older crawlers have no identity.
+	Crawl string `json:"crawl,omitempty"`
+	// Mode of the crawler that published the message, empty for older crawlers
+	Mode  string        `json:"mode,omitempty"`
+	Peer  *walker.Peer  `json:"peer,omitempty"`
+	Error *walker.Error `json:"error,omitempty"`
+	// Difference to the previous crawl, only set on crawl end
+	Summary *crawldiff.Summary `json:"summary,omitempty"`
+	Event   *crawler.Event     `json:"event,omitempty"`
+}
+
+// Mode of the crawler that published the message, active for older crawlers
+func (m *NatsMessage) CrawlMode() string {
+	if m.Mode == "" {
+		return ModeActive
+	}
+	return m.Mode
+}
+
+// Messages published before peers were tagged with their network belong to the amino network
+func NetworkOrDefault(network string) string {
+	if network == "" {
+		return walker.NetworkNameAmino
+	}
+	return network
 }
@@ -1,29 +1,163 @@
 package crawler
 
-import "github.com/urfave/cli/v2"
+import (
+	"time"
+
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/diogo464/telemetry/walker/preimage"
+	"github.com/urfave/cli/v2"
+)
 
 var (
 	FLAG_CONCURRENCY = &cli.IntFlag{
 		Name:    "concurrency",
 		Usage:   "how many peers to request at the same time",
 		EnvVars: []string{"CRAWLER_CONCURRENCY"},
+		Value:   walker.DEFAULT_CONCURRENCY,
 	}
 
 	FLAG_CONNECT_TIMEOUT = &cli.DurationFlag{
 		Name:    "connect-timeout",
 		Usage:   "how long before a connection attempt times out",
 		EnvVars: []string{"CRAWLER_CONNECT_TIMEOUT"},
+		Value:   walker.DEFAULT_CONNECT_TIMEOUT,
 	}
 
 	FLAG_REQUEST_TIMEOUT = &cli.DurationFlag{
 		Name:    "request-timeout",
 		Usage:   "how long before a request times out",
 		EnvVars: []string{"CRAWLER_REQUEST_TIMEOUT"},
+		Value:   walker.DEFAULT_REQUEST_TIMEOUT,
 	}
 
 	FLAG_INTERVAL = &cli.DurationFlag{
 		Name:    "interval",
 		Usage:   "how long to wait between each peer request",
 		EnvVars: []string{"CRAWLER_INTERVAL"},
+		Value:   walker.DEFAULT_INTERVAL,
+	}
+
+	FLAG_PREIMAGE_TABLE = &cli.StringFlag{
+		Name:    "preimage-table",
+		Usage:   "path of the cached preimage table, generated and written there if missing",
+		EnvVars: []string{"CRAWLER_PREIMAGE_TABLE"},
+	}
+
+	FLAG_PREIMAGE_BITS = &cli.IntFlag{
+		Name:    "preimage-bits",
+		Usage:   "number of prefix bits covered by the preimage table",
+		EnvVars: []string{"CRAWLER_PREIMAGE_BITS"},
+		Value:   preimage.DefaultBits,
+	}
+
+	FLAG_GRAPH_DIR = &cli.StringFlag{
+		Name:    "graph-dir",
+		Usage:   "directory where the routing table graph of each crawl is written as a gzipped edge list",
+		EnvVars: []string{"CRAWLER_GRAPH_DIR"},
+	}
+
+	FLAG_NETWORK = &cli.StringSliceFlag{
+		Name:        "network",
+		Usage:       "dht network to crawl as <name>=<protocol prefix>[,<protocol prefix>...], ex: lan=/ipfs/lan. defaults to the amino dht",
+		EnvVars:     []string{"CRAWLER_NETWORK"},
+		DefaultText: "amino=/ipfs",
+	}
+
+	FLAG_NETWORK_SEED = &cli.StringSliceFlag{
+		Name:    "network-seed",
+		Usage:   "seed peer of a network as <name>=<multiaddr with /p2p>, the amino network uses the default bootstrap peers if it has none",
+		EnvVars: []string{"CRAWLER_NETWORK_SEED"},
+	}
+
+	FLAG_MAX_INTERVAL = &cli.DurationFlag{
+		Name:    "max-interval",
+		Usage:   "enable adaptive pacing, the interval between peer requests grows on request timeouts up to this value",
+		EnvVars: []string{"CRAWLER_MAX_INTERVAL"},
+	}
+
+	FLAG_MAX_PER_PREFIX = &cli.IntFlag{
+		Name:    "max-per-prefix",
+		Usage:   "maximum number of peers of the same ipv4 /24 or ipv6 /48 walked at the same time, 0 for unlimited",
+		EnvVars: []string{"CRAWLER_MAX_PER_PREFIX"},
+	}
+
+	FLAG_MAX_PER_ASN = &cli.IntFlag{
+		Name:    "max-per-asn",
+		Usage:   "maximum number of peers of the same autonomous system walked at the same time, 0 for unlimited. requires the --geoip-asn database",
+		EnvVars: []string{"CRAWLER_MAX_PER_ASN"},
+	}
+
+	FLAG_CONNECTION_BUDGET = &cli.IntFlag{
+		Name:    "connection-budget",
+		Usage:   "maximum number of open connections across all networks, 0 for unlimited",
+		EnvVars: []string{"CRAWLER_CONNECTION_BUDGET"},
+	}
+
+	FLAG_DIAL_CACHE_TTL = &cli.DurationFlag{
+		Name:    "dial-cache-ttl",
+		Usage:   "how long peers that could not be connected to are skipped by the following crawls, 0 to disable",
+		EnvVars: []string{"CRAWLER_DIAL_CACHE_TTL"},
+	}
+
+	FLAG_DIAL_CACHE_SIZE = &cli.IntFlag{
+		Name:    "dial-cache-size",
+		Usage:   "maximum number of peers in the dial cache",
+		EnvVars: []string{"CRAWLER_DIAL_CACHE_SIZE"},
+		Value:   100000,
+	}
+
+	FLAG_CONTINUOUS = &cli.BoolFlag{
+		Name:    "continuous",
+		Usage:   "after the first crawl keep a live set of peers, query each one on its own schedule and publish peer join, leave and address change events. requires a single network",
+		EnvVars: []string{"CRAWLER_CONTINUOUS"},
+	}
+
+	FLAG_REQUERY_INTERVAL = &cli.DurationFlag{
+		Name:    "requery-interval",
+		Usage:   "how long to wait before querying a live peer again, in continuous mode",
+		EnvVars: []string{"CRAWLER_REQUERY_INTERVAL"},
+		Value:   crawler.DEFAULT_REQUERY_INTERVAL,
+	}
+
+	FLAG_RETRY_INTERVAL = &cli.DurationFlag{
+		Name:    "retry-interval",
+		Usage:   "how long to wait before querying a peer again after a failed query, in continuous mode",
+		EnvVars: []string{"CRAWLER_RETRY_INTERVAL"},
+		Value:   crawler.DEFAULT_RETRY_INTERVAL,
+	}
+
+	FLAG_LEAVE_AFTER = &cli.IntFlag{
+		Name:    "leave-after",
+		Usage:   "number of consecutive failed queries after which a peer has left the network, in continuous mode",
+		EnvVars: []string{"CRAWLER_LEAVE_AFTER"},
+		Value:   crawler.DEFAULT_LEAVE_AFTER,
+	}
+
+	FLAG_CONTINUOUS_CONCURRENCY = &cli.IntFlag{
+		Name:    "continuous-concurrency",
+		Usage:   "maximum number of peers queried at the same time, in continuous mode",
+		EnvVars: []string{"CRAWLER_CONTINUOUS_CONCURRENCY"},
+		Value:   crawler.DEFAULT_CONTINUOUS_CONCURRENCY,
+	}
+
+	FLAG_PASSIVE = &cli.BoolFlag{
+		Name:    "passive",
+		Usage:   "discover peers passively by running a dht server node and recording every peer that contacts it instead of crawling",
+		EnvVars: []string{"CRAWLER_PASSIVE"},
+	}
+
+	FLAG_PASSIVE_LISTEN = &cli.StringSliceFlag{
+		Name:        "passive-listen",
+		Usage:       "multiaddrs the passive dht server node listens on, defaults to port 4001 on all interfaces",
+		EnvVars:     []string{"CRAWLER_PASSIVE_LISTEN"},
+		DefaultText: "/ip4/0.0.0.0/tcp/4001, /ip4/0.0.0.0/udp/4001/quic-v1, /ip6/::/tcp/4001, /ip6/::/udp/4001/quic-v1",
+	}
+
+	FLAG_PASSIVE_ROUND = &cli.DurationFlag{
+		Name:    "passive-round",
+		Usage:   "how often the peers seen by the passive node are published as a crawl",
+		EnvVars: []string{"CRAWLER_PASSIVE_ROUND"},
+		Value:   30 * time.Minute,
 	}
 )
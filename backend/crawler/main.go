@@ -1,11 +1,26 @@
 package crawler
 
 import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
 	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/enrich"
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
 	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/crawler/passive"
 	"github.com/diogo464/telemetry/walker"
+	"github.com/diogo464/telemetry/walker/preimage"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/urfave/cli/v2"
 	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
 )
 
 var Command *cli.Command = &cli.Command{
@@ -16,6 +31,25 @@ var Command *cli.Command = &cli.Command{
 		FLAG_CONNECT_TIMEOUT,
 		FLAG_REQUEST_TIMEOUT,
 		FLAG_INTERVAL,
+		FLAG_PREIMAGE_TABLE,
+		FLAG_PREIMAGE_BITS,
+		FLAG_GRAPH_DIR,
+		FLAG_NETWORK,
+		FLAG_NETWORK_SEED,
+		FLAG_MAX_INTERVAL,
+		FLAG_MAX_PER_PREFIX,
+		FLAG_MAX_PER_ASN,
+		FLAG_CONNECTION_BUDGET,
+		FLAG_DIAL_CACHE_TTL,
+		FLAG_DIAL_CACHE_SIZE,
+		FLAG_CONTINUOUS,
+		FLAG_REQUERY_INTERVAL,
+		FLAG_RETRY_INTERVAL,
+		FLAG_LEAVE_AFTER,
+		FLAG_CONTINUOUS_CONCURRENCY,
+		FLAG_PASSIVE,
+		FLAG_PASSIVE_LISTEN,
+		FLAG_PASSIVE_ROUND,
 	},
 	Action: main,
 }
@@ -37,20 +71,118 @@ func main(c *cli.Context) error {
 		walkerOpts = append(walkerOpts, walker.WithInterval(c.Duration(FLAG_INTERVAL.Name)))
 	}
 
+	enricher, err := enrich.FromFlags(logger.Named("enrich"), c)
+	if err != nil {
+		return fmt.Errorf("failed to open geoip databases: %w", err)
+	}
+	defer enricher.Close()
+
+	if c.IsSet(FLAG_MAX_INTERVAL.Name) {
+		walkerOpts = append(walkerOpts, walker.WithAdaptivePacing(c.Duration(FLAG_MAX_INTERVAL.Name)))
+	}
+	if n := c.Int(FLAG_MAX_PER_PREFIX.Name); n > 0 {
+		walkerOpts = append(walkerOpts, walker.WithGroupLimit(walker.GroupByPrefix(24, 48), n))
+	}
+	if n := c.Int(FLAG_MAX_PER_ASN.Name); n > 0 {
+		if !enricher.HasASN() {
+			return fmt.Errorf("--%s requires the --%s database", FLAG_MAX_PER_ASN.Name, backend.Flag_GeoIPAsn.Name)
+		}
+		walkerOpts = append(walkerOpts, walker.WithGroupLimit(walker.GroupByASN(func(ip net.IP) (uint, bool) {
+			if loc := enricher.Lookup(ip); loc != nil && loc.ASN != 0 {
+				return loc.ASN, true
+			}
+			return 0, false
+		}), n))
+	}
+	// shared by the walkers of every network
+	if n := c.Int(FLAG_CONNECTION_BUDGET.Name); n > 0 {
+		walkerOpts = append(walkerOpts, walker.WithConnectionBudget(walker.NewConnectionBudget(n)))
+	}
+	if ttl := c.Duration(FLAG_DIAL_CACHE_TTL.Name); ttl > 0 {
+		walkerOpts = append(walkerOpts, walker.WithDialCache(walker.NewDialCache(ttl, c.Int(FLAG_DIAL_CACHE_SIZE.Name))))
+	}
+
+	bits := c.Int(FLAG_PREIMAGE_BITS.Name)
+	if bits < 1 || bits > preimage.MaxBits {
+		return fmt.Errorf("invalid number of preimage bits %v, must be between 1 and %v", bits, preimage.MaxBits)
+	}
+	var table *preimage.Table
+	if path := c.String(FLAG_PREIMAGE_TABLE.Name); path != "" {
+		logger.Info("loading preimage table", zap.String("path", path), zap.Int("bits", bits))
+		t, err := preimage.LoadOrGenerate(path, bits)
+		if err != nil {
+			return fmt.Errorf("failed to load preimage table: %w", err)
+		}
+		table = t
+	} else {
+		logger.Info("generating preimage table", zap.Int("bits", bits))
+		table = preimage.GenerateWithBits(bits)
+	}
+	walkerOpts = append(walkerOpts, walker.WithPreimageTable(table))
+
+	if c.Bool(FLAG_PASSIVE.Name) && c.Bool(FLAG_CONTINUOUS.Name) {
+		return fmt.Errorf("--%s and --%s can not be used together", FLAG_PASSIVE.Name, FLAG_CONTINUOUS.Name)
+	}
+	mode := ModeActive
+	if c.Bool(FLAG_PASSIVE.Name) {
+		mode = ModePassive
+	} else if c.Bool(FLAG_CONTINUOUS.Name) {
+		mode = ModeContinuous
+	}
+
 	url := c.String(backend.Flag_NatsUrl.Name)
-	natsObserver, err := newNatsObserver(logger.Named("nats-observer"), url)
+	natsObserver, err := newNatsObserver(logger.Named("nats-observer"), url, backend.NatsEncoding(logger, c), mode, enricher)
 	if err != nil {
 		return err
 	}
+	// messages buffered by the connection are published before exiting
+	defer natsObserver.nc.Drain()
+	backend.AddNatsReadinessCheck(natsObserver.nc)
+	js, err := jetstream.New(natsObserver.nc)
+	if err != nil {
+		return err
+	}
+	if err := streams.ValidateStream(c.Context, js, StreamCrawler); err != nil {
+		return err
+	}
 
-	logger.Info("creating crawler")
-	crlwr, err := crawler.NewCrawler(
+	networks, err := networksFromFlags(c)
+	if err != nil {
+		return err
+	}
+	if mode == ModePassive {
+		return runPassive(c, logger, networks, natsObserver)
+	}
+
+	crawlerOpts := []crawler.Option{
 		crawler.WithWalkerObserver(newLoggerObserver(logger)),
 		crawler.WithObserver(natsObserver),
 		crawler.WithWalkerOption(walkerOpts...),
 		crawler.WithLogger(logger.Named("crawler")),
 		crawler.WithMeterProvider(otel.GetMeterProvider()),
-	)
+	}
+	if mode == ModeContinuous {
+		crawlerOpts = append(crawlerOpts,
+			crawler.WithContinuous(true),
+			crawler.WithRequeryInterval(c.Duration(FLAG_REQUERY_INTERVAL.Name)),
+			crawler.WithRetryInterval(c.Duration(FLAG_RETRY_INTERVAL.Name)),
+			crawler.WithLeaveAfter(c.Int(FLAG_LEAVE_AFTER.Name)),
+			crawler.WithContinuousConcurrency(c.Int(FLAG_CONTINUOUS_CONCURRENCY.Name)),
+		)
+	}
+	for _, network := range networks {
+		logger.Info("crawling network", zap.String("network", network.Name), zap.Any("protocols", network.Protocols), zap.Int("seeds", len(network.Seeds)))
+		crawlerOpts = append(crawlerOpts, crawler.WithNetwork(network))
+	}
+	if dir := c.String(FLAG_GRAPH_DIR.Name); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create graph directory: %w", err)
+		}
+		crawlerOpts = append(crawlerOpts, crawler.WithObserver(newGraphObserver(logger.Named("graph-observer"), dir)))
+	}
+
+	logger.Info("creating crawler")
+	crlwr, err := crawler.NewCrawler(crawlerOpts...)
 	if err != nil {
 		return err
 	}
@@ -58,3 +190,84 @@ func main(c *cli.Context) error {
 	logger.Info("starting crawler")
 	return crlwr.Run(c.Context)
 }
+
+func runPassive(c *cli.Context, logger *zap.Logger, networks []walker.Network, natsObserver *natsObserver) error {
+	if len(networks) != 1 {
+		return fmt.Errorf("passive discovery requires exactly one network, got %v", len(networks))
+	}
+	network := networks[0]
+
+	passiveOpts := []passive.Option{
+		passive.WithLogger(logger.Named("passive")),
+		passive.WithNetwork(network),
+		passive.WithWalkerObserver(newLoggerObserver(logger)),
+		passive.WithObserver(natsObserver),
+		passive.WithRoundInterval(c.Duration(FLAG_PASSIVE_ROUND.Name)),
+	}
+	if c.IsSet(FLAG_PASSIVE_LISTEN.Name) {
+		addrs := make([]multiaddr.Multiaddr, 0)
+		for _, value := range c.StringSlice(FLAG_PASSIVE_LISTEN.Name) {
+			addr, err := multiaddr.NewMultiaddr(value)
+			if err != nil {
+				return fmt.Errorf("invalid passive listen address %q: %w", value, err)
+			}
+			addrs = append(addrs, addr)
+		}
+		passiveOpts = append(passiveOpts, passive.WithListenAddrs(addrs...))
+	}
+
+	logger.Info("creating passive node", zap.String("network", network.Name), zap.Any("protocols", network.Protocols))
+	node, err := passive.New(c.Context, passiveOpts...)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	logger.Info("starting passive node", zap.String("id", node.Host().ID().String()), zap.Any("addrs", node.Host().Addrs()))
+	return node.Run(c.Context)
+}
+
+func networksFromFlags(c *cli.Context) ([]walker.Network, error) {
+	networks := make([]walker.Network, 0)
+	for _, value := range c.StringSlice(FLAG_NETWORK.Name) {
+		name, prefixes, ok := strings.Cut(value, "=")
+		if !ok || name == "" || prefixes == "" {
+			return nil, fmt.Errorf("invalid network %q, expected <name>=<protocol prefix>[,<protocol prefix>...]", value)
+		}
+		protocols := make([]protocol.ID, 0)
+		for _, prefix := range strings.Split(prefixes, ",") {
+			protocols = append(protocols, protocol.ID(prefix))
+		}
+		networks = append(networks, walker.NewNetwork(name, protocols, nil))
+	}
+
+	for _, value := range c.StringSlice(FLAG_NETWORK_SEED.Name) {
+		name, addr, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid network seed %q, expected <name>=<multiaddr>", value)
+		}
+		idx := slices.IndexFunc(networks, func(n walker.Network) bool { return n.Name == name })
+		if idx == -1 {
+			return nil, fmt.Errorf("seed %q for unknown network %q", addr, name)
+		}
+		info, err := peer.AddrInfoFromString(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed for network %q: %w", name, err)
+		}
+		networks[idx].Seeds = append(networks[idx].Seeds, *info)
+	}
+
+	for i, network := range networks {
+		if len(network.Seeds) > 0 {
+			continue
+		}
+		if network.Name != walker.NetworkNameAmino {
+			return nil, fmt.Errorf("network %q has no seeds", network.Name)
+		}
+		networks[i].Seeds = walker.NetworkAmino().Seeds
+	}
+	if len(networks) == 0 {
+		networks = append(networks, walker.NetworkAmino())
+	}
+	return networks, nil
+}
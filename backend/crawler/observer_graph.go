@@ -0,0 +1,22 @@
+package crawler
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/diogo464/telemetry/crawler/graph"
+	"go.uber.org/zap"
+)
+
+// Writes the routing table graph of each crawl to <dir>/crawl-<unix timestamp>.edges.gz
+func newGraphObserver(l *zap.Logger, dir string) *graph.Observer {
+	return graph.NewObserver(func(g *graph.Graph) {
+		path := filepath.Join(dir, fmt.Sprintf("crawl-%d.edges.gz", time.Now().Unix()))
+		if err := graph.WriteEdgeListFile(path, g); err != nil {
+			l.Error("failed to write crawl graph", zap.String("path", path), zap.Error(err))
+			return
+		}
+		l.Info("wrote crawl graph", zap.String("path", path), zap.Int("nodes", g.NumNodes()), zap.Int("edges", g.NumEdges()))
+	})
+}
@@ -17,7 +17,7 @@ func newLoggerObserver(l *zap.Logger) *loggerObserver {
 
 // ObserveError implements walker.Observer
 func (o *loggerObserver) ObserveError(e *walker.Error) {
-	o.l.Warn("error", zap.String("peer", e.ID.String()), zap.Error(e.Err))
+	o.l.Debug("error", zap.String("peer", e.ID.String()), zap.String("stage", string(e.Stage)), zap.String("reason", string(e.Reason)), zap.Error(e.Err))
 }
 
 // ObservePeer implements walker.Observer
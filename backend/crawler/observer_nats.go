@@ -1,13 +1,15 @@
 package crawler
 
 import (
-	"encoding/json"
 	"time"
 
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/crawldiff"
 	"github.com/diogo464/ipfs-telemetry/backend/monitor"
 	"github.com/diogo464/telemetry"
 	"github.com/diogo464/telemetry/crawler"
 	"github.com/diogo464/telemetry/walker"
+	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 )
@@ -15,24 +17,44 @@ import (
 var _ (crawler.Observer) = (*natsObserver)(nil)
 
 type natsObserver struct {
-	l  *zap.Logger
-	nc *nats.Conn
+	l        *zap.Logger
+	nc       *nats.Conn
+	encoding backend.Encoding
+	mode     string
+	// identity of the current crawl, stamped on every message
+	crawl string
+	// continuous crawlers keep querying peers after their first crawl ends, those results are only published as events
+	crawling bool
+
+	// used to summarize each crawl, the locator can be nil
+	locator  crawldiff.Locator
+	previous *crawldiff.Snapshot
+	current  *crawldiff.Snapshot
 }
 
-func newNatsObserver(l *zap.Logger, natsUrl string) (*natsObserver, error) {
+func newNatsObserver(l *zap.Logger, natsUrl string, encoding backend.Encoding, mode string, locator crawldiff.Locator) (*natsObserver, error) {
 	l.Info("connecting to nats at " + natsUrl)
-	nc, err := nats.Connect(natsUrl)
+	nc, err := nats.Connect(natsUrl, nats.MaxReconnects(-1))
 	if err != nil {
 		l.Error("failed to connect to nats at "+natsUrl, zap.Error(err))
 		return nil, err
 	}
 	return &natsObserver{
-		l:  l,
-		nc: nc,
+		l:        l,
+		nc:       nc,
+		encoding: encoding,
+		mode:     mode,
+
+		locator:  locator,
+		previous: crawldiff.NewSnapshot(),
+		current:  crawldiff.NewSnapshot(),
 	}, nil
 }
 
 func (o *natsObserver) CrawlBegin() {
+	o.current = crawldiff.NewSnapshot()
+	o.crawl = uuid.NewString()
+	o.crawling = true
 	o.publishMessage(NatsMessage{
 		Kind:      KindCrawlBegin,
 		Timestamp: time.Now(),
@@ -40,22 +62,30 @@ func (o *natsObserver) CrawlBegin() {
 }
 
 func (o *natsObserver) CrawlEnd() {
+	diff := crawldiff.Compare(o.previous, o.current)
+	o.previous = o.current
+	o.current = crawldiff.NewSnapshot()
+	o.crawling = false
 	o.publishMessage(NatsMessage{
 		Kind:      KindCrawlEnd,
 		Timestamp: time.Now(),
+		Summary:   &diff.Summary,
 	})
 }
 
 func (o *natsObserver) ObservePeer(c *walker.Peer) {
-	o.publishMessage(NatsMessage{
-		Kind:      KindPeer,
-		Timestamp: time.Now(),
-		Peer:      c,
-	})
+	if o.crawling {
+		o.current.AddWalkerPeer(c, o.locator)
+		o.publishMessage(NatsMessage{
+			Kind:      KindPeer,
+			Timestamp: time.Now(),
+			Peer:      c,
+		})
+	}
 
 	if c.ContainsProtocol(telemetry.ID_TELEMETRY) {
-		if m, err := walkerPeerToDiscoveryMarshaled(c); err == nil {
-			if err := o.nc.Publish(monitor.Subject_Discover, m); err != nil {
+		if m, err := backend.NatsMsg(o.encoding, monitor.Subject_Discover, walkerPeerToDiscovery(c)); err == nil {
+			if err := o.nc.PublishMsg(m); err != nil {
 				o.l.Error("failed to publish discovery message", zap.String("subject", monitor.Subject_Discover), zap.Error(err))
 			}
 		} else {
@@ -64,31 +94,44 @@ func (o *natsObserver) ObservePeer(c *walker.Peer) {
 	}
 }
 
-func (*natsObserver) ObserveError(*walker.Error) {
+func (o *natsObserver) ObserveError(e *walker.Error) {
+	if !o.crawling {
+		return
+	}
+	o.publishMessage(NatsMessage{
+		Kind:      KindError,
+		Timestamp: time.Now(),
+		Error:     e,
+	})
+}
+
+// Peer events are only emitted in continuous mode, they carry the identity of the first crawl
+func (o *natsObserver) ObserveEvent(e *crawler.Event) {
+	o.publishMessage(NatsMessage{
+		Kind:      KindEvent,
+		Timestamp: time.Now(),
+		Event:     e,
+	})
 }
 
 func (o *natsObserver) publishMessage(msg NatsMessage) {
-	m, err := json.Marshal(&msg)
+	msg.Crawl = o.crawl
+	msg.Mode = o.mode
+	m, err := backend.NatsMsg(o.encoding, SubjectCrawler, &msg)
 	if err != nil {
 		o.l.Error("failed to marshal message", zap.Error(err))
 		return
 	}
 
-	if err := o.nc.Publish(SubjectCrawler, m); err != nil {
+	if err := o.nc.PublishMsg(m); err != nil {
 		o.l.Error("failed to publish message", zap.Error(err))
 		return
 	}
 }
 
-func walkerPeerToDiscovery(c *walker.Peer) monitor.DiscoveryMessage {
-	return monitor.DiscoveryMessage{
+func walkerPeerToDiscovery(c *walker.Peer) *monitor.DiscoveryMessage {
+	return &monitor.DiscoveryMessage{
 		ID:        c.ID,
 		Addresses: c.Addresses,
 	}
 }
-
-func walkerPeerToDiscoveryMarshaled(c *walker.Peer) ([]byte, error) {
-	d := walkerPeerToDiscovery(c)
-	m, err := json.Marshal(d)
-	return m, err
-}
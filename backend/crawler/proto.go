@@ -0,0 +1,461 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/crawldiff"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/pb"
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	_ (backend.ProtoMessage)        = (*NatsMessage)(nil)
+	_ (backend.ProtoMessageDecoder) = (*NatsMessage)(nil)
+)
+
+var (
+	kindToProto = map[string]pb.CrawlerMessageKind{
+		KindPeer:       pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_PEER,
+		KindCrawlBegin: pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_CRAWL_BEGIN,
+		KindCrawlEnd:   pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_CRAWL_END,
+		KindError:      pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_ERROR,
+		KindEvent:      pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_EVENT,
+	}
+	kindFromProto = map[pb.CrawlerMessageKind]string{
+		pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_PEER:        KindPeer,
+		pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_CRAWL_BEGIN: KindCrawlBegin,
+		pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_CRAWL_END:   KindCrawlEnd,
+		pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_ERROR:       KindError,
+		pb.CrawlerMessageKind_CRAWLER_MESSAGE_KIND_EVENT:       KindEvent,
+	}
+)
+
+// ToProto implements backend.ProtoMessage
+func (m *NatsMessage) ToProto() (proto.Message, error) {
+	kind, ok := kindToProto[m.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown crawler message kind %q", m.Kind)
+	}
+
+	msg := &pb.CrawlerMessage{
+		Kind:      kind,
+		Timestamp: timestamppb.New(m.Timestamp),
+		Crawl:     m.Crawl,
+		Mode:      m.Mode,
+	}
+	if m.Peer != nil {
+		msg.Peer = walkerPeerToProto(m.Peer)
+	}
+	if m.Error != nil {
+		msg.Error = walkerErrorToProto(m.Error)
+	}
+	if m.Summary != nil {
+		msg.Summary = crawlSummaryToProto(m.Summary)
+	}
+	if m.Event != nil {
+		msg.Event = crawlerEventToProto(m.Event)
+	}
+	return msg, nil
+}
+
+// NewProto implements backend.ProtoMessageDecoder
+func (m *NatsMessage) NewProto() proto.Message {
+	return new(pb.CrawlerMessage)
+}
+
+// FromProto implements backend.ProtoMessageDecoder
+func (m *NatsMessage) FromProto(msg proto.Message) error {
+	p := msg.(*pb.CrawlerMessage)
+	kind, ok := kindFromProto[p.GetKind()]
+	if !ok {
+		return fmt.Errorf("unknown crawler message kind %v", p.GetKind())
+	}
+
+	var wpeer *walker.Peer
+	if p.GetPeer() != nil {
+		var err error
+		if wpeer, err = walkerPeerFromProto(p.GetPeer()); err != nil {
+			return err
+		}
+	}
+
+	var werr *walker.Error
+	if p.GetError() != nil {
+		var err error
+		if werr, err = walkerErrorFromProto(p.GetError()); err != nil {
+			return err
+		}
+	}
+
+	var event *crawler.Event
+	if p.GetEvent() != nil {
+		var err error
+		if event, err = crawlerEventFromProto(p.GetEvent()); err != nil {
+			return err
+		}
+	}
+
+	m.Kind = kind
+	m.Timestamp = p.GetTimestamp().AsTime()
+	m.Crawl = p.GetCrawl()
+	m.Mode = p.GetMode()
+	m.Peer = wpeer
+	m.Error = werr
+	m.Summary = crawlSummaryFromProto(p.GetSummary())
+	m.Event = event
+	return nil
+}
+
+func walkerPeerToProto(p *walker.Peer) *pb.WalkerPeer {
+	protocols := make([]string, len(p.Protocols))
+	for i, proto := range p.Protocols {
+		protocols[i] = string(proto)
+	}
+
+	buckets := make([]*pb.BucketEntry, len(p.Buckets))
+	for i, entry := range p.Buckets {
+		buckets[i] = &pb.BucketEntry{
+			Id:        []byte(entry.ID),
+			Addresses: monitor.MultiaddrsToProto(entry.Addrs),
+		}
+	}
+
+	requests := make([]*pb.Request, len(p.Requests))
+	for i, req := range p.Requests {
+		requests[i] = &pb.Request{
+			Start:    timestamppb.New(req.Start),
+			Duration: durationpb.New(req.Duration),
+		}
+	}
+
+	return &pb.WalkerPeer{
+		Id:              []byte(p.ID),
+		Network:         p.Network,
+		Addresses:       monitor.MultiaddrsToProto(p.Addresses),
+		Agent:           p.Agent,
+		Protocols:       protocols,
+		Buckets:         buckets,
+		Requests:        requests,
+		ConnectStart:    timestamppb.New(p.ConnectStart),
+		ConnectDuration: durationpb.New(p.ConnectDuration),
+		Identify:        walkerIdentifyToProto(p.Identify),
+		Fingerprint: &pb.WalkerFingerprint{
+			Implementation: string(p.Fingerprint.Implementation),
+			Agent:          p.Fingerprint.Agent,
+			Version:        p.Fingerprint.Version,
+		},
+		Messages: p.Messages,
+	}
+}
+
+func walkerPeerFromProto(p *pb.WalkerPeer) (*walker.Peer, error) {
+	id, err := peer.IDFromBytes(p.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := monitor.MultiaddrsFromProto(p.GetAddresses())
+	if err != nil {
+		return nil, err
+	}
+
+	protocols := make([]protocol.ID, len(p.GetProtocols()))
+	for i, proto := range p.GetProtocols() {
+		protocols[i] = protocol.ID(proto)
+	}
+
+	buckets := make([]walker.BucketEntry, len(p.GetBuckets()))
+	for i, entry := range p.GetBuckets() {
+		eid, err := peer.IDFromBytes(entry.GetId())
+		if err != nil {
+			return nil, err
+		}
+		eaddrs, err := monitor.MultiaddrsFromProto(entry.GetAddresses())
+		if err != nil {
+			return nil, err
+		}
+		buckets[i] = walker.BucketEntry{ID: eid, Addrs: eaddrs}
+	}
+
+	requests := make([]walker.Request, len(p.GetRequests()))
+	for i, req := range p.GetRequests() {
+		requests[i] = walker.Request{
+			Start:    req.GetStart().AsTime(),
+			Duration: req.GetDuration().AsDuration(),
+		}
+	}
+
+	identify, err := walkerIdentifyFromProto(p.GetIdentify())
+	if err != nil {
+		return nil, err
+	}
+
+	return &walker.Peer{
+		ID:              id,
+		Network:         p.GetNetwork(),
+		Addresses:       addrs,
+		Agent:           p.GetAgent(),
+		Protocols:       protocols,
+		Buckets:         buckets,
+		Requests:        requests,
+		ConnectStart:    p.GetConnectStart().AsTime(),
+		ConnectDuration: p.GetConnectDuration().AsDuration(),
+		Identify:        identify,
+		Fingerprint: walker.Fingerprint{
+			Implementation: walker.Implementation(p.GetFingerprint().GetImplementation()),
+			Agent:          p.GetFingerprint().GetAgent(),
+			Version:        p.GetFingerprint().GetVersion(),
+		},
+		Messages: p.GetMessages(),
+	}, nil
+}
+
+func walkerIdentifyToProto(id *walker.Identify) *pb.WalkerIdentify {
+	if id == nil {
+		return nil
+	}
+
+	protocols := make([]string, len(id.Protocols))
+	for i, proto := range id.Protocols {
+		protocols[i] = string(proto)
+	}
+	msg := &pb.WalkerIdentify{
+		ProtocolVersion: id.ProtocolVersion,
+		AgentVersion:    id.AgentVersion,
+		ListenAddresses: monitor.MultiaddrsToProto(id.ListenAddrs),
+		Protocols:       protocols,
+		Transports:      id.Transports,
+		Transport:       id.Transport,
+		Security:        string(id.Security),
+		Muxer:           string(id.Muxer),
+	}
+	if id.ObservedAddr != nil {
+		msg.ObservedAddress = id.ObservedAddr.Bytes()
+	}
+	if id.SignedRecord != nil {
+		msg.SignedRecord = &pb.WalkerSignedRecord{
+			Seq:       id.SignedRecord.Seq,
+			Addresses: monitor.MultiaddrsToProto(id.SignedRecord.Addrs),
+		}
+	}
+	return msg
+}
+
+func walkerIdentifyFromProto(p *pb.WalkerIdentify) (*walker.Identify, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	listenAddrs, err := monitor.MultiaddrsFromProto(p.GetListenAddresses())
+	if err != nil {
+		return nil, err
+	}
+	protocols := make([]protocol.ID, len(p.GetProtocols()))
+	for i, proto := range p.GetProtocols() {
+		protocols[i] = protocol.ID(proto)
+	}
+	id := &walker.Identify{
+		ProtocolVersion: p.GetProtocolVersion(),
+		AgentVersion:    p.GetAgentVersion(),
+		ListenAddrs:     listenAddrs,
+		Protocols:       protocols,
+		Transports:      p.GetTransports(),
+		Transport:       p.GetTransport(),
+		Security:        protocol.ID(p.GetSecurity()),
+		Muxer:           protocol.ID(p.GetMuxer()),
+	}
+	if len(p.GetObservedAddress()) > 0 {
+		if id.ObservedAddr, err = multiaddr.NewMultiaddrBytes(p.GetObservedAddress()); err != nil {
+			return nil, err
+		}
+	}
+	if rec := p.GetSignedRecord(); rec != nil {
+		addrs, err := monitor.MultiaddrsFromProto(rec.GetAddresses())
+		if err != nil {
+			return nil, err
+		}
+		id.SignedRecord = &walker.SignedRecord{Seq: rec.GetSeq(), Addrs: addrs}
+	}
+	return id, nil
+}
+
+func walkerErrorToProto(e *walker.Error) *pb.WalkerError {
+	msg := ""
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	dials := make([]*pb.WalkerDialFailure, len(e.Dials))
+	for i, dial := range e.Dials {
+		dials[i] = &pb.WalkerDialFailure{
+			Transport: dial.Transport,
+			Reason:    string(dial.Reason),
+			Error:     dial.Error,
+		}
+		if dial.Address != nil {
+			dials[i].Address = dial.Address.Bytes()
+		}
+	}
+	return &pb.WalkerError{
+		Id:        []byte(e.ID),
+		Network:   e.Network,
+		Addresses: monitor.MultiaddrsToProto(e.Addresses),
+		Time:      timestamppb.New(e.Time),
+		Error:     msg,
+		Stage:     string(e.Stage),
+		Reason:    string(e.Reason),
+		Dials:     dials,
+	}
+}
+
+func walkerErrorFromProto(p *pb.WalkerError) (*walker.Error, error) {
+	id, err := peer.IDFromBytes(p.GetId())
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := monitor.MultiaddrsFromProto(p.GetAddresses())
+	if err != nil {
+		return nil, err
+	}
+	dials := make([]walker.DialFailure, len(p.GetDials()))
+	for i, dial := range p.GetDials() {
+		dials[i] = walker.DialFailure{
+			Transport: dial.GetTransport(),
+			Reason:    walker.FailureReason(dial.GetReason()),
+			Error:     dial.GetError(),
+		}
+		if len(dial.GetAddress()) > 0 {
+			if dials[i].Address, err = multiaddr.NewMultiaddrBytes(dial.GetAddress()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var werr error
+	if p.GetError() != "" {
+		werr = errors.New(p.GetError())
+	}
+	return &walker.Error{
+		ID:        id,
+		Network:   p.GetNetwork(),
+		Addresses: addrs,
+		Time:      p.GetTime().AsTime(),
+		Err:       werr,
+		Stage:     walker.Stage(p.GetStage()),
+		Reason:    walker.FailureReason(p.GetReason()),
+		Dials:     dials,
+	}, nil
+}
+
+func crawlerEventToProto(e *crawler.Event) *pb.CrawlerEvent {
+	msg := &pb.CrawlerEvent{
+		Kind:              string(e.Kind),
+		Id:                []byte(e.ID),
+		Network:           e.Network,
+		Time:              timestamppb.New(e.Time),
+		Addresses:         monitor.MultiaddrsToProto(e.Addresses),
+		PreviousAddresses: monitor.MultiaddrsToProto(e.PreviousAddresses),
+	}
+	if e.SessionLength != 0 {
+		msg.SessionLength = durationpb.New(e.SessionLength)
+	}
+	return msg
+}
+
+func crawlerEventFromProto(p *pb.CrawlerEvent) (*crawler.Event, error) {
+	id, err := peer.IDFromBytes(p.GetId())
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := monitor.MultiaddrsFromProto(p.GetAddresses())
+	if err != nil {
+		return nil, err
+	}
+	var previous []multiaddr.Multiaddr
+	if len(p.GetPreviousAddresses()) > 0 {
+		if previous, err = monitor.MultiaddrsFromProto(p.GetPreviousAddresses()); err != nil {
+			return nil, err
+		}
+	}
+	return &crawler.Event{
+		Kind:              crawler.EventKind(p.GetKind()),
+		ID:                id,
+		Network:           p.GetNetwork(),
+		Time:              p.GetTime().AsTime(),
+		Addresses:         addrs,
+		PreviousAddresses: previous,
+		SessionLength:     p.GetSessionLength().AsDuration(),
+	}, nil
+}
+
+func crawlSummaryToProto(s *crawldiff.Summary) *pb.CrawlSummary {
+	msg := &pb.CrawlSummary{
+		Peers:           uint32(s.Peers),
+		PreviousPeers:   uint32(s.PreviousPeers),
+		New:             uint32(s.New),
+		Vanished:        uint32(s.Vanished),
+		AgentUpgrades:   uint32(s.AgentUpgrades),
+		AgentDowngrades: uint32(s.AgentDowngrades),
+		AgentChanges:    uint32(s.AgentChanges),
+		AddressChanges:  uint32(s.AddressChanges),
+		Protocols:       make(map[string]*pb.CrawlShift, len(s.Protocols)),
+		Asns:            make(map[uint32]*pb.CrawlShift, len(s.ASNs)),
+		Countries:       make(map[string]*pb.CrawlShift, len(s.Countries)),
+	}
+	for proto, shift := range s.Protocols {
+		msg.Protocols[proto] = crawlShiftToProto(shift)
+	}
+	for asn, shift := range s.ASNs {
+		msg.Asns[uint32(asn)] = crawlShiftToProto(shift)
+	}
+	for country, shift := range s.Countries {
+		msg.Countries[country] = crawlShiftToProto(shift)
+	}
+	return msg
+}
+
+func crawlSummaryFromProto(p *pb.CrawlSummary) *crawldiff.Summary {
+	if p == nil {
+		return nil
+	}
+	s := &crawldiff.Summary{
+		Peers:           int(p.GetPeers()),
+		PreviousPeers:   int(p.GetPreviousPeers()),
+		New:             int(p.GetNew()),
+		Vanished:        int(p.GetVanished()),
+		AgentUpgrades:   int(p.GetAgentUpgrades()),
+		AgentDowngrades: int(p.GetAgentDowngrades()),
+		AgentChanges:    int(p.GetAgentChanges()),
+		AddressChanges:  int(p.GetAddressChanges()),
+		Protocols:       make(map[string]crawldiff.Shift, len(p.GetProtocols())),
+		ASNs:            make(map[uint]crawldiff.Shift, len(p.GetAsns())),
+		Countries:       make(map[string]crawldiff.Shift, len(p.GetCountries())),
+	}
+	for proto, shift := range p.GetProtocols() {
+		s.Protocols[proto] = crawlShiftFromProto(shift)
+	}
+	for asn, shift := range p.GetAsns() {
+		s.ASNs[uint(asn)] = crawlShiftFromProto(shift)
+	}
+	for country, shift := range p.GetCountries() {
+		s.Countries[country] = crawlShiftFromProto(shift)
+	}
+	return s
+}
+
+func crawlShiftToProto(s crawldiff.Shift) *pb.CrawlShift {
+	return &pb.CrawlShift{Before: uint32(s.Before), After: uint32(s.After)}
+}
+
+func crawlShiftFromProto(p *pb.CrawlShift) crawldiff.Shift {
+	return crawldiff.Shift{Before: int(p.GetBefore()), After: int(p.GetAfter())}
+}
@@ -0,0 +1,141 @@
+package crawler_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/crawldiff"
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	tcrawler "github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const samplePeerId = "12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC"
+
+func TestErrorRoundTrip(t *testing.T) {
+	pid, err := peer.Decode(samplePeerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/tcp/4001")
+	expected := &crawler.NatsMessage{
+		Kind:      crawler.KindError,
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Crawl:     "6f1c1b9e-55a4-4a7e-9a43-2f0c2f8e3b11",
+		Mode:      crawler.ModeContinuous,
+		Error: &walker.Error{
+			ID:        pid,
+			Network:   walker.NetworkNameAmino,
+			Addresses: []multiaddr.Multiaddr{addr},
+			Time:      time.Date(2024, 3, 1, 11, 59, 0, 0, time.UTC),
+			Err:       errors.New("Connect: failed to dial"),
+			Stage:     walker.StageConnect,
+			Reason:    walker.FailureConnectionRefused,
+			Dials: []walker.DialFailure{
+				{Address: addr, Transport: "tcp", Reason: walker.FailureConnectionRefused, Error: "connection refused"},
+			},
+		},
+	}
+
+	for _, encoding := range []backend.Encoding{backend.EncodingJson, backend.EncodingProtobuf} {
+		t.Run(string(encoding), func(t *testing.T) {
+			msg, err := backend.NatsMsg(encoding, crawler.SubjectCrawler, expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded := new(crawler.NatsMessage)
+			if err := backend.NatsDecode(msg.Header, msg.Data, decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			e := decoded.Error
+			if decoded.Kind != crawler.KindError || decoded.Crawl != expected.Crawl || e == nil {
+				t.Fatalf("unexpected message: %+v", decoded)
+			}
+			if e.ID != pid || e.Err.Error() != expected.Error.Err.Error() || e.Reason != walker.FailureConnectionRefused || e.Stage != walker.StageConnect {
+				t.Fatalf("error mismatch: %+v", e)
+			}
+			if len(e.Dials) != 1 || !e.Dials[0].Address.Equal(addr) || e.Dials[0].Reason != walker.FailureConnectionRefused {
+				t.Fatalf("dials mismatch: %+v", e.Dials)
+			}
+		})
+	}
+}
+
+func TestSummaryRoundTrip(t *testing.T) {
+	expected := &crawler.NatsMessage{
+		Kind:      crawler.KindCrawlEnd,
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Summary: &crawldiff.Summary{
+			Peers:          10,
+			PreviousPeers:  8,
+			New:            3,
+			Vanished:       1,
+			AgentUpgrades:  2,
+			AddressChanges: 4,
+			Protocols:      map[string]crawldiff.Shift{"/ipfs/kad/1.0.0": {Before: 8, After: 10}},
+			ASNs:           map[uint]crawldiff.Shift{13335: {Before: 1, After: 0}},
+			Countries:      map[string]crawldiff.Shift{"Portugal": {Before: 0, After: 2}},
+		},
+	}
+
+	for _, encoding := range []backend.Encoding{backend.EncodingJson, backend.EncodingProtobuf} {
+		t.Run(string(encoding), func(t *testing.T) {
+			msg, err := backend.NatsMsg(encoding, crawler.SubjectCrawler, expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded := new(crawler.NatsMessage)
+			if err := backend.NatsDecode(msg.Header, msg.Data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Summary, expected.Summary) {
+				t.Fatalf("summary mismatch: %+v", decoded.Summary)
+			}
+		})
+	}
+}
+
+func TestEventRoundTrip(t *testing.T) {
+	pid, err := peer.Decode(samplePeerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &crawler.NatsMessage{
+		Kind:      crawler.KindEvent,
+		Timestamp: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Crawl:     "6f1c1b9e-55a4-4a7e-9a43-2f0c2f8e3b11",
+		Event: &tcrawler.Event{
+			Kind:              tcrawler.EventAddressChange,
+			ID:                pid,
+			Network:           walker.NetworkNameAmino,
+			Time:              time.Date(2024, 3, 1, 11, 59, 0, 0, time.UTC),
+			Addresses:         []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/1.2.3.4/tcp/4001")},
+			PreviousAddresses: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/5.6.7.8/tcp/4001")},
+		},
+	}
+
+	for _, encoding := range []backend.Encoding{backend.EncodingJson, backend.EncodingProtobuf} {
+		t.Run(string(encoding), func(t *testing.T) {
+			msg, err := backend.NatsMsg(encoding, crawler.SubjectCrawler, expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded := new(crawler.NatsMessage)
+			if err := backend.NatsDecode(msg.Header, msg.Data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Kind != crawler.KindEvent || !reflect.DeepEqual(decoded.Event, expected.Event) {
+				t.Fatalf("event mismatch: %+v", decoded.Event)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	HeaderContentType = "Content-Type"
+
+	ContentTypeJson     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+
+	// Media type parameter carrying the fully qualified name of the protobuf message.
+	// Ex: application/protobuf; proto=backend.v1.Export
+	contentTypeParamProto = "proto"
+)
+
+type Encoding string
+
+const (
+	EncodingJson     Encoding = "json"
+	EncodingProtobuf Encoding = "protobuf"
+)
+
+func ParseEncoding(s string) (Encoding, error) {
+	switch Encoding(s) {
+	case EncodingJson, EncodingProtobuf:
+		return Encoding(s), nil
+	default:
+		return "", fmt.Errorf("unknown encoding %q, expected %q or %q", s, EncodingJson, EncodingProtobuf)
+	}
+}
+
+// Implemented by message types that have a protobuf wire representation.
+type ProtoMessage interface {
+	ToProto() (proto.Message, error)
+}
+
+// Implemented by message types that can be decoded from their protobuf wire representation.
+// NewProto returns an empty protobuf message to decode into and FromProto fills the receiver from it.
+type ProtoMessageDecoder interface {
+	NewProto() proto.Message
+	FromProto(proto.Message) error
+}
+
+// Marshal value using the given encoding and return the payload and its content type.
+// Values that do not implement ProtoMessage are always encoded as json.
+func Marshal(encoding Encoding, value any) ([]byte, string, error) {
+	if pm, ok := value.(ProtoMessage); ok && encoding == EncodingProtobuf {
+		m, err := pm.ToProto()
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := proto.Marshal(m)
+		if err != nil {
+			return nil, "", err
+		}
+		contentType := mime.FormatMediaType(ContentTypeProtobuf, map[string]string{
+			contentTypeParamProto: string(m.ProtoReflect().Descriptor().FullName()),
+		})
+		return data, contentType, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, ContentTypeJson, nil
+}
+
+// Unmarshal data into value according to contentType.
+// An empty content type is treated as json since that was the only format before content types were introduced.
+func Unmarshal(contentType string, data []byte, value any) error {
+	if contentType == "" {
+		return json.Unmarshal(data, value)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	switch mediaType {
+	case ContentTypeJson:
+		return json.Unmarshal(data, value)
+	case ContentTypeProtobuf:
+		decoder, ok := value.(ProtoMessageDecoder)
+		if !ok {
+			return fmt.Errorf("value of type %T cannot be decoded from protobuf", value)
+		}
+		m := decoder.NewProto()
+		if name, ok := params[contentTypeParamProto]; ok {
+			if expected := string(m.ProtoReflect().Descriptor().FullName()); name != expected {
+				return fmt.Errorf("unexpected protobuf message %q, expected %q", name, expected)
+			}
+		}
+		if err := proto.Unmarshal(data, m); err != nil {
+			return err
+		}
+		return decoder.FromProto(m)
+	default:
+		return fmt.Errorf("unsupported content type %q", contentType)
+	}
+}
+
+// Create a nats message for the given subject with the value encoded using encoding.
+func NatsMsg(encoding Encoding, subject string, value any) (*nats.Msg, error) {
+	data, contentType, err := Marshal(encoding, value)
+	if err != nil {
+		return nil, err
+	}
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(HeaderContentType, contentType)
+	return msg, nil
+}
+
+// Decode a message received from nats, using its content type header.
+func NatsDecode(header nats.Header, data []byte, value any) error {
+	return Unmarshal(header.Get(HeaderContentType), data, value)
+}
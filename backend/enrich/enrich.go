@@ -0,0 +1,259 @@
+// Package enrich locates ip addresses using the GeoLite2 City and ASN databases and classifies
+// the autonomous systems of hosting providers. It is shared by the crawler and the exporters.
+package enrich
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/oschwald/geoip2-golang"
+	"go.uber.org/zap"
+)
+
+// Everything known about a public ip address, fields are empty when the databases have no information
+type Location struct {
+	IP          string  `json:"ip"`
+	ASN         uint    `json:"asn,omitempty"`
+	ASNOrg      string  `json:"asn_org,omitempty"`
+	Country     string  `json:"country,omitempty"`
+	CountryCode string  `json:"country_code,omitempty"`
+	City        string  `json:"city,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	// Name of the hosting provider that owns the autonomous system, empty if it is not a known hosting provider
+	HostingProvider string `json:"hosting_provider,omitempty"`
+}
+
+func (l *Location) Hosting() bool {
+	return l.HostingProvider != ""
+}
+
+type database struct {
+	path    string
+	reader  *geoip2.Reader
+	modTime time.Time
+	size    int64
+}
+
+type Enricher struct {
+	l       *zap.Logger
+	opts    *options
+	cache   *lru.Cache
+	hosting map[uint]string
+
+	// serializes reloads, the databases can only be swapped while holding it
+	reloadMu sync.Mutex
+	mu       sync.RWMutex
+	city     *database
+	asn      *database
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(o ...Option) (*Enricher, error) {
+	opts := defaults()
+	if err := apply(opts, o...); err != nil {
+		return nil, err
+	}
+
+	cache, err := lru.New(opts.cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	hosting, err := loadHosting(opts.hostingLists)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Enricher{
+		l:       opts.logger,
+		opts:    opts,
+		cache:   cache,
+		hosting: hosting,
+		done:    make(chan struct{}),
+	}
+	if opts.cityPath != "" {
+		if e.city, err = openDatabase(opts.cityPath); err != nil {
+			return nil, err
+		}
+	}
+	if opts.asnPath != "" {
+		if e.asn, err = openDatabase(opts.asnPath); err != nil {
+			e.Close()
+			return nil, err
+		}
+	}
+
+	if opts.reloadInterval > 0 && (e.city != nil || e.asn != nil) {
+		e.wg.Add(1)
+		go e.reloadLoop()
+	}
+	return e, nil
+}
+
+// Whether the city database is loaded
+func (e *Enricher) HasCity() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.city != nil
+}
+
+// Whether the asn database is loaded
+func (e *Enricher) HasASN() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.asn != nil
+}
+
+// Locate a single ip address, returns nil if the address is not public
+func (e *Enricher) Lookup(ip net.IP) *Location {
+	ip = normalize(ip)
+	if !IsPublic(ip) {
+		return nil
+	}
+
+	key := ip.String()
+	if cached, ok := e.cache.Get(key); ok {
+		return cached.(*Location)
+	}
+
+	loc := &Location{IP: key}
+	e.mu.RLock()
+	if e.asn != nil {
+		if gasn, err := e.asn.reader.ASN(ip); err == nil {
+			loc.ASN = gasn.AutonomousSystemNumber
+			loc.ASNOrg = gasn.AutonomousSystemOrganization
+		}
+	}
+	if e.city != nil {
+		if gcity, err := e.city.reader.City(ip); err == nil {
+			loc.Country = gcity.Country.Names["en"]
+			loc.CountryCode = gcity.Country.IsoCode
+			loc.City = gcity.City.Names["en"]
+			loc.Latitude = gcity.Location.Latitude
+			loc.Longitude = gcity.Location.Longitude
+		}
+	}
+	e.mu.RUnlock()
+	loc.HostingProvider = e.hosting[loc.ASN]
+
+	e.cache.Add(key, loc)
+	return loc
+}
+
+// Locate every distinct public ip address of the multiaddrs, in order
+func (e *Enricher) LookupAddrs(maddrs []multiaddr.Multiaddr) []*Location {
+	ips := PublicIPs(maddrs)
+	locations := make([]*Location, 0, len(ips))
+	for _, ip := range ips {
+		if loc := e.Lookup(ip); loc != nil {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// Locate implements crawldiff.Locator
+func (e *Enricher) Locate(ip net.IP) (uint, string, bool) {
+	loc := e.Lookup(ip)
+	if loc == nil {
+		return 0, "", false
+	}
+	return loc.ASN, loc.Country, loc.ASN != 0 || loc.Country != ""
+}
+
+// Reopen the databases whose files changed, lookups keep using the previous databases until the new ones are open
+func (e *Enricher) Reload() error {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	city, err := e.reloadDatabase(e.city)
+	if err != nil {
+		return err
+	}
+	asn, err := e.reloadDatabase(e.asn)
+	if err != nil {
+		if city != e.city {
+			city.reader.Close()
+		}
+		return err
+	}
+	if city == e.city && asn == e.asn {
+		return nil
+	}
+
+	e.mu.Lock()
+	prevCity, prevAsn := e.city, e.asn
+	e.city, e.asn = city, asn
+	e.mu.Unlock()
+	e.cache.Purge()
+
+	if prevCity != city {
+		e.l.Info("reloaded city database", zap.String("path", city.path))
+		prevCity.reader.Close()
+	}
+	if prevAsn != asn {
+		e.l.Info("reloaded asn database", zap.String("path", asn.path))
+		prevAsn.reader.Close()
+	}
+	return nil
+}
+
+func (e *Enricher) Close() {
+	close(e.done)
+	e.wg.Wait()
+	if e.city != nil {
+		e.city.reader.Close()
+	}
+	if e.asn != nil {
+		e.asn.reader.Close()
+	}
+}
+
+func (e *Enricher) reloadLoop() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.opts.reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+			if err := e.Reload(); err != nil {
+				e.l.Warn("failed to reload geoip databases", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Returns the same database if its file did not change
+func (e *Enricher) reloadDatabase(db *database) (*database, error) {
+	if db == nil {
+		return nil, nil
+	}
+	stat, err := os.Stat(db.path)
+	if err != nil {
+		return nil, err
+	}
+	if stat.ModTime().Equal(db.modTime) && stat.Size() == db.size {
+		return db, nil
+	}
+	return openDatabase(db.path)
+}
+
+func openDatabase(path string) (*database, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &database{path: path, reader: reader, modTime: stat.ModTime(), size: stat.Size()}, nil
+}
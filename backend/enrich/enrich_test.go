@@ -0,0 +1,116 @@
+package enrich
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestPublicIPs(t *testing.T) {
+	maddrs := []multiaddr.Multiaddr{
+		multiaddr.StringCast("/ip4/132.226.169.184/tcp/4001/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit"),
+		multiaddr.StringCast("/ip4/192.168.1.10/tcp/4001"),
+		multiaddr.StringCast("/ip4/100.64.3.1/tcp/4001"),
+		multiaddr.StringCast("/ip4/35.79.127.140/udp/4001/quic-v1"),
+		multiaddr.StringCast("/ip6/64:ff9b::234f:7f8c/udp/4001/quic-v1"),
+		multiaddr.StringCast("/ip6/fe80::1/tcp/4001"),
+		multiaddr.StringCast("/ip6/2a01:4f8:c17:1::1/tcp/4001"),
+		multiaddr.StringCast("/dns4/example.com/tcp/4001"),
+		multiaddr.StringCast("/ip4/35.79.127.140/tcp/4001"),
+	}
+
+	ips := PublicIPs(maddrs)
+	expected := []string{"35.79.127.140", "2a01:4f8:c17:1::1"}
+	if len(ips) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ips)
+	}
+	for i, ip := range ips {
+		if ip.String() != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, ips)
+		}
+	}
+}
+
+func TestPublicIPsSkipsRelays(t *testing.T) {
+	addrStrings := []string{
+		"/ip4/132.226.169.184/tcp/4001/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+		"/ip4/35.79.127.140/udp/4001/quic",
+		"/ip4/5.166.238.76/udp/50336/quic-v1/webtransport/certhash/uEiAuVXxdGPSOrIiBTrHiwwt0xKQg9jICllwdo_RGx5FPiw/certhash/uEiChf3IVddFEZtPWxsjVOLMyD4jE9gwFjTTgVAr2V-7ydQ/p2p/12D3KooWSVfiLWLjmVMGrm8QABTVoh79E8xWGN7BuLoif3bDQ9tt/p2p-circuit",
+		"/ip6/64:ff9b::234f:7f8c/udp/4001/quic-v1/webtransport/certhash/uEiBbHljKvqSp2dcDTRWH9Nbyb6ariy1FbZyF2z9iFUPORA/certhash/uEiCs0BB3jRz7-VqQwomju1vP8FRCb6gOpfl7k8SAbgKAhA",
+		"/ip6/64:ff9b::234f:7f8c/udp/4001/quic-v1",
+		"/ip4/35.79.127.140/tcp/4001",
+		"/ip4/5.166.238.76/udp/50336/quic-v1/p2p/12D3KooWSVfiLWLjmVMGrm8QABTVoh79E8xWGN7BuLoif3bDQ9tt/p2p-circuit",
+		"/ip4/35.79.127.140/udp/4001/quic-v1/webtransport/certhash/uEiBbHljKvqSp2dcDTRWH9Nbyb6ariy1FbZyF2z9iFUPORA/certhash/uEiCs0BB3jRz7-VqQwomju1vP8FRCb6gOpfl7k8SAbgKAhA",
+		"/ip4/118.33.40.93/udp/4001/quic-v1/webtransport/certhash/uEiBwnR07HvXax_Eu_W-lOVav4BMjoHTWgKRBRxgTc5QVGw/certhash/uEiDWs0DMVoQs4rxRfkRE8-pseUP42Qy1Q_RGexYTNPxNGA/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+		"/ip4/118.33.40.93/udp/4001/quic-v1/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+		"/ip4/118.33.40.93/udp/4001/quic/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+		"/ip4/5.166.238.76/tcp/50336/p2p/12D3KooWSVfiLWLjmVMGrm8QABTVoh79E8xWGN7BuLoif3bDQ9tt/p2p-circuit",
+		"/ip4/132.226.169.184/udp/4001/quic-v1/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+		"/ip4/35.79.127.140/udp/4001/quic-v1",
+		"/ip4/132.226.169.184/udp/4001/quic-v1/webtransport/certhash/uEiBwnR07HvXax_Eu_W-lOVav4BMjoHTWgKRBRxgTc5QVGw/certhash/uEiDWs0DMVoQs4rxRfkRE8-pseUP42Qy1Q_RGexYTNPxNGA/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+		"/ip4/118.33.40.93/tcp/4001/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit",
+	}
+
+	maddrs := make([]multiaddr.Multiaddr, len(addrStrings))
+	for i, s := range addrStrings {
+		maddrs[i] = multiaddr.StringCast(s)
+	}
+
+	ips := PublicIPs(maddrs)
+	if len(ips) != 1 || !ips[0].Equal(net.ParseIP("35.79.127.140")) {
+		t.Fatalf("expected [35.79.127.140], got %v", ips)
+	}
+}
+
+func TestLookupWithoutDatabases(t *testing.T) {
+	e, err := New(WithCacheSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if loc := e.Lookup(net.ParseIP("10.0.0.1")); loc != nil {
+		t.Fatalf("private address was located: %+v", loc)
+	}
+	loc := e.Lookup(net.ParseIP("64:ff9b::234f:7f8c"))
+	if loc == nil || loc.IP != "35.79.127.140" || loc.Hosting() {
+		t.Fatalf("unexpected location %+v", loc)
+	}
+	if cached := e.Lookup(net.ParseIP("35.79.127.140")); cached != loc {
+		t.Fatalf("lookup was not cached")
+	}
+	if _, _, ok := e.Locate(net.ParseIP("35.79.127.140")); ok {
+		t.Fatalf("located an address without databases")
+	}
+}
+
+func TestHostingList(t *testing.T) {
+	hosting := make(map[uint]string)
+	list := "# providers\n64496 Example Cloud\nAS64497\n\n"
+	if err := parseHostingList(bufio.NewScanner(strings.NewReader(list)), hosting); err != nil {
+		t.Fatal(err)
+	}
+	if hosting[64496] != "Example Cloud" || hosting[64497] != "AS64497" {
+		t.Fatalf("unexpected hosting providers %v", hosting)
+	}
+	if err := parseHostingList(bufio.NewScanner(strings.NewReader("cloud 1\n")), hosting); err == nil {
+		t.Fatalf("expected an error for an invalid asn")
+	}
+
+	path := filepath.Join(t.TempDir(), "hosting.txt")
+	if err := os.WriteFile(path, []byte("16509 Not Amazon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadHosting([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded[16509] != "Not Amazon" || loaded[15169] != "Google" {
+		t.Fatalf("lists do not extend the builtin providers: %v %v", loaded[16509], loaded[15169])
+	}
+}
@@ -0,0 +1,24 @@
+package enrich
+
+import (
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+// Create an enricher from the global geoip flags. The options are applied first so flags that are set override them.
+func FromFlags(logger *zap.Logger, c *cli.Context, o ...Option) (*Enricher, error) {
+	opts := append([]Option{WithLogger(logger)}, o...)
+	if c.IsSet(backend.Flag_GeoIPCity.Name) {
+		opts = append(opts, WithCityDatabase(c.String(backend.Flag_GeoIPCity.Name)))
+	}
+	if c.IsSet(backend.Flag_GeoIPAsn.Name) {
+		opts = append(opts, WithASNDatabase(c.String(backend.Flag_GeoIPAsn.Name)))
+	}
+	opts = append(opts,
+		WithHostingLists(c.StringSlice(backend.Flag_HostingAsns.Name)...),
+		WithCacheSize(c.Int(backend.Flag_GeoIPCacheSize.Name)),
+		WithReloadInterval(c.Duration(backend.Flag_GeoIPReloadInterval.Name)),
+	)
+	return New(opts...)
+}
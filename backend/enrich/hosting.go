@@ -0,0 +1,75 @@
+package enrich
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Autonomous systems of well known cloud and hosting providers
+var builtinHosting = map[uint]string{
+	16509:  "Amazon",
+	14618:  "Amazon",
+	15169:  "Google",
+	396982: "Google",
+	8075:   "Microsoft",
+	31898:  "Oracle",
+	45102:  "Alibaba",
+	14061:  "DigitalOcean",
+	24940:  "Hetzner",
+	213230: "Hetzner",
+	16276:  "OVH",
+	63949:  "Akamai",
+	20473:  "Vultr",
+	51167:  "Contabo",
+	12876:  "Scaleway",
+	13335:  "Cloudflare",
+	9009:   "M247",
+	60781:  "Leaseweb",
+	40021:  "Contabo",
+	197540: "netcup",
+}
+
+// Load hosting providers from the lists, later lists override earlier ones
+func loadHosting(paths []string) (map[uint]string, error) {
+	hosting := make(map[uint]string, len(builtinHosting))
+	for asn, provider := range builtinHosting {
+		hosting[asn] = provider
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open hosting list: %w", err)
+		}
+		err = parseHostingList(bufio.NewScanner(f), hosting)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid hosting list %v: %w", path, err)
+		}
+	}
+	return hosting, nil
+}
+
+func parseHostingList(scanner *bufio.Scanner, hosting map[uint]string) error {
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		asnStr, provider, _ := strings.Cut(text, " ")
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(asnStr), "AS"), 10, 32)
+		if err != nil {
+			return fmt.Errorf("line %v: invalid asn %q", line, asnStr)
+		}
+		provider = strings.TrimSpace(provider)
+		if provider == "" {
+			provider = fmt.Sprintf("AS%v", asn)
+		}
+		hosting[uint(asn)] = provider
+	}
+	return scanner.Err()
+}
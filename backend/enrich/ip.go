@@ -0,0 +1,73 @@
+package enrich
+
+import (
+	"net"
+
+	"github.com/multiformats/go-multiaddr"
+)
+
+var (
+	// carrier grade nat, rfc 6598
+	sharedAddressSpace = mustParseCIDR("100.64.0.0/10")
+	// well known prefix of ipv4 addresses embedded in ipv6, rfc 6052
+	nat64Prefix = mustParseCIDR("64:ff9b::/96")
+)
+
+// Whether the address is routable on the public internet
+func IsPublic(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || sharedAddressSpace.Contains(ip))
+}
+
+// Distinct public ip addresses of the multiaddrs in order, relay addresses are skipped
+func PublicIPs(maddrs []multiaddr.Multiaddr) []net.IP {
+	ips := make([]net.IP, 0)
+	seen := make(map[string]struct{})
+	for _, maddr := range maddrs {
+		if len(maddr) == 0 {
+			continue
+		}
+		if _, err := maddr.ValueForProtocol(multiaddr.P_CIRCUIT); err == nil {
+			continue
+		}
+		code := maddr[0].Protocol().Code
+		if code != multiaddr.P_IP4 && code != multiaddr.P_IP6 {
+			continue
+		}
+		ip := normalize(net.ParseIP(maddr[0].Value()))
+		if !IsPublic(ip) {
+			continue
+		}
+		if _, ok := seen[ip.String()]; ok {
+			continue
+		}
+		seen[ip.String()] = struct{}{}
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+// Unwrap ipv4 addresses embedded in ipv6 ones
+func normalize(ip net.IP) net.IP {
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	if nat64Prefix.Contains(ip) {
+		return net.IPv4(ip[12], ip[13], ip[14], ip[15]).To4()
+	}
+	return ip
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
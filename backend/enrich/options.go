@@ -0,0 +1,91 @@
+package enrich
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Option func(*options) error
+
+type options struct {
+	logger         *zap.Logger
+	cityPath       string
+	asnPath        string
+	hostingLists   []string
+	cacheSize      int
+	reloadInterval time.Duration
+}
+
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) error {
+		o.logger = l
+		return nil
+	}
+}
+
+// Path of the GeoLite2 or GeoIP2 City database
+func WithCityDatabase(path string) Option {
+	return func(o *options) error {
+		o.cityPath = path
+		return nil
+	}
+}
+
+// Path of the GeoLite2 or GeoIP2 ASN database
+func WithASNDatabase(path string) Option {
+	return func(o *options) error {
+		o.asnPath = path
+		return nil
+	}
+}
+
+// Files with autonomous systems of hosting providers, in addition to the builtin list.
+// Each line has an asn optionally followed by the provider name, lines starting with # are ignored.
+func WithHostingLists(paths ...string) Option {
+	return func(o *options) error {
+		o.hostingLists = append(o.hostingLists, paths...)
+		return nil
+	}
+}
+
+// Number of lookups kept in memory
+func WithCacheSize(size int) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid cache size %v", size)
+		}
+		o.cacheSize = size
+		return nil
+	}
+}
+
+// How often the databases are checked for changes, 0 disables reloading
+func WithReloadInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval < 0 {
+			return fmt.Errorf("invalid reload interval %v", interval)
+		}
+		o.reloadInterval = interval
+		return nil
+	}
+}
+
+func defaults() *options {
+	return &options{
+		logger:         zap.NewNop(),
+		hostingLists:   []string{},
+		cacheSize:      65536,
+		reloadInterval: time.Minute,
+	}
+}
+
+func apply(opts *options, o ...Option) error {
+	for _, opt := range o {
+		if err := opt(opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
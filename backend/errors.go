@@ -0,0 +1,47 @@
+package backend
+
+import "errors"
+
+// A failure that is expected to go away, ex: the database is restarting.
+// Messages that fail with a retryable error are redelivered after a delay, no matter how many times they were delivered.
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// A message that can never be processed, ex: it can not be decoded.
+// Poison messages are published on the dead letter stream instead of being redelivered.
+type PoisonError struct {
+	Err error
+}
+
+func (e *PoisonError) Error() string { return e.Err.Error() }
+func (e *PoisonError) Unwrap() error { return e.Err }
+
+// Mark err as retryable, nil stays nil
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{Err: err}
+}
+
+// Mark err as caused by a poison message, nil stays nil
+func Poison(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PoisonError{Err: err}
+}
+
+func IsRetryable(err error) bool {
+	var retryable *RetryableError
+	return errors.As(err, &retryable)
+}
+
+func IsPoison(err error) bool {
+	var poison *PoisonError
+	return errors.As(err, &poison)
+}
@@ -0,0 +1,154 @@
+package eventmetrics
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	v1_common "go.opentelemetry.io/proto/otlp/common/v1"
+	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// Instrumentation scope of the metrics created from events
+const ScopeName = "github.com/diogo464/ipfs-telemetry/backend/eventmetrics"
+
+type Mapper struct {
+	rules []Rule
+}
+
+func NewMapper(rules *Rules) *Mapper {
+	return &Mapper{rules: rules.Rules}
+}
+
+// Evaluate the rules on the events of an export, nil if no rule produced a data point.
+// Events whose payload is not json are skipped.
+func (m *Mapper) Map(export *monitor.Export) *v1.ScopeMetrics {
+	metrics := make(map[int]*v1.Metric)
+	for _, events := range export.Events {
+		for i := range m.rules {
+			rule := &m.rules[i]
+			if rule.Event != events.Descriptor.Name || (rule.Scope != "" && rule.Scope != events.Descriptor.Scope.Name) {
+				continue
+			}
+			for _, event := range events.Events {
+				var payload any
+				if err := json.Unmarshal(event.Data, &payload); err != nil {
+					continue
+				}
+				points := rule.evaluate(payload, uint64(event.Timestamp.UnixNano()))
+				if len(points) == 0 {
+					continue
+				}
+				metric, ok := metrics[i]
+				if !ok {
+					metric = &v1.Metric{
+						Name:        rule.Metric,
+						Description: rule.Description,
+						Unit:        rule.Unit,
+						Data:        &v1.Metric_Gauge{Gauge: &v1.Gauge{}},
+					}
+					metrics[i] = metric
+				}
+				gauge := metric.GetGauge()
+				gauge.DataPoints = append(gauge.DataPoints, points...)
+			}
+		}
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	// keep the output in rule order
+	indices := make([]int, 0, len(metrics))
+	for i := range metrics {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	sm := &v1.ScopeMetrics{Scope: &v1_common.InstrumentationScope{Name: ScopeName}}
+	for _, i := range indices {
+		sm.Metrics = append(sm.Metrics, metrics[i])
+	}
+	return sm
+}
+
+type group struct {
+	labels []*v1_common.KeyValue
+	value  float64
+	count  int
+}
+
+func (r *Rule) evaluate(payload any, timeUnixNano uint64) []*v1.NumberDataPoint {
+	names := make([]string, 0, len(r.Labels))
+	for name := range r.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make(map[string]*group)
+	order := make([]string, 0)
+	for _, item := range r.each.eval(payload) {
+		value := 1.0
+		if r.Value != nil {
+			v, ok := r.Value.value(item)
+			if !ok {
+				continue
+			}
+			value = v
+		}
+
+		labels := make([]*v1_common.KeyValue, 0, len(names))
+		key := strings.Builder{}
+		for _, name := range names {
+			field := r.Labels[name]
+			v, ok := field.label(item)
+			if !ok {
+				continue
+			}
+			labels = append(labels, &v1_common.KeyValue{Key: name, Value: &v1_common.AnyValue{Value: &v1_common.AnyValue_StringValue{StringValue: v}}})
+			key.WriteString(name)
+			key.WriteByte(0)
+			key.WriteString(v)
+			key.WriteByte(0)
+		}
+
+		g, ok := groups[key.String()]
+		if !ok {
+			g = &group{labels: labels, value: value}
+			groups[key.String()] = g
+			order = append(order, key.String())
+		} else {
+			g.value = r.combine(g.value, value)
+		}
+		g.count++
+	}
+
+	points := make([]*v1.NumberDataPoint, 0, len(groups))
+	for _, key := range order {
+		g := groups[key]
+		value := g.value
+		if r.Aggregate == AggregateAvg {
+			value /= float64(g.count)
+		}
+		points = append(points, &v1.NumberDataPoint{
+			Attributes:   g.labels,
+			TimeUnixNano: timeUnixNano,
+			Value:        &v1.NumberDataPoint_AsDouble{AsDouble: value},
+		})
+	}
+	return points
+}
+
+func (r *Rule) combine(current, value float64) float64 {
+	switch r.Aggregate {
+	case AggregateMin:
+		return min(current, value)
+	case AggregateMax:
+		return max(current, value)
+	case AggregateLast:
+		return value
+	default:
+		// sum and avg, the average is computed once all the values are combined
+		return current + value
+	}
+}
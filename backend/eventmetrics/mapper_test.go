@@ -0,0 +1,144 @@
+package eventmetrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+const connectionsPayload = `[
+	{"id": "a", "addr": "/ip4/1.2.3.4/tcp/4001", "latency": 100, "streams": [
+		{"protocol": "/ipfs/kad/1.0.0", "direction": "Inbound"},
+		{"protocol": "/ipfs/bitswap/1.2.0", "direction": "Outbound"}
+	]},
+	{"id": "b", "addr": "/ip4/5.6.7.8/udp/4001/quic-v1", "latency": 300, "streams": [
+		{"protocol": "/ipfs/kad/1.0.0", "direction": "Inbound"}
+	]},
+	{"id": "c", "addr": "/ip6/::1/udp/4001/quic-v1", "latency": 200, "streams": []}
+]`
+
+func testExport(name string, payload []byte) *monitor.Export {
+	return &monitor.Export{
+		Events: []monitor.ExportEvents{{
+			Descriptor: telemetry.EventDescriptor{Name: name},
+			Events:     []telemetry.Event{{Timestamp: time.Unix(10, 0), Data: payload}},
+		}},
+	}
+}
+
+func points(t *testing.T, sm *v1.ScopeMetrics, metric string) map[string]float64 {
+	if sm == nil {
+		t.Fatalf("no metrics were created")
+	}
+	for _, m := range sm.Metrics {
+		if m.Name != metric {
+			continue
+		}
+		values := make(map[string]float64)
+		for _, dp := range m.GetGauge().DataPoints {
+			if dp.TimeUnixNano != uint64(10*time.Second) {
+				t.Fatalf("unexpected timestamp %v", dp.TimeUnixNano)
+			}
+			key := ""
+			for _, kv := range dp.Attributes {
+				key += kv.Key + "=" + kv.Value.GetStringValue() + ","
+			}
+			values[key] = dp.GetAsDouble()
+		}
+		return values
+	}
+	t.Fatalf("metric %v was not created", metric)
+	return nil
+}
+
+func expect(t *testing.T, got map[string]float64, want map[string]float64) {
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper := NewMapper(rules)
+	sm := mapper.Map(testExport("libp2p.network.connections", []byte(connectionsPayload)))
+
+	expect(t, points(t, sm, "telemetry.events.libp2p.network.connections"), map[string]float64{
+		"transport=tcp,":     1,
+		"transport=quic-v1,": 2,
+	})
+	expect(t, points(t, sm, "telemetry.events.libp2p.network.connections.latency"), map[string]float64{
+		"": 200,
+	})
+	expect(t, points(t, sm, "telemetry.events.libp2p.network.streams"), map[string]float64{
+		"direction=Inbound,protocol=/ipfs/kad/1.0.0,":      2,
+		"direction=Outbound,protocol=/ipfs/bitswap/1.2.0,": 1,
+	})
+}
+
+func TestTracerouteHops(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := " 1?: [LOCALHOST]                      pmtu 1500\n 1:  10.0.0.1      0.301ms\n 2:  10.0.1.1      1.022ms\n 3:  1.2.3.4       9.120ms reached\n     Resume: pmtu 1500 hops 3 back 3\n"
+	payload, _ := json.Marshal(map[string]any{"target": "a", "provider": "tracepath", "output": []byte(output)})
+	sm := NewMapper(rules).Map(testExport("telemetry.misc.traceroute", payload))
+
+	expect(t, points(t, sm, "telemetry.events.traceroute.hops"), map[string]float64{
+		"provider=tracepath,": 3,
+	})
+}
+
+func TestMapIgnoresOtherEvents(t *testing.T) {
+	rules, err := DefaultRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm := NewMapper(rules).Map(testExport("libp2p.network.addresses", []byte(`["/ip4/1.2.3.4/tcp/4001"]`))); sm != nil {
+		t.Fatalf("unexpected metrics %v", sm)
+	}
+	if sm := NewMapper(rules).Map(testExport("libp2p.network.connections", []byte("not json"))); sm != nil {
+		t.Fatalf("unexpected metrics %v", sm)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`
+rules:
+  - event: example
+    metric: example.max
+    each: "items[]"
+    value: size
+    aggregate: max
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := NewMapper(rules).Map(testExport("example", []byte(`{"items": [{"size": 3}, {"size": 7}, {"size": 5}]}`)))
+	expect(t, points(t, sm, "example.max"), map[string]float64{"": 7})
+
+	for _, invalid := range []string{
+		"rules: [{metric: m}]",
+		"rules: [{event: e}]",
+		"rules: [{event: e, metric: m, aggregate: median}]",
+		"rules: [{event: e, metric: m, each: 'a..b'}]",
+		"rules: [{event: e, metric: m, labels: {l: 'a[]'}}]",
+		"rules: [{event: e, metric: m, value: {path: v, match: '('}}]",
+	} {
+		if _, err := ParseRules([]byte(invalid)); err == nil {
+			t.Fatalf("expected an error for %v", invalid)
+		}
+	}
+}
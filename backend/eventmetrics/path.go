@@ -0,0 +1,61 @@
+package eventmetrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+type segment struct {
+	key     string
+	iterate bool
+}
+
+type path []segment
+
+func parsePath(s string) (path, error) {
+	if s == "" {
+		return path{}, nil
+	}
+	p := make(path, 0)
+	for _, part := range strings.Split(s, ".") {
+		seg := segment{}
+		if strings.HasSuffix(part, "[]") {
+			seg.iterate = true
+			part = strings.TrimSuffix(part, "[]")
+		}
+		if strings.ContainsAny(part, "[]") || (part == "" && !seg.iterate) {
+			return nil, fmt.Errorf("invalid path %q", s)
+		}
+		seg.key = part
+		p = append(p, seg)
+	}
+	return p, nil
+}
+
+// Values selected by the path, missing keys and non array values where an array is expected select nothing
+func (p path) eval(root any) []any {
+	values := []any{root}
+	for _, seg := range p {
+		next := make([]any, 0, len(values))
+		for _, v := range values {
+			if seg.key != "" {
+				object, ok := v.(map[string]any)
+				if !ok {
+					continue
+				}
+				if v, ok = object[seg.key]; !ok {
+					continue
+				}
+			}
+			if seg.iterate {
+				if array, ok := v.([]any); ok {
+					next = append(next, array...)
+				}
+				continue
+			}
+			next = append(next, v)
+		}
+		values = next
+	}
+	return values
+}
@@ -0,0 +1,261 @@
+// Package eventmetrics turns telemetry events into OTLP gauges following rules from a config file.
+//
+// A rule selects items from the json payload of an event, groups them by labels and aggregates a value per group:
+//
+//	rules:
+//	  - event: libp2p.network.connections
+//	    metric: telemetry.events.libp2p.network.streams
+//	    each: "[].streams[]"
+//	    labels:
+//	      protocol: protocol
+//	      direction: direction
+//
+// Paths are dot separated object keys, a key followed by [] iterates over an array and an empty path is the payload itself.
+package eventmetrics
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "embed"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	AggregateSum  = "sum"
+	AggregateMin  = "min"
+	AggregateMax  = "max"
+	AggregateAvg  = "avg"
+	AggregateLast = "last"
+
+	DecodeBase64 = "base64"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+// A value extracted from an item of an event
+type Field struct {
+	// Path of the value relative to the item
+	Path string `yaml:"path"`
+	// Decoding applied to string values, only base64 is supported. []byte fields are base64 strings in json.
+	Decode string `yaml:"decode"`
+	// Regular expression applied to string values.
+	// Labels use the first non empty capture group or the whole match.
+	// Values are the largest number captured by the first group over all lines, or the number of matching lines without groups.
+	Match string `yaml:"match"`
+
+	path  path
+	regex *regexp.Regexp
+}
+
+// A field can be written as just its path
+func (f *Field) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Path = node.Value
+		return nil
+	}
+	type plain Field
+	return node.Decode((*plain)(f))
+}
+
+type Rule struct {
+	// Name of the event the rule applies to
+	Event string `yaml:"event"`
+	// Instrumentation scope of the event, any scope if empty
+	Scope string `yaml:"scope"`
+	// Name of the emitted gauge
+	Metric      string `yaml:"metric"`
+	Description string `yaml:"description"`
+	Unit        string `yaml:"unit"`
+	// Path of the items in the event payload, the payload itself if empty
+	Each string `yaml:"each"`
+	// Labels of the series, items with the same labels are aggregated into a single data point
+	Labels map[string]Field `yaml:"labels"`
+	// Value of each item, every item counts as 1 if not set
+	Value *Field `yaml:"value"`
+	// How the values of a group are combined: sum (default), min, max, avg or last
+	Aggregate string `yaml:"aggregate"`
+
+	each path
+}
+
+type Rules struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// The builtin rules, used when no rules file is configured
+func DefaultRules() (*Rules, error) {
+	return ParseRules(defaultRules)
+}
+
+// Load the rules from a yaml file, json files are also accepted
+func LoadRules(filepath string) (*Rules, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event rules file %v: %w", filepath, err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event rules file %v: %w", filepath, err)
+	}
+	return rules, nil
+}
+
+func ParseRules(data []byte) (*Rules, error) {
+	rules := new(Rules)
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, err
+	}
+	for i := range rules.Rules {
+		if err := rules.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rule %v: %w", i, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Event == "" {
+		return fmt.Errorf("missing event name")
+	}
+	if r.Metric == "" {
+		return fmt.Errorf("missing metric name")
+	}
+	switch r.Aggregate {
+	case "":
+		r.Aggregate = AggregateSum
+	case AggregateSum, AggregateMin, AggregateMax, AggregateAvg, AggregateLast:
+	default:
+		return fmt.Errorf("metric %v: invalid aggregate %q", r.Metric, r.Aggregate)
+	}
+
+	var err error
+	if r.each, err = parsePath(r.Each); err != nil {
+		return fmt.Errorf("metric %v: %w", r.Metric, err)
+	}
+	for name, field := range r.Labels {
+		if err := field.compile(); err != nil {
+			return fmt.Errorf("metric %v label %v: %w", r.Metric, name, err)
+		}
+		r.Labels[name] = field
+	}
+	if r.Value != nil {
+		if err := r.Value.compile(); err != nil {
+			return fmt.Errorf("metric %v value: %w", r.Metric, err)
+		}
+	}
+	return nil
+}
+
+func (f *Field) compile() error {
+	var err error
+	if f.path, err = parsePath(f.Path); err != nil {
+		return err
+	}
+	if strings.Contains(f.Path, "[]") {
+		return fmt.Errorf("path %q selects multiple values", f.Path)
+	}
+	if f.Decode != "" && f.Decode != DecodeBase64 {
+		return fmt.Errorf("invalid decoding %q", f.Decode)
+	}
+	if f.Match != "" {
+		if f.regex, err = regexp.Compile(f.Match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The string value of the field, false if the item has no such value
+func (f *Field) str(item any) (string, bool) {
+	values := f.path.eval(item)
+	if len(values) == 0 || values[0] == nil {
+		return "", false
+	}
+	var s string
+	switch v := values[0].(type) {
+	case string:
+		s = v
+	case float64, bool:
+		s = fmt.Sprint(v)
+	default:
+		return "", false
+	}
+	if f.Decode == DecodeBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", false
+		}
+		s = string(decoded)
+	}
+	return s, true
+}
+
+func (f *Field) label(item any) (string, bool) {
+	s, ok := f.str(item)
+	if !ok || f.regex == nil {
+		return s, ok
+	}
+	match := f.regex.FindStringSubmatch(s)
+	if match == nil {
+		return "", false
+	}
+	for _, group := range match[1:] {
+		if group != "" {
+			return group, true
+		}
+	}
+	return match[0], true
+}
+
+func (f *Field) value(item any) (float64, bool) {
+	if f.regex != nil {
+		s, ok := f.str(item)
+		if !ok {
+			return 0, false
+		}
+		count, largest, found := 0, 0.0, false
+		for _, line := range strings.Split(s, "\n") {
+			match := f.regex.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			count++
+			if len(match) > 1 {
+				if v, err := strconv.ParseFloat(match[1], 64); err == nil && (!found || v > largest) {
+					largest, found = v, true
+				}
+			}
+		}
+		if f.regex.NumSubexp() == 0 {
+			return float64(count), true
+		}
+		return largest, found
+	}
+
+	values := f.path.eval(item)
+	if len(values) == 0 {
+		return 0, false
+	}
+	switch v := values[0].(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case []any:
+		return float64(len(v)), true
+	case map[string]any:
+		return float64(len(v)), true
+	default:
+		return 0, false
+	}
+}
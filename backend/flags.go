@@ -1,10 +1,18 @@
 package backend
 
 import (
+	"time"
+
 	"github.com/urfave/cli/v2"
 )
 
 var (
+	Flag_Config = &cli.StringFlag{
+		Name:    "config",
+		Usage:   "yaml or toml file with a section per command and a global section, see `backend config print`",
+		EnvVars: []string{"BACKEND_CONFIG"},
+	}
+
 	Flag_PrometheusAddress = &cli.StringFlag{
 		Name:    "prometheus-address",
 		Aliases: []string{"prometheus"},
@@ -20,6 +28,14 @@ var (
 		Value:   "nats://localhost:4222",
 	}
 
+	Flag_NatsEncoding = &cli.StringFlag{
+		Name:    "nats-encoding",
+		Usage:   "encoding used for published nats messages, json or protobuf. consumers accept both, switch to protobuf once every consumer is upgraded",
+		EnvVars: []string{"NATS_ENCODING"},
+		// consumers released before protobuf support only understand json
+		Value: string(EncodingJson),
+	}
+
 	Flag_VmUrl = &cli.StringFlag{
 		Name:    "vm-url",
 		Usage:   "VictoriaMetrics server url",
@@ -33,4 +49,42 @@ var (
 		EnvVars: []string{"POSTGRES_URL"},
 		Value:   "postgres://postgres@localhost:5432/postgres",
 	}
+
+	Flag_GeoIPCity = &cli.StringFlag{
+		Name:    "geoip-city",
+		Aliases: []string{"geolite-city"},
+		Usage:   "path of the GeoLite2 City database used to locate peers",
+		EnvVars: []string{"GEOIP_CITY", "CRAWLER_GEOLITE_CITY"},
+		// only the pg crawler exporter has a default database
+		DefaultText: "none, ./GeoLite2-City.mmdb for pg-crawler-exporter",
+	}
+
+	Flag_GeoIPAsn = &cli.StringFlag{
+		Name:    "geoip-asn",
+		Aliases: []string{"geolite-asn"},
+		Usage:   "path of the GeoLite2 ASN database used to find the autonomous system of peers",
+		EnvVars: []string{"GEOIP_ASN", "CRAWLER_GEOLITE_ASN"},
+		// only the pg crawler exporter has a default database
+		DefaultText: "none, ./GeoLite2-ASN.mmdb for pg-crawler-exporter",
+	}
+
+	Flag_HostingAsns = &cli.StringSliceFlag{
+		Name:    "hosting-asns",
+		Usage:   "files with autonomous systems of hosting providers, one asn per line optionally followed by the provider name",
+		EnvVars: []string{"HOSTING_ASNS"},
+	}
+
+	Flag_GeoIPCacheSize = &cli.IntFlag{
+		Name:    "geoip-cache-size",
+		Usage:   "number of ip address lookups kept in memory",
+		EnvVars: []string{"GEOIP_CACHE_SIZE"},
+		Value:   65536,
+	}
+
+	Flag_GeoIPReloadInterval = &cli.DurationFlag{
+		Name:    "geoip-reload-interval",
+		Usage:   "how often the geoip databases are checked for changes and reloaded, 0 disables reloading",
+		EnvVars: []string{"GEOIP_RELOAD_INTERVAL"},
+		Value:   time.Minute,
+	}
 )
@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// Headers added to messages published on the dead letter stream, the original headers are kept
+const (
+	HeaderDeadLetterStream   = "Dead-Letter-Stream"
+	HeaderDeadLetterSubject  = "Dead-Letter-Subject"
+	HeaderDeadLetterConsumer = "Dead-Letter-Consumer"
+	HeaderDeadLetterSeqn     = "Dead-Letter-Seqn"
+	HeaderDeadLetterError    = "Dead-Letter-Error"
+)
+
+const (
+	DefaultNakDelay      = time.Minute
+	DefaultMaxDeliveries = 5
+)
+
+// Settles jetstream messages of a durable consumer from the result of processing them:
+//   - nil acks the message
+//   - poison errors publish the message on the dead letter stream and terminate it
+//   - retryable errors redeliver the message after the nak delay, or right away when shutting down
+//   - any other error is retried until the message was delivered max deliveries times and is then dead lettered
+type MsgHandler struct {
+	logger        *zap.Logger
+	js            jetstream.JetStream
+	consumer      string
+	nakDelay      time.Duration
+	maxDeliveries uint64
+}
+
+func NewMsgHandler(logger *zap.Logger, js jetstream.JetStream, consumer string, nakDelay time.Duration) *MsgHandler {
+	return &MsgHandler{
+		logger:        logger,
+		js:            js,
+		consumer:      consumer,
+		nakDelay:      nakDelay,
+		maxDeliveries: DefaultMaxDeliveries,
+	}
+}
+
+func (h *MsgHandler) Settle(ctx context.Context, msg jetstream.Msg, err error) {
+	meta, _ := msg.Metadata()
+	seqn, delivered := uint64(0), uint64(1)
+	if meta != nil {
+		seqn, delivered = meta.Sequence.Stream, meta.NumDelivered
+	}
+
+	switch {
+	case err == nil:
+		if err := msg.Ack(); err != nil {
+			h.logger.Warn("failed to ack message", zap.Uint64("seqn", seqn), zap.Error(err))
+		}
+	case ctx.Err() != nil:
+		h.Retry(ctx, msg)
+	case IsPoison(err), !IsRetryable(err) && delivered >= h.maxDeliveries:
+		h.DeadLetter(ctx, msg, err)
+	default:
+		h.logger.Warn("failed to process message, it will be redelivered", zap.Uint64("seqn", seqn), zap.Uint64("delivered", delivered), zap.Duration("delay", h.nakDelay), zap.Error(err))
+		h.Retry(ctx, msg)
+	}
+}
+
+// Hand the message back to nats to be redelivered after the nak delay
+func (h *MsgHandler) Retry(ctx context.Context, msg jetstream.Msg) {
+	if ctx.Err() != nil {
+		// shutting down, another instance can pick the message up right away
+		msg.Nak()
+		return
+	}
+	msg.NakWithDelay(h.nakDelay)
+}
+
+// Publish the message on the dead letter stream and terminate it.
+// If publishing fails the message is redelivered after the nak delay so it is not lost.
+func (h *MsgHandler) DeadLetter(ctx context.Context, msg jetstream.Msg, cause error) {
+	meta, _ := msg.Metadata()
+	seqn := uint64(0)
+	if meta != nil {
+		seqn = meta.Sequence.Stream
+	}
+
+	if err := NatsDeadLetter(ctx, h.js, h.consumer, msg, cause); err != nil {
+		h.logger.Error("failed to dead letter message", zap.Uint64("seqn", seqn), zap.NamedError("cause", cause), zap.Error(err))
+		h.Retry(ctx, msg)
+		return
+	}
+	h.logger.Error("dead lettered message", zap.Uint64("seqn", seqn), zap.Error(cause))
+	msg.Term()
+}
+
+// Publish a copy of msg on the dead letter subject of its stream and consumer
+func NatsDeadLetter(ctx context.Context, js jetstream.JetStream, consumer string, msg jetstream.Msg, cause error) error {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return fmt.Errorf("failed to get message metadata: %w", err)
+	}
+
+	dead := nats.NewMsg(streams.DeadLetterSubject(meta.Stream, consumer))
+	dead.Data = msg.Data()
+	for key, values := range msg.Headers() {
+		dead.Header[key] = values
+	}
+	dead.Header.Set(HeaderDeadLetterStream, meta.Stream)
+	dead.Header.Set(HeaderDeadLetterSubject, msg.Subject())
+	dead.Header.Set(HeaderDeadLetterConsumer, consumer)
+	dead.Header.Set(HeaderDeadLetterSeqn, strconv.FormatUint(meta.Sequence.Stream, 10))
+	dead.Header.Set(HeaderDeadLetterError, cause.Error())
+
+	if _, err := js.PublishMsg(ctx, dead); err != nil {
+		return fmt.Errorf("failed to publish on %v: %w", dead.Subject, err)
+	}
+	return nil
+}
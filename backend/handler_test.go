@@ -0,0 +1,148 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+type testMsg struct {
+	jetstream.Msg
+	delivered uint64
+	headers   nats.Header
+	settled   string
+}
+
+func (m *testMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Stream: "monitor", Sequence: jetstream.SequencePair{Stream: 42}, NumDelivered: m.delivered}, nil
+}
+func (m *testMsg) Data() []byte         { return []byte("payload") }
+func (m *testMsg) Subject() string      { return "monitor.export" }
+func (m *testMsg) Headers() nats.Header { return m.headers }
+func (m *testMsg) Ack() error           { m.settled = "ack"; return nil }
+func (m *testMsg) Nak() error           { m.settled = "nak"; return nil }
+func (m *testMsg) Term() error          { m.settled = "term"; return nil }
+func (m *testMsg) NakWithDelay(time.Duration) error {
+	m.settled = "nak-delay"
+	return nil
+}
+
+type testJetStream struct {
+	jetstream.JetStream
+	published []*nats.Msg
+	fail      bool
+}
+
+func (js *testJetStream) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if js.fail {
+		return nil, errors.New("no responders")
+	}
+	js.published = append(js.published, msg)
+	return &jetstream.PubAck{}, nil
+}
+
+func TestErrorClassification(t *testing.T) {
+	err := fmt.Errorf("export: %w", Poison(errors.New("bad payload")))
+	if !IsPoison(err) || IsRetryable(err) {
+		t.Fatalf("wrapped poison errors must be poison")
+	}
+	if !IsRetryable(Retryable(errors.New("timeout"))) {
+		t.Fatalf("expected a retryable error")
+	}
+	if Poison(nil) != nil || Retryable(nil) != nil {
+		t.Fatalf("nil errors must stay nil")
+	}
+}
+
+func TestMsgHandlerSettle(t *testing.T) {
+	ctx := context.Background()
+	js := &testJetStream{}
+	handler := NewMsgHandler(zap.NewNop(), js, "monitor-pg-exporter", time.Minute)
+
+	cases := []struct {
+		err       error
+		delivered uint64
+		settled   string
+	}{
+		{nil, 1, "ack"},
+		{Retryable(errors.New("connection refused")), 1, "nak-delay"},
+		{Retryable(errors.New("connection refused")), 100, "nak-delay"},
+		{errors.New("unknown"), 1, "nak-delay"},
+		{errors.New("unknown"), DefaultMaxDeliveries, "term"},
+		{Poison(errors.New("bad payload")), 1, "term"},
+	}
+	for _, c := range cases {
+		msg := &testMsg{delivered: c.delivered, headers: nats.Header{}}
+		handler.Settle(ctx, msg, c.err)
+		if msg.settled != c.settled {
+			t.Fatalf("expected %v for error %v delivered %v times, got %v", c.settled, c.err, c.delivered, msg.settled)
+		}
+	}
+	if len(js.published) != 2 {
+		t.Fatalf("expected 2 dead lettered messages, got %v", len(js.published))
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	msg := &testMsg{delivered: 1, headers: nats.Header{}}
+	handler.Settle(canceled, msg, Retryable(context.Canceled))
+	if msg.settled != "nak" {
+		t.Fatalf("messages must be redelivered right away when shutting down, got %v", msg.settled)
+	}
+}
+
+func TestMsgHandlerDeadLetter(t *testing.T) {
+	js := &testJetStream{}
+	handler := NewMsgHandler(zap.NewNop(), js, "monitor-pg-exporter", time.Minute)
+	msg := &testMsg{delivered: 1, headers: nats.Header{HeaderContentType: []string{ContentTypeJson}}}
+
+	handler.DeadLetter(context.Background(), msg, errors.New("bad payload"))
+	if msg.settled != "term" || len(js.published) != 1 {
+		t.Fatalf("expected the message to be published and terminated")
+	}
+	dead := js.published[0]
+	if dead.Subject != "deadletter.monitor.monitor-pg-exporter" || string(dead.Data) != "payload" {
+		t.Fatalf("unexpected dead letter %v %q", dead.Subject, dead.Data)
+	}
+	if dead.Header.Get(HeaderContentType) != ContentTypeJson || dead.Header.Get(HeaderDeadLetterSeqn) != "42" ||
+		dead.Header.Get(HeaderDeadLetterSubject) != "monitor.export" || dead.Header.Get(HeaderDeadLetterError) != "bad payload" {
+		t.Fatalf("unexpected dead letter headers %v", dead.Header)
+	}
+
+	js.fail = true
+	msg = &testMsg{delivered: 1, headers: nats.Header{}}
+	handler.DeadLetter(context.Background(), msg, errors.New("bad payload"))
+	if msg.settled != "nak-delay" {
+		t.Fatalf("messages that fail to be dead lettered must be redelivered, got %v", msg.settled)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	h := &health{checks: make(map[string]HealthCheck)}
+	get := func(handler http.HandlerFunc) int {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	if get(h.readiness) != http.StatusOK || get(h.liveness) != http.StatusOK {
+		t.Fatalf("a service without checks must be ready")
+	}
+	h.checks["nats"] = func(ctx context.Context) error { return errors.New("connection is RECONNECTING") }
+	if get(h.readiness) != http.StatusServiceUnavailable {
+		t.Fatalf("a failing check must make the service not ready")
+	}
+	delete(h.checks, "nats")
+	h.stopping.Store(true)
+	if get(h.readiness) != http.StatusServiceUnavailable || get(h.liveness) != http.StatusOK {
+		t.Fatalf("a service shutting down must be alive but not ready")
+	}
+}
@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// Reports an error if a dependency of the service is not usable
+type HealthCheck func(ctx context.Context) error
+
+type health struct {
+	mu       sync.Mutex
+	checks   map[string]HealthCheck
+	stopping atomic.Bool
+}
+
+var serviceHealth = &health{checks: make(map[string]HealthCheck)}
+
+// Register a check of the readiness endpoint, a check with the same name is replaced
+func AddReadinessCheck(name string, check HealthCheck) {
+	serviceHealth.mu.Lock()
+	defer serviceHealth.mu.Unlock()
+	serviceHealth.checks[name] = check
+}
+
+// The process is alive as long as it answers
+func (h *health) liveness(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// The service is ready when every check passes and it is not shutting down
+func (h *health) readiness(w http.ResponseWriter, r *http.Request) {
+	if h.stopping.Load() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	h.mu.Lock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	checks := make([]HealthCheck, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+	failures := make([]string, 0)
+	for i, check := range checks {
+		if err := check(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", names[i], err))
+		}
+	}
+	if len(failures) > 0 {
+		http.Error(w, strings.Join(failures, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,22 @@
+package migrate
+
+import "github.com/urfave/cli/v2"
+
+var (
+	FLAG_COMPONENT = &cli.StringSliceFlag{
+		Name:    "component",
+		Usage:   "schema to migrate, crawler or monitor. defaults to every schema",
+		EnvVars: []string{"MIGRATE_COMPONENT"},
+	}
+
+	FLAG_TO = &cli.UintFlag{
+		Name:    "to",
+		Usage:   "version to migrate up or down to, defaults to the latest version. 0 reverts every migration",
+		EnvVars: []string{"MIGRATE_TO"},
+	}
+
+	FLAG_STATUS = &cli.BoolFlag{
+		Name:  "status",
+		Usage: "print the current and latest version of each schema without migrating",
+	}
+)
@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/pg_crawler_exporter"
+	"github.com/diogo464/ipfs-telemetry/backend/pg_monitor_exporter"
+	"github.com/diogo464/ipfs-telemetry/backend/pgmigrate"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+var Command *cli.Command = &cli.Command{
+	Name:        "migrate",
+	Description: "migrate the postgres schemas of the exporters",
+	Flags: []cli.Flag{
+		FLAG_COMPONENT,
+		FLAG_TO,
+		FLAG_STATUS,
+	},
+	Action: main,
+}
+
+func main(c *cli.Context) error {
+	logger := backend.ServiceSetup(c, "migrate")
+
+	components, err := componentsFromFlags(c)
+	if err != nil {
+		return err
+	}
+	if c.IsSet(FLAG_TO.Name) && len(components) != 1 {
+		return fmt.Errorf("--%v requires exactly one --%v", FLAG_TO.Name, FLAG_COMPONENT.Name)
+	}
+
+	conn := backend.PostgresClient(logger, c)
+	defer conn.Close(c.Context)
+
+	for _, component := range components {
+		current, err := pgmigrate.Current(c.Context, conn, component.Name)
+		if err != nil {
+			return err
+		}
+		if c.Bool(FLAG_STATUS.Name) {
+			fmt.Printf("%v: version %v of %v\n", component.Name, current, component.Latest())
+			for _, m := range pgmigrate.Pending(component, current) {
+				fmt.Printf("  pending %04d_%v\n", m.Version, m.Name)
+			}
+			continue
+		}
+
+		target := component.Latest()
+		if c.IsSet(FLAG_TO.Name) {
+			target = c.Uint(FLAG_TO.Name)
+		}
+		logger.Info("migrating", zap.String("component", component.Name), zap.Uint("from", current), zap.Uint("to", target))
+		if err := pgmigrate.To(c.Context, logger, conn, component, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Every known component, in the order they are migrated
+func Components() ([]*pgmigrate.Component, error) {
+	crawler, err := pg_crawler_exporter.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	monitor, err := pg_monitor_exporter.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return []*pgmigrate.Component{crawler, monitor}, nil
+}
+
+func componentsFromFlags(c *cli.Context) ([]*pgmigrate.Component, error) {
+	components, err := Components()
+	if err != nil {
+		return nil, err
+	}
+	names := c.StringSlice(FLAG_COMPONENT.Name)
+	if len(names) == 0 {
+		return components, nil
+	}
+
+	selected := make([]*pgmigrate.Component, 0, len(names))
+	for _, name := range names {
+		idx := slices.IndexFunc(components, func(component *pgmigrate.Component) bool { return component.Name == name })
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown component %q", name)
+		}
+		selected = append(selected, components[idx])
+	}
+	return selected, nil
+}
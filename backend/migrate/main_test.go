@@ -0,0 +1,19 @@
+package migrate_test
+
+import (
+	"testing"
+
+	"github.com/diogo464/ipfs-telemetry/backend/migrate"
+)
+
+func TestComponentsLoad(t *testing.T) {
+	components, err := migrate.Components()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, component := range components {
+		if component.Latest() == 0 {
+			t.Fatalf("component %v has no migrations", component.Name)
+		}
+	}
+}
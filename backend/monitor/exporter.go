@@ -2,10 +2,10 @@ package monitor
 
 import (
 	"context"
-	"encoding/json"
 	"sync"
 	"time"
 
+	"github.com/diogo464/ipfs-telemetry/backend"
 	"github.com/diogo464/telemetry"
 	"github.com/diogo464/telemetry/monitor"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -17,7 +17,10 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-var _ (monitor.Exporter) = (*natsExporter)(nil)
+var (
+	_ (monitor.Exporter) = (*exporter)(nil)
+	_ (ExportSink)       = (*natsSink)(nil)
+)
 
 var (
 	Scope = instrumentation.Scope{
@@ -36,10 +39,16 @@ var (
 	publishSizeLatest, _ = meter.Int64Gauge("publish_size_latest")
 )
 
-type natsExporter struct {
+// Receives every export successfully collected by the monitor
+type ExportSink interface {
+	Export(*Export)
+}
+
+// Assembles the data collected from a peer into an Export and hands it to the sinks on success
+type exporter struct {
 	sync.Mutex
-	client     *nats.Conn
 	logger     *zap.Logger
+	sinks      []ExportSink
 	inprogress map[peer.ID]*Export
 }
 
@@ -56,19 +65,20 @@ func defaultExport(p peer.ID) *Export {
 		Metrics:    []ExportMetrics{},
 		Events:     []ExportEvents{},
 		Bandwidth:  nil,
+		Probe:      nil,
 	}
 }
 
-func newExporter(client *nats.Conn, logger *zap.Logger) *natsExporter {
-	return &natsExporter{
-		client:     client,
+func newExporter(logger *zap.Logger, sinks ...ExportSink) *exporter {
+	return &exporter{
 		logger:     logger,
+		sinks:      sinks,
 		inprogress: make(map[peer.ID]*Export),
 	}
 }
 
 // PeerBegin implements monitor.Exporter
-func (e *natsExporter) PeerBegin(p peer.ID) {
+func (e *exporter) PeerBegin(p peer.ID) {
 	e.Lock()
 	defer e.Unlock()
 
@@ -76,7 +86,7 @@ func (e *natsExporter) PeerBegin(p peer.ID) {
 }
 
 // PeerFailure implements monitor.Exporter
-func (e *natsExporter) PeerFailure(p peer.ID, err error) {
+func (e *exporter) PeerFailure(p peer.ID, err error) {
 	e.Lock()
 	defer e.Unlock()
 
@@ -85,37 +95,44 @@ func (e *natsExporter) PeerFailure(p peer.ID, err error) {
 }
 
 // PeerSuccess implements monitor.Exporter
-func (e *natsExporter) PeerSuccess(p peer.ID) {
+func (e *exporter) PeerSuccess(p peer.ID) {
 	e.Lock()
 	defer e.Unlock()
 
 	exp := e.inprogress[p]
 	delete(e.inprogress, p)
-	if marshaled, err := json.Marshal(exp); err == nil {
-		publishSizeKb.Record(context.Background(), int64(len(marshaled))/1024)
-		publishSizeLatest.Record(context.Background(), int64(len(marshaled)))
-		if err := e.client.Publish(Subject_Export, marshaled); err != nil {
-			e.logger.Error("failed to publish telemetry export", zap.Error(err))
-		}
-	} else {
-		e.logger.Error("failed to marshal export", zap.Any("export", exp), zap.Error(err))
+	for _, sink := range e.sinks {
+		sink.Export(exp)
 	}
 }
 
 // Bandwidth implements monitor.Exporter
-func (e *natsExporter) Bandwidth(p peer.ID, b telemetry.Bandwidth) {
+func (e *exporter) Bandwidth(p peer.ID, b telemetry.Bandwidth) {
 	e.Lock()
 	defer e.Unlock()
 
 	exp := e.getPeerExport(p)
 	exp.Bandwidth = &ExportBandwidth{
-		UploadRate:   uint64(b.UploadRate),
-		DownloadRate: uint64(b.DownloadRate),
+		UploadRate:   b.UploadRate,
+		DownloadRate: b.DownloadRate,
+		Rtt:          b.Rtt,
+		Jitter:       b.Jitter,
+		Upload:       b.Upload,
+		Download:     b.Download,
 	}
 }
 
+// Probe implements monitor.Exporter
+func (e *exporter) Probe(p peer.ID, pr monitor.Probe) {
+	e.Lock()
+	defer e.Unlock()
+
+	exp := e.getPeerExport(p)
+	exp.Probe = &pr
+}
+
 // Events implements monitor.Exporter
-func (e *natsExporter) Events(p peer.ID, s telemetry.Session, d telemetry.EventDescriptor, es []telemetry.Event) {
+func (e *exporter) Events(p peer.ID, s telemetry.Session, d telemetry.EventDescriptor, es []telemetry.Event) {
 	e.Lock()
 	defer e.Unlock()
 
@@ -127,7 +144,7 @@ func (e *natsExporter) Events(p peer.ID, s telemetry.Session, d telemetry.EventD
 }
 
 // Metrics implements monitor.Exporter
-func (e *natsExporter) Metrics(p peer.ID, s telemetry.Session, ms telemetry.Metrics) {
+func (e *exporter) Metrics(p peer.ID, s telemetry.Session, ms telemetry.Metrics) {
 	e.Lock()
 	defer e.Unlock()
 
@@ -145,7 +162,7 @@ func (e *natsExporter) Metrics(p peer.ID, s telemetry.Session, ms telemetry.Metr
 }
 
 // Properties implements monitor.Exporter
-func (e *natsExporter) Properties(p peer.ID, s telemetry.Session, ps []telemetry.Property) {
+func (e *exporter) Properties(p peer.ID, s telemetry.Session, ps []telemetry.Property) {
 	e.Lock()
 	defer e.Unlock()
 
@@ -173,7 +190,7 @@ func (e *natsExporter) Properties(p peer.ID, s telemetry.Session, ps []telemetry
 }
 
 // Session implements monitor.Exporter
-func (e *natsExporter) Session(p peer.ID, s telemetry.Session) {
+func (e *exporter) Session(p peer.ID, s telemetry.Session) {
 	e.Lock()
 	defer e.Unlock()
 
@@ -181,16 +198,45 @@ func (e *natsExporter) Session(p peer.ID, s telemetry.Session) {
 	exp.Session = s
 }
 
-func (e *natsExporter) getPeerExport(p peer.ID) *Export {
+func (e *exporter) getPeerExport(p peer.ID) *Export {
 	exp := e.inprogress[p]
 	if exp == nil {
-		e.logger.Fatal("called getPeerExport for peer before PeerBegin", zap.String("peer", p.String()))
+		// the data is written to an export that is never published
+		e.logger.Error("called getPeerExport for peer before PeerBegin", zap.String("peer", p.String()))
+		return &Export{Peer: p}
 	}
 	return exp
 }
 
-func (e *natsExporter) getPeerExportWithSess(p peer.ID, s telemetry.Session) *Export {
+func (e *exporter) getPeerExportWithSess(p peer.ID, s telemetry.Session) *Export {
 	exp := e.getPeerExport(p)
 	exp.Session = s
 	return exp
 }
+
+type natsSink struct {
+	client   *nats.Conn
+	encoding backend.Encoding
+	logger   *zap.Logger
+}
+
+func newNatsSink(client *nats.Conn, encoding backend.Encoding, logger *zap.Logger) *natsSink {
+	return &natsSink{
+		client:   client,
+		encoding: encoding,
+		logger:   logger,
+	}
+}
+
+// Export implements ExportSink
+func (s *natsSink) Export(exp *Export) {
+	if msg, err := backend.NatsMsg(s.encoding, Subject_Export, exp); err == nil {
+		publishSizeKb.Record(context.Background(), int64(len(msg.Data))/1024)
+		publishSizeLatest.Record(context.Background(), int64(len(msg.Data)))
+		if err := s.client.PublishMsg(msg); err != nil {
+			s.logger.Error("failed to publish telemetry export", zap.Error(err))
+		}
+	} else {
+		s.logger.Error("failed to marshal export", zap.Any("export", exp), zap.Error(err))
+	}
+}
@@ -0,0 +1,30 @@
+package monitor
+
+import (
+	"github.com/diogo464/ipfs-telemetry/backend/archive"
+	"go.uber.org/zap"
+)
+
+// Name of the archive dataset containing monitor exports
+const ArchiveDatasetExport = "monitor-export"
+
+var _ (ExportSink) = (*archiveSink)(nil)
+
+type archiveSink struct {
+	writer *archive.Writer
+	logger *zap.Logger
+}
+
+func newArchiveSink(writer *archive.Writer, logger *zap.Logger) *archiveSink {
+	return &archiveSink{
+		writer: writer,
+		logger: logger,
+	}
+}
+
+// Export implements ExportSink
+func (s *archiveSink) Export(exp *Export) {
+	if err := s.writer.Write(exp.ObservedAt, exp); err != nil {
+		s.logger.Error("failed to archive export", zap.String("peer", exp.Peer.String()), zap.Error(err))
+	}
+}
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"github.com/diogo464/telemetry/monitor"
 	"github.com/urfave/cli/v2"
 )
 
@@ -9,12 +10,14 @@ var (
 		Name:    "max-failed-attemps",
 		Usage:   "how many consecutive errors can happen while making requests to a peer before removing it",
 		EnvVars: []string{"MONITOR_MAX_FAILED_ATTEMPS"},
+		Value:   monitor.DEFAULT_MAX_FAILED_ATTEMPTS,
 	}
 
 	FLAG_RETRY_INTERVAL = &cli.DurationFlag{
 		Name:    "retry-interval",
 		Usage:   "how many seconds before retrying a request to a peer after a failure",
 		EnvVars: []string{"MONITOR_RETRY_INTERVAL"},
+		Value:   monitor.DEFAULT_RETRY_INTERVAL,
 	}
 
 	FLAG_COLLECT_ENABLED = &cli.BoolFlag{
@@ -27,12 +30,14 @@ var (
 		Name:    "collect-interval",
 		Usage:   "how long between each telemetry request to a peer",
 		EnvVars: []string{"MONITOR_COLLECT_INTERVAL"},
+		Value:   monitor.DEFAULT_COLLECT_PERIOD,
 	}
 
 	FLAG_COLLECT_TIMEOUT = &cli.DurationFlag{
 		Name:    "collect-timeout",
 		Usage:   "how long before a telemetry request times out and counts as an error",
 		EnvVars: []string{"MONITOR_COLLECT_TIMEOUT"},
+		Value:   monitor.DEFAULT_COLLECT_TIMEOUT,
 	}
 
 	FLAG_BANDWIDTH_ENABLED = &cli.BoolFlag{
@@ -45,11 +50,48 @@ var (
 		Name:    "bandwidth-interval",
 		Usage:   "how long between each bandwidth request to a peer",
 		EnvVars: []string{"MONITOR_BANDWIDTH_INTERVAL"},
+		Value:   monitor.DEFAULT_BANDWIDTH_PERIOD,
 	}
 
 	FLAG_BANDWIDTH_TIMEOUT = &cli.DurationFlag{
 		Name:    "bandwidth-timeout",
 		Usage:   "how long before a bandwidth request times out and counts as an error",
 		EnvVars: []string{"MONITOR_BANDWIDTH_TIMEOUT"},
+		Value:   monitor.DEFAULT_BANDWIDTH_TIMEOUT,
+	}
+
+	FLAG_PROBE_ENABLED = &cli.BoolFlag{
+		Name:    "probe-enabled",
+		Usage:   "periodically ping, dial each transport and identify monitored peers",
+		EnvVars: []string{"MONITOR_PROBE_ENABLED"},
+		Value:   true,
+	}
+
+	FLAG_PROBE_INTERVAL = &cli.DurationFlag{
+		Name:    "probe-interval",
+		Usage:   "how long between each probe of a peer",
+		EnvVars: []string{"MONITOR_PROBE_INTERVAL"},
+		Value:   monitor.DEFAULT_PROBE_PERIOD,
+	}
+
+	FLAG_PROBE_TIMEOUT = &cli.DurationFlag{
+		Name:    "probe-timeout",
+		Usage:   "how long a probe can take before it is cut short",
+		EnvVars: []string{"MONITOR_PROBE_TIMEOUT"},
+		Value:   monitor.DEFAULT_PROBE_TIMEOUT,
+	}
+
+	FLAG_BANDWIDTH_STREAMS = &cli.UintFlag{
+		Name:    "bandwidth-streams",
+		Usage:   "number of parallel streams used in a bandwidth test",
+		EnvVars: []string{"MONITOR_BANDWIDTH_STREAMS"},
+		Value:   monitor.DEFAULT_BANDWIDTH_STREAMS,
+	}
+
+	FLAG_BANDWIDTH_DURATION = &cli.DurationFlag{
+		Name:    "bandwidth-duration",
+		Usage:   "how long data is transferred in each direction of a bandwidth test, between 500ms and 30s",
+		EnvVars: []string{"MONITOR_BANDWIDTH_DURATION"},
+		Value:   monitor.DEFAULT_BANDWIDTH_DURATION,
 	}
 )
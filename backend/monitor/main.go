@@ -1,11 +1,12 @@
 package monitor
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/archive"
 	"github.com/diogo464/telemetry/monitor"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -22,7 +23,7 @@ import (
 var Command *cli.Command = &cli.Command{
 	Name:        "monitor",
 	Description: "monitor service",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		FLAG_MAX_FAILED_ATTEMPTS,
 		FLAG_RETRY_INTERVAL,
 		FLAG_COLLECT_ENABLED,
@@ -31,7 +32,12 @@ var Command *cli.Command = &cli.Command{
 		FLAG_BANDWIDTH_ENABLED,
 		FLAG_BANDWIDTH_INTERVAL,
 		FLAG_BANDWIDTH_TIMEOUT,
-	},
+		FLAG_BANDWIDTH_STREAMS,
+		FLAG_BANDWIDTH_DURATION,
+		FLAG_PROBE_ENABLED,
+		FLAG_PROBE_INTERVAL,
+		FLAG_PROBE_TIMEOUT,
+	}, append(archive.StorageFlags, archive.WriterFlags...)...),
 	Action: main,
 }
 
@@ -68,10 +74,45 @@ func main(c *cli.Context) error {
 		monitorOptions = append(monitorOptions, monitor.WithBandwidthTimeout(c.Duration(FLAG_BANDWIDTH_TIMEOUT.Name)))
 	}
 
+	if c.IsSet(FLAG_BANDWIDTH_STREAMS.Name) {
+		monitorOptions = append(monitorOptions, monitor.WithBandwidthStreams(uint32(c.Uint(FLAG_BANDWIDTH_STREAMS.Name))))
+	}
+
+	if c.IsSet(FLAG_BANDWIDTH_DURATION.Name) {
+		monitorOptions = append(monitorOptions, monitor.WithBandwidthDuration(c.Duration(FLAG_BANDWIDTH_DURATION.Name)))
+	}
+
+	monitorOptions = append(monitorOptions, monitor.WithProbeEnabled(c.Bool(FLAG_PROBE_ENABLED.Name)))
+
+	if c.IsSet(FLAG_PROBE_INTERVAL.Name) {
+		monitorOptions = append(monitorOptions, monitor.WithProbePeriod(c.Duration(FLAG_PROBE_INTERVAL.Name)))
+	}
+
+	if c.IsSet(FLAG_PROBE_TIMEOUT.Name) {
+		monitorOptions = append(monitorOptions, monitor.WithProbeTimeout(c.Duration(FLAG_PROBE_TIMEOUT.Name)))
+	}
+
 	nc := backend.NatsClient(logger, c)
 	js := backend.NatsJetstream(logger, nc)
+	backend.NatsValidateStream(c.Context, logger, js, Stream_Monitor)
+	encoding := backend.NatsEncoding(logger, c)
+
+	sinks := []ExportSink{newNatsSink(nc, encoding, logger.Named("nats-sink"))}
+	storage, err := archive.StorageFromFlags(c)
+	backend.FatalOnError(logger, err, "failed to create archive storage")
+	if storage != nil {
+		writerOpts := append(archive.WriterOptionsFromFlags(c), archive.WithLogger(logger.Named("archive")))
+		writer, err := archive.NewWriter(storage, ArchiveDatasetExport, writerOpts...)
+		backend.FatalOnError(logger, err, "failed to create archive writer")
+		defer func() {
+			if err := writer.Close(context.Background()); err != nil {
+				logger.Error("failed to flush archive", zap.Error(err))
+			}
+		}()
+		sinks = append(sinks, newArchiveSink(writer, logger.Named("archive-sink")))
+	}
 
-	exporter := newExporter(nc, logger.Named("exporter"))
+	exporter := newExporter(logger.Named("exporter"), sinks...)
 	monitorOptions = append(monitorOptions, monitor.WithExporter(exporter))
 	monitorOptions = append(monitorOptions, monitor.WithLogger(logger.Named("telemetry.monitor")))
 	monitorOptions = append(monitorOptions, monitor.WithMeterProvider(otel.GetMeterProvider()))
@@ -88,13 +129,18 @@ func main(c *cli.Context) error {
 	backend.FatalOnError(logger, err, "failed to start monitor")
 
 	go func() {
+		ticker := time.NewTicker(time.Second * 5)
+		defer ticker.Stop()
 		for {
-			ticker := time.NewTicker(time.Second * 5)
 			select {
 			case <-ticker.C:
-				backend.NatsPublishJson(logger, nc, Subject_Active, &ActiveMessage{
+				// the next tick publishes the active peers again, a failed publish only delays them
+				err := backend.NatsPublish(nc, encoding, Subject_Active, &ActiveMessage{
 					Peers: mon.GetActivePeers(),
 				})
+				if err != nil {
+					logger.Warn("failed to publish active peers", zap.Error(err))
+				}
 			case <-c.Context.Done():
 				return
 			}
@@ -116,7 +162,7 @@ func main(c *cli.Context) error {
 	}
 	cctx, err := consumer.Consume(func(msg jetstream.Msg) {
 		var discovery DiscoveryMessage
-		err := json.Unmarshal(msg.Data(), &discovery)
+		err := backend.NatsDecode(msg.Headers(), msg.Data(), &discovery)
 		if err != nil {
 			logger.Error("failed to unmarshal discovery", zap.Error(err))
 			return
@@ -136,8 +182,13 @@ func main(c *cli.Context) error {
 		return err
 	}
 
-	<-cctx.Closed()
-	return nil
+	select {
+	case <-cctx.Closed():
+	case <-c.Context.Done():
+		cctx.Drain()
+		<-cctx.Closed()
+	}
+	return nc.Drain()
 }
 
 func discoveryToAddrInfo(logger *zap.Logger, discovery *DiscoveryMessage) (*peer.AddrInfo, error) {
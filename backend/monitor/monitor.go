@@ -1,19 +1,23 @@
 package monitor
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
 	"github.com/diogo464/telemetry"
+	"github.com/diogo464/telemetry/monitor"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 )
 
 const (
-	Stream_Monitor   = "monitor"
-	Subject_Discover = "monitor.discover"
-	Subject_Export   = "monitor.export"
-	Subject_Active   = "monitor.active"
+	Stream_Monitor   = streams.StreamMonitor
+	Subject_Discover = streams.SubjectMonitorDiscover
+	Subject_Export   = streams.SubjectMonitorExport
+	Subject_Active   = streams.SubjectMonitorActive
 )
 
 type DiscoveryMessage struct {
@@ -22,8 +26,12 @@ type DiscoveryMessage struct {
 }
 
 type ExportBandwidth struct {
-	UploadRate   uint64 `json:"upload_rate"`
-	DownloadRate uint64 `json:"download_rate"`
+	UploadRate   uint64                         `json:"upload_rate"`
+	DownloadRate uint64                         `json:"download_rate"`
+	Rtt          time.Duration                  `json:"rtt"`
+	Jitter       time.Duration                  `json:"jitter"`
+	Upload       telemetry.BandwidthMeasurement `json:"upload"`
+	Download     telemetry.BandwidthMeasurement `json:"download"`
 }
 
 type ExportEvents struct {
@@ -42,6 +50,35 @@ type ExportProperty struct {
 	Value       interface{}           `json:"value"`
 }
 
+// Split a property value into its string and integer columns
+func PropertyValue(value interface{}) (*string, *int64, error) {
+	switch v := value.(type) {
+	case *string:
+		return v, nil, nil
+	case string:
+		return &v, nil, nil
+	case *int64:
+		return nil, v, nil
+	case int64:
+		return nil, &v, nil
+	case float64:
+		// json decodes every number as a float64
+		i := int64(v)
+		return nil, &i, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown property value type %T", value)
+	}
+}
+
+// Event payloads are usually json, anything else is stored as a json string
+func EventPayload(data []byte) []byte {
+	if json.Valid(data) {
+		return data
+	}
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
+
 type Export struct {
 	ObservedAt time.Time         `json:"observed_at"`
 	Peer       peer.ID           `json:"peer"`
@@ -52,6 +89,7 @@ type Export struct {
 	Metrics    []ExportMetrics  `json:"metrics"`
 	Events     []ExportEvents   `json:"events"`
 	Bandwidth  *ExportBandwidth `json:"bandwidth"`
+	Probe      *monitor.Probe   `json:"probe"`
 }
 
 type ActiveMessage struct {
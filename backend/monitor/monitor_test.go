@@ -0,0 +1,30 @@
+package monitor_test
+
+import (
+	"testing"
+
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+)
+
+func TestPropertyValue(t *testing.T) {
+	s, i, err := monitor.PropertyValue(float64(42))
+	if err != nil || s != nil || i == nil || *i != 42 {
+		t.Fatalf("unexpected integer value %v %v %v", s, i, err)
+	}
+	s, i, err = monitor.PropertyValue("kubo")
+	if err != nil || i != nil || s == nil || *s != "kubo" {
+		t.Fatalf("unexpected string value %v %v %v", s, i, err)
+	}
+	if _, _, err := monitor.PropertyValue(true); err == nil {
+		t.Fatalf("expected an error for an unknown value type")
+	}
+}
+
+func TestEventPayload(t *testing.T) {
+	if p := string(monitor.EventPayload([]byte(`{"cid":"bafy"}`))); p != `{"cid":"bafy"}` {
+		t.Fatalf("json payload was modified: %v", p)
+	}
+	if p := string(monitor.EventPayload([]byte("not json"))); p != `"not json"` {
+		t.Fatalf("unexpected payload %v", p)
+	}
+}
@@ -0,0 +1,417 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/pb"
+	"github.com/diogo464/telemetry"
+	"github.com/diogo464/telemetry/monitor"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	_ (backend.ProtoMessage)        = (*DiscoveryMessage)(nil)
+	_ (backend.ProtoMessageDecoder) = (*DiscoveryMessage)(nil)
+	_ (backend.ProtoMessage)        = (*ActiveMessage)(nil)
+	_ (backend.ProtoMessageDecoder) = (*ActiveMessage)(nil)
+	_ (backend.ProtoMessage)        = (*Export)(nil)
+	_ (backend.ProtoMessageDecoder) = (*Export)(nil)
+)
+
+// ToProto implements backend.ProtoMessage
+func (m *DiscoveryMessage) ToProto() (proto.Message, error) {
+	return &pb.DiscoveryMessage{
+		Id:        []byte(m.ID),
+		Addresses: MultiaddrsToProto(m.Addresses),
+	}, nil
+}
+
+// NewProto implements backend.ProtoMessageDecoder
+func (m *DiscoveryMessage) NewProto() proto.Message {
+	return new(pb.DiscoveryMessage)
+}
+
+// FromProto implements backend.ProtoMessageDecoder
+func (m *DiscoveryMessage) FromProto(msg proto.Message) error {
+	p := msg.(*pb.DiscoveryMessage)
+	id, err := peer.IDFromBytes(p.GetId())
+	if err != nil {
+		return err
+	}
+	addrs, err := MultiaddrsFromProto(p.GetAddresses())
+	if err != nil {
+		return err
+	}
+	m.ID = id
+	m.Addresses = addrs
+	return nil
+}
+
+// ToProto implements backend.ProtoMessage
+func (m *ActiveMessage) ToProto() (proto.Message, error) {
+	return &pb.ActiveMessage{Peers: PeerIDsToProto(m.Peers)}, nil
+}
+
+// NewProto implements backend.ProtoMessageDecoder
+func (m *ActiveMessage) NewProto() proto.Message {
+	return new(pb.ActiveMessage)
+}
+
+// FromProto implements backend.ProtoMessageDecoder
+func (m *ActiveMessage) FromProto(msg proto.Message) error {
+	peers, err := PeerIDsFromProto(msg.(*pb.ActiveMessage).GetPeers())
+	if err != nil {
+		return err
+	}
+	m.Peers = peers
+	return nil
+}
+
+// ToProto implements backend.ProtoMessage
+func (e *Export) ToProto() (proto.Message, error) {
+	properties := make([]*pb.Property, 0, len(e.Properties))
+	for _, p := range e.Properties {
+		property := &pb.Property{
+			Scope:       scopeToProto(p.Scope),
+			Name:        p.Name,
+			Description: p.Description,
+		}
+		switch v := p.Value.(type) {
+		case *string:
+			property.Value = &pb.Property_StringValue{StringValue: *v}
+		case string:
+			property.Value = &pb.Property_StringValue{StringValue: v}
+		case *int64:
+			property.Value = &pb.Property_IntegerValue{IntegerValue: *v}
+		case int64:
+			property.Value = &pb.Property_IntegerValue{IntegerValue: v}
+		case float64:
+			property.Value = &pb.Property_IntegerValue{IntegerValue: int64(v)}
+		default:
+			return nil, fmt.Errorf("unknown property value type %T for property %v", p.Value, p.Name)
+		}
+		properties = append(properties, property)
+	}
+
+	metrics := make([][]byte, 0, len(e.Metrics))
+	for _, m := range e.Metrics {
+		metrics = append(metrics, m.OTLP)
+	}
+
+	events := make([]*pb.Events, 0, len(e.Events))
+	for _, ev := range e.Events {
+		pevents := make([]*pb.Event, 0, len(ev.Events))
+		for _, event := range ev.Events {
+			pevents = append(pevents, &pb.Event{
+				Timestamp: timestamppb.New(event.Timestamp),
+				Data:      event.Data,
+			})
+		}
+		events = append(events, &pb.Events{
+			Descriptor_: &pb.EventDescriptor{
+				EventId:     ev.Descriptor.EventId,
+				Scope:       scopeToProto(ev.Descriptor.Scope),
+				Name:        ev.Descriptor.Name,
+				Description: ev.Descriptor.Description,
+			},
+			Events: pevents,
+		})
+	}
+
+	var bandwidth *pb.Bandwidth
+	if e.Bandwidth != nil {
+		bandwidth = &pb.Bandwidth{
+			UploadRate:   e.Bandwidth.UploadRate,
+			DownloadRate: e.Bandwidth.DownloadRate,
+			Rtt:          durationpb.New(e.Bandwidth.Rtt),
+			Jitter:       durationpb.New(e.Bandwidth.Jitter),
+			Upload:       bandwidthMeasurementToProto(e.Bandwidth.Upload),
+			Download:     bandwidthMeasurementToProto(e.Bandwidth.Download),
+		}
+	}
+
+	var probe *pb.Probe
+	if e.Probe != nil {
+		probe = probeToProto(e.Probe)
+	}
+
+	return &pb.Export{
+		ObservedAt: timestamppb.New(e.ObservedAt),
+		Peer:       []byte(e.Peer),
+		Session:    e.Session[:],
+		Properties: properties,
+		Metrics:    metrics,
+		Events:     events,
+		Bandwidth:  bandwidth,
+		Probe:      probe,
+	}, nil
+}
+
+// NewProto implements backend.ProtoMessageDecoder
+func (e *Export) NewProto() proto.Message {
+	return new(pb.Export)
+}
+
+// FromProto implements backend.ProtoMessageDecoder
+func (e *Export) FromProto(msg proto.Message) error {
+	p := msg.(*pb.Export)
+
+	pid, err := peer.IDFromBytes(p.GetPeer())
+	if err != nil {
+		return err
+	}
+
+	var session telemetry.Session
+	if len(p.GetSession()) != len(session) {
+		return fmt.Errorf("invalid session length %v", len(p.GetSession()))
+	}
+	copy(session[:], p.GetSession())
+
+	properties := make([]ExportProperty, 0, len(p.GetProperties()))
+	for _, pp := range p.GetProperties() {
+		var value interface{}
+		switch v := pp.GetValue().(type) {
+		case *pb.Property_StringValue:
+			vv := v.StringValue
+			value = &vv
+		case *pb.Property_IntegerValue:
+			vv := v.IntegerValue
+			value = &vv
+		default:
+			return fmt.Errorf("unknown property value type for property %v", pp.GetName())
+		}
+		properties = append(properties, ExportProperty{
+			Scope:       scopeFromProto(pp.GetScope()),
+			Name:        pp.GetName(),
+			Description: pp.GetDescription(),
+			Value:       value,
+		})
+	}
+
+	metrics := make([]ExportMetrics, 0, len(p.GetMetrics()))
+	for _, m := range p.GetMetrics() {
+		metrics = append(metrics, ExportMetrics{OTLP: m})
+	}
+
+	events := make([]ExportEvents, 0, len(p.GetEvents()))
+	for _, pe := range p.GetEvents() {
+		es := make([]telemetry.Event, 0, len(pe.GetEvents()))
+		for _, ev := range pe.GetEvents() {
+			es = append(es, telemetry.Event{
+				Timestamp: ev.GetTimestamp().AsTime(),
+				Data:      ev.GetData(),
+			})
+		}
+		d := pe.GetDescriptor_()
+		events = append(events, ExportEvents{
+			Descriptor: telemetry.EventDescriptor{
+				EventId:     d.GetEventId(),
+				Scope:       scopeFromProto(d.GetScope()),
+				Name:        d.GetName(),
+				Description: d.GetDescription(),
+			},
+			Events: es,
+		})
+	}
+
+	var bandwidth *ExportBandwidth
+	if b := p.GetBandwidth(); b != nil {
+		bandwidth = &ExportBandwidth{
+			UploadRate:   b.GetUploadRate(),
+			DownloadRate: b.GetDownloadRate(),
+			Rtt:          b.GetRtt().AsDuration(),
+			Jitter:       b.GetJitter().AsDuration(),
+			Upload:       bandwidthMeasurementFromProto(b.GetUpload()),
+			Download:     bandwidthMeasurementFromProto(b.GetDownload()),
+		}
+	}
+
+	var probe *monitor.Probe
+	if pp := p.GetProbe(); pp != nil {
+		probe, err = probeFromProto(pp)
+		if err != nil {
+			return err
+		}
+	}
+
+	e.ObservedAt = p.GetObservedAt().AsTime()
+	e.Peer = pid
+	e.Session = session
+	e.Properties = properties
+	e.Metrics = metrics
+	e.Events = events
+	e.Bandwidth = bandwidth
+	e.Probe = probe
+	return nil
+}
+
+func PeerIDsToProto(ids []peer.ID) [][]byte {
+	out := make([][]byte, len(ids))
+	for i, id := range ids {
+		out[i] = []byte(id)
+	}
+	return out
+}
+
+func PeerIDsFromProto(ids [][]byte) ([]peer.ID, error) {
+	out := make([]peer.ID, len(ids))
+	for i, id := range ids {
+		pid, err := peer.IDFromBytes(id)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = pid
+	}
+	return out, nil
+}
+
+func MultiaddrsToProto(addrs []multiaddr.Multiaddr) [][]byte {
+	out := make([][]byte, len(addrs))
+	for i, addr := range addrs {
+		out[i] = addr.Bytes()
+	}
+	return out
+}
+
+func MultiaddrsFromProto(addrs [][]byte) ([]multiaddr.Multiaddr, error) {
+	out := make([]multiaddr.Multiaddr, len(addrs))
+	for i, addr := range addrs {
+		maddr, err := multiaddr.NewMultiaddrBytes(addr)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = maddr
+	}
+	return out, nil
+}
+
+func scopeToProto(s instrumentation.Scope) *pb.Scope {
+	return &pb.Scope{
+		Name:      s.Name,
+		Version:   s.Version,
+		SchemaUrl: s.SchemaURL,
+	}
+}
+
+func scopeFromProto(s *pb.Scope) instrumentation.Scope {
+	return instrumentation.Scope{
+		Name:      s.GetName(),
+		Version:   s.GetVersion(),
+		SchemaURL: s.GetSchemaUrl(),
+	}
+}
+
+func bandwidthMeasurementToProto(m telemetry.BandwidthMeasurement) *pb.BandwidthMeasurement {
+	samples := make([]*pb.BandwidthSample, len(m.Samples))
+	for i, sample := range m.Samples {
+		samples[i] = &pb.BandwidthSample{
+			Elapsed: durationpb.New(sample.Elapsed),
+			Bytes:   sample.Bytes,
+			Rate:    sample.Rate,
+		}
+	}
+	return &pb.BandwidthMeasurement{
+		Streams:  m.Streams,
+		Duration: durationpb.New(m.Duration),
+		Bytes:    m.Bytes,
+		Rate:     m.Rate,
+		PeakRate: m.PeakRate,
+		Samples:  samples,
+		Rtt:      durationpb.New(m.Rtt),
+		Jitter:   durationpb.New(m.Jitter),
+	}
+}
+
+func bandwidthMeasurementFromProto(p *pb.BandwidthMeasurement) telemetry.BandwidthMeasurement {
+	samples := make([]telemetry.BandwidthSample, len(p.GetSamples()))
+	for i, sample := range p.GetSamples() {
+		samples[i] = telemetry.BandwidthSample{
+			Elapsed: sample.GetElapsed().AsDuration(),
+			Bytes:   sample.GetBytes(),
+			Rate:    sample.GetRate(),
+		}
+	}
+	return telemetry.BandwidthMeasurement{
+		Streams:  p.GetStreams(),
+		Duration: p.GetDuration().AsDuration(),
+		Bytes:    p.GetBytes(),
+		Rate:     p.GetRate(),
+		PeakRate: p.GetPeakRate(),
+		Samples:  samples,
+		Rtt:      p.GetRtt().AsDuration(),
+		Jitter:   p.GetJitter().AsDuration(),
+	}
+}
+
+func probeToProto(p *monitor.Probe) *pb.Probe {
+	rtts := make([]*durationpb.Duration, len(p.PingRtts))
+	for i, rtt := range p.PingRtts {
+		rtts[i] = durationpb.New(rtt)
+	}
+	dials := make([]*pb.DialProbe, len(p.Dials))
+	for i, dial := range p.Dials {
+		var address []byte
+		if dial.Address != nil {
+			address = dial.Address.Bytes()
+		}
+		dials[i] = &pb.DialProbe{
+			Transport: string(dial.Transport),
+			Addresses: uint32(dial.Addresses),
+			Success:   dial.Success,
+			Address:   address,
+			Duration:  durationpb.New(dial.Duration),
+			Error:     dial.Error,
+		}
+	}
+	return &pb.Probe{
+		Timestamp:     timestamppb.New(p.Timestamp),
+		PingRtts:      rtts,
+		PingError:     p.PingError,
+		Dials:         dials,
+		IdentifyAddrs: MultiaddrsToProto(p.IdentifyAddrs),
+	}
+}
+
+func probeFromProto(p *pb.Probe) (*monitor.Probe, error) {
+	rtts := make([]time.Duration, len(p.GetPingRtts()))
+	for i, rtt := range p.GetPingRtts() {
+		rtts[i] = rtt.AsDuration()
+	}
+	dials := make([]monitor.DialProbe, len(p.GetDials()))
+	for i, dial := range p.GetDials() {
+		var address multiaddr.Multiaddr
+		if len(dial.GetAddress()) > 0 {
+			addr, err := multiaddr.NewMultiaddrBytes(dial.GetAddress())
+			if err != nil {
+				return nil, err
+			}
+			address = addr
+		}
+		dials[i] = monitor.DialProbe{
+			Transport: monitor.Transport(dial.GetTransport()),
+			Addresses: int(dial.GetAddresses()),
+			Success:   dial.GetSuccess(),
+			Address:   address,
+			Duration:  dial.GetDuration().AsDuration(),
+			Error:     dial.GetError(),
+		}
+	}
+	identifyAddrs, err := MultiaddrsFromProto(p.GetIdentifyAddrs())
+	if err != nil {
+		return nil, err
+	}
+	return &monitor.Probe{
+		Timestamp:     p.GetTimestamp().AsTime(),
+		PingRtts:      rtts,
+		PingError:     p.GetPingError(),
+		Dials:         dials,
+		IdentifyAddrs: identifyAddrs,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package monitor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	tmonitor "github.com/diogo464/telemetry/monitor"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+)
+
+const samplePeerId = "12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC"
+
+func sampleExport(t *testing.T) *monitor.Export {
+	pid, err := peer.Decode(samplePeerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := "kubo/0.34.0"
+	connections := int64(42)
+	addr := multiaddr.StringCast("/ip4/1.2.3.4/tcp/4001")
+	return &monitor.Export{
+		ObservedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Peer:       pid,
+		Session:    telemetry.RandomSession(),
+		Properties: []monitor.ExportProperty{
+			{Scope: instrumentation.Scope{Name: "libp2p.io/ipfs"}, Name: "agent", Value: &agent},
+			{Scope: instrumentation.Scope{Name: "libp2p.io/ipfs"}, Name: "connections", Value: &connections},
+		},
+		Metrics: []monitor.ExportMetrics{{OTLP: []byte{1, 2, 3}}},
+		Events: []monitor.ExportEvents{{
+			Descriptor: telemetry.EventDescriptor{EventId: 3, Scope: instrumentation.Scope{Name: "libp2p.io/ipfs"}, Name: "connections"},
+			Events:     []telemetry.Event{{Timestamp: time.Date(2024, 3, 1, 11, 59, 0, 0, time.UTC), Data: []byte(`{"n":1}`)}},
+		}},
+		Bandwidth: &monitor.ExportBandwidth{
+			UploadRate:   1 << 40,
+			DownloadRate: 1 << 20,
+			Rtt:          time.Millisecond * 40,
+			Jitter:       time.Millisecond * 3,
+			Upload: telemetry.BandwidthMeasurement{
+				Streams:  4,
+				Duration: time.Second * 10,
+				Bytes:    1 << 41,
+				Rate:     1 << 40,
+				PeakRate: 1 << 41,
+				Samples:  []telemetry.BandwidthSample{{Elapsed: time.Second, Bytes: 1 << 40, Rate: 1 << 40}},
+			},
+		},
+		Probe: &tmonitor.Probe{
+			Timestamp: time.Date(2024, 3, 1, 11, 58, 0, 0, time.UTC),
+			PingRtts:  []time.Duration{time.Millisecond * 30, time.Millisecond * 35},
+			Dials: []tmonitor.DialProbe{
+				{Transport: tmonitor.TransportTcp, Addresses: 1, Success: true, Address: addr, Duration: time.Millisecond * 70},
+				{Transport: tmonitor.TransportQuic, Error: tmonitor.ErrNoAddresses.Error()},
+			},
+			IdentifyAddrs: []multiaddr.Multiaddr{addr},
+		},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, encoding := range []backend.Encoding{backend.EncodingJson, backend.EncodingProtobuf} {
+		t.Run(string(encoding), func(t *testing.T) {
+			expected := sampleExport(t)
+			msg, err := backend.NatsMsg(encoding, monitor.Subject_Export, expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded := new(monitor.Export)
+			if err := backend.NatsDecode(msg.Header, msg.Data, decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			if !decoded.ObservedAt.Equal(expected.ObservedAt) || decoded.Peer != expected.Peer || decoded.Session != expected.Session {
+				t.Fatalf("header fields mismatch: %+v", decoded)
+			}
+			if len(decoded.Properties) != 2 || len(decoded.Metrics) != 1 || len(decoded.Events) != 1 {
+				t.Fatalf("unexpected export contents: %+v", decoded)
+			}
+			if string(decoded.Metrics[0].OTLP) != string(expected.Metrics[0].OTLP) {
+				t.Fatalf("metrics mismatch")
+			}
+			if string(decoded.Events[0].Events[0].Data) != `{"n":1}` || decoded.Events[0].Descriptor.Name != "connections" {
+				t.Fatalf("events mismatch: %+v", decoded.Events)
+			}
+			if decoded.Bandwidth.UploadRate != expected.Bandwidth.UploadRate ||
+				decoded.Bandwidth.DownloadRate != expected.Bandwidth.DownloadRate ||
+				decoded.Bandwidth.Rtt != expected.Bandwidth.Rtt ||
+				decoded.Bandwidth.Upload.PeakRate != expected.Bandwidth.Upload.PeakRate ||
+				len(decoded.Bandwidth.Upload.Samples) != 1 ||
+				decoded.Bandwidth.Upload.Samples[0] != expected.Bandwidth.Upload.Samples[0] {
+				t.Fatalf("bandwidth mismatch: %+v", decoded.Bandwidth)
+			}
+			if decoded.Probe == nil || len(decoded.Probe.PingRtts) != 2 || len(decoded.Probe.Dials) != 2 ||
+				!decoded.Probe.Dials[0].Address.Equal(expected.Probe.Dials[0].Address) ||
+				decoded.Probe.Dials[1].Error != expected.Probe.Dials[1].Error ||
+				len(decoded.Probe.IdentifyAddrs) != 1 {
+				t.Fatalf("probe mismatch: %+v", decoded.Probe)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyJson(t *testing.T) {
+	pid, _ := peer.Decode(samplePeerId)
+	addr, _ := multiaddr.NewMultiaddr("/ip4/35.79.127.140/tcp/4001")
+
+	msg, err := backend.NatsMsg(backend.EncodingJson, monitor.Subject_Discover, &monitor.DiscoveryMessage{ID: pid, Addresses: []multiaddr.Multiaddr{addr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// messages published before content types were introduced have no headers
+	decoded := new(monitor.DiscoveryMessage)
+	if err := backend.Unmarshal("", msg.Data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != pid || len(decoded.Addresses) != 1 || !decoded.Addresses[0].Equal(addr) {
+		t.Fatalf("unexpected discovery message: %+v", decoded)
+	}
+}
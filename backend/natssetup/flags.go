@@ -0,0 +1,38 @@
+package natssetup
+
+import "github.com/urfave/cli/v2"
+
+var (
+	FLAG_REPLICAS = &cli.IntFlag{
+		Name:    "replicas",
+		Usage:   "number of replicas of each stream",
+		EnvVars: []string{"NATS_SETUP_REPLICAS"},
+		Value:   1,
+	}
+
+	FLAG_MONITOR_MAX_BYTES = &cli.StringFlag{
+		Name:    "monitor-max-bytes",
+		Usage:   "maximum size of the monitor stream, ex: 200GB",
+		EnvVars: []string{"NATS_SETUP_MONITOR_MAX_BYTES"},
+		Value:   "200GB",
+	}
+
+	FLAG_CRAWLER_MAX_BYTES = &cli.StringFlag{
+		Name:    "crawler-max-bytes",
+		Usage:   "maximum size of the crawler stream, ex: 100GB",
+		EnvVars: []string{"NATS_SETUP_CRAWLER_MAX_BYTES"},
+		Value:   "100GB",
+	}
+
+	FLAG_DEAD_LETTER_MAX_BYTES = &cli.StringFlag{
+		Name:    "dead-letter-max-bytes",
+		Usage:   "maximum size of the dead letter stream, ex: 10GB",
+		EnvVars: []string{"NATS_SETUP_DEAD_LETTER_MAX_BYTES"},
+		Value:   "10GB",
+	}
+
+	FLAG_CHECK = &cli.BoolFlag{
+		Name:  "check",
+		Usage: "only check that the streams and consumers exist and match their declaration",
+	}
+)
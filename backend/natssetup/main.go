@@ -0,0 +1,100 @@
+package natssetup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+var Command *cli.Command = &cli.Command{
+	Name:        "nats-setup",
+	Description: "create or update the jetstream streams and durable consumers used by the backend",
+	Flags: []cli.Flag{
+		FLAG_REPLICAS,
+		FLAG_MONITOR_MAX_BYTES,
+		FLAG_CRAWLER_MAX_BYTES,
+		FLAG_DEAD_LETTER_MAX_BYTES,
+		FLAG_CHECK,
+	},
+	Action: main,
+}
+
+func main(c *cli.Context) error {
+	logger, _ := zap.NewProduction()
+
+	opts, err := optionsFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	nc := backend.NatsClient(logger, c)
+	defer nc.Close()
+	js := backend.NatsJetstream(logger, nc)
+
+	if c.Bool(FLAG_CHECK.Name) {
+		for _, stream := range streams.Declarations(opts) {
+			if err := streams.ValidateStream(c.Context, js, stream.Config.Name); err != nil {
+				return err
+			}
+			for _, consumer := range stream.Consumers {
+				if _, err := streams.Consumer(c.Context, js, stream.Config.Name, consumer.Durable); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%v: ok, %v consumers\n", stream.Config.Name, len(stream.Consumers))
+		}
+		return nil
+	}
+
+	return streams.Setup(c.Context, logger, js, opts)
+}
+
+func optionsFromFlags(c *cli.Context) (streams.Options, error) {
+	opts := streams.DefaultOptions()
+	opts.Replicas = c.Int(FLAG_REPLICAS.Name)
+	if opts.Replicas < 1 {
+		return opts, fmt.Errorf("invalid number of replicas %v", opts.Replicas)
+	}
+
+	var err error
+	if opts.MonitorMaxBytes, err = parseSize(c.String(FLAG_MONITOR_MAX_BYTES.Name)); err != nil {
+		return opts, fmt.Errorf("invalid --%v: %w", FLAG_MONITOR_MAX_BYTES.Name, err)
+	}
+	if opts.CrawlerMaxBytes, err = parseSize(c.String(FLAG_CRAWLER_MAX_BYTES.Name)); err != nil {
+		return opts, fmt.Errorf("invalid --%v: %w", FLAG_CRAWLER_MAX_BYTES.Name, err)
+	}
+	if opts.DeadLetterMaxBytes, err = parseSize(c.String(FLAG_DEAD_LETTER_MAX_BYTES.Name)); err != nil {
+		return opts, fmt.Errorf("invalid --%v: %w", FLAG_DEAD_LETTER_MAX_BYTES.Name, err)
+	}
+	return opts, nil
+}
+
+// Parse a size like 200GB, units are powers of 1024 like in the nats cli. -1 means unlimited.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		shift  uint
+	}{{"TB", 40}, {"GB", 30}, {"MB", 20}, {"KB", 10}, {"T", 40}, {"G", 30}, {"M", 20}, {"K", 10}, {"B", 0}}
+	shift := uint(0)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			shift = unit.shift
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return -1, nil
+	}
+	return n << shift, nil
+}
@@ -0,0 +1,22 @@
+package natssetup
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"200GB": 200 << 30,
+		"100g":  100 << 30,
+		"512MB": 512 << 20,
+		"1 KB":  1 << 10,
+		"42":    42,
+		"-1":    -1,
+	} {
+		size, err := parseSize(input)
+		if err != nil || size != expected {
+			t.Fatalf("expected %v for %q, got %v %v", expected, input, size, err)
+		}
+	}
+	if _, err := parseSize("lots"); err == nil {
+		t.Fatalf("expected an error for an invalid size")
+	}
+}
@@ -0,0 +1,2368 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.1
+// source: pb/backend.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CrawlerMessageKind int32
+
+const (
+	CrawlerMessageKind_CRAWLER_MESSAGE_KIND_UNSPECIFIED CrawlerMessageKind = 0
+	CrawlerMessageKind_CRAWLER_MESSAGE_KIND_PEER        CrawlerMessageKind = 1
+	CrawlerMessageKind_CRAWLER_MESSAGE_KIND_CRAWL_BEGIN CrawlerMessageKind = 2
+	CrawlerMessageKind_CRAWLER_MESSAGE_KIND_CRAWL_END   CrawlerMessageKind = 3
+	CrawlerMessageKind_CRAWLER_MESSAGE_KIND_ERROR       CrawlerMessageKind = 4
+	CrawlerMessageKind_CRAWLER_MESSAGE_KIND_EVENT       CrawlerMessageKind = 5
+)
+
+// Enum value maps for CrawlerMessageKind.
+var (
+	CrawlerMessageKind_name = map[int32]string{
+		0: "CRAWLER_MESSAGE_KIND_UNSPECIFIED",
+		1: "CRAWLER_MESSAGE_KIND_PEER",
+		2: "CRAWLER_MESSAGE_KIND_CRAWL_BEGIN",
+		3: "CRAWLER_MESSAGE_KIND_CRAWL_END",
+		4: "CRAWLER_MESSAGE_KIND_ERROR",
+		5: "CRAWLER_MESSAGE_KIND_EVENT",
+	}
+	CrawlerMessageKind_value = map[string]int32{
+		"CRAWLER_MESSAGE_KIND_UNSPECIFIED": 0,
+		"CRAWLER_MESSAGE_KIND_PEER":        1,
+		"CRAWLER_MESSAGE_KIND_CRAWL_BEGIN": 2,
+		"CRAWLER_MESSAGE_KIND_CRAWL_END":   3,
+		"CRAWLER_MESSAGE_KIND_ERROR":       4,
+		"CRAWLER_MESSAGE_KIND_EVENT":       5,
+	}
+)
+
+func (x CrawlerMessageKind) Enum() *CrawlerMessageKind {
+	p := new(CrawlerMessageKind)
+	*p = x
+	return p
+}
+
+func (x CrawlerMessageKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CrawlerMessageKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_backend_proto_enumTypes[0].Descriptor()
+}
+
+func (CrawlerMessageKind) Type() protoreflect.EnumType {
+	return &file_pb_backend_proto_enumTypes[0]
+}
+
+func (x CrawlerMessageKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CrawlerMessageKind.Descriptor instead.
+func (CrawlerMessageKind) EnumDescriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{0}
+}
+
+// Published on `monitor.discover`.
+type DiscoveryMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Binary encoded multiaddrs
+	Addresses     [][]byte `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiscoveryMessage) Reset() {
+	*x = DiscoveryMessage{}
+	mi := &file_pb_backend_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoveryMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoveryMessage) ProtoMessage() {}
+
+func (x *DiscoveryMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoveryMessage.ProtoReflect.Descriptor instead.
+func (*DiscoveryMessage) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DiscoveryMessage) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *DiscoveryMessage) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+// Published on `monitor.active`.
+type ActiveMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         [][]byte               `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActiveMessage) Reset() {
+	*x = ActiveMessage{}
+	mi := &file_pb_backend_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActiveMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActiveMessage) ProtoMessage() {}
+
+func (x *ActiveMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActiveMessage.ProtoReflect.Descriptor instead.
+func (*ActiveMessage) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ActiveMessage) GetPeers() [][]byte {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+type Scope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	SchemaUrl     string                 `protobuf:"bytes,3,opt,name=schema_url,json=schemaUrl,proto3" json:"schema_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Scope) Reset() {
+	*x = Scope{}
+	mi := &file_pb_backend_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Scope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Scope) ProtoMessage() {}
+
+func (x *Scope) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Scope.ProtoReflect.Descriptor instead.
+func (*Scope) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Scope) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Scope) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Scope) GetSchemaUrl() string {
+	if x != nil {
+		return x.SchemaUrl
+	}
+	return ""
+}
+
+type Property struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Scope       *Scope                 `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//
+	//	*Property_IntegerValue
+	//	*Property_StringValue
+	Value         isProperty_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Property) Reset() {
+	*x = Property{}
+	mi := &file_pb_backend_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Property) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Property) ProtoMessage() {}
+
+func (x *Property) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Property.ProtoReflect.Descriptor instead.
+func (*Property) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Property) GetScope() *Scope {
+	if x != nil {
+		return x.Scope
+	}
+	return nil
+}
+
+func (x *Property) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Property) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Property) GetValue() isProperty_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Property) GetIntegerValue() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*Property_IntegerValue); ok {
+			return x.IntegerValue
+		}
+	}
+	return 0
+}
+
+func (x *Property) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Value.(*Property_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+type isProperty_Value interface {
+	isProperty_Value()
+}
+
+type Property_IntegerValue struct {
+	IntegerValue int64 `protobuf:"varint,4,opt,name=integer_value,json=integerValue,proto3,oneof"`
+}
+
+type Property_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+func (*Property_IntegerValue) isProperty_Value() {}
+
+func (*Property_StringValue) isProperty_Value() {}
+
+type EventDescriptor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       uint32                 `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Scope         *Scope                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventDescriptor) Reset() {
+	*x = EventDescriptor{}
+	mi := &file_pb_backend_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventDescriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventDescriptor) ProtoMessage() {}
+
+func (x *EventDescriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventDescriptor.ProtoReflect.Descriptor instead.
+func (*EventDescriptor) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EventDescriptor) GetEventId() uint32 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *EventDescriptor) GetScope() *Scope {
+	if x != nil {
+		return x.Scope
+	}
+	return nil
+}
+
+func (x *EventDescriptor) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EventDescriptor) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_pb_backend_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Event) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Event) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Events struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Descriptor_   *EventDescriptor       `protobuf:"bytes,1,opt,name=descriptor,proto3" json:"descriptor,omitempty"`
+	Events        []*Event               `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Events) Reset() {
+	*x = Events{}
+	mi := &file_pb_backend_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Events) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Events) ProtoMessage() {}
+
+func (x *Events) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Events.ProtoReflect.Descriptor instead.
+func (*Events) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Events) GetDescriptor_() *EventDescriptor {
+	if x != nil {
+		return x.Descriptor_
+	}
+	return nil
+}
+
+func (x *Events) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type BandwidthSample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Elapsed       *durationpb.Duration   `protobuf:"bytes,1,opt,name=elapsed,proto3" json:"elapsed,omitempty"`
+	Bytes         uint64                 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Rate          uint64                 `protobuf:"varint,3,opt,name=rate,proto3" json:"rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BandwidthSample) Reset() {
+	*x = BandwidthSample{}
+	mi := &file_pb_backend_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BandwidthSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BandwidthSample) ProtoMessage() {}
+
+func (x *BandwidthSample) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BandwidthSample.ProtoReflect.Descriptor instead.
+func (*BandwidthSample) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BandwidthSample) GetElapsed() *durationpb.Duration {
+	if x != nil {
+		return x.Elapsed
+	}
+	return nil
+}
+
+func (x *BandwidthSample) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *BandwidthSample) GetRate() uint64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+type BandwidthMeasurement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Streams       uint32                 `protobuf:"varint,1,opt,name=streams,proto3" json:"streams,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	Bytes         uint64                 `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Rate          uint64                 `protobuf:"varint,4,opt,name=rate,proto3" json:"rate,omitempty"`
+	PeakRate      uint64                 `protobuf:"varint,5,opt,name=peak_rate,json=peakRate,proto3" json:"peak_rate,omitempty"`
+	Samples       []*BandwidthSample     `protobuf:"bytes,6,rep,name=samples,proto3" json:"samples,omitempty"`
+	Rtt           *durationpb.Duration   `protobuf:"bytes,7,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	Jitter        *durationpb.Duration   `protobuf:"bytes,8,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BandwidthMeasurement) Reset() {
+	*x = BandwidthMeasurement{}
+	mi := &file_pb_backend_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BandwidthMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BandwidthMeasurement) ProtoMessage() {}
+
+func (x *BandwidthMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BandwidthMeasurement.ProtoReflect.Descriptor instead.
+func (*BandwidthMeasurement) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BandwidthMeasurement) GetStreams() uint32 {
+	if x != nil {
+		return x.Streams
+	}
+	return 0
+}
+
+func (x *BandwidthMeasurement) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *BandwidthMeasurement) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *BandwidthMeasurement) GetRate() uint64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *BandwidthMeasurement) GetPeakRate() uint64 {
+	if x != nil {
+		return x.PeakRate
+	}
+	return 0
+}
+
+func (x *BandwidthMeasurement) GetSamples() []*BandwidthSample {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+func (x *BandwidthMeasurement) GetRtt() *durationpb.Duration {
+	if x != nil {
+		return x.Rtt
+	}
+	return nil
+}
+
+func (x *BandwidthMeasurement) GetJitter() *durationpb.Duration {
+	if x != nil {
+		return x.Jitter
+	}
+	return nil
+}
+
+type Bandwidth struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Rates in bytes per second
+	UploadRate    uint64                `protobuf:"varint,1,opt,name=upload_rate,json=uploadRate,proto3" json:"upload_rate,omitempty"`
+	DownloadRate  uint64                `protobuf:"varint,2,opt,name=download_rate,json=downloadRate,proto3" json:"download_rate,omitempty"`
+	Rtt           *durationpb.Duration  `protobuf:"bytes,3,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	Jitter        *durationpb.Duration  `protobuf:"bytes,4,opt,name=jitter,proto3" json:"jitter,omitempty"`
+	Upload        *BandwidthMeasurement `protobuf:"bytes,5,opt,name=upload,proto3" json:"upload,omitempty"`
+	Download      *BandwidthMeasurement `protobuf:"bytes,6,opt,name=download,proto3" json:"download,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bandwidth) Reset() {
+	*x = Bandwidth{}
+	mi := &file_pb_backend_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bandwidth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bandwidth) ProtoMessage() {}
+
+func (x *Bandwidth) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bandwidth.ProtoReflect.Descriptor instead.
+func (*Bandwidth) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Bandwidth) GetUploadRate() uint64 {
+	if x != nil {
+		return x.UploadRate
+	}
+	return 0
+}
+
+func (x *Bandwidth) GetDownloadRate() uint64 {
+	if x != nil {
+		return x.DownloadRate
+	}
+	return 0
+}
+
+func (x *Bandwidth) GetRtt() *durationpb.Duration {
+	if x != nil {
+		return x.Rtt
+	}
+	return nil
+}
+
+func (x *Bandwidth) GetJitter() *durationpb.Duration {
+	if x != nil {
+		return x.Jitter
+	}
+	return nil
+}
+
+func (x *Bandwidth) GetUpload() *BandwidthMeasurement {
+	if x != nil {
+		return x.Upload
+	}
+	return nil
+}
+
+func (x *Bandwidth) GetDownload() *BandwidthMeasurement {
+	if x != nil {
+		return x.Download
+	}
+	return nil
+}
+
+type DialProbe struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One of tcp, quic, webtransport or relay
+	Transport string `protobuf:"bytes,1,opt,name=transport,proto3" json:"transport,omitempty"`
+	Addresses uint32 `protobuf:"varint,2,opt,name=addresses,proto3" json:"addresses,omitempty"`
+	Success   bool   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	// Binary encoded multiaddr of the successful dial
+	Address       []byte               `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	Duration      *durationpb.Duration `protobuf:"bytes,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Error         string               `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DialProbe) Reset() {
+	*x = DialProbe{}
+	mi := &file_pb_backend_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DialProbe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DialProbe) ProtoMessage() {}
+
+func (x *DialProbe) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DialProbe.ProtoReflect.Descriptor instead.
+func (*DialProbe) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DialProbe) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *DialProbe) GetAddresses() uint32 {
+	if x != nil {
+		return x.Addresses
+	}
+	return 0
+}
+
+func (x *DialProbe) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DialProbe) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *DialProbe) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *DialProbe) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type Probe struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	PingRtts  []*durationpb.Duration `protobuf:"bytes,2,rep,name=ping_rtts,json=pingRtts,proto3" json:"ping_rtts,omitempty"`
+	PingError string                 `protobuf:"bytes,3,opt,name=ping_error,json=pingError,proto3" json:"ping_error,omitempty"`
+	Dials     []*DialProbe           `protobuf:"bytes,4,rep,name=dials,proto3" json:"dials,omitempty"`
+	// Binary encoded multiaddrs
+	IdentifyAddrs [][]byte `protobuf:"bytes,5,rep,name=identify_addrs,json=identifyAddrs,proto3" json:"identify_addrs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Probe) Reset() {
+	*x = Probe{}
+	mi := &file_pb_backend_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Probe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Probe) ProtoMessage() {}
+
+func (x *Probe) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Probe.ProtoReflect.Descriptor instead.
+func (*Probe) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Probe) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Probe) GetPingRtts() []*durationpb.Duration {
+	if x != nil {
+		return x.PingRtts
+	}
+	return nil
+}
+
+func (x *Probe) GetPingError() string {
+	if x != nil {
+		return x.PingError
+	}
+	return ""
+}
+
+func (x *Probe) GetDials() []*DialProbe {
+	if x != nil {
+		return x.Dials
+	}
+	return nil
+}
+
+func (x *Probe) GetIdentifyAddrs() [][]byte {
+	if x != nil {
+		return x.IdentifyAddrs
+	}
+	return nil
+}
+
+// Published on `monitor.export`.
+type Export struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ObservedAt *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	Peer       []byte                 `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"`
+	// 16 byte session uuid
+	Session    []byte      `protobuf:"bytes,3,opt,name=session,proto3" json:"session,omitempty"`
+	Properties []*Property `protobuf:"bytes,4,rep,name=properties,proto3" json:"properties,omitempty"`
+	// Each entry is a serialized opentelemetry.proto.metrics.v1.ResourceMetrics
+	Metrics       [][]byte   `protobuf:"bytes,5,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	Events        []*Events  `protobuf:"bytes,6,rep,name=events,proto3" json:"events,omitempty"`
+	Bandwidth     *Bandwidth `protobuf:"bytes,7,opt,name=bandwidth,proto3" json:"bandwidth,omitempty"`
+	Probe         *Probe     `protobuf:"bytes,8,opt,name=probe,proto3" json:"probe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Export) Reset() {
+	*x = Export{}
+	mi := &file_pb_backend_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Export) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Export) ProtoMessage() {}
+
+func (x *Export) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Export.ProtoReflect.Descriptor instead.
+func (*Export) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Export) GetObservedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ObservedAt
+	}
+	return nil
+}
+
+func (x *Export) GetPeer() []byte {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+func (x *Export) GetSession() []byte {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *Export) GetProperties() []*Property {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *Export) GetMetrics() [][]byte {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *Export) GetEvents() []*Events {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Export) GetBandwidth() *Bandwidth {
+	if x != nil {
+		return x.Bandwidth
+	}
+	return nil
+}
+
+func (x *Export) GetProbe() *Probe {
+	if x != nil {
+		return x.Probe
+	}
+	return nil
+}
+
+type BucketEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Addresses     [][]byte               `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BucketEntry) Reset() {
+	*x = BucketEntry{}
+	mi := &file_pb_backend_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BucketEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BucketEntry) ProtoMessage() {}
+
+func (x *BucketEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BucketEntry.ProtoReflect.Descriptor instead.
+func (*BucketEntry) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BucketEntry) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *BucketEntry) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type Request struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_pb_backend_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Request) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *Request) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+type WalkerSignedRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Addresses     [][]byte               `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalkerSignedRecord) Reset() {
+	*x = WalkerSignedRecord{}
+	mi := &file_pb_backend_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalkerSignedRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalkerSignedRecord) ProtoMessage() {}
+
+func (x *WalkerSignedRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalkerSignedRecord.ProtoReflect.Descriptor instead.
+func (*WalkerSignedRecord) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WalkerSignedRecord) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *WalkerSignedRecord) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type WalkerIdentify struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion string                 `protobuf:"bytes,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	AgentVersion    string                 `protobuf:"bytes,2,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	ListenAddresses [][]byte               `protobuf:"bytes,3,rep,name=listen_addresses,json=listenAddresses,proto3" json:"listen_addresses,omitempty"`
+	ObservedAddress []byte                 `protobuf:"bytes,4,opt,name=observed_address,json=observedAddress,proto3" json:"observed_address,omitempty"`
+	Protocols       []string               `protobuf:"bytes,5,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	SignedRecord    *WalkerSignedRecord    `protobuf:"bytes,6,opt,name=signed_record,json=signedRecord,proto3" json:"signed_record,omitempty"`
+	Transports      []string               `protobuf:"bytes,7,rep,name=transports,proto3" json:"transports,omitempty"`
+	Transport       string                 `protobuf:"bytes,8,opt,name=transport,proto3" json:"transport,omitempty"`
+	Security        string                 `protobuf:"bytes,9,opt,name=security,proto3" json:"security,omitempty"`
+	Muxer           string                 `protobuf:"bytes,10,opt,name=muxer,proto3" json:"muxer,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WalkerIdentify) Reset() {
+	*x = WalkerIdentify{}
+	mi := &file_pb_backend_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalkerIdentify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalkerIdentify) ProtoMessage() {}
+
+func (x *WalkerIdentify) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalkerIdentify.ProtoReflect.Descriptor instead.
+func (*WalkerIdentify) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WalkerIdentify) GetProtocolVersion() string {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return ""
+}
+
+func (x *WalkerIdentify) GetAgentVersion() string {
+	if x != nil {
+		return x.AgentVersion
+	}
+	return ""
+}
+
+func (x *WalkerIdentify) GetListenAddresses() [][]byte {
+	if x != nil {
+		return x.ListenAddresses
+	}
+	return nil
+}
+
+func (x *WalkerIdentify) GetObservedAddress() []byte {
+	if x != nil {
+		return x.ObservedAddress
+	}
+	return nil
+}
+
+func (x *WalkerIdentify) GetProtocols() []string {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *WalkerIdentify) GetSignedRecord() *WalkerSignedRecord {
+	if x != nil {
+		return x.SignedRecord
+	}
+	return nil
+}
+
+func (x *WalkerIdentify) GetTransports() []string {
+	if x != nil {
+		return x.Transports
+	}
+	return nil
+}
+
+func (x *WalkerIdentify) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *WalkerIdentify) GetSecurity() string {
+	if x != nil {
+		return x.Security
+	}
+	return ""
+}
+
+func (x *WalkerIdentify) GetMuxer() string {
+	if x != nil {
+		return x.Muxer
+	}
+	return ""
+}
+
+type WalkerFingerprint struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Implementation string                 `protobuf:"bytes,1,opt,name=implementation,proto3" json:"implementation,omitempty"`
+	Agent          string                 `protobuf:"bytes,2,opt,name=agent,proto3" json:"agent,omitempty"`
+	Version        string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WalkerFingerprint) Reset() {
+	*x = WalkerFingerprint{}
+	mi := &file_pb_backend_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalkerFingerprint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalkerFingerprint) ProtoMessage() {}
+
+func (x *WalkerFingerprint) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalkerFingerprint.ProtoReflect.Descriptor instead.
+func (*WalkerFingerprint) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WalkerFingerprint) GetImplementation() string {
+	if x != nil {
+		return x.Implementation
+	}
+	return ""
+}
+
+func (x *WalkerFingerprint) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *WalkerFingerprint) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type WalkerPeer struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Addresses       [][]byte               `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Agent           string                 `protobuf:"bytes,3,opt,name=agent,proto3" json:"agent,omitempty"`
+	Protocols       []string               `protobuf:"bytes,4,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	Buckets         []*BucketEntry         `protobuf:"bytes,5,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Requests        []*Request             `protobuf:"bytes,6,rep,name=requests,proto3" json:"requests,omitempty"`
+	ConnectStart    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=connect_start,json=connectStart,proto3" json:"connect_start,omitempty"`
+	ConnectDuration *durationpb.Duration   `protobuf:"bytes,8,opt,name=connect_duration,json=connectDuration,proto3" json:"connect_duration,omitempty"`
+	// Name of the dht network the peer was found in
+	Network     string             `protobuf:"bytes,9,opt,name=network,proto3" json:"network,omitempty"`
+	Identify    *WalkerIdentify    `protobuf:"bytes,10,opt,name=identify,proto3" json:"identify,omitempty"`
+	Fingerprint *WalkerFingerprint `protobuf:"bytes,11,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	// Number of dht messages of each type received from the peer, only set by passive discovery
+	Messages      map[string]uint64 `protobuf:"bytes,12,rep,name=messages,proto3" json:"messages,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalkerPeer) Reset() {
+	*x = WalkerPeer{}
+	mi := &file_pb_backend_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalkerPeer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalkerPeer) ProtoMessage() {}
+
+func (x *WalkerPeer) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalkerPeer.ProtoReflect.Descriptor instead.
+func (*WalkerPeer) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WalkerPeer) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *WalkerPeer) GetProtocols() []string {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetBuckets() []*BucketEntry {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetRequests() []*Request {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetConnectStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConnectStart
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetConnectDuration() *durationpb.Duration {
+	if x != nil {
+		return x.ConnectDuration
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *WalkerPeer) GetIdentify() *WalkerIdentify {
+	if x != nil {
+		return x.Identify
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetFingerprint() *WalkerFingerprint {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return nil
+}
+
+func (x *WalkerPeer) GetMessages() map[string]uint64 {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type WalkerDialFailure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Transport     string                 `protobuf:"bytes,2,opt,name=transport,proto3" json:"transport,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalkerDialFailure) Reset() {
+	*x = WalkerDialFailure{}
+	mi := &file_pb_backend_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalkerDialFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalkerDialFailure) ProtoMessage() {}
+
+func (x *WalkerDialFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalkerDialFailure.ProtoReflect.Descriptor instead.
+func (*WalkerDialFailure) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *WalkerDialFailure) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *WalkerDialFailure) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *WalkerDialFailure) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *WalkerDialFailure) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type WalkerError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Network       string                 `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	Addresses     [][]byte               `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Stage         string                 `protobuf:"bytes,6,opt,name=stage,proto3" json:"stage,omitempty"`
+	Reason        string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	Dials         []*WalkerDialFailure   `protobuf:"bytes,8,rep,name=dials,proto3" json:"dials,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalkerError) Reset() {
+	*x = WalkerError{}
+	mi := &file_pb_backend_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalkerError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalkerError) ProtoMessage() {}
+
+func (x *WalkerError) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalkerError.ProtoReflect.Descriptor instead.
+func (*WalkerError) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WalkerError) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *WalkerError) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *WalkerError) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *WalkerError) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *WalkerError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WalkerError) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *WalkerError) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *WalkerError) GetDials() []*WalkerDialFailure {
+	if x != nil {
+		return x.Dials
+	}
+	return nil
+}
+
+// Change to the live peer set of a continuous crawler
+type CrawlerEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// join, leave or address_change
+	Kind      string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Id        []byte                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Network   string                 `protobuf:"bytes,3,opt,name=network,proto3" json:"network,omitempty"`
+	Time      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	Addresses [][]byte               `protobuf:"bytes,5,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	// Addresses before the change, only set for address changes
+	PreviousAddresses [][]byte `protobuf:"bytes,6,rep,name=previous_addresses,json=previousAddresses,proto3" json:"previous_addresses,omitempty"`
+	// Time between the peer joining and when it was last seen, only set for leaves
+	SessionLength *durationpb.Duration `protobuf:"bytes,7,opt,name=session_length,json=sessionLength,proto3" json:"session_length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlerEvent) Reset() {
+	*x = CrawlerEvent{}
+	mi := &file_pb_backend_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlerEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlerEvent) ProtoMessage() {}
+
+func (x *CrawlerEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlerEvent.ProtoReflect.Descriptor instead.
+func (*CrawlerEvent) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CrawlerEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *CrawlerEvent) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *CrawlerEvent) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *CrawlerEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *CrawlerEvent) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *CrawlerEvent) GetPreviousAddresses() [][]byte {
+	if x != nil {
+		return x.PreviousAddresses
+	}
+	return nil
+}
+
+func (x *CrawlerEvent) GetSessionLength() *durationpb.Duration {
+	if x != nil {
+		return x.SessionLength
+	}
+	return nil
+}
+
+type CrawlShift struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Before        uint32                 `protobuf:"varint,1,opt,name=before,proto3" json:"before,omitempty"`
+	After         uint32                 `protobuf:"varint,2,opt,name=after,proto3" json:"after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlShift) Reset() {
+	*x = CrawlShift{}
+	mi := &file_pb_backend_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlShift) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlShift) ProtoMessage() {}
+
+func (x *CrawlShift) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlShift.ProtoReflect.Descriptor instead.
+func (*CrawlShift) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CrawlShift) GetBefore() uint32 {
+	if x != nil {
+		return x.Before
+	}
+	return 0
+}
+
+func (x *CrawlShift) GetAfter() uint32 {
+	if x != nil {
+		return x.After
+	}
+	return 0
+}
+
+type CrawlSummary struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Peers           uint32                 `protobuf:"varint,1,opt,name=peers,proto3" json:"peers,omitempty"`
+	PreviousPeers   uint32                 `protobuf:"varint,2,opt,name=previous_peers,json=previousPeers,proto3" json:"previous_peers,omitempty"`
+	New             uint32                 `protobuf:"varint,3,opt,name=new,proto3" json:"new,omitempty"`
+	Vanished        uint32                 `protobuf:"varint,4,opt,name=vanished,proto3" json:"vanished,omitempty"`
+	AgentUpgrades   uint32                 `protobuf:"varint,5,opt,name=agent_upgrades,json=agentUpgrades,proto3" json:"agent_upgrades,omitempty"`
+	AgentDowngrades uint32                 `protobuf:"varint,6,opt,name=agent_downgrades,json=agentDowngrades,proto3" json:"agent_downgrades,omitempty"`
+	AgentChanges    uint32                 `protobuf:"varint,7,opt,name=agent_changes,json=agentChanges,proto3" json:"agent_changes,omitempty"`
+	AddressChanges  uint32                 `protobuf:"varint,8,opt,name=address_changes,json=addressChanges,proto3" json:"address_changes,omitempty"`
+	Protocols       map[string]*CrawlShift `protobuf:"bytes,9,rep,name=protocols,proto3" json:"protocols,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Asns            map[uint32]*CrawlShift `protobuf:"bytes,10,rep,name=asns,proto3" json:"asns,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Countries       map[string]*CrawlShift `protobuf:"bytes,11,rep,name=countries,proto3" json:"countries,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CrawlSummary) Reset() {
+	*x = CrawlSummary{}
+	mi := &file_pb_backend_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlSummary) ProtoMessage() {}
+
+func (x *CrawlSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlSummary.ProtoReflect.Descriptor instead.
+func (*CrawlSummary) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CrawlSummary) GetPeers() uint32 {
+	if x != nil {
+		return x.Peers
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetPreviousPeers() uint32 {
+	if x != nil {
+		return x.PreviousPeers
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetNew() uint32 {
+	if x != nil {
+		return x.New
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetVanished() uint32 {
+	if x != nil {
+		return x.Vanished
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetAgentUpgrades() uint32 {
+	if x != nil {
+		return x.AgentUpgrades
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetAgentDowngrades() uint32 {
+	if x != nil {
+		return x.AgentDowngrades
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetAgentChanges() uint32 {
+	if x != nil {
+		return x.AgentChanges
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetAddressChanges() uint32 {
+	if x != nil {
+		return x.AddressChanges
+	}
+	return 0
+}
+
+func (x *CrawlSummary) GetProtocols() map[string]*CrawlShift {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *CrawlSummary) GetAsns() map[uint32]*CrawlShift {
+	if x != nil {
+		return x.Asns
+	}
+	return nil
+}
+
+func (x *CrawlSummary) GetCountries() map[string]*CrawlShift {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+// Published on `crawler`.
+type CrawlerMessage struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Kind      CrawlerMessageKind     `protobuf:"varint,1,opt,name=kind,proto3,enum=backend.v1.CrawlerMessageKind" json:"kind,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Peer      *WalkerPeer            `protobuf:"bytes,3,opt,name=peer,proto3" json:"peer,omitempty"`
+	Error     *WalkerError           `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Difference to the previous crawl, only set on crawl end
+	Summary *CrawlSummary `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	// Identity of the crawl the message belongs to, unique across crawlers
+	Crawl string `protobuf:"bytes,6,opt,name=crawl,proto3" json:"crawl,omitempty"`
+	// Only set on events
+	Event *CrawlerEvent `protobuf:"bytes,7,opt,name=event,proto3" json:"event,omitempty"`
+	// Mode of the crawler, ex: active, continuous or passive. Empty for older crawlers
+	Mode          string `protobuf:"bytes,8,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlerMessage) Reset() {
+	*x = CrawlerMessage{}
+	mi := &file_pb_backend_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlerMessage) ProtoMessage() {}
+
+func (x *CrawlerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_backend_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlerMessage.ProtoReflect.Descriptor instead.
+func (*CrawlerMessage) Descriptor() ([]byte, []int) {
+	return file_pb_backend_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CrawlerMessage) GetKind() CrawlerMessageKind {
+	if x != nil {
+		return x.Kind
+	}
+	return CrawlerMessageKind_CRAWLER_MESSAGE_KIND_UNSPECIFIED
+}
+
+func (x *CrawlerMessage) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *CrawlerMessage) GetPeer() *WalkerPeer {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+func (x *CrawlerMessage) GetError() *WalkerError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *CrawlerMessage) GetSummary() *CrawlSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *CrawlerMessage) GetCrawl() string {
+	if x != nil {
+		return x.Crawl
+	}
+	return ""
+}
+
+func (x *CrawlerMessage) GetEvent() *CrawlerEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CrawlerMessage) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+var File_pb_backend_proto protoreflect.FileDescriptor
+
+const file_pb_backend_proto_rawDesc = "" +
+	"\n" +
+	"\x10pb/backend.proto\x12\n" +
+	"backend.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"@\n" +
+	"\x10DiscoveryMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x1c\n" +
+	"\taddresses\x18\x02 \x03(\fR\taddresses\"%\n" +
+	"\rActiveMessage\x12\x14\n" +
+	"\x05peers\x18\x01 \x03(\fR\x05peers\"T\n" +
+	"\x05Scope\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"schema_url\x18\x03 \x01(\tR\tschemaUrl\"\xbe\x01\n" +
+	"\bProperty\x12'\n" +
+	"\x05scope\x18\x01 \x01(\v2\x11.backend.v1.ScopeR\x05scope\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12%\n" +
+	"\rinteger_value\x18\x04 \x01(\x03H\x00R\fintegerValue\x12#\n" +
+	"\fstring_value\x18\x05 \x01(\tH\x00R\vstringValueB\a\n" +
+	"\x05value\"\x8b\x01\n" +
+	"\x0fEventDescriptor\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\rR\aeventId\x12'\n" +
+	"\x05scope\x18\x02 \x01(\v2\x11.backend.v1.ScopeR\x05scope\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"U\n" +
+	"\x05Event\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"p\n" +
+	"\x06Events\x12;\n" +
+	"\n" +
+	"descriptor\x18\x01 \x01(\v2\x1b.backend.v1.EventDescriptorR\n" +
+	"descriptor\x12)\n" +
+	"\x06events\x18\x02 \x03(\v2\x11.backend.v1.EventR\x06events\"p\n" +
+	"\x0fBandwidthSample\x123\n" +
+	"\aelapsed\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\aelapsed\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x04R\x05bytes\x12\x12\n" +
+	"\x04rate\x18\x03 \x01(\x04R\x04rate\"\xc5\x02\n" +
+	"\x14BandwidthMeasurement\x12\x18\n" +
+	"\astreams\x18\x01 \x01(\rR\astreams\x125\n" +
+	"\bduration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bduration\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x04R\x05bytes\x12\x12\n" +
+	"\x04rate\x18\x04 \x01(\x04R\x04rate\x12\x1b\n" +
+	"\tpeak_rate\x18\x05 \x01(\x04R\bpeakRate\x125\n" +
+	"\asamples\x18\x06 \x03(\v2\x1b.backend.v1.BandwidthSampleR\asamples\x12+\n" +
+	"\x03rtt\x18\a \x01(\v2\x19.google.protobuf.DurationR\x03rtt\x121\n" +
+	"\x06jitter\x18\b \x01(\v2\x19.google.protobuf.DurationR\x06jitter\"\xa9\x02\n" +
+	"\tBandwidth\x12\x1f\n" +
+	"\vupload_rate\x18\x01 \x01(\x04R\n" +
+	"uploadRate\x12#\n" +
+	"\rdownload_rate\x18\x02 \x01(\x04R\fdownloadRate\x12+\n" +
+	"\x03rtt\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x03rtt\x121\n" +
+	"\x06jitter\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x06jitter\x128\n" +
+	"\x06upload\x18\x05 \x01(\v2 .backend.v1.BandwidthMeasurementR\x06upload\x12<\n" +
+	"\bdownload\x18\x06 \x01(\v2 .backend.v1.BandwidthMeasurementR\bdownload\"\xc8\x01\n" +
+	"\tDialProbe\x12\x1c\n" +
+	"\ttransport\x18\x01 \x01(\tR\ttransport\x12\x1c\n" +
+	"\taddresses\x18\x02 \x01(\rR\taddresses\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\fR\aaddress\x125\n" +
+	"\bduration\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\bduration\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"\xec\x01\n" +
+	"\x05Probe\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x126\n" +
+	"\tping_rtts\x18\x02 \x03(\v2\x19.google.protobuf.DurationR\bpingRtts\x12\x1d\n" +
+	"\n" +
+	"ping_error\x18\x03 \x01(\tR\tpingError\x12+\n" +
+	"\x05dials\x18\x04 \x03(\v2\x15.backend.v1.DialProbeR\x05dials\x12%\n" +
+	"\x0eidentify_addrs\x18\x05 \x03(\fR\ridentifyAddrs\"\xcd\x02\n" +
+	"\x06Export\x12;\n" +
+	"\vobserved_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"observedAt\x12\x12\n" +
+	"\x04peer\x18\x02 \x01(\fR\x04peer\x12\x18\n" +
+	"\asession\x18\x03 \x01(\fR\asession\x124\n" +
+	"\n" +
+	"properties\x18\x04 \x03(\v2\x14.backend.v1.PropertyR\n" +
+	"properties\x12\x18\n" +
+	"\ametrics\x18\x05 \x03(\fR\ametrics\x12*\n" +
+	"\x06events\x18\x06 \x03(\v2\x12.backend.v1.EventsR\x06events\x123\n" +
+	"\tbandwidth\x18\a \x01(\v2\x15.backend.v1.BandwidthR\tbandwidth\x12'\n" +
+	"\x05probe\x18\b \x01(\v2\x11.backend.v1.ProbeR\x05probe\";\n" +
+	"\vBucketEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x1c\n" +
+	"\taddresses\x18\x02 \x03(\fR\taddresses\"r\n" +
+	"\aRequest\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x125\n" +
+	"\bduration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bduration\"D\n" +
+	"\x12WalkerSignedRecord\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x1c\n" +
+	"\taddresses\x18\x02 \x03(\fR\taddresses\"\x89\x03\n" +
+	"\x0eWalkerIdentify\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\tR\x0fprotocolVersion\x12#\n" +
+	"\ragent_version\x18\x02 \x01(\tR\fagentVersion\x12)\n" +
+	"\x10listen_addresses\x18\x03 \x03(\fR\x0flistenAddresses\x12)\n" +
+	"\x10observed_address\x18\x04 \x01(\fR\x0fobservedAddress\x12\x1c\n" +
+	"\tprotocols\x18\x05 \x03(\tR\tprotocols\x12C\n" +
+	"\rsigned_record\x18\x06 \x01(\v2\x1e.backend.v1.WalkerSignedRecordR\fsignedRecord\x12\x1e\n" +
+	"\n" +
+	"transports\x18\a \x03(\tR\n" +
+	"transports\x12\x1c\n" +
+	"\ttransport\x18\b \x01(\tR\ttransport\x12\x1a\n" +
+	"\bsecurity\x18\t \x01(\tR\bsecurity\x12\x14\n" +
+	"\x05muxer\x18\n" +
+	" \x01(\tR\x05muxer\"k\n" +
+	"\x11WalkerFingerprint\x12&\n" +
+	"\x0eimplementation\x18\x01 \x01(\tR\x0eimplementation\x12\x14\n" +
+	"\x05agent\x18\x02 \x01(\tR\x05agent\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\"\xeb\x04\n" +
+	"\n" +
+	"WalkerPeer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x1c\n" +
+	"\taddresses\x18\x02 \x03(\fR\taddresses\x12\x14\n" +
+	"\x05agent\x18\x03 \x01(\tR\x05agent\x12\x1c\n" +
+	"\tprotocols\x18\x04 \x03(\tR\tprotocols\x121\n" +
+	"\abuckets\x18\x05 \x03(\v2\x17.backend.v1.BucketEntryR\abuckets\x12/\n" +
+	"\brequests\x18\x06 \x03(\v2\x13.backend.v1.RequestR\brequests\x12?\n" +
+	"\rconnect_start\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\fconnectStart\x12D\n" +
+	"\x10connect_duration\x18\b \x01(\v2\x19.google.protobuf.DurationR\x0fconnectDuration\x12\x18\n" +
+	"\anetwork\x18\t \x01(\tR\anetwork\x126\n" +
+	"\bidentify\x18\n" +
+	" \x01(\v2\x1a.backend.v1.WalkerIdentifyR\bidentify\x12?\n" +
+	"\vfingerprint\x18\v \x01(\v2\x1d.backend.v1.WalkerFingerprintR\vfingerprint\x12@\n" +
+	"\bmessages\x18\f \x03(\v2$.backend.v1.WalkerPeer.MessagesEntryR\bmessages\x1a;\n" +
+	"\rMessagesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"y\n" +
+	"\x11WalkerDialFailure\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\x12\x1c\n" +
+	"\ttransport\x18\x02 \x01(\tR\ttransport\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\xfe\x01\n" +
+	"\vWalkerError\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x18\n" +
+	"\anetwork\x18\x02 \x01(\tR\anetwork\x12\x1c\n" +
+	"\taddresses\x18\x03 \x03(\fR\taddresses\x12.\n" +
+	"\x04time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x14\n" +
+	"\x05stage\x18\x06 \x01(\tR\x05stage\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x123\n" +
+	"\x05dials\x18\b \x03(\v2\x1d.backend.v1.WalkerDialFailureR\x05dials\"\x8b\x02\n" +
+	"\fCrawlerEvent\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\fR\x02id\x12\x18\n" +
+	"\anetwork\x18\x03 \x01(\tR\anetwork\x12.\n" +
+	"\x04time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x1c\n" +
+	"\taddresses\x18\x05 \x03(\fR\taddresses\x12-\n" +
+	"\x12previous_addresses\x18\x06 \x03(\fR\x11previousAddresses\x12@\n" +
+	"\x0esession_length\x18\a \x01(\v2\x19.google.protobuf.DurationR\rsessionLength\":\n" +
+	"\n" +
+	"CrawlShift\x12\x16\n" +
+	"\x06before\x18\x01 \x01(\rR\x06before\x12\x14\n" +
+	"\x05after\x18\x02 \x01(\rR\x05after\"\xdc\x05\n" +
+	"\fCrawlSummary\x12\x14\n" +
+	"\x05peers\x18\x01 \x01(\rR\x05peers\x12%\n" +
+	"\x0eprevious_peers\x18\x02 \x01(\rR\rpreviousPeers\x12\x10\n" +
+	"\x03new\x18\x03 \x01(\rR\x03new\x12\x1a\n" +
+	"\bvanished\x18\x04 \x01(\rR\bvanished\x12%\n" +
+	"\x0eagent_upgrades\x18\x05 \x01(\rR\ragentUpgrades\x12)\n" +
+	"\x10agent_downgrades\x18\x06 \x01(\rR\x0fagentDowngrades\x12#\n" +
+	"\ragent_changes\x18\a \x01(\rR\fagentChanges\x12'\n" +
+	"\x0faddress_changes\x18\b \x01(\rR\x0eaddressChanges\x12E\n" +
+	"\tprotocols\x18\t \x03(\v2'.backend.v1.CrawlSummary.ProtocolsEntryR\tprotocols\x126\n" +
+	"\x04asns\x18\n" +
+	" \x03(\v2\".backend.v1.CrawlSummary.AsnsEntryR\x04asns\x12E\n" +
+	"\tcountries\x18\v \x03(\v2'.backend.v1.CrawlSummary.CountriesEntryR\tcountries\x1aT\n" +
+	"\x0eProtocolsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.backend.v1.CrawlShiftR\x05value:\x028\x01\x1aO\n" +
+	"\tAsnsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\rR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.backend.v1.CrawlShiftR\x05value:\x028\x01\x1aT\n" +
+	"\x0eCountriesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12,\n" +
+	"\x05value\x18\x02 \x01(\v2\x16.backend.v1.CrawlShiftR\x05value:\x028\x01\"\xe7\x02\n" +
+	"\x0eCrawlerMessage\x122\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x1e.backend.v1.CrawlerMessageKindR\x04kind\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12*\n" +
+	"\x04peer\x18\x03 \x01(\v2\x16.backend.v1.WalkerPeerR\x04peer\x12-\n" +
+	"\x05error\x18\x04 \x01(\v2\x17.backend.v1.WalkerErrorR\x05error\x122\n" +
+	"\asummary\x18\x05 \x01(\v2\x18.backend.v1.CrawlSummaryR\asummary\x12\x14\n" +
+	"\x05crawl\x18\x06 \x01(\tR\x05crawl\x12.\n" +
+	"\x05event\x18\a \x01(\v2\x18.backend.v1.CrawlerEventR\x05event\x12\x12\n" +
+	"\x04mode\x18\b \x01(\tR\x04mode*\xe3\x01\n" +
+	"\x12CrawlerMessageKind\x12$\n" +
+	" CRAWLER_MESSAGE_KIND_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19CRAWLER_MESSAGE_KIND_PEER\x10\x01\x12$\n" +
+	" CRAWLER_MESSAGE_KIND_CRAWL_BEGIN\x10\x02\x12\"\n" +
+	"\x1eCRAWLER_MESSAGE_KIND_CRAWL_END\x10\x03\x12\x1e\n" +
+	"\x1aCRAWLER_MESSAGE_KIND_ERROR\x10\x04\x12\x1e\n" +
+	"\x1aCRAWLER_MESSAGE_KIND_EVENT\x10\x05B/Z-github.com/diogo464/ipfs-telemetry/backend/pbb\x06proto3"
+
+var (
+	file_pb_backend_proto_rawDescOnce sync.Once
+	file_pb_backend_proto_rawDescData []byte
+)
+
+func file_pb_backend_proto_rawDescGZIP() []byte {
+	file_pb_backend_proto_rawDescOnce.Do(func() {
+		file_pb_backend_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_backend_proto_rawDesc), len(file_pb_backend_proto_rawDesc)))
+	})
+	return file_pb_backend_proto_rawDescData
+}
+
+var file_pb_backend_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pb_backend_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_pb_backend_proto_goTypes = []any{
+	(CrawlerMessageKind)(0),       // 0: backend.v1.CrawlerMessageKind
+	(*DiscoveryMessage)(nil),      // 1: backend.v1.DiscoveryMessage
+	(*ActiveMessage)(nil),         // 2: backend.v1.ActiveMessage
+	(*Scope)(nil),                 // 3: backend.v1.Scope
+	(*Property)(nil),              // 4: backend.v1.Property
+	(*EventDescriptor)(nil),       // 5: backend.v1.EventDescriptor
+	(*Event)(nil),                 // 6: backend.v1.Event
+	(*Events)(nil),                // 7: backend.v1.Events
+	(*BandwidthSample)(nil),       // 8: backend.v1.BandwidthSample
+	(*BandwidthMeasurement)(nil),  // 9: backend.v1.BandwidthMeasurement
+	(*Bandwidth)(nil),             // 10: backend.v1.Bandwidth
+	(*DialProbe)(nil),             // 11: backend.v1.DialProbe
+	(*Probe)(nil),                 // 12: backend.v1.Probe
+	(*Export)(nil),                // 13: backend.v1.Export
+	(*BucketEntry)(nil),           // 14: backend.v1.BucketEntry
+	(*Request)(nil),               // 15: backend.v1.Request
+	(*WalkerSignedRecord)(nil),    // 16: backend.v1.WalkerSignedRecord
+	(*WalkerIdentify)(nil),        // 17: backend.v1.WalkerIdentify
+	(*WalkerFingerprint)(nil),     // 18: backend.v1.WalkerFingerprint
+	(*WalkerPeer)(nil),            // 19: backend.v1.WalkerPeer
+	(*WalkerDialFailure)(nil),     // 20: backend.v1.WalkerDialFailure
+	(*WalkerError)(nil),           // 21: backend.v1.WalkerError
+	(*CrawlerEvent)(nil),          // 22: backend.v1.CrawlerEvent
+	(*CrawlShift)(nil),            // 23: backend.v1.CrawlShift
+	(*CrawlSummary)(nil),          // 24: backend.v1.CrawlSummary
+	(*CrawlerMessage)(nil),        // 25: backend.v1.CrawlerMessage
+	nil,                           // 26: backend.v1.WalkerPeer.MessagesEntry
+	nil,                           // 27: backend.v1.CrawlSummary.ProtocolsEntry
+	nil,                           // 28: backend.v1.CrawlSummary.AsnsEntry
+	nil,                           // 29: backend.v1.CrawlSummary.CountriesEntry
+	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 31: google.protobuf.Duration
+}
+var file_pb_backend_proto_depIdxs = []int32{
+	3,  // 0: backend.v1.Property.scope:type_name -> backend.v1.Scope
+	3,  // 1: backend.v1.EventDescriptor.scope:type_name -> backend.v1.Scope
+	30, // 2: backend.v1.Event.timestamp:type_name -> google.protobuf.Timestamp
+	5,  // 3: backend.v1.Events.descriptor:type_name -> backend.v1.EventDescriptor
+	6,  // 4: backend.v1.Events.events:type_name -> backend.v1.Event
+	31, // 5: backend.v1.BandwidthSample.elapsed:type_name -> google.protobuf.Duration
+	31, // 6: backend.v1.BandwidthMeasurement.duration:type_name -> google.protobuf.Duration
+	8,  // 7: backend.v1.BandwidthMeasurement.samples:type_name -> backend.v1.BandwidthSample
+	31, // 8: backend.v1.BandwidthMeasurement.rtt:type_name -> google.protobuf.Duration
+	31, // 9: backend.v1.BandwidthMeasurement.jitter:type_name -> google.protobuf.Duration
+	31, // 10: backend.v1.Bandwidth.rtt:type_name -> google.protobuf.Duration
+	31, // 11: backend.v1.Bandwidth.jitter:type_name -> google.protobuf.Duration
+	9,  // 12: backend.v1.Bandwidth.upload:type_name -> backend.v1.BandwidthMeasurement
+	9,  // 13: backend.v1.Bandwidth.download:type_name -> backend.v1.BandwidthMeasurement
+	31, // 14: backend.v1.DialProbe.duration:type_name -> google.protobuf.Duration
+	30, // 15: backend.v1.Probe.timestamp:type_name -> google.protobuf.Timestamp
+	31, // 16: backend.v1.Probe.ping_rtts:type_name -> google.protobuf.Duration
+	11, // 17: backend.v1.Probe.dials:type_name -> backend.v1.DialProbe
+	30, // 18: backend.v1.Export.observed_at:type_name -> google.protobuf.Timestamp
+	4,  // 19: backend.v1.Export.properties:type_name -> backend.v1.Property
+	7,  // 20: backend.v1.Export.events:type_name -> backend.v1.Events
+	10, // 21: backend.v1.Export.bandwidth:type_name -> backend.v1.Bandwidth
+	12, // 22: backend.v1.Export.probe:type_name -> backend.v1.Probe
+	30, // 23: backend.v1.Request.start:type_name -> google.protobuf.Timestamp
+	31, // 24: backend.v1.Request.duration:type_name -> google.protobuf.Duration
+	16, // 25: backend.v1.WalkerIdentify.signed_record:type_name -> backend.v1.WalkerSignedRecord
+	14, // 26: backend.v1.WalkerPeer.buckets:type_name -> backend.v1.BucketEntry
+	15, // 27: backend.v1.WalkerPeer.requests:type_name -> backend.v1.Request
+	30, // 28: backend.v1.WalkerPeer.connect_start:type_name -> google.protobuf.Timestamp
+	31, // 29: backend.v1.WalkerPeer.connect_duration:type_name -> google.protobuf.Duration
+	17, // 30: backend.v1.WalkerPeer.identify:type_name -> backend.v1.WalkerIdentify
+	18, // 31: backend.v1.WalkerPeer.fingerprint:type_name -> backend.v1.WalkerFingerprint
+	26, // 32: backend.v1.WalkerPeer.messages:type_name -> backend.v1.WalkerPeer.MessagesEntry
+	30, // 33: backend.v1.WalkerError.time:type_name -> google.protobuf.Timestamp
+	20, // 34: backend.v1.WalkerError.dials:type_name -> backend.v1.WalkerDialFailure
+	30, // 35: backend.v1.CrawlerEvent.time:type_name -> google.protobuf.Timestamp
+	31, // 36: backend.v1.CrawlerEvent.session_length:type_name -> google.protobuf.Duration
+	27, // 37: backend.v1.CrawlSummary.protocols:type_name -> backend.v1.CrawlSummary.ProtocolsEntry
+	28, // 38: backend.v1.CrawlSummary.asns:type_name -> backend.v1.CrawlSummary.AsnsEntry
+	29, // 39: backend.v1.CrawlSummary.countries:type_name -> backend.v1.CrawlSummary.CountriesEntry
+	0,  // 40: backend.v1.CrawlerMessage.kind:type_name -> backend.v1.CrawlerMessageKind
+	30, // 41: backend.v1.CrawlerMessage.timestamp:type_name -> google.protobuf.Timestamp
+	19, // 42: backend.v1.CrawlerMessage.peer:type_name -> backend.v1.WalkerPeer
+	21, // 43: backend.v1.CrawlerMessage.error:type_name -> backend.v1.WalkerError
+	24, // 44: backend.v1.CrawlerMessage.summary:type_name -> backend.v1.CrawlSummary
+	22, // 45: backend.v1.CrawlerMessage.event:type_name -> backend.v1.CrawlerEvent
+	23, // 46: backend.v1.CrawlSummary.ProtocolsEntry.value:type_name -> backend.v1.CrawlShift
+	23, // 47: backend.v1.CrawlSummary.AsnsEntry.value:type_name -> backend.v1.CrawlShift
+	23, // 48: backend.v1.CrawlSummary.CountriesEntry.value:type_name -> backend.v1.CrawlShift
+	49, // [49:49] is the sub-list for method output_type
+	49, // [49:49] is the sub-list for method input_type
+	49, // [49:49] is the sub-list for extension type_name
+	49, // [49:49] is the sub-list for extension extendee
+	0,  // [0:49] is the sub-list for field type_name
+}
+
+func init() { file_pb_backend_proto_init() }
+func file_pb_backend_proto_init() {
+	if File_pb_backend_proto != nil {
+		return
+	}
+	file_pb_backend_proto_msgTypes[3].OneofWrappers = []any{
+		(*Property_IntegerValue)(nil),
+		(*Property_StringValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_backend_proto_rawDesc), len(file_pb_backend_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   29,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pb_backend_proto_goTypes,
+		DependencyIndexes: file_pb_backend_proto_depIdxs,
+		EnumInfos:         file_pb_backend_proto_enumTypes,
+		MessageInfos:      file_pb_backend_proto_msgTypes,
+	}.Build()
+	File_pb_backend_proto = out.File
+	file_pb_backend_proto_goTypes = nil
+	file_pb_backend_proto_depIdxs = nil
+}
@@ -0,0 +1,438 @@
+package pg_crawler_exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	"github.com/diogo464/ipfs-telemetry/backend/enrich"
+	"github.com/diogo464/ipfs-telemetry/backend/pgmigrate"
+	tcrawler "github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/crawler/graph"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultGeoIPCityPath = "./GeoLite2-City.mmdb"
+	DefaultGeoIPAsnPath  = "./GeoLite2-ASN.mmdb"
+)
+
+// Migrations of the crawler schema
+func Migrations() (*pgmigrate.Component, error) {
+	migrations, err := pgmigrate.Load(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &pgmigrate.Component{Name: "crawler", Migrations: migrations}, nil
+}
+
+// Migrate the crawler schema to the latest version. If recreate is true every migration is reverted first, dropping all data.
+func SetupSchema(ctx context.Context, logger *zap.Logger, conn *pgx.Conn, recreate bool) error {
+	component, err := Migrations()
+	if err != nil {
+		return err
+	}
+	if recreate {
+		return pgmigrate.Reset(ctx, logger, conn, component)
+	}
+	return pgmigrate.Up(ctx, logger, conn, component)
+}
+
+// Prefix of the identity given to crawls of crawlers that do not send one, followed by the sequence of their begin
+const legacyCrawlPrefix = "seqn-"
+
+var (
+	peerColumns  = []string{"crawl", "seqn", "timestamp", "network", "peer_id", "agent", "addresses", "protocols", "dht_entries", "implementation", "version", "ip", "asn", "asn_org", "country", "city", "latitude", "longitude", "country_code", "hosting_provider", "locations"}
+	errorColumns = []string{"crawl", "seqn", "timestamp", "network", "peer_id", "addresses", "stage", "reason", "error", "dials"}
+	eventColumns = []string{"crawl_id", "seqn", "timestamp", "network", "peer_id", "kind", "addresses", "previous_addresses", "session_length"}
+)
+
+// A crawl that began but did not end yet
+type openCrawl struct {
+	// id of the crawl row, 0 until the batch with its begin is flushed
+	id    int
+	graph *graph.Graph
+}
+
+type pendingMessage struct {
+	seqn  uint64
+	crawl string
+	msg   *crawler.NatsMessage
+}
+
+// Exporter writes crawler messages into postgres in batches.
+// Messages must be added in stream order. Each batch is committed in a single transaction together with its last
+// stream sequence, so an exporter recovered from the database resumes exactly after the last committed message.
+// After any error the exporter must be discarded.
+type Exporter struct {
+	logger *zap.Logger
+	conn   *pgx.Conn
+	// locates every public address of the peers
+	enricher *enrich.Enricher
+
+	// last stream sequence committed to the database
+	committed uint64
+	// open crawls by identity
+	crawls map[string]*openCrawl
+	// identity of the last crawl begun by a crawler that does not send one
+	legacyCrawl string
+	// crawls whose begin was never seen, their messages are dropped
+	skipped map[string]struct{}
+	pending []pendingMessage
+}
+
+func NewExporter(logger *zap.Logger, conn *pgx.Conn, enricher *enrich.Enricher) *Exporter {
+	return &Exporter{
+		logger:   logger,
+		conn:     conn,
+		enricher: enricher,
+
+		crawls:  make(map[string]*openCrawl),
+		skipped: make(map[string]struct{}),
+		pending: make([]pendingMessage, 0),
+	}
+}
+
+// Load the ingestion state and the open crawls from the database and return the stream sequence to consume from.
+// The graph of every open crawl is rebuilt by consuming from its begin, messages that were already committed are not written again.
+// Open crawls older than maxCrawlAge are considered abandoned and are not resumed.
+// A sequence of 0 means only new messages should be consumed.
+func (e *Exporter) Recover(ctx context.Context, maxCrawlAge time.Duration) (uint64, error) {
+	var committed int64
+	hasState := true
+	if err := e.conn.QueryRow(ctx, "SELECT seqn FROM crawler.ingest WHERE stream = $1", crawler.StreamCrawler).Scan(&committed); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("failed to read ingestion state: %w", err)
+		}
+		hasState = false
+	}
+	e.committed = uint64(committed)
+
+	rows, err := e.conn.Query(ctx,
+		"SELECT id, crawl_id, begin_seqn FROM crawler.crawl WHERE timestamp_end IS NULL AND crawl_id IS NOT NULL AND timestamp_begin > $1 ORDER BY begin_seqn",
+		time.Now().Add(-maxCrawlAge))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query open crawls: %w", err)
+	}
+	defer rows.Close()
+
+	var openBegin uint64
+	for rows.Next() {
+		var id int
+		var crawlId string
+		var beginSeqn int64
+		if err := rows.Scan(&id, &crawlId, &beginSeqn); err != nil {
+			return 0, fmt.Errorf("failed to scan open crawl: %w", err)
+		}
+		e.logger.Info("resuming crawl", zap.String("crawl", crawlId), zap.Int64("begin-seqn", beginSeqn))
+		e.crawls[crawlId] = &openCrawl{id: id, graph: graph.New()}
+		if strings.HasPrefix(crawlId, legacyCrawlPrefix) {
+			e.legacyCrawl = crawlId
+		}
+		if openBegin == 0 {
+			openBegin = uint64(beginSeqn)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read open crawls: %w", err)
+	}
+
+	var crawls int
+	if err := e.conn.QueryRow(ctx, "SELECT COUNT(*) FROM crawler.crawl").Scan(&crawls); err != nil {
+		return 0, fmt.Errorf("failed to count crawls: %w", err)
+	}
+	if !hasState && crawls > 0 {
+		e.logger.Warn("database was filled by an exporter without ingestion state, only new messages are consumed")
+	}
+	return startSequence(hasState, e.committed, openBegin, crawls > 0), nil
+}
+
+// Stream sequence to consume from, 0 for new messages only
+func startSequence(hasState bool, committed uint64, openBegin uint64, hasCrawls bool) uint64 {
+	switch {
+	case hasState && openBegin > 0:
+		return min(openBegin, committed+1)
+	case hasState:
+		return committed + 1
+	case hasCrawls:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Add a message to the current batch. seqn is the stream sequence number of the message.
+func (e *Exporter) Add(seqn uint64, cmsg *crawler.NatsMessage) error {
+	crawl := e.crawlOf(seqn, cmsg)
+	// committed messages are only consumed again to rebuild the graphs of open crawls
+	replay := seqn <= e.committed
+
+	switch cmsg.Kind {
+	case crawler.KindCrawlBegin:
+		if replay {
+			return nil
+		}
+		e.logger.Info("crawl started", zap.String("crawl", crawl))
+		e.crawls[crawl] = &openCrawl{graph: graph.New()}
+	case crawler.KindCrawlEnd:
+		if replay {
+			return nil
+		}
+		if _, ok := e.crawls[crawl]; !ok {
+			e.skip(crawl)
+			return nil
+		}
+		e.logger.Info("crawl ended", zap.String("crawl", crawl))
+	case crawler.KindPeer:
+		if cmsg.Peer == nil {
+			return fmt.Errorf("crawler peer message without peer at seqn %v", seqn)
+		}
+		state, ok := e.crawls[crawl]
+		if !ok {
+			e.skip(crawl)
+			return nil
+		}
+		state.graph.AddPeer(cmsg.Peer)
+	case crawler.KindError:
+		if cmsg.Error == nil {
+			return fmt.Errorf("crawler error message without error at seqn %v", seqn)
+		}
+		state, ok := e.crawls[crawl]
+		if !ok {
+			e.skip(crawl)
+			return nil
+		}
+		state.graph.AddUnreachable(cmsg.Error.ID)
+	case crawler.KindEvent:
+		// events are published after the first crawl of a continuous crawler ended, they do not need an open crawl
+		if cmsg.Event == nil {
+			return fmt.Errorf("crawler event message without event at seqn %v", seqn)
+		}
+		if replay {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown crawler message kind %q at seqn %v", cmsg.Kind, seqn)
+	}
+
+	if !replay {
+		e.pending = append(e.pending, pendingMessage{seqn: seqn, crawl: crawl, msg: cmsg})
+	}
+	return nil
+}
+
+// Number of messages in the current batch
+func (e *Exporter) Pending() int {
+	return len(e.pending)
+}
+
+// Write the current batch to the database in a single transaction
+func (e *Exporter) Flush(ctx context.Context) error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+
+	tx, err := e.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	peers := make([][]any, 0)
+	errs := make([][]any, 0)
+	events := make([][]any, 0)
+	ended := make([]string, 0)
+	for _, p := range e.pending {
+		state := e.crawls[p.crawl]
+		switch p.msg.Kind {
+		case crawler.KindCrawlBegin:
+			row := tx.QueryRow(ctx,
+				"INSERT INTO crawler.crawl(crawl_id, timestamp_begin, begin_seqn, mode) VALUES($1, $2, $3, $4) ON CONFLICT (crawl_id) DO UPDATE SET crawl_id = EXCLUDED.crawl_id RETURNING id",
+				p.crawl, p.msg.Timestamp, p.seqn, p.msg.CrawlMode())
+			if err := row.Scan(&state.id); err != nil {
+				return fmt.Errorf("failed to create crawl %v: %w", p.crawl, err)
+			}
+		case crawler.KindPeer:
+			peers = append(peers, e.peerRow(state.id, p.seqn, p.msg))
+		case crawler.KindError:
+			errs = append(errs, errorRow(state.id, p.seqn, p.msg))
+		case crawler.KindEvent:
+			events = append(events, eventRow(p.crawl, p.seqn, p.msg))
+		case crawler.KindCrawlEnd:
+			// every row of the crawl must be written before its statistics
+			if err := copyRows(ctx, tx, "peer", peerColumns, peers); err != nil {
+				return err
+			}
+			if err := copyRows(ctx, tx, "error", errorColumns, errs); err != nil {
+				return err
+			}
+			peers, errs = peers[:0], errs[:0]
+			if err := closeCrawl(ctx, tx, state, p); err != nil {
+				return err
+			}
+			ended = append(ended, p.crawl)
+		}
+	}
+	if err := copyRows(ctx, tx, "peer", peerColumns, peers); err != nil {
+		return err
+	}
+	if err := copyRows(ctx, tx, "error", errorColumns, errs); err != nil {
+		return err
+	}
+	if err := copyRows(ctx, tx, "event", eventColumns, events); err != nil {
+		return err
+	}
+
+	last := e.pending[len(e.pending)-1].seqn
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO crawler.ingest(stream, seqn) VALUES($1, $2) ON CONFLICT (stream) DO UPDATE SET seqn = GREATEST(crawler.ingest.seqn, EXCLUDED.seqn)",
+		crawler.StreamCrawler, last); err != nil {
+		return fmt.Errorf("failed to update ingestion state: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	e.logger.Debug("flushed batch", zap.Int("messages", len(e.pending)), zap.Uint64("seqn", last))
+	e.committed = last
+	e.pending = e.pending[:0]
+	for _, crawl := range ended {
+		delete(e.crawls, crawl)
+	}
+	return nil
+}
+
+func (e *Exporter) crawlOf(seqn uint64, cmsg *crawler.NatsMessage) string {
+	if cmsg.Crawl != "" {
+		return cmsg.Crawl
+	}
+	if cmsg.Kind == crawler.KindCrawlBegin {
+		e.legacyCrawl = fmt.Sprintf("%v%v", legacyCrawlPrefix, seqn)
+	}
+	return e.legacyCrawl
+}
+
+func (e *Exporter) skip(crawl string) {
+	if _, ok := e.skipped[crawl]; !ok {
+		e.logger.Warn("dropping messages of a crawl whose begin was not consumed", zap.String("crawl", crawl))
+		e.skipped[crawl] = struct{}{}
+	}
+}
+
+func (e *Exporter) peerRow(crawl int, seqn uint64, cmsg *crawler.NatsMessage) []any {
+	p := cmsg.Peer
+	addrs := make([]string, len(p.Addresses))
+	for i, maddr := range p.Addresses {
+		addrs[i] = maddr.String()
+	}
+	protocols := make([]string, len(p.Protocols))
+	for i, proto := range p.Protocols {
+		protocols[i] = string(proto)
+	}
+
+	// the first public address is stored in its own columns, every address is in locations
+	locations := make([]*enrich.Location, 0)
+	if e.enricher != nil {
+		locations = e.enricher.LookupAddrs(p.Addresses)
+	}
+	var ip, country, countryCode, city, asnOrg, hostingProvider *string
+	var asn *uint
+	var latitude, longitude *float64
+	if len(locations) > 0 {
+		first := locations[0]
+		ip = &first.IP
+		if first.ASN != 0 {
+			asn, asnOrg = &first.ASN, &first.ASNOrg
+		}
+		if first.Country != "" {
+			country, countryCode = &first.Country, &first.CountryCode
+			latitude, longitude = &first.Latitude, &first.Longitude
+		}
+		if first.City != "" {
+			city = &first.City
+		}
+		if first.Hosting() {
+			hostingProvider = &first.HostingProvider
+		}
+	}
+
+	return []any{crawl, int64(seqn), cmsg.Timestamp, crawler.NetworkOrDefault(p.Network), p.ID.String(), p.Agent, addrs, protocols, len(p.Buckets), string(p.Fingerprint.Implementation), p.Fingerprint.Version, ip, asn, asnOrg, country, city, latitude, longitude, countryCode, hostingProvider, locations}
+}
+
+func errorRow(crawl int, seqn uint64, cmsg *crawler.NatsMessage) []any {
+	werr := cmsg.Error
+	addrs := make([]string, len(werr.Addresses))
+	for i, maddr := range werr.Addresses {
+		addrs[i] = maddr.String()
+	}
+	msg := ""
+	if werr.Err != nil {
+		msg = werr.Err.Error()
+	}
+	dials := werr.Dials
+	if dials == nil {
+		dials = []walker.DialFailure{}
+	}
+	return []any{crawl, int64(seqn), werr.Time, crawler.NetworkOrDefault(werr.Network), werr.ID.String(), addrs, string(werr.Stage), string(werr.Reason), msg, dials}
+}
+
+func eventRow(crawl string, seqn uint64, cmsg *crawler.NatsMessage) []any {
+	event := cmsg.Event
+	addrs := make([]string, len(event.Addresses))
+	for i, maddr := range event.Addresses {
+		addrs[i] = maddr.String()
+	}
+	previous := make([]string, len(event.PreviousAddresses))
+	for i, maddr := range event.PreviousAddresses {
+		previous[i] = maddr.String()
+	}
+	var sessionLength *time.Duration
+	if event.Kind == tcrawler.EventLeave {
+		sessionLength = &event.SessionLength
+	}
+	return []any{crawl, int64(seqn), event.Time, crawler.NetworkOrDefault(event.Network), event.ID.String(), string(event.Kind), addrs, previous, sessionLength}
+}
+
+// Copy the rows into a staging table and insert them into the crawler table, rows that already exist are ignored
+func copyRows(ctx context.Context, tx pgx.Tx, table string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	stage := table + "_stage"
+	cols := strings.Join(columns, ", ")
+	if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE TEMP TABLE IF NOT EXISTS %s ON COMMIT DROP AS SELECT %s FROM crawler.%s WITH NO DATA", stage, cols, table)); err != nil {
+		return fmt.Errorf("failed to create %v staging table: %w", table, err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{stage}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("failed to copy %v rows: %w", table, err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO crawler.%s(%s) SELECT %s FROM %s ON CONFLICT DO NOTHING", table, cols, cols, stage)); err != nil {
+		return fmt.Errorf("failed to insert %v rows: %w", table, err)
+	}
+	if _, err := tx.Exec(ctx, "TRUNCATE "+stage); err != nil {
+		return fmt.Errorf("failed to truncate %v staging table: %w", table, err)
+	}
+	return nil
+}
+
+// Set the end of the crawl and store its graph statistics
+func closeCrawl(ctx context.Context, tx pgx.Tx, state *openCrawl, p pendingMessage) error {
+	if _, err := tx.Exec(ctx, "UPDATE crawler.crawl SET timestamp_end = $2, end_seqn = $3 WHERE id = $1", state.id, p.msg.Timestamp, p.seqn); err != nil {
+		return fmt.Errorf("failed to end crawl %v: %w", p.crawl, err)
+	}
+
+	// unreachable peers were queried and failed, unknown peers were only seen in routing tables and never queried
+	stats := graph.ComputeStats(state.graph)
+	if _, err := tx.Exec(ctx,
+		"INSERT INTO crawler.graph(crawl, nodes, edges, reachable, unreachable, unknown, stale_entries, out_degree, in_degree, unreachable_in_degree) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (crawl) DO NOTHING",
+		state.id, stats.Nodes, stats.Edges, stats.Reachable, stats.Unreachable, stats.Unknown, stats.StaleEntries, stats.OutDegree, stats.InDegree, stats.UnreachableInDegree); err != nil {
+		return fmt.Errorf("failed to insert graph statistics of crawl %v: %w", p.crawl, err)
+	}
+	return nil
+}
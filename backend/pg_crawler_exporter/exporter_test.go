@@ -0,0 +1,131 @@
+package pg_crawler_exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	tcrawler "github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/crawler/graph"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.uber.org/zap"
+)
+
+const samplePeerId = "12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC"
+
+func TestStartSequence(t *testing.T) {
+	cases := []struct {
+		name      string
+		hasState  bool
+		committed uint64
+		openBegin uint64
+		hasCrawls bool
+		expected  uint64
+	}{
+		{"empty database", false, 0, 0, false, 1},
+		{"database without ingestion state", false, 0, 0, true, 0},
+		{"no open crawls", true, 100, 0, true, 101},
+		{"open crawl", true, 100, 40, true, 40},
+	}
+	for _, c := range cases {
+		if s := startSequence(c.hasState, c.committed, c.openBegin, c.hasCrawls); s != c.expected {
+			t.Fatalf("%v: expected start sequence %v, got %v", c.name, c.expected, s)
+		}
+	}
+}
+
+func TestAddInterleavedCrawls(t *testing.T) {
+	pid, err := peer.Decode(samplePeerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	e := NewExporter(zap.NewNop(), nil, nil)
+	messages := []*crawler.NatsMessage{
+		{Kind: crawler.KindCrawlBegin, Timestamp: now, Crawl: "active"},
+		{Kind: crawler.KindCrawlBegin, Timestamp: now, Crawl: "passive"},
+		{Kind: crawler.KindPeer, Timestamp: now, Crawl: "passive", Peer: &walker.Peer{ID: pid}},
+		{Kind: crawler.KindPeer, Timestamp: now, Crawl: "active", Peer: &walker.Peer{ID: pid}},
+		{Kind: crawler.KindCrawlEnd, Timestamp: now, Crawl: "passive"},
+		// the begin of this crawl was never consumed
+		{Kind: crawler.KindPeer, Timestamp: now, Crawl: "unknown", Peer: &walker.Peer{ID: pid}},
+	}
+	for i, m := range messages {
+		if err := e.Add(uint64(i+1), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e.Pending() != 5 {
+		t.Fatalf("expected 5 pending messages, got %v", e.Pending())
+	}
+	if e.crawls["active"].graph == e.crawls["passive"].graph {
+		t.Fatalf("crawls share a graph")
+	}
+	if _, ok := e.skipped["unknown"]; !ok {
+		t.Fatalf("messages of a crawl without begin were not skipped")
+	}
+}
+
+func TestAddReplay(t *testing.T) {
+	pid, err := peer.Decode(samplePeerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewExporter(zap.NewNop(), nil, nil)
+	// state after recovering with an open legacy crawl that began at seqn 3
+	e.committed = 10
+	e.crawls["seqn-3"] = &openCrawl{id: 1, graph: graph.New()}
+	e.legacyCrawl = "seqn-3"
+
+	if err := e.Add(3, &crawler.NatsMessage{Kind: crawler.KindCrawlBegin}); err != nil {
+		t.Fatal(err)
+	}
+	if e.legacyCrawl != "seqn-3" {
+		t.Fatalf("unexpected legacy crawl %v", e.legacyCrawl)
+	}
+	if err := e.Add(4, &crawler.NatsMessage{Kind: crawler.KindPeer, Peer: &walker.Peer{ID: pid}}); err != nil {
+		t.Fatal(err)
+	}
+	if e.Pending() != 0 {
+		t.Fatalf("committed messages were queued again")
+	}
+	if err := e.Add(11, &crawler.NatsMessage{Kind: crawler.KindCrawlEnd}); err != nil {
+		t.Fatal(err)
+	}
+	if e.Pending() != 1 || e.pending[0].crawl != "seqn-3" {
+		t.Fatalf("unexpected pending messages %+v", e.pending)
+	}
+}
+
+func TestAddEvent(t *testing.T) {
+	pid, err := peer.Decode(samplePeerId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewExporter(zap.NewNop(), nil, nil)
+	e.committed = 10
+
+	// the first crawl of the continuous crawler already ended
+	leave := &crawler.NatsMessage{Kind: crawler.KindEvent, Crawl: "continuous", Event: &tcrawler.Event{Kind: tcrawler.EventLeave, ID: pid, SessionLength: time.Hour}}
+	if err := e.Add(5, leave); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Add(11, leave); err != nil {
+		t.Fatal(err)
+	}
+	if e.Pending() != 1 || e.pending[0].crawl != "continuous" {
+		t.Fatalf("unexpected pending messages %+v", e.pending)
+	}
+	if _, ok := e.skipped["continuous"]; ok {
+		t.Fatalf("events were skipped")
+	}
+
+	row := eventRow("continuous", 11, leave)
+	if length, ok := row[len(row)-1].(*time.Duration); !ok || *length != time.Hour {
+		t.Fatalf("unexpected session length %v", row[len(row)-1])
+	}
+	if err := e.Add(12, &crawler.NatsMessage{Kind: crawler.KindEvent}); err == nil {
+		t.Fatal("expected an error for an event message without event")
+	}
+}
@@ -1,279 +1,178 @@
 package pg_crawler_exporter
 
 import (
-	"encoding/json"
-	"net"
-	"strconv"
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/diogo464/ipfs-telemetry/backend"
 	"github.com/diogo464/ipfs-telemetry/backend/crawler"
-	"github.com/diogo464/telemetry/walker"
-	"github.com/libp2p/go-libp2p/core/peer"
-	"github.com/multiformats/go-multiaddr"
+	"github.com/diogo464/ipfs-telemetry/backend/enrich"
+	"github.com/jackc/pgx/v5"
+	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
-	"github.com/oschwald/geoip2-golang"
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 
-	_ "embed"
+	"embed"
 )
 
-//go:embed schema.sql
-var schema string
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
 
 var FlagRecreate *cli.BoolFlag = &cli.BoolFlag{
 	Name:  "recreate",
-	Usage: "recreate the postgres database schema",
+	Usage: "revert every migration of the postgres database schema and apply them again, dropping all data",
 	Value: false,
 }
 
+var FlagBatchSize *cli.IntFlag = &cli.IntFlag{
+	Name:  "batch-size",
+	Usage: "maximum number of messages written to postgres in a single transaction",
+	Value: 1000,
+}
+
+var FlagBatchWait *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "batch-wait",
+	Usage: "how long to wait for a batch to fill before writing it",
+	Value: time.Second,
+}
+
+var FlagMaxCrawlAge *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "max-crawl-age",
+	Usage: "crawls that did not end this long after they began are considered abandoned and are not resumed after a restart",
+	Value: 24 * time.Hour,
+}
+
+var FlagRetryDelay *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "retry-delay",
+	Usage: "how long to wait before resuming from the database after failing to read or write crawler messages",
+	Value: backend.DefaultNakDelay,
+}
+
+// Name of the ordered consumer in the subject of dead lettered messages
+const deadLetterConsumer = "crawler-pg-exporter"
+
 var Command *cli.Command = &cli.Command{
 	Name:        "pg-crawler-exporter",
 	Description: "export crawler information to postgres",
 	Flags: []cli.Flag{
 		FlagRecreate,
+		FlagBatchSize,
+		FlagBatchWait,
+		FlagMaxCrawlAge,
+		FlagRetryDelay,
 	},
 	Action: main,
 }
 
-const (
-	ProtocolTcp = "tcp"
-	ProtocolUdp = "udp"
-)
-
-type PublicAddress struct {
-	Ip       net.IP
-	Port     uint16
-	Protocol string
-}
-
 func main(c *cli.Context) error {
 	logger := backend.ServiceSetup(c, "pg-crawler-exporter")
 
 	conn := backend.PostgresClient(logger, c)
 	nc := backend.NatsClient(logger, c)
 	js := backend.NatsJetstream(logger, nc)
+	backend.NatsValidateStream(c.Context, logger, js, crawler.StreamCrawler)
 
-	defer conn.Close(c.Context)
-
-	cityDbPath := "./GeoLite2-City.mmdb"
-	asnDbPath := "./GeoLite2-ASN.mmdb"
-	cityDb, err := geoip2.Open(cityDbPath)
-	if err != nil {
-		logger.Fatal("failed to open geolite City database", zap.String("path", cityDbPath), zap.Error(err))
-	}
-	asnDb, err := geoip2.Open(asnDbPath)
-	if err != nil {
-		logger.Fatal("failed to open geolite ASN database", zap.String("path", asnDbPath), zap.Error(err))
-	}
-
-	recreate := c.Bool(FlagRecreate.Name)
-	if recreate {
-		conn.Exec(c.Context, "DROP SCHEMA crawler CASCADE")
-	}
+	defer func() { conn.Close(context.Background()) }()
 
-	logger.Debug("running schema", zap.String("schema", schema))
-	if _, err := conn.Exec(c.Context, schema); recreate && err != nil {
-		logger.Fatal("failed to execute schema", zap.Error(err))
-	}
+	enricher, err := NewEnricher(logger.Named("enrich"), c)
+	backend.FatalOnError(logger, err, "failed to open geoip databases")
+	defer enricher.Close()
 
-	consumer, err := js.CreateConsumer(c.Context, crawler.StreamCrawler, jetstream.ConsumerConfig{
-		Description: "crawler postgres exporter",
-	})
-	if err != nil {
-		logger.Fatal("failed to create crawler consumer", zap.Error(err))
-	}
+	err = SetupSchema(c.Context, logger, conn, c.Bool(FlagRecreate.Name))
+	backend.FatalOnError(logger, err, "failed to execute schema")
 
-	peerIds := make(map[peer.ID]uint64)
-	crawlBegin := time.Time{}
-	crawlInProgress := false
-	cctx, err := consumer.Consume(func(msg jetstream.Msg) {
-		metadata, _ := msg.Metadata()
-		logger.Info("received crawler message", zap.Int("size", len(msg.Data())), zap.Uint64("seqn", metadata.Sequence.Stream))
-		cmsg := crawler.NatsMessage{}
-		if err := json.Unmarshal(msg.Data(), &cmsg); err != nil {
-			logger.Error("failed to decode crawler nats message", zap.Error(err))
-			return
+	// the database holds the position in the stream, after a failure the exporter resumes from it
+	for c.Context.Err() == nil {
+		err := consume(c, logger, conn, js, enricher)
+		if err == nil || c.Context.Err() != nil {
+			break
+		}
+		delay := c.Duration(FlagRetryDelay.Name)
+		logger.Error("failed to export crawler messages, resuming from the database", zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-time.After(delay):
+		case <-c.Context.Done():
 		}
 
-		switch cmsg.Kind {
-		case crawler.KindCrawlBegin:
-			peerIds = make(map[peer.ID]uint64)
-			logger.Info("crawl started")
-
-			if _, err := conn.Exec(c.Context, "DELETE FROM crawler.peer WHERE crawl = NULL"); err != nil {
-				logger.Fatal("failed to remove existing peers without associated crawl", zap.Error(err))
-			}
-
-			crawlBegin = cmsg.Timestamp
-			crawlInProgress = true
-		case crawler.KindCrawlEnd:
-			peerIds = make(map[peer.ID]uint64)
-			logger.Info("crawl ended")
-
-			if crawlInProgress {
-				tx, err := conn.Begin(c.Context)
-				if err != nil {
-					logger.Fatal("failed to create transaction", zap.Error(err))
-				}
-
-				row := tx.QueryRow(c.Context, "INSERT INTO crawler.crawl(timestamp_begin, timestamp_end) VALUES($1, $2) RETURNING id", crawlBegin, cmsg.Timestamp)
-
-				var id int
-				if err := row.Scan(&id); err != nil {
-					logger.Fatal("failed to obtain id of newly created crawl", zap.Error(err))
-				}
-
-				if _, err := tx.Exec(c.Context, "UPDATE crawler.peer SET crawl = $1 WHERE crawl IS NULL", id); err != nil {
-					logger.Fatal("failed to update crawl id on existing peers", zap.Int("id", id), zap.Error(err))
-				}
-
-				if err := tx.Commit(c.Context); err != nil {
-					logger.Fatal("failed to commit transaction", zap.Error(err))
-				}
+		if conn.IsClosed() && c.Context.Err() == nil {
+			logger.Info("reconnecting to the database")
+			if reconnected, err := backend.PostgresConnect(c); err == nil {
+				conn = reconnected
 			} else {
-				tx, err := conn.Begin(c.Context)
-				if err != nil {
-					logger.Fatal("failed to create transaction", zap.Error(err))
-				}
-				if _, err := tx.Exec(c.Context, "DELETE FROM crawler.peer WHERE crawl IS NULL"); err != nil {
-					logger.Fatal("failed to remove existing crawl peers", zap.Error(err))
-				}
-				if err := tx.Commit(c.Context); err != nil {
-					logger.Fatal("failed to commit transaction", zap.Error(err))
-				}
-			}
-
-			crawlInProgress = false
-		case crawler.KindPeer:
-			p := cmsg.Peer
-			addrs := make([]string, len(p.Addresses))
-			for i, maddr := range p.Addresses {
-				addrs[i] = maddr.String()
-			}
-			protocols := make([]string, len(p.Protocols))
-			for i, proto := range p.Protocols {
-				protocols[i] = string(proto)
+				logger.Error("failed to reconnect to the database", zap.Error(err))
 			}
-
-			var ip *string
-			var country *string
-			var city *string
-			var asn *uint
-			var asnOrg *string
-			var latitude *float64
-			var longitude *float64
-			if pip, ok := ExtractFirstPublicIp(p.Addresses); ok {
-				if gasn, err := asnDb.ASN(pip); err == nil {
-					asn = &gasn.AutonomousSystemNumber
-					asnOrg = &gasn.AutonomousSystemOrganization
-				} else {
-					logger.Warn("failed to obtain ip asn information", zap.String("ip", pip.String()), zap.Error(err))
-				}
-				if gcity, err := cityDb.City(pip); err == nil {
-					if countryName, ok := gcity.Country.Names["en"]; ok {
-						country = &countryName
-					}
-					if cityName, ok := gcity.City.Names["en"]; ok {
-						city = &cityName
-					}
-					latitude = &gcity.Location.Latitude
-					longitude = &gcity.Location.Longitude
-				} else {
-					logger.Warn("failed to obtain ip city information", zap.String("ip", pip.String()), zap.Error(err))
-				}
-
-				ip = new(string)
-				*ip = pip.String()
-			}
-
-			if s, ok := peerIds[p.ID]; ok {
-				logger.Fatal("peer id already exists", zap.String("id", p.ID.String()), zap.Uint64("seqn", s))
-			}
-			peerIds[p.ID] = metadata.Sequence.Stream
-			_, err := conn.Exec(c.Context,
-				"INSERT INTO crawler.peer(timestamp, peer_id, agent, addresses, protocols, dht_entries, ip, asn, asn_org, country, city, latitude, longitude) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
-				cmsg.Timestamp, p.ID.String(), p.Agent, addrs, protocols, len(p.Buckets), ip, asn, asnOrg, country, city, latitude, longitude)
-			if err != nil {
-				logger.Fatal("failed to insert peer entry into database", zap.Error(err))
-			}
-		default:
-			logger.Fatal("unknown crawler message kind", zap.String("kind", cmsg.Kind))
 		}
-
-		if err := msg.Ack(); err != nil {
-			logger.Fatal("failed to ack jetstream message", zap.Error(err))
-		}
-	})
-	if err != nil {
-		logger.Fatal("failed to create nats consumer to crawler stream", zap.String("stream", crawler.StreamCrawler), zap.Error(err))
 	}
-	<-cctx.Closed()
 
-	return nil
+	return nc.Drain()
 }
 
-func containsProtocol(addr multiaddr.Multiaddr, name string) bool {
-	for _, c := range addr {
-		if c.Protocol().Name == name {
-			return true
-		}
+// Recover the exporter state and export crawler messages until the context is canceled or reading or writing them fails.
+// Messages that can not be exported are dead lettered and skipped.
+func consume(c *cli.Context, logger *zap.Logger, conn *pgx.Conn, js jetstream.JetStream, enricher *enrich.Enricher) error {
+	exporter := NewExporter(logger, conn, enricher)
+	start, err := exporter.Recover(c.Context, c.Duration(FlagMaxCrawlAge.Name))
+	if err != nil {
+		return fmt.Errorf("failed to recover exporter state: %w", err)
 	}
-	return false
-}
 
-func ExtractFirstPublicIp(maddrs []multiaddr.Multiaddr) (net.IP, bool) {
-	for _, maddr := range maddrs {
-		if containsProtocol(maddr, "p2p-circuit") {
-			continue
-		}
-
-		ip := net.ParseIP(maddr[0].Value())
-		if ip == nil {
-			continue
-		}
-
-		if ip.IsPrivate() {
-			continue
-		}
-
-		return ip, true
+	// an ordered consumer delivers every message in order from the recovered position
+	config := jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{crawler.SubjectCrawler},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	}
+	if start > 0 {
+		config.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		config.OptStartSeq = start
+	}
+	logger.Info("consuming crawler stream", zap.Uint64("start-seqn", start))
+	consumer, err := js.OrderedConsumer(c.Context, crawler.StreamCrawler, config)
+	if err != nil {
+		return fmt.Errorf("failed to create crawler consumer: %w", err)
 	}
-	return nil, false
-}
-
-func ExtractPublicAddresses(maddrs []multiaddr.Multiaddr) []PublicAddress {
-	addrs := make([]PublicAddress, 0)
-	for _, maddr := range maddrs {
-		if containsProtocol(maddr, "p2p-circuit") {
-			continue
-		}
 
-		if len(maddr) < 4 {
-			continue
+	for c.Context.Err() == nil {
+		batch, err := consumer.Fetch(c.Int(FlagBatchSize.Name), jetstream.FetchMaxWait(c.Duration(FlagBatchWait.Name)))
+		if err != nil {
+			if c.Context.Err() != nil {
+				break
+			}
+			return fmt.Errorf("failed to fetch crawler messages: %w", err)
 		}
 
-		ip := net.ParseIP(maddr[0].Value())
-		if ip == nil {
-			continue
+		for msg := range batch.Messages() {
+			metadata, _ := msg.Metadata()
+			cmsg, err := backend.NatsJetstreamDecode[crawler.NatsMessage](msg)
+			if err == nil {
+				err = exporter.Add(metadata.Sequence.Stream, cmsg)
+			}
+			if err != nil {
+				// ordered consumers do not ack, the message is skipped once it is dead lettered
+				if derr := backend.NatsDeadLetter(c.Context, js, deadLetterConsumer, msg, err); derr != nil {
+					logger.Error("failed to dead letter crawler message", zap.Uint64("seqn", metadata.Sequence.Stream), zap.NamedError("cause", err), zap.Error(derr))
+				} else {
+					logger.Error("dead lettered crawler message", zap.Uint64("seqn", metadata.Sequence.Stream), zap.Error(err))
+				}
+			}
 		}
-		protocol := maddr[1].Protocol().Name
-		port, err := strconv.ParseUint(maddr[1].Value(), 10, 16)
-		if err != nil {
-			continue
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) && c.Context.Err() == nil {
+			return fmt.Errorf("failed to fetch crawler messages: %w", err)
 		}
 
-		addrs = append(addrs, PublicAddress{
-			Ip:       ip,
-			Port:     uint16(port),
-			Protocol: protocol,
-		})
+		if err := exporter.Flush(c.Context); err != nil {
+			if c.Context.Err() != nil {
+				break
+			}
+			return fmt.Errorf("failed to flush crawler messages: %w", err)
+		}
 	}
-	return addrs
+	return nil
 }
 
-func foo(db *geoip2.Reader, p *walker.Peer) {
-	// for _, addr := range p.Addresses {
-	// }
+// Create the enricher from the global geoip flags, the databases default to the working directory
+func NewEnricher(logger *zap.Logger, c *cli.Context) (*enrich.Enricher, error) {
+	return enrich.FromFlags(logger, c, enrich.WithCityDatabase(DefaultGeoIPCityPath), enrich.WithASNDatabase(DefaultGeoIPAsnPath))
 }
@@ -0,0 +1 @@
+DROP SCHEMA crawler CASCADE;
@@ -1,12 +1,13 @@
-CREATE SCHEMA crawler;
+-- databases created before migrations already have these tables
+CREATE SCHEMA IF NOT EXISTS crawler;
 
-CREATE TABLE crawler.crawl(
+CREATE TABLE IF NOT EXISTS crawler.crawl(
     id                  SERIAL PRIMARY KEY,
     timestamp_begin     TIMESTAMP NOT NULL,
     timestamp_end       TIMESTAMP NOT NULL
 );
 
-CREATE TABLE crawler.peer(
+CREATE TABLE IF NOT EXISTS crawler.peer(
     id          SERIAL PRIMARY KEY,
     crawl       INTEGER REFERENCES crawler.crawl(id),
     timestamp   TIMESTAMP NOT NULL,
@@ -27,4 +28,4 @@ CREATE TABLE crawler.peer(
     CONSTRAINT craw_peer_id_uniq UNIQUE (crawl, peer_id)
 );
 
-CREATE INDEX crawler_peer_crawl_index ON crawler.peer USING HASH (crawl);
+CREATE INDEX IF NOT EXISTS crawler_peer_crawl_index ON crawler.peer USING HASH (crawl);
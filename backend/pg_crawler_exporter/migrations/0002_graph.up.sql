@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS crawler.graph(
+    crawl                   INTEGER PRIMARY KEY REFERENCES crawler.crawl(id),
+    nodes                   INTEGER NOT NULL,
+    edges                   INTEGER NOT NULL,
+    reachable               INTEGER NOT NULL,
+    unreachable             INTEGER NOT NULL,
+    stale_entries           INTEGER NOT NULL,
+    out_degree              JSONB NOT NULL,
+    in_degree               JSONB NOT NULL,
+    unreachable_in_degree   JSONB NOT NULL
+);
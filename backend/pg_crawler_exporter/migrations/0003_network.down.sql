@@ -0,0 +1,4 @@
+ALTER TABLE crawler.peer DROP CONSTRAINT craw_peer_id_uniq;
+DELETE FROM crawler.peer WHERE network <> 'amino';
+ALTER TABLE crawler.peer DROP COLUMN network;
+ALTER TABLE crawler.peer ADD CONSTRAINT craw_peer_id_uniq UNIQUE (crawl, peer_id);
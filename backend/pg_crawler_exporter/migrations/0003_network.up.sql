@@ -0,0 +1,5 @@
+ALTER TABLE crawler.peer ADD COLUMN IF NOT EXISTS network VARCHAR(255) NOT NULL DEFAULT 'amino';
+
+-- the same peer can be found in several networks of a crawl
+ALTER TABLE crawler.peer DROP CONSTRAINT IF EXISTS craw_peer_id_uniq;
+ALTER TABLE crawler.peer ADD CONSTRAINT craw_peer_id_uniq UNIQUE (crawl, network, peer_id);
@@ -0,0 +1,2 @@
+ALTER TABLE crawler.peer DROP COLUMN implementation;
+ALTER TABLE crawler.peer DROP COLUMN version;
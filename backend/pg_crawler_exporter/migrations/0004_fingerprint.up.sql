@@ -0,0 +1,2 @@
+ALTER TABLE crawler.peer ADD COLUMN IF NOT EXISTS implementation VARCHAR(64);
+ALTER TABLE crawler.peer ADD COLUMN IF NOT EXISTS version VARCHAR(255);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS crawler.error(
+    id          SERIAL PRIMARY KEY,
+    crawl       INTEGER REFERENCES crawler.crawl(id),
+    timestamp   TIMESTAMP NOT NULL,
+    network     VARCHAR(255) NOT NULL,
+    peer_id     VARCHAR(512) NOT NULL,
+    addresses   VARCHAR(512) ARRAY NOT NULL,
+    stage       VARCHAR(64) NOT NULL,
+    reason      VARCHAR(64) NOT NULL,
+    error       TEXT NOT NULL,
+    -- failure of each dialed address: address, transport, reason and error
+    dials       JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS crawler_error_crawl_index ON crawler.error USING HASH (crawl);
+CREATE INDEX IF NOT EXISTS crawler_error_reason_index ON crawler.error (reason);
@@ -0,0 +1,13 @@
+DROP TABLE crawler.ingest;
+
+ALTER TABLE crawler.error DROP COLUMN seqn;
+ALTER TABLE crawler.peer DROP COLUMN seqn;
+
+DELETE FROM crawler.graph WHERE crawl IN (SELECT id FROM crawler.crawl WHERE timestamp_end IS NULL);
+DELETE FROM crawler.error WHERE crawl IN (SELECT id FROM crawler.crawl WHERE timestamp_end IS NULL);
+DELETE FROM crawler.peer WHERE crawl IN (SELECT id FROM crawler.crawl WHERE timestamp_end IS NULL);
+DELETE FROM crawler.crawl WHERE timestamp_end IS NULL;
+ALTER TABLE crawler.crawl ALTER COLUMN timestamp_end SET NOT NULL;
+ALTER TABLE crawler.crawl DROP COLUMN end_seqn;
+ALTER TABLE crawler.crawl DROP COLUMN begin_seqn;
+ALTER TABLE crawler.crawl DROP COLUMN crawl_id;
@@ -0,0 +1,19 @@
+-- crawls are created when they begin and closed when they end, rows reference them from the start
+ALTER TABLE crawler.crawl ADD COLUMN crawl_id VARCHAR(64) UNIQUE;
+ALTER TABLE crawler.crawl ADD COLUMN begin_seqn BIGINT;
+ALTER TABLE crawler.crawl ADD COLUMN end_seqn BIGINT;
+ALTER TABLE crawler.crawl ALTER COLUMN timestamp_end DROP NOT NULL;
+
+-- rows of the crawl in progress were only assigned a crawl when it ended
+DELETE FROM crawler.peer WHERE crawl IS NULL;
+DELETE FROM crawler.error WHERE crawl IS NULL;
+
+-- stream sequence of the message each row was inserted from, makes redelivered messages no-ops
+ALTER TABLE crawler.peer ADD COLUMN seqn BIGINT UNIQUE;
+ALTER TABLE crawler.error ADD COLUMN seqn BIGINT UNIQUE;
+
+-- last stream sequence committed by the exporter
+CREATE TABLE crawler.ingest(
+    stream  VARCHAR(255) PRIMARY KEY,
+    seqn    BIGINT NOT NULL
+);
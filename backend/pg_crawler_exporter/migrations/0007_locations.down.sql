@@ -0,0 +1,3 @@
+ALTER TABLE crawler.peer DROP COLUMN locations;
+ALTER TABLE crawler.peer DROP COLUMN hosting_provider;
+ALTER TABLE crawler.peer DROP COLUMN country_code;
@@ -0,0 +1,5 @@
+ALTER TABLE crawler.peer ADD COLUMN country_code VARCHAR(8);
+-- provider that owns the autonomous system of the first public address, null if it is not a hosting provider
+ALTER TABLE crawler.peer ADD COLUMN hosting_provider VARCHAR(255);
+-- location of every public address of the peer
+ALTER TABLE crawler.peer ADD COLUMN locations JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,18 @@
+-- joins, leaves and address changes of the live peer set of continuous crawlers
+CREATE TABLE IF NOT EXISTS crawler.event(
+    id                  SERIAL PRIMARY KEY,
+    -- identity of the first crawl of the continuous crawler
+    crawl_id            VARCHAR(64) NOT NULL,
+    seqn                BIGINT UNIQUE NOT NULL,
+    timestamp           TIMESTAMP NOT NULL,
+    network             VARCHAR(255) NOT NULL,
+    peer_id             VARCHAR(512) NOT NULL,
+    kind                VARCHAR(64) NOT NULL,
+    addresses           VARCHAR(512) ARRAY NOT NULL,
+    previous_addresses  VARCHAR(512) ARRAY NOT NULL,
+    -- only set for leaves
+    session_length      INTERVAL
+);
+
+CREATE INDEX IF NOT EXISTS crawler_event_peer_index ON crawler.event (peer_id, timestamp);
+CREATE INDEX IF NOT EXISTS crawler_event_timestamp_index ON crawler.event (timestamp);
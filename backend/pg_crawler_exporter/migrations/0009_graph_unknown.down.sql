@@ -0,0 +1 @@
+ALTER TABLE crawler.graph DROP COLUMN unknown;
@@ -0,0 +1,3 @@
+-- peers found in routing tables that were never queried, they used to be counted as unreachable.
+-- null for crawls stored before the split, their unreachable column includes the unknown peers
+ALTER TABLE crawler.graph ADD COLUMN unknown INTEGER;
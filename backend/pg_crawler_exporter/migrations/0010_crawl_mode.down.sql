@@ -0,0 +1 @@
+ALTER TABLE crawler.crawl DROP COLUMN mode;
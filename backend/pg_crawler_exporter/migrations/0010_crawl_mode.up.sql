@@ -0,0 +1,2 @@
+-- how the peers of the crawl were found: active, continuous or passive. crawls stored before were all active
+ALTER TABLE crawler.crawl ADD COLUMN mode VARCHAR(16) NOT NULL DEFAULT 'active';
@@ -0,0 +1,183 @@
+package pg_monitor_exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/pgmigrate"
+	"github.com/diogo464/telemetry"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// Migrations of the monitor schema
+func Migrations() (*pgmigrate.Component, error) {
+	migrations, err := pgmigrate.Load(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &pgmigrate.Component{Name: "monitor", Migrations: migrations}, nil
+}
+
+// Migrate the monitor schema to the latest version. If recreate is true every migration is reverted first, dropping all data.
+func SetupSchema(ctx context.Context, logger *zap.Logger, db *pgx.Conn, recreate bool) error {
+	component, err := Migrations()
+	if err != nil {
+		return err
+	}
+	if recreate {
+		return pgmigrate.Reset(ctx, logger, db, component)
+	}
+	return pgmigrate.Up(ctx, logger, db, component)
+}
+
+// Replace the set of active peers with the ones in the message
+func ExportActive(ctx context.Context, db *pgx.Conn, active *monitor.ActiveMessage) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM monitor.active"); err != nil {
+		return err
+	}
+
+	for _, peerId := range active.Peers {
+		tx.Exec(ctx, "INSERT INTO monitor.active(peer_id) VALUES ($1)", peerId.String())
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Exporter writes monitor exports into postgres.
+// Exporting the same export more than once has no effect, so messages can be redelivered.
+type Exporter struct {
+	logger *zap.Logger
+	conn   *pgx.Conn
+	// monthly partitions known to exist, ex: event_2024_03
+	partitions map[string]struct{}
+}
+
+func NewExporter(logger *zap.Logger, conn *pgx.Conn) *Exporter {
+	return &Exporter{
+		logger:     logger,
+		conn:       conn,
+		partitions: make(map[string]struct{}),
+	}
+}
+
+// Export the session, properties, bandwidth and events of a single export in one transaction
+func (e *Exporter) Export(ctx context.Context, exp *monitor.Export) error {
+	peerId := exp.Peer.String()
+	observedAt := exp.ObservedAt.UTC()
+	// bandwidth and probe exports of a peer whose telemetry was never collected have no session, it is stored as null
+	var session any
+	if exp.Session != telemetry.InvalidSession {
+		session = exp.Session.String()
+	}
+
+	if err := e.ensurePartition(ctx, "bandwidth", observedAt); err != nil {
+		return err
+	}
+	for _, events := range exp.Events {
+		for _, event := range events.Events {
+			if err := e.ensurePartition(ctx, "event", event.Timestamp.UTC()); err != nil {
+				return err
+			}
+		}
+	}
+
+	tx, err := e.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	if session != nil {
+		batch.Queue(
+			`INSERT INTO monitor.session(peer_id, session, first_seen, last_seen) VALUES($1, $2, $3, $3)
+			ON CONFLICT (peer_id, session) DO UPDATE SET
+				first_seen = LEAST(monitor.session.first_seen, EXCLUDED.first_seen),
+				last_seen = GREATEST(monitor.session.last_seen, EXCLUDED.last_seen)`,
+			peerId, session, observedAt)
+	}
+
+	for _, property := range exp.Properties {
+		valueString, valueInteger, err := monitor.PropertyValue(property.Value)
+		if err != nil {
+			return backend.Poison(fmt.Errorf("property %v: %w", property.Name, err))
+		}
+		batch.Queue(
+			`INSERT INTO monitor.property(peer_id, session, scope, name, description, observed_at, value_string, value_integer) VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (peer_id, session, scope, name) DO UPDATE SET
+				description = EXCLUDED.description,
+				observed_at = EXCLUDED.observed_at,
+				value_string = EXCLUDED.value_string,
+				value_integer = EXCLUDED.value_integer
+			WHERE monitor.property.observed_at <= EXCLUDED.observed_at`,
+			peerId, session, property.Scope.Name, property.Name, property.Description, observedAt, valueString, valueInteger)
+	}
+
+	if b := exp.Bandwidth; b != nil {
+		batch.Queue(
+			`INSERT INTO monitor.bandwidth(peer_id, session, observed_at, upload_rate, download_rate, rtt, jitter, upload, download) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT DO NOTHING`,
+			peerId, session, observedAt, int64(b.UploadRate), int64(b.DownloadRate), interval(b.Rtt), interval(b.Jitter), b.Upload, b.Download)
+	}
+
+	for _, events := range exp.Events {
+		d := events.Descriptor
+		for _, event := range events.Events {
+			payload := monitor.EventPayload(event.Data)
+			hash := sha256.Sum256(payload)
+			batch.Queue(
+				`INSERT INTO monitor.event(peer_id, session, scope, name, description, timestamp, payload_hash, payload) VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+				ON CONFLICT DO NOTHING`,
+				peerId, session, d.Scope.Name, d.Name, d.Description, event.Timestamp.UTC(), hash[:], string(payload))
+		}
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to insert export of peer %v: %w", peerId, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Create the monthly partition of table that contains t, if it does not exist yet
+func (e *Exporter) ensurePartition(ctx context.Context, table string, t time.Time) error {
+	name, from, to := partitionFor(table, t)
+	if _, ok := e.partitions[name]; ok {
+		return nil
+	}
+
+	e.logger.Info("creating partition", zap.String("partition", name))
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS monitor.%s PARTITION OF monitor.%s FOR VALUES FROM ('%s') TO ('%s')",
+		name, table, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if _, err := e.conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to create partition %v: %w", name, err)
+	}
+	e.partitions[name] = struct{}{}
+	return nil
+}
+
+// Name and bounds of the monthly partition of table that contains t
+func partitionFor(table string, t time.Time) (string, time.Time, time.Time) {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	return fmt.Sprintf("%s_%04d_%02d", table, from.Year(), int(from.Month())), from, to
+}
+
+func interval(d time.Duration) pgtype.Interval {
+	return pgtype.Interval{Microseconds: d.Microseconds(), Valid: true}
+}
@@ -0,0 +1,16 @@
+package pg_monitor_exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPartitionFor(t *testing.T) {
+	name, from, to := partitionFor("event", time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC))
+	if name != "event_2024_12" {
+		t.Fatalf("unexpected partition name %v", name)
+	}
+	if !from.Equal(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected partition bounds %v - %v", from, to)
+	}
+}
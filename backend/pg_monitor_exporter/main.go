@@ -6,27 +6,35 @@ import (
 	"github.com/diogo464/ipfs-telemetry/backend"
 	"github.com/diogo464/ipfs-telemetry/backend/crawler"
 	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 
-	_ "embed"
+	"embed"
 )
 
-//go:embed schema.sql
-var schema string
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
 
 var FlagRecreate *cli.BoolFlag = &cli.BoolFlag{
 	Name:  "recreate",
-	Usage: "recreate the postgres database schema",
+	Usage: "revert every migration of the postgres database schema and apply them again, dropping all data",
 	Value: false,
 }
 
+var FlagNakDelay *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "nak-delay",
+	Usage: "how long nats waits before redelivering an export that could not be written",
+	Value: backend.DefaultNakDelay,
+}
+
 var Command *cli.Command = &cli.Command{
 	Name:        "pg-monitor-exporter",
 	Description: "export monitor information to postgres",
 	Flags: []cli.Flag{
 		FlagRecreate,
+		FlagNakDelay,
 	},
 	Action: main,
 }
@@ -39,15 +47,8 @@ func main(c *cli.Context) error {
 	js := backend.NatsJetstream(logger, nc)
 	defer db.Close(c.Context)
 
-	recreate := c.Bool(FlagRecreate.Name)
-	if recreate {
-		db.Exec(c.Context, "DROP SCHEMA monitor CASCADE")
-	}
-
-	logger.Debug("running schema", zap.String("schema", schema))
-	if _, err := db.Exec(c.Context, schema); recreate && err != nil {
-		logger.Fatal("failed to execute schema", zap.Error(err))
-	}
+	err := SetupSchema(c.Context, logger, db, c.Bool(FlagRecreate.Name))
+	backend.FatalOnError(logger, err, "failed to execute schema")
 
 	startTime := time.Now().Add(-time.Second * 10)
 	consumer, err := js.CreateConsumer(c.Context, monitor.Stream_Monitor, jetstream.ConsumerConfig{
@@ -59,23 +60,43 @@ func main(c *cli.Context) error {
 	})
 	backend.FatalOnError(logger, err, "failed to create monitor consumer")
 
+	// active peers are published every few seconds, a message that fails is superseded by the next one
 	cctx, err := consumer.Consume(func(msg jetstream.Msg) {
-		active := backend.NatsJetstreamDecodeJson[monitor.ActiveMessage](logger, msg)
-		tx, err := db.Begin(c.Context)
-		backend.FatalOnError(logger, err, "failed to start transaction")
+		active, err := backend.NatsJetstreamDecode[monitor.ActiveMessage](msg)
+		if err == nil {
+			err = ExportActive(c.Context, db, active)
+		}
+		if err != nil {
+			logger.Warn("failed to export active peers", zap.Error(err))
+		}
+	})
+	backend.FatalOnError(logger, err, "failed to create nats consumer to crawler stream", zap.String("stream", crawler.StreamCrawler))
+	defer cctx.Stop()
 
-		_, err = tx.Exec(c.Context, "DELETE FROM monitor.active")
-		backend.FatalOnError(logger, err, "failed to delete current active peers")
+	// exports are idempotent so a durable consumer can redeliver anything that was not acked
+	exportConsumer := backend.NatsConsumer(c.Context, logger, js, streams.StreamMonitor, streams.ConsumerMonitorPg)
 
-		for _, peerId := range active.Peers {
-			tx.Exec(c.Context, "INSERT INTO monitor.active(peer_id) VALUES ($1)", peerId.String())
+	exporter := NewExporter(logger, db)
+	handler := backend.NewMsgHandler(logger, js, streams.ConsumerMonitorPg, c.Duration(FlagNakDelay.Name))
+	ectx, err := exportConsumer.Consume(func(msg jetstream.Msg) {
+		export, err := backend.NatsJetstreamDecode[monitor.Export](msg)
+		if err == nil {
+			err = exporter.Export(c.Context, export)
 		}
-
-		err = tx.Commit(c.Context)
-		backend.FatalOnError(logger, err, "failed to commit transaction")
+		handler.Settle(c.Context, msg, err)
 	})
-	backend.FatalOnError(logger, err, "failed to create nats consumer to crawler stream", zap.String("stream", crawler.StreamCrawler))
+	backend.FatalOnError(logger, err, "failed to create nats consumer to monitor export subject")
+
+	select {
+	case <-cctx.Closed():
+	case <-ectx.Closed():
+	case <-c.Context.Done():
+	}
+	// wait for the export in progress before closing the database connection, an interrupted export is handed back to nats
+	cctx.Drain()
+	ectx.Drain()
 	<-cctx.Closed()
+	<-ectx.Closed()
 
-	return nil
+	return nc.Drain()
 }
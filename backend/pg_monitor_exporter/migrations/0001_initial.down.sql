@@ -0,0 +1 @@
+DROP SCHEMA monitor CASCADE;
@@ -0,0 +1,6 @@
+-- databases created before migrations already have these tables
+CREATE SCHEMA IF NOT EXISTS monitor;
+
+CREATE TABLE IF NOT EXISTS monitor.active(
+    peer_id VARCHAR(255) NOT NULL
+);
@@ -0,0 +1,4 @@
+DROP TABLE monitor.event;
+DROP TABLE monitor.bandwidth;
+DROP TABLE monitor.property;
+DROP TABLE monitor.session;
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS monitor.session(
+    peer_id     VARCHAR(255) NOT NULL,
+    session     UUID NOT NULL,
+    first_seen  TIMESTAMP NOT NULL,
+    last_seen   TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (peer_id, session)
+);
+
+-- properties are constant during a session, the last observed value is kept
+CREATE TABLE IF NOT EXISTS monitor.property(
+    peer_id         VARCHAR(255) NOT NULL,
+    session         UUID NOT NULL,
+    scope           VARCHAR(255) NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    description     TEXT NOT NULL,
+    observed_at     TIMESTAMP NOT NULL,
+    value_string    TEXT,
+    value_integer   BIGINT,
+
+    PRIMARY KEY (peer_id, session, scope, name)
+);
+
+-- partitions are created by the exporter, one per month
+CREATE TABLE IF NOT EXISTS monitor.bandwidth(
+    peer_id         VARCHAR(255) NOT NULL,
+    session         UUID NOT NULL,
+    observed_at     TIMESTAMP NOT NULL,
+    upload_rate     BIGINT NOT NULL,
+    download_rate   BIGINT NOT NULL,
+    rtt             INTERVAL NOT NULL,
+    jitter          INTERVAL NOT NULL,
+    -- full upload and download measurements, including samples
+    upload          JSONB NOT NULL,
+    download        JSONB NOT NULL,
+
+    PRIMARY KEY (peer_id, observed_at)
+) PARTITION BY RANGE (observed_at);
+
+-- partitions are created by the exporter, one per month
+CREATE TABLE IF NOT EXISTS monitor.event(
+    peer_id         VARCHAR(255) NOT NULL,
+    session         UUID NOT NULL,
+    scope           VARCHAR(255) NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    description     TEXT NOT NULL,
+    timestamp       TIMESTAMP NOT NULL,
+    -- sha256 of the payload, distinguishes events with the same timestamp
+    payload_hash    BYTEA NOT NULL,
+    payload         JSONB NOT NULL,
+
+    PRIMARY KEY (peer_id, session, scope, name, timestamp, payload_hash)
+) PARTITION BY RANGE (timestamp);
+
+CREATE INDEX IF NOT EXISTS monitor_event_name_index ON monitor.event (name, timestamp);
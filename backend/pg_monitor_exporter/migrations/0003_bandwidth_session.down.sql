@@ -0,0 +1,2 @@
+DELETE FROM monitor.bandwidth WHERE session IS NULL;
+ALTER TABLE monitor.bandwidth ALTER COLUMN session SET NOT NULL;
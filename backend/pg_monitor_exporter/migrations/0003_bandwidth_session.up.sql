@@ -0,0 +1,2 @@
+-- bandwidth tests of peers whose telemetry was never collected have no session
+ALTER TABLE monitor.bandwidth ALTER COLUMN session DROP NOT NULL;
@@ -1,5 +0,0 @@
-CREATE SCHEMA monitor;
-
-CREATE TABLE monitor.active(
-    peer_id VARCHAR(255) NOT NULL
-);
@@ -0,0 +1,203 @@
+// Package pgmigrate applies numbered up and down sql migrations to postgres.
+//
+// Migrations are files named <version>_<name>.up.sql and <version>_<name>.down.sql, versions start at 1 and have no gaps.
+// The applied migrations of each component are recorded in public.schema_migration and concurrent migrations
+// of the same component are serialized with an advisory lock.
+package pgmigrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const versionTable = `CREATE TABLE IF NOT EXISTS public.schema_migration(
+    component   VARCHAR(64) NOT NULL,
+    version     INTEGER NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    applied_at  TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    PRIMARY KEY (component, version)
+)`
+
+var filenameRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// The migrations of a single component, ex: the crawler schema
+type Component struct {
+	Name       string
+	Migrations []Migration
+}
+
+// Latest version of the component
+func (c *Component) Latest() uint {
+	return uint(len(c.Migrations))
+}
+
+// Load the migrations in dir, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := filenameRegex.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration filename %q", entry.Name())
+		}
+		version, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: m[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %v has two names, %q and %q", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version := uint(1); version <= uint(len(byVersion)); version++ {
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("missing migration %v", version)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %v_%v requires both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	return migrations, nil
+}
+
+// Version of the last migration applied to the component, 0 if none was applied
+func Current(ctx context.Context, conn *pgx.Conn, component string) (uint, error) {
+	if _, err := conn.Exec(ctx, versionTable); err != nil {
+		return 0, fmt.Errorf("failed to create migration table: %w", err)
+	}
+	var version uint
+	row := conn.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM public.schema_migration WHERE component = $1", component)
+	if err := row.Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read version of %v: %w", component, err)
+	}
+	return version, nil
+}
+
+// Apply every pending migration of the component
+func Up(ctx context.Context, logger *zap.Logger, conn *pgx.Conn, component *Component) error {
+	return To(ctx, logger, conn, component, component.Latest())
+}
+
+// Migrate the component up or down to the target version, 0 removes every migration
+func To(ctx context.Context, logger *zap.Logger, conn *pgx.Conn, component *Component, target uint) error {
+	if target > component.Latest() {
+		return fmt.Errorf("invalid target version %v, the latest version of %v is %v", target, component.Name, component.Latest())
+	}
+
+	unlock, err := lock(ctx, conn, component.Name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := Current(ctx, conn, component.Name)
+	if err != nil {
+		return err
+	}
+	if current > component.Latest() {
+		return fmt.Errorf("database version %v of %v is newer than the latest known version %v", current, component.Name, component.Latest())
+	}
+
+	for current < target {
+		m := component.Migrations[current]
+		logger.Info("applying migration", zap.String("component", component.Name), zap.Uint("version", m.Version), zap.String("name", m.Name))
+		if err := apply(ctx, conn, m.Up, "INSERT INTO public.schema_migration(component, version, name) VALUES($1, $2, $3)", component.Name, m.Version, m.Name); err != nil {
+			return fmt.Errorf("failed to apply migration %v_%v of %v: %w", m.Version, m.Name, component.Name, err)
+		}
+		current += 1
+	}
+	for current > target {
+		m := component.Migrations[current-1]
+		logger.Info("reverting migration", zap.String("component", component.Name), zap.Uint("version", m.Version), zap.String("name", m.Name))
+		if err := apply(ctx, conn, m.Down, "DELETE FROM public.schema_migration WHERE component = $1 AND version = $2", component.Name, m.Version); err != nil {
+			return fmt.Errorf("failed to revert migration %v_%v of %v: %w", m.Version, m.Name, component.Name, err)
+		}
+		current -= 1
+	}
+	return nil
+}
+
+// Revert every migration of the component and apply them again, dropping all of its data
+func Reset(ctx context.Context, logger *zap.Logger, conn *pgx.Conn, component *Component) error {
+	if err := To(ctx, logger, conn, component, 0); err != nil {
+		return err
+	}
+	return Up(ctx, logger, conn, component)
+}
+
+// Run the migration sql and record it in the version table in a single transaction
+func apply(ctx context.Context, conn *pgx.Conn, sql string, record string, args ...any) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Take the advisory lock of the component, blocking until any other migration of it finishes
+func lock(ctx context.Context, conn *pgx.Conn, component string) (func(), error) {
+	key := "pgmigrate/" + component
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock(hashtext($1))", key); err != nil {
+		return nil, fmt.Errorf("failed to lock migrations of %v: %w", component, err)
+	}
+	return func() {
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", key)
+	}, nil
+}
+
+// Pending migrations of the component given its current version
+func Pending(component *Component, current uint) []Migration {
+	if current >= component.Latest() {
+		return nil
+	}
+	return slices.Clone(component.Migrations[current:])
+}
@@ -0,0 +1,53 @@
+package pgmigrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_second.up.sql":   {Data: []byte("CREATE TABLE b()")},
+		"m/0002_second.down.sql": {Data: []byte("DROP TABLE b")},
+		"m/0001_first.up.sql":    {Data: []byte("CREATE TABLE a()")},
+		"m/0001_first.down.sql":  {Data: []byte("DROP TABLE a")},
+	}
+	migrations, err := Load(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Version != 2 || migrations[1].Down != "DROP TABLE b" {
+		t.Fatalf("unexpected migrations: %+v", migrations)
+	}
+
+	component := &Component{Name: "test", Migrations: migrations}
+	if pending := Pending(component, 1); len(pending) != 1 || pending[0].Name != "second" {
+		t.Fatalf("unexpected pending migrations: %+v", pending)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"gap": {
+			"m/0001_first.up.sql":   {Data: []byte("a")},
+			"m/0001_first.down.sql": {Data: []byte("a")},
+			"m/0003_third.up.sql":   {Data: []byte("c")},
+			"m/0003_third.down.sql": {Data: []byte("c")},
+		},
+		"missing down": {
+			"m/0001_first.up.sql": {Data: []byte("a")},
+		},
+		"bad name": {
+			"m/first.sql": {Data: []byte("a")},
+		},
+		"two names": {
+			"m/0001_first.up.sql":   {Data: []byte("a")},
+			"m/0001_other.down.sql": {Data: []byte("a")},
+		},
+	}
+	for name, fsys := range cases {
+		if _, err := Load(fsys, "m"); err == nil {
+			t.Fatalf("%v: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package replay
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	SourceJetstream = "jetstream"
+	SourceArchive   = "archive"
+)
+
+var (
+	FLAG_SOURCE = &cli.StringFlag{
+		Name:  "source",
+		Usage: fmt.Sprintf("where to read messages from, %s or %s", SourceJetstream, SourceArchive),
+		Value: SourceJetstream,
+	}
+
+	FLAG_TARGET = &cli.StringFlag{
+		Name:     "target",
+		Usage:    fmt.Sprintf("exporter to replay messages into, one of %s", strings.Join(TargetNames(), ", ")),
+		Required: true,
+	}
+
+	FLAG_STREAM = &cli.StringFlag{
+		Name:  "stream",
+		Usage: "jetstream stream to replay from, defaults to the stream consumed by the target",
+	}
+
+	FLAG_SUBJECT = &cli.StringSliceFlag{
+		Name:  "subject",
+		Usage: "only replay messages with this subject, can be repeated. defaults to the subjects consumed by the target",
+	}
+
+	FLAG_START_SEQ = &cli.Uint64Flag{
+		Name:  "start-seq",
+		Usage: "first stream sequence number to replay, combined with --since only messages after both are replayed",
+	}
+
+	FLAG_END_SEQ = &cli.Uint64Flag{
+		Name:  "end-seq",
+		Usage: "last stream sequence number to replay, defaults to the last message in the stream when the replay starts",
+	}
+
+	FLAG_SINCE = &cli.TimestampFlag{
+		Name:   "since",
+		Usage:  "only replay messages published at or after this time (RFC3339)",
+		Layout: "2006-01-02T15:04:05Z07:00",
+	}
+
+	FLAG_UNTIL = &cli.TimestampFlag{
+		Name:   "until",
+		Usage:  "only replay messages published before this time (RFC3339)",
+		Layout: "2006-01-02T15:04:05Z07:00",
+	}
+
+	FLAG_RATE = &cli.Float64Flag{
+		Name:  "rate",
+		Usage: "maximum number of messages replayed per second, 0 means unlimited",
+	}
+
+	FLAG_DRY_RUN = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "decode messages without exporting them",
+	}
+)
@@ -0,0 +1,171 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/archive"
+	"github.com/diogo464/ipfs-telemetry/backend/vm_otlp_exporter"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const progressInterval = time.Second * 10
+
+var Command *cli.Command = &cli.Command{
+	Name:        "replay",
+	Description: "replay retained stream messages or archived exports into an exporter",
+	Flags: append([]cli.Flag{
+		FLAG_SOURCE,
+		FLAG_TARGET,
+		FLAG_STREAM,
+		FLAG_SUBJECT,
+		FLAG_START_SEQ,
+		FLAG_END_SEQ,
+		FLAG_SINCE,
+		FLAG_UNTIL,
+		FLAG_RATE,
+		FLAG_DRY_RUN,
+		vm_otlp_exporter.FlagEventRules,
+	}, archive.StorageFlags...),
+	Action: main,
+}
+
+type stats struct {
+	read     uint64
+	skipped  uint64
+	failed   uint64
+	exported uint64
+}
+
+func main(c *cli.Context) error {
+	logger := backend.ServiceSetup(c, "replay")
+	dryRun := c.Bool(FLAG_DRY_RUN.Name)
+
+	target, err := NewTarget(c, logger, c.String(FLAG_TARGET.Name), dryRun)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	source, err := newSource(c, logger, target)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if r := c.Float64(FLAG_RATE.Name); r > 0 {
+		limiter = rate.NewLimiter(rate.Limit(r), 1)
+	}
+
+	logger.Info("starting replay",
+		zap.String("source", c.String(FLAG_SOURCE.Name)),
+		zap.String("target", c.String(FLAG_TARGET.Name)),
+		zap.Bool("dry-run", dryRun))
+
+	s, err := replay(c.Context, logger, source, target, limiter)
+	logger.Info("replay finished",
+		zap.Uint64("read", s.read),
+		zap.Uint64("skipped", s.skipped),
+		zap.Uint64("failed", s.failed),
+		zap.Uint64("exported", s.exported))
+	return err
+}
+
+func newSource(c *cli.Context, logger *zap.Logger, target *Target) (Source, error) {
+	var since, until time.Time
+	if t := c.Timestamp(FLAG_SINCE.Name); t != nil {
+		since = *t
+	}
+	if t := c.Timestamp(FLAG_UNTIL.Name); t != nil {
+		until = *t
+	}
+
+	switch c.String(FLAG_SOURCE.Name) {
+	case SourceJetstream:
+		nc := backend.NatsClient(logger, c)
+		js := backend.NatsJetstream(logger, nc)
+
+		stream := target.Stream
+		if c.IsSet(FLAG_STREAM.Name) {
+			stream = c.String(FLAG_STREAM.Name)
+		}
+		subjects := target.Subjects
+		if c.IsSet(FLAG_SUBJECT.Name) {
+			subjects = c.StringSlice(FLAG_SUBJECT.Name)
+		}
+
+		return NewJetstreamSource(c.Context, js, JetstreamRange{
+			Stream:   stream,
+			Subjects: subjects,
+			StartSeq: c.Uint64(FLAG_START_SEQ.Name),
+			EndSeq:   c.Uint64(FLAG_END_SEQ.Name),
+			Since:    since,
+			Until:    until,
+		})
+	case SourceArchive:
+		storage, err := archive.StorageFromFlags(c)
+		if err != nil {
+			return nil, err
+		}
+		if storage == nil {
+			return nil, fmt.Errorf("the %s source requires --%s or --%s", SourceArchive, archive.FLAG_ARCHIVE_DIR.Name, archive.FLAG_ARCHIVE_S3_ENDPOINT.Name)
+		}
+		return NewArchiveSource(c.Context, storage, since, until)
+	default:
+		return nil, fmt.Errorf("unknown replay source %q", c.String(FLAG_SOURCE.Name))
+	}
+}
+
+func replay(ctx context.Context, logger *zap.Logger, source Source, target *Target, limiter *rate.Limiter) (stats, error) {
+	s := stats{}
+	lastProgress := time.Now()
+	for {
+		msg, err := source.Next(ctx)
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return s, fmt.Errorf("failed to read message: %w", err)
+		}
+		s.read += 1
+
+		if time.Since(lastProgress) > progressInterval {
+			logger.Info("replay progress",
+				zap.Uint64("read", s.read),
+				zap.Uint64("exported", s.exported),
+				zap.Uint64("seqn", msg.Sequence),
+				zap.Time("timestamp", msg.Timestamp))
+			lastProgress = time.Now()
+		}
+
+		if !target.Accepts(msg.Subject) {
+			s.skipped += 1
+			continue
+		}
+
+		value, err := target.Decode(msg)
+		if err != nil {
+			logger.Error("failed to decode message", zap.String("subject", msg.Subject), zap.Uint64("seqn", msg.Sequence), zap.Error(err))
+			s.failed += 1
+			continue
+		}
+
+		if target.Export == nil {
+			continue
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return s, err
+		}
+		if err := target.Export(ctx, msg, value); err != nil {
+			return s, fmt.Errorf("failed to export message with seqn %v: %w", msg.Sequence, err)
+		}
+		s.exported += 1
+	}
+}
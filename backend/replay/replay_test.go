@@ -0,0 +1,139 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/archive"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+func TestReplayArchive(t *testing.T) {
+	ctx := context.Background()
+	storage, err := archive.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := archive.NewWriter(storage, monitor.ArchiveDatasetExport, archive.WithMaxRecords(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid, err := peer.Decode("12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 8; i++ {
+		exp := &monitor.Export{
+			ObservedAt: base.Add(time.Duration(i) * time.Minute),
+			Peer:       pid,
+			Session:    telemetry.RandomSession(),
+		}
+		if err := writer.Write(exp.ObservedAt, exp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewArchiveSource(ctx, storage, base.Add(2*time.Minute), base.Add(6*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	exported := make([]time.Time, 0)
+	target := &Target{
+		Subjects: []string{monitor.Subject_Export},
+		Decode:   decoder[monitor.Export](),
+		Export: func(_ context.Context, _ *Message, v any) error {
+			exported = append(exported, v.(*monitor.Export).ObservedAt)
+			return nil
+		},
+		Close: func() {},
+	}
+
+	s, err := replay(ctx, zap.NewNop(), source, target, rate.NewLimiter(rate.Inf, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.exported != 4 || len(exported) != 4 {
+		t.Fatalf("expected 4 exports, got %v", exported)
+	}
+	for i, ts := range exported {
+		if !ts.Equal(base.Add(time.Duration(i+2) * time.Minute)) {
+			t.Fatalf("unexpected export order %v", exported)
+		}
+	}
+}
+
+// Source of the messages of a slice, in order
+type sliceSource struct {
+	messages []*Message
+}
+
+func (s *sliceSource) Next(ctx context.Context) (*Message, error) {
+	if len(s.messages) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func (s *sliceSource) Close() {}
+
+func TestReplayPgMonitorExports(t *testing.T) {
+	pid, err := peer.Decode("12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &sliceSource{}
+	for _, m := range []struct {
+		subject string
+		value   any
+	}{
+		{monitor.Subject_Active, &monitor.ActiveMessage{Peers: []peer.ID{pid}}},
+		{monitor.Subject_Export, &monitor.Export{Peer: pid, Session: telemetry.RandomSession()}},
+		{monitor.Subject_Discover, &monitor.DiscoveryMessage{ID: pid}},
+	} {
+		msg, err := backend.NatsMsg(backend.EncodingJson, m.subject, m.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		source.messages = append(source.messages, &Message{Subject: msg.Subject, Header: msg.Header, Data: msg.Data})
+	}
+
+	target, err := newPgMonitorTarget(nil, zap.NewNop(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := make([]any, 0)
+	target.Export = func(_ context.Context, _ *Message, v any) error {
+		exported = append(exported, v)
+		return nil
+	}
+
+	s, err := replay(context.Background(), zap.NewNop(), source, target, rate.NewLimiter(rate.Inf, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.exported != 2 || s.skipped != 1 {
+		t.Fatalf("expected the active and export messages to be exported and the discovery skipped, got %+v", s)
+	}
+	if _, ok := exported[0].(*monitor.ActiveMessage); !ok {
+		t.Fatalf("expected an active message, got %T", exported[0])
+	}
+	if exp, ok := exported[1].(*monitor.Export); !ok || exp.Peer != pid {
+		t.Fatalf("expected the export of the peer, got %v", exported[1])
+	}
+}
@@ -0,0 +1,234 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/archive"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	jetstreamFetchBatch   = 256
+	jetstreamFetchMaxWait = time.Second * 5
+)
+
+// A message to be replayed
+type Message struct {
+	Subject string
+	Header  nats.Header
+	Data    []byte
+	// Stream sequence number, zero if the message did not come from a stream
+	Sequence  uint64
+	Timestamp time.Time
+}
+
+// Source of messages to replay. Next returns io.EOF when there are no more messages.
+type Source interface {
+	Next(ctx context.Context) (*Message, error)
+	Close()
+}
+
+var _ (Source) = (*jetstreamSource)(nil)
+
+type JetstreamRange struct {
+	Stream   string
+	Subjects []string
+	// First and last stream sequence, inclusive. Zero means unbounded.
+	StartSeq uint64
+	EndSeq   uint64
+	// Time window [Since, Until). Zero means unbounded.
+	// When combined with the sequence range only the messages in both are replayed.
+	Since time.Time
+	Until time.Time
+}
+
+// Replays messages retained in a JetStream stream using an ordered consumer.
+// The end of the range is fixed when the source is created so messages published during the replay are not included.
+type jetstreamSource struct {
+	consumer jetstream.Consumer
+	rng      JetstreamRange
+	batch    jetstream.MessageBatch
+	received int
+	done     bool
+}
+
+func NewJetstreamSource(ctx context.Context, js jetstream.JetStream, rng JetstreamRange) (Source, error) {
+	stream, err := js.Stream(ctx, rng.Stream)
+	if err != nil {
+		return nil, err
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rng.EndSeq == 0 || rng.EndSeq > info.State.LastSeq {
+		rng.EndSeq = info.State.LastSeq
+	}
+
+	cfg := jetstream.OrderedConsumerConfig{
+		FilterSubjects: rng.Subjects,
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	}
+	switch {
+	case rng.StartSeq != 0:
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = rng.StartSeq
+	case !rng.Since.IsZero():
+		since := rng.Since
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &since
+	}
+
+	consumer, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jetstreamSource{
+		consumer: consumer,
+		rng:      rng,
+		done:     info.State.Msgs == 0 || (rng.StartSeq != 0 && rng.StartSeq > rng.EndSeq),
+	}, nil
+}
+
+// Next implements Source
+func (s *jetstreamSource) Next(ctx context.Context) (*Message, error) {
+	for !s.done {
+		if s.batch == nil {
+			batch, err := s.consumer.Fetch(jetstreamFetchBatch, jetstream.FetchMaxWait(jetstreamFetchMaxWait))
+			if err != nil {
+				return nil, err
+			}
+			s.batch = batch
+			s.received = 0
+		}
+
+		var msg jetstream.Msg
+		select {
+		case msg = <-s.batch.Messages():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if msg == nil {
+			err := s.batch.Error()
+			s.batch = nil
+			if err != nil && err != nats.ErrTimeout && err != jetstream.ErrNoMessages {
+				return nil, err
+			}
+			if s.received == 0 {
+				// nothing left matching the filter
+				s.done = true
+			}
+			continue
+		}
+		s.received += 1
+
+		meta, err := msg.Metadata()
+		if err != nil {
+			return nil, err
+		}
+		if meta.Sequence.Stream > s.rng.EndSeq || (!s.rng.Until.IsZero() && !meta.Timestamp.Before(s.rng.Until)) {
+			s.done = true
+			break
+		}
+		if meta.Sequence.Stream == s.rng.EndSeq || meta.NumPending == 0 {
+			s.done = true
+		}
+		if !s.rng.Since.IsZero() && meta.Timestamp.Before(s.rng.Since) {
+			// delivery started from the start sequence, which is before the time window
+			continue
+		}
+
+		return &Message{
+			Subject:   msg.Subject(),
+			Header:    msg.Headers(),
+			Data:      msg.Data(),
+			Sequence:  meta.Sequence.Stream,
+			Timestamp: meta.Timestamp,
+		}, nil
+	}
+	return nil, io.EOF
+}
+
+// Close implements Source
+func (s *jetstreamSource) Close() {
+}
+
+var _ (Source) = (*archiveSource)(nil)
+
+// Replays monitor exports from the archive, ordered by file.
+type archiveSource struct {
+	reader    *archive.Reader
+	manifests []archive.Manifest
+	records   *archive.Records
+	since     time.Time
+	until     time.Time
+}
+
+func NewArchiveSource(ctx context.Context, storage archive.Storage, since, until time.Time) (Source, error) {
+	reader := archive.NewReader(storage, monitor.ArchiveDatasetExport)
+	manifests, err := reader.Manifests(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveSource{
+		reader:    reader,
+		manifests: manifests,
+		since:     since,
+		until:     until,
+	}, nil
+}
+
+// Next implements Source
+func (s *archiveSource) Next(ctx context.Context) (*Message, error) {
+	for {
+		if s.records == nil {
+			if len(s.manifests) == 0 {
+				return nil, io.EOF
+			}
+			records, err := s.reader.Open(ctx, s.manifests[0])
+			if err != nil {
+				return nil, err
+			}
+			s.records = records
+			s.manifests = s.manifests[1:]
+		}
+
+		if !s.records.Next() {
+			err := s.records.Err()
+			s.records.Close()
+			s.records = nil
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		ts := s.records.Timestamp()
+		if (!s.since.IsZero() && ts.Before(s.since)) || (!s.until.IsZero() && !ts.Before(s.until)) {
+			continue
+		}
+
+		header := nats.Header{}
+		header.Set(backend.HeaderContentType, backend.ContentTypeJson)
+		return &Message{
+			Subject:   monitor.Subject_Export,
+			Header:    header,
+			Data:      s.records.Bytes(),
+			Timestamp: ts,
+		}, nil
+	}
+}
+
+// Close implements Source
+func (s *archiveSource) Close() {
+	if s.records != nil {
+		s.records.Close()
+	}
+}
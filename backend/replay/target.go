@@ -0,0 +1,200 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/crawler"
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/pg_crawler_exporter"
+	"github.com/diogo464/ipfs-telemetry/backend/pg_monitor_exporter"
+	"github.com/diogo464/ipfs-telemetry/backend/vm_otlp_exporter"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	TargetVmOtlp    = "vm-otlp"
+	TargetPgMonitor = "pg-monitor"
+	TargetPgCrawler = "pg-crawler"
+
+	pgCrawlerBatchSize = 1000
+)
+
+// An exporter messages can be replayed into
+type Target struct {
+	// Stream the target consumes from
+	Stream string
+	// Subjects the target consumes, other messages are skipped
+	Subjects []string
+	// Decode a message into the value passed to Export
+	Decode func(*Message) (any, error)
+	// Export a decoded message. Nil when running in dry-run mode.
+	Export func(context.Context, *Message, any) error
+	// Release any resources held by the target
+	Close func()
+}
+
+func (t *Target) Accepts(subject string) bool {
+	for _, s := range t.Subjects {
+		if s == subject {
+			return true
+		}
+	}
+	return false
+}
+
+type targetConstructor func(c *cli.Context, logger *zap.Logger, dryRun bool) (*Target, error)
+
+var targets = map[string]targetConstructor{
+	TargetVmOtlp:    newVmOtlpTarget,
+	TargetPgMonitor: newPgMonitorTarget,
+	TargetPgCrawler: newPgCrawlerTarget,
+}
+
+// Names of the available targets
+func TargetNames() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func NewTarget(c *cli.Context, logger *zap.Logger, name string, dryRun bool) (*Target, error) {
+	constructor, ok := targets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown replay target %q, available targets: %v", name, TargetNames())
+	}
+	return constructor(c, logger, dryRun)
+}
+
+func decoder[T any]() func(*Message) (any, error) {
+	return func(msg *Message) (any, error) {
+		value := new(T)
+		if err := backend.NatsDecode(msg.Header, msg.Data, value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// Decode each subject into its own message type
+func subjectDecoder(decoders map[string]func(*Message) (any, error)) func(*Message) (any, error) {
+	return func(msg *Message) (any, error) {
+		decode, ok := decoders[msg.Subject]
+		if !ok {
+			return nil, fmt.Errorf("no decoder for subject %v", msg.Subject)
+		}
+		return decode(msg)
+	}
+}
+
+func newVmOtlpTarget(c *cli.Context, logger *zap.Logger, dryRun bool) (*Target, error) {
+	target := &Target{
+		Stream:   monitor.Stream_Monitor,
+		Subjects: []string{monitor.Subject_Export},
+		Decode:   decoder[monitor.Export](),
+		Close:    func() {},
+	}
+	if !dryRun {
+		mapper, err := vm_otlp_exporter.EventMapper(c.String(vm_otlp_exporter.FlagEventRules.Name))
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := vm_otlp_exporter.NewExporter(
+			c.String(backend.Flag_VmUrl.Name),
+			vm_otlp_exporter.WithLogger(logger.Named("exporter")),
+			vm_otlp_exporter.WithEventMapper(mapper),
+		)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("replaying into victoria metrics", zap.String("export-url", exporter.ExportUrl()))
+		target.Export = func(ctx context.Context, _ *Message, v any) error {
+			return exporter.Export(ctx, v.(*monitor.Export))
+		}
+	}
+	return target, nil
+}
+
+func newPgMonitorTarget(c *cli.Context, logger *zap.Logger, dryRun bool) (*Target, error) {
+	target := &Target{
+		Stream:   monitor.Stream_Monitor,
+		Subjects: []string{monitor.Subject_Active, monitor.Subject_Export},
+		Decode: subjectDecoder(map[string]func(*Message) (any, error){
+			monitor.Subject_Active: decoder[monitor.ActiveMessage](),
+			monitor.Subject_Export: decoder[monitor.Export](),
+		}),
+		Close: func() {},
+	}
+	if !dryRun {
+		db := backend.PostgresClient(logger, c)
+		if err := pg_monitor_exporter.SetupSchema(c.Context, logger, db, false); err != nil {
+			db.Close(c.Context)
+			return nil, err
+		}
+		exporter := pg_monitor_exporter.NewExporter(logger.Named("exporter"), db)
+		target.Export = func(ctx context.Context, _ *Message, v any) error {
+			switch m := v.(type) {
+			case *monitor.ActiveMessage:
+				return pg_monitor_exporter.ExportActive(ctx, db, m)
+			case *monitor.Export:
+				// exports are idempotent, the ones already in the database are not duplicated
+				return exporter.Export(ctx, m)
+			default:
+				return fmt.Errorf("unexpected message %T", v)
+			}
+		}
+		target.Close = func() { db.Close(context.Background()) }
+	}
+	return target, nil
+}
+
+func newPgCrawlerTarget(c *cli.Context, logger *zap.Logger, dryRun bool) (*Target, error) {
+	target := &Target{
+		Stream:   crawler.StreamCrawler,
+		Subjects: []string{crawler.SubjectCrawler},
+		Decode:   decoder[crawler.NatsMessage](),
+		Close:    func() {},
+	}
+	if !dryRun {
+		enricher, err := pg_crawler_exporter.NewEnricher(logger.Named("enrich"), c)
+		if err != nil {
+			return nil, err
+		}
+		conn := backend.PostgresClient(logger, c)
+		if err := pg_crawler_exporter.SetupSchema(c.Context, logger, conn, false); err != nil {
+			conn.Close(c.Context)
+			enricher.Close()
+			return nil, err
+		}
+		exporter := pg_crawler_exporter.NewExporter(logger, conn, enricher)
+		// messages already in the database are skipped, the start sequence only matters to live consumers
+		if _, err := exporter.Recover(c.Context, pg_crawler_exporter.FlagMaxCrawlAge.Value); err != nil {
+			conn.Close(c.Context)
+			enricher.Close()
+			return nil, err
+		}
+		target.Export = func(ctx context.Context, msg *Message, v any) error {
+			if err := exporter.Add(msg.Sequence, v.(*crawler.NatsMessage)); err != nil {
+				return err
+			}
+			if exporter.Pending() >= pgCrawlerBatchSize {
+				return exporter.Flush(ctx)
+			}
+			return nil
+		}
+		target.Close = func() {
+			if err := exporter.Flush(context.Background()); err != nil {
+				logger.Error("failed to flush crawler messages", zap.Error(err))
+			}
+			conn.Close(context.Background())
+			enricher.Close()
+		}
+	}
+	return target, nil
+}
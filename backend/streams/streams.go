@@ -0,0 +1,218 @@
+// Package streams declares every JetStream stream and durable consumer used by the backend.
+//
+// The `nats-setup` command creates or updates them and services validate the ones they use on startup,
+// so a missing or misconfigured stream is reported before any message is published or consumed.
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+const (
+	StreamMonitor    = "monitor"
+	StreamCrawler    = "crawler"
+	StreamDeadLetter = "deadletter"
+
+	SubjectMonitorDiscover = "monitor.discover"
+	SubjectMonitorExport   = "monitor.export"
+	SubjectMonitorActive   = "monitor.active"
+	SubjectCrawler         = "crawler"
+	SubjectDeadLetter      = "deadletter"
+
+	ConsumerMonitorVmOtlp     = "monitor-vm-otlp-exporter"
+	ConsumerMonitorPg         = "monitor-pg-exporter"
+	ConsumerMonitorClickhouse = "monitor-clickhouse-exporter"
+	ConsumerCrawlerClickhouse = "crawler-clickhouse-exporter"
+)
+
+const (
+	defaultMonitorMaxBytes = 200 << 30
+	defaultCrawlerMaxBytes = 100 << 30
+	defaultDeadLetterBytes = 10 << 30
+	exporterAckWait        = time.Minute
+)
+
+// A stream and its durable consumers
+type Stream struct {
+	Config    jetstream.StreamConfig
+	Consumers []jetstream.ConsumerConfig
+}
+
+// Deployment specific settings of the streams
+type Options struct {
+	Replicas           int
+	MonitorMaxBytes    int64
+	CrawlerMaxBytes    int64
+	DeadLetterMaxBytes int64
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Replicas:           1,
+		MonitorMaxBytes:    defaultMonitorMaxBytes,
+		CrawlerMaxBytes:    defaultCrawlerMaxBytes,
+		DeadLetterMaxBytes: defaultDeadLetterBytes,
+	}
+}
+
+// Every stream used by the backend
+func Declarations(opts Options) []Stream {
+	exporter := func(name, description, subject string) jetstream.ConsumerConfig {
+		return jetstream.ConsumerConfig{
+			Durable:       name,
+			Description:   description,
+			FilterSubject: subject,
+			DeliverPolicy: jetstream.DeliverAllPolicy,
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			AckWait:       exporterAckWait,
+			ReplayPolicy:  jetstream.ReplayInstantPolicy,
+			MaxAckPending: -1,
+		}
+	}
+
+	return []Stream{
+		{
+			Config: jetstream.StreamConfig{
+				Name:        StreamMonitor,
+				Description: "monitor capture stream",
+				Subjects:    []string{"monitor.*"},
+				Retention:   jetstream.LimitsPolicy,
+				Storage:     jetstream.FileStorage,
+				MaxBytes:    opts.MonitorMaxBytes,
+				Replicas:    opts.Replicas,
+			},
+			Consumers: []jetstream.ConsumerConfig{
+				exporter(ConsumerMonitorVmOtlp, "monitor export victoria metrics exporter", SubjectMonitorExport),
+				exporter(ConsumerMonitorPg, "monitor export postgres exporter", SubjectMonitorExport),
+				exporter(ConsumerMonitorClickhouse, "monitor export clickhouse exporter", SubjectMonitorExport),
+			},
+		},
+		{
+			Config: jetstream.StreamConfig{
+				Name:        StreamCrawler,
+				Description: "crawler capture stream",
+				Subjects:    []string{SubjectCrawler, "crawler.*"},
+				Retention:   jetstream.LimitsPolicy,
+				Storage:     jetstream.FileStorage,
+				MaxBytes:    opts.CrawlerMaxBytes,
+				Replicas:    opts.Replicas,
+			},
+			Consumers: []jetstream.ConsumerConfig{
+				exporter(ConsumerCrawlerClickhouse, "crawler clickhouse exporter", SubjectCrawler),
+			},
+		},
+		{
+			// messages that could not be processed, kept for inspection with `nats stream view`
+			Config: jetstream.StreamConfig{
+				Name:        StreamDeadLetter,
+				Description: "messages rejected by their consumers",
+				Subjects:    []string{SubjectDeadLetter + ".>"},
+				Retention:   jetstream.LimitsPolicy,
+				Storage:     jetstream.FileStorage,
+				MaxBytes:    opts.DeadLetterMaxBytes,
+				Replicas:    opts.Replicas,
+			},
+		},
+	}
+}
+
+// Create or update every declared stream and consumer
+func Setup(ctx context.Context, logger *zap.Logger, js jetstream.JetStream, opts Options) error {
+	for _, stream := range Declarations(opts) {
+		logger.Info("setting up stream", zap.String("stream", stream.Config.Name))
+		if _, err := js.CreateOrUpdateStream(ctx, stream.Config); err != nil {
+			return fmt.Errorf("failed to setup stream %v: %w", stream.Config.Name, err)
+		}
+		for _, consumer := range stream.Consumers {
+			logger.Info("setting up consumer", zap.String("stream", stream.Config.Name), zap.String("consumer", consumer.Durable))
+			if _, err := js.CreateOrUpdateConsumer(ctx, stream.Config.Name, consumer); err != nil {
+				return fmt.Errorf("failed to setup consumer %v of stream %v: %w", consumer.Durable, stream.Config.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check that the stream exists and accepts its declared subjects
+func ValidateStream(ctx context.Context, js jetstream.JetStream, name string) error {
+	declared, err := declaration(name)
+	if err != nil {
+		return err
+	}
+	stream, err := js.Stream(ctx, name)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return fmt.Errorf("stream %v does not exist, run `backend nats-setup`", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get stream %v: %w", name, err)
+	}
+	return validateStreamConfig(&declared.Config, &stream.CachedInfo().Config)
+}
+
+// Validate the stream and the durable consumer and return the consumer
+func Consumer(ctx context.Context, js jetstream.JetStream, stream, consumer string) (jetstream.Consumer, error) {
+	if err := ValidateStream(ctx, js, stream); err != nil {
+		return nil, err
+	}
+	declared, _ := declaration(stream)
+	idx := slices.IndexFunc(declared.Consumers, func(c jetstream.ConsumerConfig) bool { return c.Durable == consumer })
+	if idx < 0 {
+		return nil, fmt.Errorf("consumer %v of stream %v is not declared", consumer, stream)
+	}
+
+	c, err := js.Consumer(ctx, stream, consumer)
+	if errors.Is(err, jetstream.ErrConsumerNotFound) {
+		return nil, fmt.Errorf("consumer %v of stream %v does not exist, run `backend nats-setup`", consumer, stream)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consumer %v of stream %v: %w", consumer, stream, err)
+	}
+	if err := validateConsumerConfig(stream, &declared.Consumers[idx], &c.CachedInfo().Config); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Subject a message of the stream rejected by the consumer is published on, ex: deadletter.monitor.monitor-pg-exporter
+func DeadLetterSubject(stream, consumer string) string {
+	return SubjectDeadLetter + "." + stream + "." + consumer
+}
+
+func declaration(name string) (*Stream, error) {
+	for _, stream := range Declarations(DefaultOptions()) {
+		if stream.Config.Name == name {
+			return &stream, nil
+		}
+	}
+	return nil, fmt.Errorf("stream %v is not declared", name)
+}
+
+// Only the settings services depend on are compared, limits and replicas are left to the deployment
+func validateStreamConfig(declared, actual *jetstream.StreamConfig) error {
+	for _, subject := range declared.Subjects {
+		if !slices.Contains(actual.Subjects, subject) {
+			return fmt.Errorf("stream %v does not accept subject %v, run `backend nats-setup`", declared.Name, subject)
+		}
+	}
+	if actual.Retention != declared.Retention {
+		return fmt.Errorf("stream %v has retention %v, expected %v", declared.Name, actual.Retention, declared.Retention)
+	}
+	return nil
+}
+
+func validateConsumerConfig(stream string, declared, actual *jetstream.ConsumerConfig) error {
+	if actual.FilterSubject != declared.FilterSubject || len(actual.FilterSubjects) > 0 {
+		return fmt.Errorf("consumer %v of stream %v has filter %q %v, expected %q, run `backend nats-setup`", declared.Durable, stream, actual.FilterSubject, actual.FilterSubjects, declared.FilterSubject)
+	}
+	if actual.AckPolicy != declared.AckPolicy {
+		return fmt.Errorf("consumer %v of stream %v has ack policy %v, expected %v, run `backend nats-setup`", declared.Durable, stream, actual.AckPolicy, declared.AckPolicy)
+	}
+	return nil
+}
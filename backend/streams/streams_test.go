@@ -0,0 +1,94 @@
+package streams
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestDeclarations(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replicas = 3
+	names := make(map[string]bool)
+	for _, stream := range Declarations(opts) {
+		if stream.Config.Replicas != 3 {
+			t.Fatalf("stream %v ignores the replicas option", stream.Config.Name)
+		}
+		for _, consumer := range stream.Consumers {
+			if names[consumer.Durable] {
+				t.Fatalf("consumer %v is declared twice", consumer.Durable)
+			}
+			names[consumer.Durable] = true
+			if !accepts(stream.Config.Subjects, consumer.FilterSubject) {
+				t.Fatalf("stream %v does not accept the filter of consumer %v", stream.Config.Name, consumer.Durable)
+			}
+		}
+	}
+	for _, name := range []string{ConsumerMonitorVmOtlp, ConsumerMonitorPg, ConsumerMonitorClickhouse, ConsumerCrawlerClickhouse} {
+		if !names[name] {
+			t.Fatalf("consumer %v is not declared", name)
+		}
+	}
+}
+
+func TestValidateStreamConfig(t *testing.T) {
+	declared, err := declaration(StreamCrawler)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := declared.Config
+	actual.MaxBytes = 1 << 20
+	actual.Replicas = 5
+	if err := validateStreamConfig(&declared.Config, &actual); err != nil {
+		t.Fatalf("limits and replicas must not be validated: %v", err)
+	}
+
+	actual.Subjects = []string{"crawler.*"}
+	if err := validateStreamConfig(&declared.Config, &actual); err == nil {
+		t.Fatalf("expected an error for a missing subject")
+	}
+
+	actual = declared.Config
+	actual.Retention = jetstream.WorkQueuePolicy
+	if err := validateStreamConfig(&declared.Config, &actual); err == nil {
+		t.Fatalf("expected an error for a different retention")
+	}
+}
+
+func TestValidateConsumerConfig(t *testing.T) {
+	declared, err := declaration(StreamMonitor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumer := declared.Consumers[0]
+
+	actual := consumer
+	actual.AckWait = 0
+	if err := validateConsumerConfig(StreamMonitor, &consumer, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	actual.FilterSubject = SubjectMonitorActive
+	if err := validateConsumerConfig(StreamMonitor, &consumer, &actual); err == nil {
+		t.Fatalf("expected an error for a different filter subject")
+	}
+
+	actual = consumer
+	actual.AckPolicy = jetstream.AckNonePolicy
+	if err := validateConsumerConfig(StreamMonitor, &consumer, &actual); err == nil {
+		t.Fatalf("expected an error for a different ack policy")
+	}
+}
+
+// Only single token wildcards are used by the declarations
+func accepts(subjects []string, subject string) bool {
+	for _, s := range subjects {
+		prefix, wildcard := strings.CutSuffix(s, "*")
+		if s == subject || (wildcard && strings.HasPrefix(subject, prefix) && !strings.Contains(subject[len(prefix):], ".")) {
+			return true
+		}
+	}
+	return false
+}
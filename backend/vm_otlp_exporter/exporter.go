@@ -0,0 +1,222 @@
+package vm_otlp_exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	"github.com/klauspost/compress/snappy"
+	v1_service "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	v1_common "go.opentelemetry.io/proto/otlp/common/v1"
+	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	v1_resource "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Resource attribute with the id of the peer the metrics were collected from
+	AttributePeer = "peer.id"
+	// Resource attribute with the telemetry session of the peer
+	AttributeSession = "session.id"
+)
+
+// StatusError is returned when VictoriaMetrics answers with a status other than 2xx
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %v: %v", e.StatusCode, e.Body)
+}
+
+// Rate limiting and server errors are worth retrying, every other status means the request is rejected
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Whether the request that failed with err may succeed if sent again
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	// connection errors and timeouts
+	return true
+}
+
+// Exporter pushes the OTLP metrics of monitor exports to VictoriaMetrics
+type Exporter struct {
+	l         *zap.Logger
+	opts      *options
+	exportUrl string
+}
+
+func NewExporter(vmUrl string, o ...Option) (*Exporter, error) {
+	opts := defaults()
+	if err := apply(opts, o...); err != nil {
+		return nil, err
+	}
+
+	exportUrl := fmt.Sprintf("%s/opentelemetry/v1/metrics", vmUrl)
+	if opts.format == FormatRemoteWrite {
+		exportUrl = fmt.Sprintf("%s/api/v1/write", vmUrl)
+	}
+	return &Exporter{
+		l:         opts.logger,
+		opts:      opts,
+		exportUrl: exportUrl,
+	}, nil
+}
+
+func (e *Exporter) ExportUrl() string {
+	return e.exportUrl
+}
+
+func (e *Exporter) Format() string {
+	return e.opts.format
+}
+
+// Export all the metrics in export with a single request
+func (e *Exporter) Export(ctx context.Context, export *monitor.Export) error {
+	rms, err := e.ResourceMetrics(export)
+	if err != nil {
+		return err
+	}
+	return e.Send(ctx, rms)
+}
+
+// Send the resource metrics in a single request, retrying with backoff while the failure is retryable
+func (e *Exporter) Send(ctx context.Context, rms []*v1.ResourceMetrics) error {
+	if len(rms) == 0 {
+		return nil
+	}
+
+	var payload []byte
+	header := http.Header{}
+	switch e.opts.format {
+	case FormatRemoteWrite:
+		series := toTimeSeries(rms)
+		if len(series) == 0 {
+			return nil
+		}
+		payload = snappy.Encode(nil, marshalWriteRequest(series))
+		header.Set("Content-Type", "application/x-protobuf")
+		header.Set("Content-Encoding", "snappy")
+		header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	default:
+		request := v1_service.ExportMetricsServiceRequest{ResourceMetrics: rms}
+		encoded, err := proto.Marshal(&request)
+		if err != nil {
+			return fmt.Errorf("failed to marshal export metrics request: %w", err)
+		}
+		payload = encoded
+		header.Set("Content-Type", "application/x-protobuf")
+	}
+
+	backoff := e.opts.backoff
+	for attempt := 0; ; attempt++ {
+		err := e.post(ctx, payload, header)
+		if err == nil || !Retryable(err) || attempt >= e.opts.retries {
+			return err
+		}
+
+		delay := backoff
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			delay = min(statusErr.RetryAfter, e.opts.maxBackoff)
+		}
+		e.l.Warn("failed to send metrics to victoria metrics, retrying", zap.Int("attempt", attempt+1), zap.Duration("delay", delay), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, e.opts.maxBackoff)
+	}
+}
+
+func (e *Exporter) post(ctx context.Context, payload []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.exportUrl, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+
+	res, err := e.opts.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to POST metrics to victoria metrics: %w", err)
+	}
+	defer res.Body.Close()
+
+	// the body is drained so the connection can be reused
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode/100 == 2 {
+		return nil
+	}
+
+	statusErr := &StatusError{StatusCode: res.StatusCode, Body: string(bytes.TrimSpace(body))}
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		statusErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return fmt.Errorf("failed to POST metrics to victoria metrics: %w", statusErr)
+}
+
+// Decode the OTLP metrics of an export and add the metrics created from its events.
+// The peer and session are added to the resource attributes, the session only if the export has one.
+func (e *Exporter) ResourceMetrics(export *monitor.Export) ([]*v1.ResourceMetrics, error) {
+	rms := make([]*v1.ResourceMetrics, 0, len(export.Metrics))
+	for _, metrics := range export.Metrics {
+		rm := new(v1.ResourceMetrics)
+		if err := proto.Unmarshal(metrics.OTLP, rm); err != nil {
+			return nil, fmt.Errorf("failed to decode resource metrics protobuf: %w", err)
+		}
+		rms = append(rms, rm)
+	}
+
+	if e.opts.mapper != nil {
+		if sm := e.opts.mapper.Map(export); sm != nil {
+			// the event metrics share the resource of the node's own metrics
+			if len(rms) == 0 {
+				rms = append(rms, new(v1.ResourceMetrics))
+			}
+			rms[0].ScopeMetrics = append(rms[0].ScopeMetrics, sm)
+		}
+	}
+
+	for _, rm := range rms {
+		if rm.Resource == nil {
+			rm.Resource = new(v1_resource.Resource)
+		}
+		setAttribute(rm.Resource, AttributePeer, export.Peer.String())
+		if export.Session != telemetry.InvalidSession {
+			setAttribute(rm.Resource, AttributeSession, export.Session.String())
+		}
+	}
+	return rms, nil
+}
+
+func setAttribute(resource *v1_resource.Resource, key string, value string) {
+	v := &v1_common.AnyValue{Value: &v1_common.AnyValue_StringValue{StringValue: value}}
+	for _, kv := range resource.Attributes {
+		if kv.Key == key {
+			kv.Value = v
+			return
+		}
+	}
+	resource.Attributes = append(resource.Attributes, &v1_common.KeyValue{Key: key, Value: v})
+}
@@ -0,0 +1,236 @@
+package vm_otlp_exporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/telemetry"
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/snappy"
+	"github.com/libp2p/go-libp2p/core/peer"
+	v1_common "go.opentelemetry.io/proto/otlp/common/v1"
+	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	v1_resource "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func stringAttribute(key, value string) *v1_common.KeyValue {
+	return &v1_common.KeyValue{Key: key, Value: &v1_common.AnyValue{Value: &v1_common.AnyValue_StringValue{StringValue: value}}}
+}
+
+func testResourceMetrics() *v1.ResourceMetrics {
+	sum := 7.5
+	return &v1.ResourceMetrics{
+		Resource: &v1_resource.Resource{Attributes: []*v1_common.KeyValue{
+			stringAttribute("service.name", "kubo"),
+			stringAttribute(AttributePeer, "spoofed"),
+		}},
+		ScopeMetrics: []*v1.ScopeMetrics{{Metrics: []*v1.Metric{
+			{Name: "connections", Data: &v1.Metric_Gauge{Gauge: &v1.Gauge{DataPoints: []*v1.NumberDataPoint{{
+				Attributes:   []*v1_common.KeyValue{stringAttribute("direction", "inbound")},
+				TimeUnixNano: uint64(2 * time.Second),
+				Value:        &v1.NumberDataPoint_AsInt{AsInt: 3},
+			}}}}},
+			{Name: "latency", Data: &v1.Metric_Histogram{Histogram: &v1.Histogram{DataPoints: []*v1.HistogramDataPoint{{
+				TimeUnixNano:   uint64(2 * time.Second),
+				Count:          3,
+				Sum:            &sum,
+				BucketCounts:   []uint64{1, 2, 0},
+				ExplicitBounds: []float64{1, 5},
+			}}}}},
+		}}},
+	}
+}
+
+func testExport(t *testing.T) *monitor.Export {
+	encoded, err := proto.Marshal(testResourceMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &monitor.Export{
+		Peer:    peer.ID("peer"),
+		Session: telemetry.Session(uuid.New()),
+		Metrics: []monitor.ExportMetrics{{OTLP: encoded}},
+	}
+}
+
+func attributeValue(attributes []*v1_common.KeyValue, key string) string {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return kv.Value.GetStringValue()
+		}
+	}
+	return ""
+}
+
+func TestResourceMetricsInjectsPeerAndSession(t *testing.T) {
+	export := testExport(t)
+	exporter, err := NewExporter("http://localhost:8428")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rms, err := exporter.ResourceMetrics(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rms) != 1 {
+		t.Fatalf("expected 1 resource metrics, got %v", len(rms))
+	}
+	attributes := rms[0].Resource.Attributes
+	if v := attributeValue(attributes, AttributePeer); v != export.Peer.String() {
+		t.Fatalf("unexpected peer attribute %q", v)
+	}
+	if v := attributeValue(attributes, AttributeSession); v != export.Session.String() {
+		t.Fatalf("unexpected session attribute %q", v)
+	}
+	if len(attributes) != 3 {
+		t.Fatalf("expected the peer attribute to be replaced, got %v attributes", len(attributes))
+	}
+}
+
+func TestResourceMetricsWithoutSession(t *testing.T) {
+	export := testExport(t)
+	export.Session = telemetry.InvalidSession
+	exporter, err := NewExporter("http://localhost:8428")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rms, err := exporter.ResourceMetrics(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := attributeValue(rms[0].Resource.Attributes, AttributeSession); v != "" {
+		t.Fatalf("exports without a session must not set the session attribute, got %q", v)
+	}
+}
+
+func TestToTimeSeries(t *testing.T) {
+	series := toTimeSeries([]*v1.ResourceMetrics{testResourceMetrics()})
+	// one gauge, three buckets, sum and count
+	if len(series) != 6 {
+		t.Fatalf("expected 6 series, got %v", len(series))
+	}
+
+	gauge := series[0]
+	expected := []label{{"__name__", "connections"}, {"direction", "inbound"}, {AttributePeer, "spoofed"}, {"service.name", "kubo"}}
+	if len(gauge.labels) != len(expected) {
+		t.Fatalf("unexpected labels %v", gauge.labels)
+	}
+	for i := range expected {
+		if gauge.labels[i] != expected[i] {
+			t.Fatalf("unexpected labels %v", gauge.labels)
+		}
+	}
+	if gauge.samples[0].value != 3 || gauge.samples[0].timestamp != 2000 {
+		t.Fatalf("unexpected sample %v", gauge.samples[0])
+	}
+
+	for i, want := range []struct {
+		le    string
+		value float64
+	}{{"1", 1}, {"5", 3}, {"+Inf", 3}} {
+		bucket := series[1+i]
+		if bucket.labels[0].value != "latency_bucket" || bucket.labels[1] != (label{"le", want.le}) || bucket.samples[0].value != want.value {
+			t.Fatalf("unexpected bucket %v", bucket)
+		}
+	}
+	if series[4].labels[0].value != "latency_sum" || series[4].samples[0].value != 7.5 {
+		t.Fatalf("unexpected sum %v", series[4])
+	}
+	if series[5].labels[0].value != "latency_count" || series[5].samples[0].value != 3 {
+		t.Fatalf("unexpected count %v", series[5])
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(server.URL, WithBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(context.Background(), testExport(t)); err != nil {
+		t.Fatal(err)
+	}
+	if attempts.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %v", attempts.Load())
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(server.URL, WithBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exporter.Export(context.Background(), testExport(t))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest || Retryable(err) {
+		t.Fatalf("expected a non retryable status error, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Fatalf("rejected requests must not be retried, got %v attempts", attempts.Load())
+	}
+}
+
+func TestSendRemoteWrite(t *testing.T) {
+	var series int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/write" || r.Header.Get("Content-Encoding") != "snappy" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		request, err := snappy.Decode(nil, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for len(request) > 0 {
+			num, typ, n := protowire.ConsumeTag(request)
+			if num != 1 || typ != protowire.BytesType {
+				http.Error(w, "unexpected field", http.StatusBadRequest)
+				return
+			}
+			request = request[n:]
+			_, n = protowire.ConsumeBytes(request)
+			request = request[n:]
+			series++
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(server.URL, WithFormat(FormatRemoteWrite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(context.Background(), testExport(t)); err != nil {
+		t.Fatal(err)
+	}
+	if series != 6 {
+		t.Fatalf("expected 6 series, got %v", series)
+	}
+}
@@ -1,27 +1,93 @@
 package vm_otlp_exporter
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
+	"time"
 
 	"github.com/diogo464/ipfs-telemetry/backend"
+	"github.com/diogo464/ipfs-telemetry/backend/eventmetrics"
 	"github.com/diogo464/ipfs-telemetry/backend/monitor"
+	"github.com/diogo464/ipfs-telemetry/backend/streams"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/urfave/cli/v2"
-	v1_service "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
 	"go.uber.org/zap"
-
-	"google.golang.org/protobuf/proto"
 )
 
+var FlagFormat *cli.StringFlag = &cli.StringFlag{
+	Name:  "format",
+	Usage: "format of the requests sent to victoria metrics, otlp or remote-write",
+	Value: FormatOtlp,
+}
+
+var FlagBatchSize *cli.IntFlag = &cli.IntFlag{
+	Name:  "batch-size",
+	Usage: "maximum number of exports sent to victoria metrics in a single request",
+	Value: 100,
+}
+
+var FlagBatchWait *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "batch-wait",
+	Usage: "how long to wait for a batch to fill before sending it",
+	Value: time.Second,
+}
+
+var FlagQueueSize *cli.IntFlag = &cli.IntFlag{
+	Name:  "queue-size",
+	Usage: "maximum number of messages buffered before the consumer stops pulling from nats",
+	Value: 1000,
+}
+
+var FlagRetries *cli.IntFlag = &cli.IntFlag{
+	Name:  "retries",
+	Usage: "number of times a failed request is retried before the batch is handed back to nats",
+	Value: 5,
+}
+
+var FlagRetryBackoff *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "retry-backoff",
+	Usage: "delay before the first retry, doubled after every failed attempt",
+	Value: time.Second,
+}
+
+var FlagRetryMaxBackoff *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "retry-max-backoff",
+	Usage: "maximum delay between retries",
+	Value: 30 * time.Second,
+}
+
+var FlagNakDelay *cli.DurationFlag = &cli.DurationFlag{
+	Name:  "nak-delay",
+	Usage: "how long nats waits before redelivering a batch that could not be sent",
+	Value: time.Minute,
+}
+
+var FlagEventRules *cli.StringFlag = &cli.StringFlag{
+	Name:  "event-rules",
+	Usage: "yaml file with the rules used to create metrics from events, the builtin rules are used if not set",
+}
+
 var Command *cli.Command = &cli.Command{
 	Name:        "vm-otlp-exporter",
 	Description: "export OTLP metrics to VictoriaMetrics",
-	Flags:       []cli.Flag{},
-	Action:      main,
+	Flags: []cli.Flag{
+		FlagFormat,
+		FlagBatchSize,
+		FlagBatchWait,
+		FlagQueueSize,
+		FlagRetries,
+		FlagRetryBackoff,
+		FlagRetryMaxBackoff,
+		FlagNakDelay,
+		FlagEventRules,
+	},
+	Action: main,
+}
+
+type pending struct {
+	msg jetstream.Msg
+	rms []*v1.ResourceMetrics
 }
 
 func main(c *cli.Context) error {
@@ -29,55 +95,182 @@ func main(c *cli.Context) error {
 
 	nc := backend.NatsClient(logger, c)
 	js := backend.NatsJetstream(logger, nc)
-	consumer := backend.NatsConsumer(c.Context, logger, js, "monitor", "monitor-vm-otlp-exporter")
+	consumer := backend.NatsConsumer(c.Context, logger, js, streams.StreamMonitor, streams.ConsumerMonitorVmOtlp)
 
-	vmExportUrl := fmt.Sprintf("%s/opentelemetry/v1/metrics", c.String(backend.Flag_VmUrl.Name))
-	logger.Info("starting victoria metrics otlp exporter", zap.String("export-url", vmExportUrl))
+	mapper, err := EventMapper(c.String(FlagEventRules.Name))
+	backend.FatalOnError(logger, err, "failed to load event rules")
 
+	exporter, err := NewExporter(
+		c.String(backend.Flag_VmUrl.Name),
+		WithLogger(logger.Named("exporter")),
+		WithFormat(c.String(FlagFormat.Name)),
+		WithRetries(c.Int(FlagRetries.Name)),
+		WithBackoff(c.Duration(FlagRetryBackoff.Name), c.Duration(FlagRetryMaxBackoff.Name)),
+		WithEventMapper(mapper),
+	)
+	backend.FatalOnError(logger, err, "failed to create exporter")
+	logger.Info("starting victoria metrics exporter", zap.String("export-url", exporter.ExportUrl()), zap.String("format", exporter.Format()))
+
+	// the queue is bounded, once it is full the consumer blocks and stops pulling messages
+	queueSize := c.Int(FlagQueueSize.Name)
+	queue := make(chan jetstream.Msg, queueSize)
 	cctx, err := consumer.Consume(func(msg jetstream.Msg) {
-		meta, _ := msg.Metadata()
-		logger.Info("processing message", zap.Uint64("seqn", meta.Sequence.Stream))
+		select {
+		case queue <- msg:
+		case <-c.Context.Done():
+		}
+	}, jetstream.PullMaxMessages(queueSize))
+	if err != nil {
+		logger.Error("failed to consume messages", zap.Error(err))
+		return err
+	}
+	defer cctx.Stop()
 
-		export := new(monitor.Export)
-		decode(msg.Data(), export)
+	ackWait := 30 * time.Second
+	if info := consumer.CachedInfo(); info != nil && info.Config.AckWait > 0 {
+		ackWait = info.Config.AckWait
+	}
 
-		for _, metrics := range export.Metrics {
-			rm := new(v1.ResourceMetrics)
-			if err := proto.Unmarshal(metrics.OTLP, rm); err != nil {
-				logger.Fatal("failed to decode resource metrics protobuf", zap.Error(err))
-			}
-			request := v1_service.ExportMetricsServiceRequest{
-				ResourceMetrics: []*v1.ResourceMetrics{rm},
-			}
-			requestPayload, err := proto.Marshal(&request)
-			if err != nil {
-				logger.Fatal("failed to marshal export metrics request", zap.Error(err))
-			}
+	batcher := &batcher{
+		logger:   logger,
+		exporter: exporter,
+		handler:  backend.NewMsgHandler(logger, js, streams.ConsumerMonitorVmOtlp, c.Duration(FlagNakDelay.Name)),
+		size:     c.Int(FlagBatchSize.Name),
+		ackWait:  ackWait,
+	}
+	batcher.run(c.Context, queue, c.Duration(FlagBatchWait.Name))
+
+	// messages still in the queue were never added to a batch
+	cctx.Drain()
+	for {
+		select {
+		case msg := <-queue:
+			msg.Nak()
+		case <-cctx.Closed():
+			return nc.Drain()
+		}
+	}
+}
 
-			res, err := http.Post(vmExportUrl, "application/octet-stream", bytes.NewReader(requestPayload))
-			if err != nil {
-				logger.Fatal("failed to POST metrics export request to victoria metrics", zap.Error(err))
+// Mapper with the rules from the given file or the builtin rules if empty
+func EventMapper(rulesPath string) (*eventmetrics.Mapper, error) {
+	var rules *eventmetrics.Rules
+	var err error
+	if rulesPath == "" {
+		rules, err = eventmetrics.DefaultRules()
+	} else {
+		rules, err = eventmetrics.LoadRules(rulesPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return eventmetrics.NewMapper(rules), nil
+}
+
+type batcher struct {
+	logger   *zap.Logger
+	exporter *Exporter
+	handler  *backend.MsgHandler
+	size     int
+	ackWait  time.Duration
+	batch    []pending
+}
+
+func (b *batcher) run(ctx context.Context, queue <-chan jetstream.Msg, wait time.Duration) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// anything not sent yet is redelivered once the exporter is back
+			for _, p := range b.batch {
+				p.msg.Nak()
 			}
-			if res.StatusCode != 200 {
-				logger.Fatal("failed to POST metrics export request to victoria metrics, unexpected status code", zap.Int("code", res.StatusCode))
+			return
+		case msg := <-queue:
+			b.add(ctx, msg)
+			if len(b.batch) >= b.size {
+				b.flush(ctx)
+				timer.Reset(wait)
 			}
+		case <-timer.C:
+			b.flush(ctx)
+			timer.Reset(wait)
 		}
+	}
+}
 
-		msg.Ack()
-	})
-
+func (b *batcher) add(ctx context.Context, msg jetstream.Msg) {
+	export := new(monitor.Export)
+	if err := backend.NatsDecode(msg.Headers(), msg.Data(), export); err != nil {
+		b.handler.DeadLetter(ctx, msg, fmt.Errorf("failed to decode export: %w", err))
+		return
+	}
+	rms, err := b.exporter.ResourceMetrics(export)
 	if err != nil {
-		logger.Error("failed to consume messages", zap.Error(err))
-		return err
+		b.handler.DeadLetter(ctx, msg, fmt.Errorf("failed to decode export metrics: %w", err))
+		return
+	}
+	b.batch = append(b.batch, pending{msg: msg, rms: rms})
+}
 
+func (b *batcher) flush(ctx context.Context) {
+	if len(b.batch) == 0 {
+		return
 	}
-	<-cctx.Closed()
+	batch := b.batch
+	b.batch = nil
+
+	// retries can outlast the ack wait, keep the messages from being redelivered in the meantime
+	progressCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(b.ackWait / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-progressCtx.Done():
+				return
+			case <-ticker.C:
+				for _, p := range batch {
+					p.msg.InProgress()
+				}
+			}
+		}
+	}()
 
-	return nil
+	err := b.exporter.Send(ctx, resourceMetricsOf(batch))
+	switch {
+	case err == nil:
+		b.logger.Info("exported batch", zap.Int("exports", len(batch)))
+		for _, p := range batch {
+			p.msg.Ack()
+		}
+	case ctx.Err() != nil:
+		for _, p := range batch {
+			p.msg.Nak()
+		}
+	case Retryable(err):
+		b.logger.Error("failed to export batch, handing it back to nats", zap.Int("exports", len(batch)), zap.Error(err))
+		for _, p := range batch {
+			b.handler.Retry(ctx, p.msg)
+		}
+	case len(batch) > 1:
+		// the batch was rejected, send the exports one by one so only the offending ones are dropped
+		b.logger.Warn("batch rejected, sending exports individually", zap.Int("exports", len(batch)), zap.Error(err))
+		for _, p := range batch {
+			b.batch = []pending{p}
+			b.flush(ctx)
+		}
+	default:
+		b.handler.DeadLetter(ctx, batch[0].msg, fmt.Errorf("export rejected: %w", err))
+	}
 }
 
-func decode(data []byte, output any) {
-	if err := json.Unmarshal(data, output); err != nil {
-		panic("failed to unmarshal json")
+func resourceMetricsOf(batch []pending) []*v1.ResourceMetrics {
+	rms := make([]*v1.ResourceMetrics, 0, len(batch))
+	for _, p := range batch {
+		rms = append(rms, p.rms...)
 	}
+	return rms
 }
@@ -0,0 +1,105 @@
+package vm_otlp_exporter
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diogo464/ipfs-telemetry/backend/eventmetrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// OTLP/HTTP protobuf requests to /opentelemetry/v1/metrics
+	FormatOtlp = "otlp"
+	// Prometheus remote-write requests to /api/v1/write
+	FormatRemoteWrite = "remote-write"
+)
+
+type Option func(*options) error
+
+type options struct {
+	logger     *zap.Logger
+	client     *http.Client
+	format     string
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	mapper     *eventmetrics.Mapper
+}
+
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) error {
+		o.logger = l
+		return nil
+	}
+}
+
+func WithHttpClient(client *http.Client) Option {
+	return func(o *options) error {
+		o.client = client
+		return nil
+	}
+}
+
+// Wire format of the requests, FormatOtlp or FormatRemoteWrite
+func WithFormat(format string) Option {
+	return func(o *options) error {
+		if format != FormatOtlp && format != FormatRemoteWrite {
+			return fmt.Errorf("invalid export format %q, expected %q or %q", format, FormatOtlp, FormatRemoteWrite)
+		}
+		o.format = format
+		return nil
+	}
+}
+
+// Number of times a failed request is retried before giving up, 0 disables retries
+func WithRetries(retries int) Option {
+	return func(o *options) error {
+		if retries < 0 {
+			return fmt.Errorf("invalid number of retries %v", retries)
+		}
+		o.retries = retries
+		return nil
+	}
+}
+
+// Delay before the first retry, doubled after every failed attempt up to max
+func WithBackoff(initial, max time.Duration) Option {
+	return func(o *options) error {
+		if initial <= 0 || max < initial {
+			return fmt.Errorf("invalid backoff %v up to %v", initial, max)
+		}
+		o.backoff = initial
+		o.maxBackoff = max
+		return nil
+	}
+}
+
+// Create metrics from the events of each export, they are sent alongside the node's own metrics
+func WithEventMapper(mapper *eventmetrics.Mapper) Option {
+	return func(o *options) error {
+		o.mapper = mapper
+		return nil
+	}
+}
+
+func defaults() *options {
+	return &options{
+		logger:     zap.NewNop(),
+		client:     &http.Client{Timeout: 30 * time.Second},
+		format:     FormatOtlp,
+		retries:    5,
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+}
+
+func apply(opts *options, o ...Option) error {
+	for _, opt := range o {
+		if err := opt(opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package vm_otlp_exporter
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	v1_common "go.opentelemetry.io/proto/otlp/common/v1"
+	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Prometheus remote-write series. Metric and label names are kept as is, like VictoriaMetrics does when ingesting OTLP,
+// so both formats produce the same series.
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+func toTimeSeries(rms []*v1.ResourceMetrics) []timeSeries {
+	series := make([]timeSeries, 0)
+	for _, rm := range rms {
+		resource := attributeLabels(nil, rm.GetResource().GetAttributes())
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				series = appendMetric(series, resource, metric)
+			}
+		}
+	}
+	return series
+}
+
+func appendMetric(series []timeSeries, resource []label, metric *v1.Metric) []timeSeries {
+	name := metric.Name
+	add := func(name string, attributes []*v1_common.KeyValue, value float64, timeUnixNano uint64, extra ...label) {
+		labels := make([]label, 0, len(resource)+len(attributes)+len(extra)+1)
+		labels = append(labels, resource...)
+		labels = attributeLabels(labels, attributes)
+		labels = append(labels, extra...)
+		labels = append(labels, label{name: "__name__", value: name})
+		series = append(series, timeSeries{
+			labels:  normalizeLabels(labels),
+			samples: []sample{{value: value, timestamp: int64(timeUnixNano / 1e6)}},
+		})
+	}
+
+	switch data := metric.Data.(type) {
+	case *v1.Metric_Gauge:
+		for _, dp := range data.Gauge.DataPoints {
+			if v, ok := numberValue(dp); ok {
+				add(name, dp.Attributes, v, dp.TimeUnixNano)
+			}
+		}
+	case *v1.Metric_Sum:
+		for _, dp := range data.Sum.DataPoints {
+			if v, ok := numberValue(dp); ok {
+				add(name, dp.Attributes, v, dp.TimeUnixNano)
+			}
+		}
+	case *v1.Metric_Histogram:
+		for _, dp := range data.Histogram.DataPoints {
+			if dp.Flags&uint32(v1.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+				continue
+			}
+			cumulative := uint64(0)
+			for i, bound := range dp.ExplicitBounds {
+				if i < len(dp.BucketCounts) {
+					cumulative += dp.BucketCounts[i]
+				}
+				add(name+"_bucket", dp.Attributes, float64(cumulative), dp.TimeUnixNano, label{name: "le", value: formatFloat(bound)})
+			}
+			add(name+"_bucket", dp.Attributes, float64(dp.Count), dp.TimeUnixNano, label{name: "le", value: "+Inf"})
+			if dp.Sum != nil {
+				add(name+"_sum", dp.Attributes, *dp.Sum, dp.TimeUnixNano)
+			}
+			add(name+"_count", dp.Attributes, float64(dp.Count), dp.TimeUnixNano)
+		}
+	case *v1.Metric_ExponentialHistogram:
+		// buckets have no remote-write representation, only the totals are kept
+		for _, dp := range data.ExponentialHistogram.DataPoints {
+			if dp.Flags&uint32(v1.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+				continue
+			}
+			if dp.Sum != nil {
+				add(name+"_sum", dp.Attributes, *dp.Sum, dp.TimeUnixNano)
+			}
+			add(name+"_count", dp.Attributes, float64(dp.Count), dp.TimeUnixNano)
+		}
+	case *v1.Metric_Summary:
+		for _, dp := range data.Summary.DataPoints {
+			if dp.Flags&uint32(v1.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+				continue
+			}
+			for _, q := range dp.QuantileValues {
+				add(name, dp.Attributes, q.Value, dp.TimeUnixNano, label{name: "quantile", value: formatFloat(q.Quantile)})
+			}
+			add(name+"_sum", dp.Attributes, dp.Sum, dp.TimeUnixNano)
+			add(name+"_count", dp.Attributes, float64(dp.Count), dp.TimeUnixNano)
+		}
+	}
+	return series
+}
+
+func numberValue(dp *v1.NumberDataPoint) (float64, bool) {
+	if dp.Flags&uint32(v1.DataPointFlags_DATA_POINT_FLAGS_NO_RECORDED_VALUE_MASK) != 0 {
+		return 0, false
+	}
+	switch v := dp.Value.(type) {
+	case *v1.NumberDataPoint_AsDouble:
+		return v.AsDouble, true
+	case *v1.NumberDataPoint_AsInt:
+		return float64(v.AsInt), true
+	default:
+		return 0, false
+	}
+}
+
+func attributeLabels(labels []label, attributes []*v1_common.KeyValue) []label {
+	for _, kv := range attributes {
+		if value := anyValueString(kv.Value); value != "" {
+			labels = append(labels, label{name: kv.Key, value: value})
+		}
+	}
+	return labels
+}
+
+func anyValueString(v *v1_common.AnyValue) string {
+	switch v := v.GetValue().(type) {
+	case *v1_common.AnyValue_StringValue:
+		return v.StringValue
+	case *v1_common.AnyValue_BoolValue:
+		return strconv.FormatBool(v.BoolValue)
+	case *v1_common.AnyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *v1_common.AnyValue_DoubleValue:
+		return formatFloat(v.DoubleValue)
+	case *v1_common.AnyValue_BytesValue:
+		return string(v.BytesValue)
+	case *v1_common.AnyValue_ArrayValue:
+		encoded, _ := protojson.Marshal(v.ArrayValue)
+		return string(encoded)
+	case *v1_common.AnyValue_KvlistValue:
+		encoded, _ := protojson.Marshal(v.KvlistValue)
+		return string(encoded)
+	default:
+		return ""
+	}
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	if math.IsInf(v, -1) {
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Remote-write requires the labels sorted by name, later labels replace earlier ones with the same name
+func normalizeLabels(labels []label) []label {
+	slices.SortStableFunc(labels, func(a, b label) int { return strings.Compare(a.name, b.name) })
+	normalized := labels[:0]
+	for _, l := range labels {
+		if n := len(normalized); n > 0 && normalized[n-1].name == l.name {
+			normalized[n-1] = l
+			continue
+		}
+		normalized = append(normalized, l)
+	}
+	return normalized
+}
+
+// Encode a prometheus.WriteRequest protobuf
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func marshalWriteRequest(series []timeSeries) []byte {
+	var request, ts, msg []byte
+	for _, s := range series {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.name)
+			msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+			msg = protowire.AppendString(msg, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, msg)
+		}
+		for _, sample := range s.samples {
+			msg = msg[:0]
+			msg = protowire.AppendTag(msg, 1, protowire.Fixed64Type)
+			msg = protowire.AppendFixed64(msg, math.Float64bits(sample.value))
+			msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+			msg = protowire.AppendVarint(msg, uint64(sample.timestamp))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, msg)
+		}
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+	return request
+}
@@ -0,0 +1,6 @@
+#!/usr/bin/env -S bash -x
+set -e
+cd $(dirname $0)/..
+
+PROMETHEUS_ADDRESS="0.0.0.0:9095" \
+    bin/backend clickhouse-exporter $@
@@ -0,0 +1,10 @@
+#!/usr/bin/env -S bash -x
+set -e
+cd $(dirname $0)/..
+
+mkdir -p data/clickhouse
+podman run -d --name clickhouse --network host \
+    --ulimit nofile=262144:262144 \
+    -e CLICKHOUSE_SKIP_USER_SETUP=1 \
+    -v ./data/clickhouse:/var/lib/clickhouse:z \
+    docker.io/clickhouse/clickhouse-server:latest
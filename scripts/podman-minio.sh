@@ -0,0 +1,13 @@
+#!/usr/bin/env -S bash -x
+set -e
+cd $(dirname $0)/..
+
+# uses port 9000 and 9001
+# archive with: --archive-s3-endpoint localhost:9000 --archive-s3-insecure \
+#   --archive-s3-access-key minioadmin --archive-s3-secret-key minioadmin
+
+mkdir -p data/minio
+podman run -d --name minio --network host \
+    -v ./data/minio:/data:z \
+    docker.io/minio/minio:latest \
+    server /data --console-address :9001
@@ -2,14 +2,13 @@ package main
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/diogo464/telemetry"
 	"github.com/urfave/cli/v2"
 )
 
 var CommandUpload = &cli.Command{
 	Name:   "upload",
+	Flags:  BANDWIDTH_FLAGS,
 	Action: actionUpload,
 }
 
@@ -20,10 +19,10 @@ func actionUpload(c *cli.Context) error {
 	}
 	defer client.Close()
 
-	rate, err := client.Upload(context.Background(), telemetry.DEFAULT_BANDWIDTH_PAYLOAD_SIZE)
+	measurement, err := client.Upload(context.Background(), bandwidthOptionsFromContext(c)...)
 	if err != nil {
 		return err
 	}
-	fmt.Println("Upload rate:", rate/(1024*1024), "MB/s")
+	printBandwidthMeasurement("Upload", measurement)
 	return nil
 }
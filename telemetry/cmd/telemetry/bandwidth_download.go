@@ -2,14 +2,13 @@ package main
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/diogo464/telemetry"
 	"github.com/urfave/cli/v2"
 )
 
 var CommandDownload = &cli.Command{
 	Name:   "download",
+	Flags:  BANDWIDTH_FLAGS,
 	Action: actionDownload,
 }
 
@@ -20,10 +19,10 @@ func actionDownload(c *cli.Context) error {
 	}
 	defer client.Close()
 
-	rate, err := client.Download(context.Background(), telemetry.DEFAULT_BANDWIDTH_PAYLOAD_SIZE)
+	measurement, err := client.Download(context.Background(), bandwidthOptionsFromContext(c)...)
 	if err != nil {
 		return err
 	}
-	fmt.Println("Download rate:", rate/(1024*1024), "MB/s")
+	printBandwidthMeasurement("Download", measurement)
 	return nil
 }
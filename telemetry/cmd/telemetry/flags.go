@@ -1,6 +1,12 @@
 package main
 
-import "github.com/urfave/cli/v2"
+import (
+	"fmt"
+	"time"
+
+	"github.com/diogo464/telemetry"
+	"github.com/urfave/cli/v2"
+)
 
 var (
 	FLAG_CONN_TYPE = &cli.StringFlag{
@@ -16,4 +22,36 @@ var (
 		Value:   "localhost:4000",
 		EnvVars: []string{"TELEMETRY_HOST"},
 	}
+
+	FLAG_BANDWIDTH_STREAMS = &cli.UintFlag{
+		Name:  "streams",
+		Usage: "Number of parallel streams used in a bandwidth test",
+		Value: telemetry.DEFAULT_BANDWIDTH_STREAMS,
+	}
+
+	FLAG_BANDWIDTH_DURATION = &cli.DurationFlag{
+		Name:  "duration",
+		Usage: "How long to transfer data for in a bandwidth test",
+		Value: telemetry.DEFAULT_BANDWIDTH_DURATION,
+	}
 )
+
+var BANDWIDTH_FLAGS = []cli.Flag{
+	FLAG_BANDWIDTH_STREAMS,
+	FLAG_BANDWIDTH_DURATION,
+}
+
+func bandwidthOptionsFromContext(c *cli.Context) []telemetry.BandwidthOption {
+	return []telemetry.BandwidthOption{
+		telemetry.WithBandwidthStreams(uint32(c.Uint(FLAG_BANDWIDTH_STREAMS.Name))),
+		telemetry.WithBandwidthDuration(c.Duration(FLAG_BANDWIDTH_DURATION.Name)),
+	}
+}
+
+func printBandwidthMeasurement(name string, m telemetry.BandwidthMeasurement) {
+	fmt.Printf("%s rate: %.2f MB/s (peak %.2f MB/s, %d streams, %d bytes)\n", name, float64(m.Rate)/(1024*1024), float64(m.PeakRate)/(1024*1024), m.Streams, m.Bytes)
+	fmt.Printf("RTT: %v, jitter: %v\n", m.Rtt, m.Jitter)
+	for _, sample := range m.Samples {
+		fmt.Printf("  %8v %10.2f MB/s\n", sample.Elapsed.Round(time.Millisecond), float64(sample.Rate)/(1024*1024))
+	}
+}
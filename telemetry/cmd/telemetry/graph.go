@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/diogo464/telemetry/crawler/graph"
+	"github.com/urfave/cli/v2"
+)
+
+var FLAG_GRAPH_FORMAT = &cli.StringFlag{
+	Name:  "format",
+	Usage: "output format, one of graphml, gexf or edges",
+	Value: "graphml",
+}
+
+var CommandGraph = &cli.Command{
+	Name:  "graph",
+	Usage: "inspect routing table graphs written by the crawler",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "export",
+			Usage:     "convert an edge list to another format, writes to stdout if no output path is given",
+			ArgsUsage: "<edge list> [output]",
+			Flags:     []cli.Flag{FLAG_GRAPH_FORMAT},
+			Action:    actionGraphExport,
+		},
+		{
+			Name:      "stats",
+			Usage:     "print graph statistics of an edge list as json",
+			ArgsUsage: "<edge list>",
+			Action:    actionGraphStats,
+		},
+	},
+}
+
+func actionGraphExport(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing edge list path")
+	}
+
+	var write func(io.Writer, *graph.Graph) error
+	switch format := c.String(FLAG_GRAPH_FORMAT.Name); format {
+	case "graphml":
+		write = graph.WriteGraphML
+	case "gexf":
+		write = graph.WriteGEXF
+	case "edges":
+		write = graph.WriteEdgeList
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+
+	g, err := graph.ReadEdgeListFile(path)
+	if err != nil {
+		return err
+	}
+
+	output := c.Args().Get(1)
+	if output == "" {
+		return write(os.Stdout, g)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := write(f, g); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func actionGraphStats(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing edge list path")
+	}
+	g, err := graph.ReadEdgeListFile(path)
+	if err != nil {
+		return err
+	}
+	marshaled, err := json.MarshalIndent(graph.ComputeStats(g), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(marshaled))
+	return nil
+}
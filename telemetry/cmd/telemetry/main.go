@@ -19,6 +19,8 @@ var COMMANDS = []*cli.Command{
 	CommandEvent,
 	CommandProperties,
 	CommandDescriptors,
+	CommandPreimage,
+	CommandGraph,
 }
 
 func main() {
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diogo464/telemetry/walker/preimage"
+	"github.com/urfave/cli/v2"
+)
+
+var FLAG_PREIMAGE_BITS = &cli.IntFlag{
+	Name:  "bits",
+	Usage: fmt.Sprintf("number of prefix bits covered by the table, at most %v", preimage.MaxBits),
+	Value: preimage.DefaultBits,
+}
+
+var CommandPreimage = &cli.Command{
+	Name:  "preimage",
+	Usage: "manage preimage tables used by the walker",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "generate",
+			Usage:     "generate a preimage table and write it to a file",
+			ArgsUsage: "<path>",
+			Flags:     []cli.Flag{FLAG_PREIMAGE_BITS},
+			Action:    actionPreimageGenerate,
+		},
+		{
+			Name:      "verify",
+			Usage:     "check that a preimage table file is complete and correct",
+			ArgsUsage: "<path>",
+			Action:    actionPreimageVerify,
+		},
+	},
+}
+
+func actionPreimageGenerate(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing output path")
+	}
+	bits := c.Int(FLAG_PREIMAGE_BITS.Name)
+	if bits < 1 || bits > preimage.MaxBits {
+		return fmt.Errorf("invalid number of bits %v, must be between 1 and %v", bits, preimage.MaxBits)
+	}
+
+	start := time.Now()
+	table := preimage.GenerateWithBits(bits)
+	if err := preimage.WriteFile(path, table); err != nil {
+		return err
+	}
+	fmt.Printf("Generated %v bit table in %v\n", bits, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func actionPreimageVerify(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing table path")
+	}
+	table, err := preimage.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Valid %v bit table\n", table.Bits())
+	return nil
+}
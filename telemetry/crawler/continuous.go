@@ -0,0 +1,282 @@
+package crawler
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// Window over which the churn rate is computed
+const churnWindow = time.Hour
+
+// A peer in the live set or a candidate discovered in some peer's buckets.
+// Candidates only join the live set after they are successfully queried.
+type livePeer struct {
+	id       peer.ID
+	addrs    []multiaddr.Multiaddr
+	joined   time.Time
+	lastSeen time.Time
+	failures int
+	next     time.Time
+	index    int
+}
+
+func (p *livePeer) isLive() bool {
+	return !p.joined.IsZero()
+}
+
+// Peers ordered by their next query time
+type liveSchedule []*livePeer
+
+func (s liveSchedule) Len() int           { return len(s) }
+func (s liveSchedule) Less(i, j int) bool { return s[i].next.Before(s[j].next) }
+func (s liveSchedule) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+	s[i].index = i
+	s[j].index = j
+}
+func (s *liveSchedule) Push(x any) {
+	p := x.(*livePeer)
+	p.index = len(*s)
+	*s = append(*s, p)
+}
+func (s *liveSchedule) Pop() any {
+	old := *s
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	p.index = -1
+	*s = old[:n-1]
+	return p
+}
+
+type liveResult struct {
+	peer *livePeer
+	ok   *walker.Peer
+	err  *walker.Error
+}
+
+// Timestamps of recent joins and leaves, used to compute the churn rate
+type churnTracker struct {
+	mu     sync.Mutex
+	events []time.Time
+}
+
+func (t *churnTracker) add(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, now)
+}
+
+// Joins and leaves per hour over the last churn window
+func (t *churnTracker) rate(now time.Time) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cutoff := now.Add(-churnWindow)
+	i := 0
+	for i < len(t.events) && t.events[i].Before(cutoff) {
+		i++
+	}
+	t.events = t.events[i:]
+	return float64(len(t.events)) / churnWindow.Hours()
+}
+
+func (c *Crawler) runContinuous(ctx context.Context) error {
+	// the initial full crawl populates the live set through ObservePeer, joins are not emitted for it
+	c.bootstrapping = true
+	for _, observer := range c.opts.observers {
+		observer.CrawlBegin()
+	}
+	if err := c.w[0].Walk(ctx); err != nil {
+		return err
+	}
+	for _, observer := range c.opts.observers {
+		observer.CrawlEnd()
+	}
+	c.bootstrapping = false
+	c.cold = c.cnow.clone()
+	c.completed.Inc()
+	c.l.Info("initial crawl completed, entering continuous mode", zap.Int("peers", c.liveCount()))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	results := make(chan liveResult)
+	inflight := 0
+	for {
+		now := time.Now()
+		for inflight < c.opts.continuousConcurrency && c.schedule.Len() > 0 && !c.schedule[0].next.After(now) {
+			lp := heap.Pop(&c.schedule).(*livePeer)
+			info := peer.AddrInfo{ID: lp.id, Addrs: lp.addrs}
+			inflight += 1
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := c.w[0].WalkPeer(ctx, info)
+				select {
+				case results <- liveResult{peer: lp, ok: ok, err: err}:
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		var wait <-chan time.Time
+		if inflight < c.opts.continuousConcurrency && c.schedule.Len() > 0 {
+			wait = time.After(time.Until(c.schedule[0].next))
+		}
+
+		select {
+		case result := <-results:
+			inflight -= 1
+			if result.ok != nil {
+				c.liveSuccess(result.peer, result.ok)
+			} else {
+				c.liveFailure(result.peer, result.err)
+			}
+		case <-wait:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Update the live set after a successful query, discovered peers are scheduled as candidates
+func (c *Crawler) liveSuccess(lp *livePeer, p *walker.Peer) {
+	now := time.Now()
+
+	c.peers_mu.Lock()
+	if !lp.isLive() {
+		lp.joined = now
+		c.live[lp.id] = lp
+		if !c.bootstrapping {
+			c.emitEvent(&Event{Kind: EventJoin, ID: lp.id, Network: p.Network, Time: now, Addresses: p.Addresses})
+		}
+	} else if len(p.Addresses) > 0 && !sameAddrs(lp.addrs, p.Addresses) {
+		c.emitEvent(&Event{Kind: EventAddressChange, ID: lp.id, Network: p.Network, Time: now, Addresses: p.Addresses, PreviousAddresses: lp.addrs})
+	}
+	if len(p.Addresses) > 0 {
+		lp.addrs = p.Addresses
+	}
+	lp.lastSeen = now
+	lp.failures = 0
+	lp.next = now.Add(c.opts.requeryInterval)
+	c.pending[lp.id] = lp
+	c.reschedule(lp)
+
+	for _, entry := range p.Buckets {
+		if _, ok := c.pending[entry.ID]; ok {
+			continue
+		}
+		candidate := &livePeer{id: entry.ID, addrs: entry.Addrs, next: now, index: -1}
+		if c.bootstrapping {
+			// the initial crawl already queries every discovered peer
+			candidate.next = now.Add(c.opts.requeryInterval)
+		}
+		c.pending[entry.ID] = candidate
+		heap.Push(&c.schedule, candidate)
+	}
+	c.peers_mu.Unlock()
+
+	if !c.bootstrapping {
+		c.ObservePeer(p)
+	}
+}
+
+// Update the live set after a failed query, the peer leaves after enough consecutive failures
+func (c *Crawler) liveFailure(lp *livePeer, e *walker.Error) {
+	now := time.Now()
+	c.ObserveError(e)
+
+	c.peers_mu.Lock()
+	defer c.peers_mu.Unlock()
+
+	lp.failures += 1
+	if lp.failures < c.opts.leaveAfter {
+		lp.next = now.Add(c.opts.retryInterval)
+		c.reschedule(lp)
+		return
+	}
+
+	delete(c.pending, lp.id)
+	if lp.isLive() {
+		delete(c.live, lp.id)
+		c.emitEvent(&Event{
+			Kind:          EventLeave,
+			ID:            lp.id,
+			Network:       e.Network,
+			Time:          now,
+			Addresses:     lp.addrs,
+			SessionLength: lp.lastSeen.Sub(lp.joined),
+		})
+	}
+}
+
+// Peers found by the initial crawl are added to the live set without emitting joins
+func (c *Crawler) bootstrapPeer(p *walker.Peer) {
+	c.peers_mu.Lock()
+	lp, ok := c.pending[p.ID]
+	if !ok {
+		lp = &livePeer{id: p.ID, index: -1}
+	}
+	c.peers_mu.Unlock()
+	c.liveSuccess(lp, p)
+}
+
+func (c *Crawler) reschedule(lp *livePeer) {
+	if lp.index >= 0 {
+		heap.Fix(&c.schedule, lp.index)
+	} else {
+		heap.Push(&c.schedule, lp)
+	}
+}
+
+func (c *Crawler) emitEvent(e *Event) {
+	ctx := context.Background()
+	switch e.Kind {
+	case EventJoin:
+		c.m.PeerJoins.Add(ctx, 1)
+		c.churn.add(e.Time)
+	case EventLeave:
+		c.m.PeerLeaves.Add(ctx, 1)
+		c.m.SessionLength.Record(ctx, e.SessionLength.Seconds())
+		c.churn.add(e.Time)
+	case EventAddressChange:
+		c.m.AddressChanges.Add(ctx, 1)
+	}
+
+	c.l.Debug("peer event", zap.String("kind", string(e.Kind)), zap.String("peer", e.ID.String()))
+	for _, observer := range c.opts.observers {
+		observer.ObserveEvent(e)
+	}
+}
+
+func (c *Crawler) liveCount() int {
+	c.peers_mu.Lock()
+	defer c.peers_mu.Unlock()
+	return len(c.live)
+}
+
+func (c *Crawler) observeContinuous(observer metric.Observer) {
+	observer.ObserveInt64(c.m.LivePeers, int64(c.liveCount()))
+	observer.ObserveFloat64(c.m.ChurnRate, c.churn.rate(time.Now()))
+}
+
+func sameAddrs(a, b []multiaddr.Multiaddr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, addr := range a {
+		if !slices.ContainsFunc(b, addr.Equal) {
+			return false
+		}
+	}
+	return true
+}
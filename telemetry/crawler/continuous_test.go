@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diogo464/telemetry/crawler/metrics"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.uber.org/zap"
+)
+
+type eventCollector struct {
+	walkerObserverBridge
+	events []*Event
+}
+
+func (o *eventCollector) ObserveEvent(e *Event) {
+	o.events = append(o.events, e)
+}
+
+func TestContinuousEvents(t *testing.T) {
+	m, err := metrics.New(noop.NewMeterProvider())
+	require.NoError(t, err)
+
+	collector := &eventCollector{walkerObserverBridge: walkerObserverBridge{&walker.NullObserver{}}}
+	opts := defaults()
+	opts.observers = []Observer{collector}
+	opts.leaveAfter = 2
+	c := &Crawler{
+		l:       zap.NewNop(),
+		opts:    opts,
+		peers:   make(map[peer.ID]struct{}),
+		tpeers:  make(map[peer.ID]struct{}),
+		cnow:    newCounters(),
+		cold:    newCounters(),
+		m:       m,
+		live:    make(map[peer.ID]*livePeer),
+		pending: make(map[peer.ID]*livePeer),
+	}
+
+	pid := peer.ID("peer")
+	addr1 := multiaddr.StringCast("/ip4/1.1.1.1/tcp/4001")
+	addr2 := multiaddr.StringCast("/ip4/1.1.1.2/tcp/4001")
+	lp := &livePeer{id: pid, index: -1}
+
+	c.liveSuccess(lp, &walker.Peer{ID: pid, Addresses: []multiaddr.Multiaddr{addr1}})
+	c.liveSuccess(lp, &walker.Peer{ID: pid, Addresses: []multiaddr.Multiaddr{addr1}})
+	c.liveSuccess(lp, &walker.Peer{ID: pid, Addresses: []multiaddr.Multiaddr{addr2}})
+	c.liveFailure(lp, &walker.Error{ID: pid})
+	assert.Equal(t, 1, c.liveCount())
+	c.liveFailure(lp, &walker.Error{ID: pid})
+	assert.Equal(t, 0, c.liveCount())
+
+	kinds := make([]EventKind, 0, len(collector.events))
+	for _, e := range collector.events {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []EventKind{EventJoin, EventAddressChange, EventLeave}, kinds)
+	assert.Equal(t, []multiaddr.Multiaddr{addr1}, collector.events[1].PreviousAddresses)
+	assert.Equal(t, 2/churnWindow.Hours(), c.churn.rate(time.Now()))
+}
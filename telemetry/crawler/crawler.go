@@ -2,12 +2,15 @@ package crawler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/diogo464/telemetry"
 	"github.com/diogo464/telemetry/crawler/metrics"
 	"github.com/diogo464/telemetry/walker"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -39,9 +42,11 @@ func (c *counters) clone() *counters {
 
 type Crawler struct {
 	l    *zap.Logger
-	w    walker.Walker
+	w    []walker.Walker // one walker for each network
 	opts *options
 
+	observers_mu sync.Mutex // walkers of different networks observe concurrently
+
 	peers_mu sync.Mutex
 	peers    map[peer.ID]struct{} // active peers
 	tpeers   map[peer.ID]struct{} //active telemetry peers
@@ -49,6 +54,15 @@ type Crawler struct {
 	completed *atomic.Uint64
 	cnow      *counters
 	cold      *counters
+
+	m *metrics.Metrics
+
+	// continuous mode, protected by peers_mu
+	bootstrapping bool
+	live          map[peer.ID]*livePeer // peers currently in the live set
+	pending       map[peer.ID]*livePeer // live peers and candidates waiting to be queried
+	schedule      liveSchedule
+	churn         churnTracker
 }
 
 func NewCrawler(o ...Option) (*Crawler, error) {
@@ -56,10 +70,13 @@ func NewCrawler(o ...Option) (*Crawler, error) {
 	if err := apply(opts, o...); err != nil {
 		return nil, err
 	}
+	if opts.continuous && len(opts.networks) > 1 {
+		return nil, fmt.Errorf("continuous mode supports a single network")
+	}
 
 	c := &Crawler{
 		l:    opts.logger,
-		w:    nil,
+		w:    make([]walker.Walker, 0, len(opts.networks)),
 		opts: opts,
 
 		peers:  make(map[peer.ID]struct{}),
@@ -68,22 +85,36 @@ func NewCrawler(o ...Option) (*Crawler, error) {
 		completed: atomic.NewUint64(0),
 		cnow:      newCounters(),
 		cold:      newCounters(),
+
+		live:    make(map[peer.ID]*livePeer),
+		pending: make(map[peer.ID]*livePeer),
 	}
 
-	walkerOpts := []walker.Option{}
-	walkerOpts = append(walkerOpts, opts.walkerOpts...)
-	walkerOpts = append(walkerOpts, walker.WithObserver(c))
+	networks := opts.networks
+	if len(networks) == 0 {
+		// use whatever network the walker options configure
+		networks = []*walker.Network{nil}
+	}
+	for _, network := range networks {
+		walkerOpts := []walker.Option{}
+		walkerOpts = append(walkerOpts, opts.walkerOpts...)
+		if network != nil {
+			walkerOpts = append(walkerOpts, walker.WithNetwork(*network))
+		}
+		walkerOpts = append(walkerOpts, walker.WithObserver(c))
 
-	w, err := walker.New(walkerOpts...)
-	if err != nil {
-		return nil, err
+		w, err := walker.New(walkerOpts...)
+		if err != nil {
+			return nil, err
+		}
+		c.w = append(c.w, w)
 	}
-	c.w = w
 
 	m, err := metrics.New(opts.meterProvider)
 	if err != nil {
 		return nil, err
 	}
+	c.m = m
 	m.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
 		observer.ObserveInt64(m.PeersCurrentCrawl, int64(c.cnow.peers.Load()))
 		observer.ObserveInt64(m.PeersTelemetryCurrentCrawl, int64(c.cnow.tpeers.Load()))
@@ -94,6 +125,10 @@ func NewCrawler(o ...Option) (*Crawler, error) {
 		observer.ObserveInt64(m.ErrorsLastCrawl, int64(c.cold.errors.Load()))
 
 		observer.ObserveInt64(m.CompletedCrawls, int64(c.completed.Load()))
+
+		if c.opts.continuous {
+			c.observeContinuous(observer)
+		}
 		return nil
 	})
 
@@ -101,6 +136,10 @@ func NewCrawler(o ...Option) (*Crawler, error) {
 }
 
 func (c *Crawler) Run(ctx context.Context) error {
+	if c.opts.continuous {
+		return c.runContinuous(ctx)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -112,7 +151,7 @@ func (c *Crawler) Run(ctx context.Context) error {
 			observer.CrawlBegin()
 		}
 
-		if err := c.w.Walk(ctx); err != nil {
+		if err := c.walk(ctx); err != nil {
 			return err
 		}
 
@@ -129,10 +168,33 @@ func (c *Crawler) Run(ctx context.Context) error {
 	}
 }
 
+// Walk every network at the same time
+func (c *Crawler) walk(ctx context.Context) error {
+	if len(c.w) == 1 {
+		return c.w[0].Walk(ctx)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(c.w))
+	for i, w := range c.w {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = w.Walk(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 // ObservePeer implements walker.Observer
 func (c *Crawler) ObservePeer(p *walker.Peer) {
 	hasTelemetry := p.ContainsProtocol(telemetry.ID_TELEMETRY)
 
+	if c.opts.continuous && c.bootstrapping {
+		c.bootstrapPeer(p)
+	}
+
 	c.peers_mu.Lock()
 	{
 		if _, ok := c.peers[p.ID]; !ok {
@@ -148,17 +210,37 @@ func (c *Crawler) ObservePeer(p *walker.Peer) {
 	}
 	c.peers_mu.Unlock()
 
+	c.observers_mu.Lock()
 	for _, observer := range c.opts.observers {
 		observer.ObservePeer(p)
 	}
+	c.observers_mu.Unlock()
 
 	if hasTelemetry {
-		c.l.Info("found telemetry peer", zap.String("peer", p.ID.String()))
+		c.l.Info("found telemetry peer", zap.String("peer", p.ID.String()), zap.String("network", p.Network))
 	}
 }
 
 // ObserveError implements walker.Observer
 func (c *Crawler) ObserveError(e *walker.Error) {
 	c.cnow.errors.Inc()
-	c.l.Info("error", zap.String("peer", e.ID.String()), zap.Any("addresses", e.Addresses), zap.Error(e.Err))
+	c.l.Info("error", zap.String("peer", e.ID.String()), zap.String("network", e.Network), zap.String("reason", string(e.Reason)), zap.Any("addresses", e.Addresses), zap.Error(e.Err))
+
+	ctx := context.Background()
+	c.m.Failures.Add(ctx, 1, metric.WithAttributes(
+		attribute.String(metrics.AttrStage, string(e.Stage)),
+		attribute.String(metrics.AttrReason, string(e.Reason)),
+	))
+	for _, dial := range e.Dials {
+		c.m.DialFailures.Add(ctx, 1, metric.WithAttributes(
+			attribute.String(metrics.AttrTransport, dial.Transport),
+			attribute.String(metrics.AttrReason, string(dial.Reason)),
+		))
+	}
+
+	c.observers_mu.Lock()
+	for _, observer := range c.opts.observers {
+		observer.ObserveError(e)
+	}
+	c.observers_mu.Unlock()
 }
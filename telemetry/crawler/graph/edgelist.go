@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Edge list format:
+//
+//	telemetry-graph 1 <nodes> <edges>
+//	<peer id> <state>     one line per node, the line order is the node index
+//	<from> <to>           one line per edge, using node indices
+const edgeListHeader = "telemetry-graph"
+const edgeListVersion = 1
+
+var ErrInvalidEdgeList = fmt.Errorf("invalid edge list")
+
+func WriteEdgeList(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "%s %d %d %d\n", edgeListHeader, edgeListVersion, len(g.nodes), len(g.edges)); err != nil {
+		return err
+	}
+	for i, id := range g.nodes {
+		if _, err := fmt.Fprintf(bw, "%s %d\n", id, g.states[i]); err != nil {
+			return err
+		}
+	}
+	for _, edge := range g.edges {
+		if _, err := fmt.Fprintf(bw, "%d %d\n", edge.From, edge.To); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func ReadEdgeList(r io.Reader) (*Graph, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := func() ([]string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: unexpected end of file", ErrInvalidEdgeList)
+		}
+		return strings.Fields(scanner.Text()), nil
+	}
+
+	header, err := line()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) != 4 || header[0] != edgeListHeader || header[1] != strconv.Itoa(edgeListVersion) {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidEdgeList)
+	}
+	numNodes, err := strconv.ParseUint(header[2], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad node count: %v", ErrInvalidEdgeList, err)
+	}
+	numEdges, err := strconv.ParseUint(header[3], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad edge count: %v", ErrInvalidEdgeList, err)
+	}
+
+	g := New()
+	for i := uint64(0); i < numNodes; i++ {
+		fields, err := line()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: bad node line %d", ErrInvalidEdgeList, i)
+		}
+		id, err := peer.Decode(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad peer id %q: %v", ErrInvalidEdgeList, fields[0], err)
+		}
+		state, err := strconv.ParseUint(fields[1], 10, 8)
+		if err != nil || NodeState(state) > NodeUnreachable {
+			return nil, fmt.Errorf("%w: bad node state %q", ErrInvalidEdgeList, fields[1])
+		}
+		if _, ok := g.index[id]; ok {
+			return nil, fmt.Errorf("%w: duplicate peer id %v", ErrInvalidEdgeList, id)
+		}
+		g.states[g.node(id)] = NodeState(state)
+	}
+
+	g.edges = make([]Edge, 0, numEdges)
+	for i := uint64(0); i < numEdges; i++ {
+		fields, err := line()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: bad edge line %d", ErrInvalidEdgeList, i)
+		}
+		from, err1 := strconv.ParseUint(fields[0], 10, 32)
+		to, err2 := strconv.ParseUint(fields[1], 10, 32)
+		if err1 != nil || err2 != nil || from >= numNodes || to >= numNodes {
+			return nil, fmt.Errorf("%w: bad edge %q", ErrInvalidEdgeList, scanner.Text())
+		}
+		g.edges = append(g.edges, Edge{From: uint32(from), To: uint32(to)})
+	}
+	return g, nil
+}
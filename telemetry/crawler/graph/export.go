@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case NodeReachable:
+		return "reachable"
+	case NodeUnreachable:
+		return "unreachable"
+	default:
+		return "unknown"
+	}
+}
+
+// Write the graph in the GraphML format, nodes have "peer" and "state" attributes
+func WriteGraphML(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(bw, `  <key id="peer" for="node" attr.name="peer" attr.type="string"/>`)
+	fmt.Fprintln(bw, `  <key id="state" for="node" attr.name="state" attr.type="string"/>`)
+	fmt.Fprintln(bw, `  <graph id="crawl" edgedefault="directed">`)
+	for i, id := range g.nodes {
+		fmt.Fprintf(bw, "    <node id=\"n%d\"><data key=\"peer\">%s</data><data key=\"state\">%s</data></node>\n", i, id, g.states[i])
+	}
+	for i, edge := range g.edges {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"n%d\" target=\"n%d\"/>\n", i, edge.From, edge.To)
+	}
+	fmt.Fprintln(bw, `  </graph>`)
+	fmt.Fprintln(bw, `</graphml>`)
+	return bw.Flush()
+}
+
+// Write the graph in the GEXF format, nodes are labeled with their peer id and have a "state" attribute
+func WriteGEXF(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<gexf xmlns="http://gexf.net/1.3" version="1.3">`)
+	fmt.Fprintln(bw, `  <graph defaultedgetype="directed">`)
+	fmt.Fprintln(bw, `    <attributes class="node">`)
+	fmt.Fprintln(bw, `      <attribute id="state" title="state" type="string"/>`)
+	fmt.Fprintln(bw, `    </attributes>`)
+	fmt.Fprintln(bw, `    <nodes>`)
+	for i, id := range g.nodes {
+		fmt.Fprintf(bw, "      <node id=\"%d\" label=\"%s\"><attvalues><attvalue for=\"state\" value=\"%s\"/></attvalues></node>\n", i, id, g.states[i])
+	}
+	fmt.Fprintln(bw, `    </nodes>`)
+	fmt.Fprintln(bw, `    <edges>`)
+	for i, edge := range g.edges {
+		fmt.Fprintf(bw, "      <edge id=\"%d\" source=\"%d\" target=\"%d\"/>\n", i, edge.From, edge.To)
+	}
+	fmt.Fprintln(bw, `    </edges>`)
+	fmt.Fprintln(bw, `  </graph>`)
+	fmt.Fprintln(bw, `</gexf>`)
+	return bw.Flush()
+}
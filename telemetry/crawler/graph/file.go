@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Write the graph's edge list to a file, gzip compressed if the path ends in .gz.
+// The file is written to a temporary path first and renamed so readers never see a partial graph.
+func WriteEdgeListFile(path string, g *Graph) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var w io.Writer = f
+	var gw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gw = gzip.NewWriter(f)
+		w = gw
+	}
+	if err := WriteEdgeList(w, g); err != nil {
+		return err
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Read an edge list file, decompressing it if the path ends in .gz
+func ReadEdgeListFile(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return ReadEdgeList(r)
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+type NodeState uint8
+
+const (
+	// The peer was found in a routing table but was never queried
+	NodeUnknown NodeState = 0
+	// The peer was queried successfully
+	NodeReachable NodeState = 1
+	// Querying the peer failed
+	NodeUnreachable NodeState = 2
+)
+
+// A routing table entry, From has To in its routing table.
+// Both are indices into the graph's nodes.
+type Edge struct {
+	From uint32
+	To   uint32
+}
+
+// Who-knows-whom graph of a crawl, built from the routing tables of the crawled peers
+type Graph struct {
+	nodes  []peer.ID
+	states []NodeState
+	index  map[peer.ID]uint32
+	edges  []Edge
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes:  make([]peer.ID, 0),
+		states: make([]NodeState, 0),
+		index:  make(map[peer.ID]uint32),
+		edges:  make([]Edge, 0),
+	}
+}
+
+// Add a successfully queried peer and an edge to each of its routing table entries
+func (g *Graph) AddPeer(p *walker.Peer) {
+	from := g.node(p.ID)
+	g.states[from] = NodeReachable
+	for _, entry := range p.Buckets {
+		if entry.ID == p.ID {
+			continue
+		}
+		g.edges = append(g.edges, Edge{From: from, To: g.node(entry.ID)})
+	}
+}
+
+// Mark a peer as unreachable, unless it was already queried successfully
+func (g *Graph) AddUnreachable(id peer.ID) {
+	i := g.node(id)
+	if g.states[i] != NodeReachable {
+		g.states[i] = NodeUnreachable
+	}
+}
+
+func (g *Graph) NumNodes() int {
+	return len(g.nodes)
+}
+
+func (g *Graph) NumEdges() int {
+	return len(g.edges)
+}
+
+func (g *Graph) Node(i uint32) peer.ID {
+	return g.nodes[i]
+}
+
+func (g *Graph) State(i uint32) NodeState {
+	return g.states[i]
+}
+
+func (g *Graph) Edges() []Edge {
+	return g.edges
+}
+
+// Index of the peer, adding it as an unknown node if it is not yet part of the graph
+func (g *Graph) node(id peer.ID) uint32 {
+	if i, ok := g.index[id]; ok {
+		return i
+	}
+	i := uint32(len(g.nodes))
+	g.nodes = append(g.nodes, id)
+	g.states = append(g.states, NodeUnknown)
+	g.index[id] = i
+	return i
+}
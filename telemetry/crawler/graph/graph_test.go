@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	ids := make([]peer.ID, 4)
+	for i := range ids {
+		id, err := test.RandPeerID()
+		require.NoError(t, err)
+		ids[i] = id
+	}
+
+	g := New()
+	g.AddPeer(&walker.Peer{ID: ids[0], Buckets: []walker.BucketEntry{{ID: ids[1]}, {ID: ids[2]}, {ID: ids[3]}}})
+	g.AddPeer(&walker.Peer{ID: ids[1], Buckets: []walker.BucketEntry{{ID: ids[0]}, {ID: ids[2]}}})
+	g.AddUnreachable(ids[2])
+
+	stats := ComputeStats(g)
+	assert.Equal(t, 4, stats.Nodes)
+	assert.Equal(t, 5, stats.Edges)
+	assert.Equal(t, 2, stats.Reachable)
+	assert.Equal(t, 1, stats.Unreachable)
+	assert.Equal(t, 1, stats.Unknown)
+	assert.Equal(t, 3, stats.StaleEntries)
+	assert.Equal(t, 2, stats.OutDegree.Min)
+	assert.Equal(t, 3, stats.OutDegree.Max)
+	assert.Equal(t, map[int]int{1: 1, 2: 1}, stats.UnreachableInDegree.Histogram)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEdgeList(&buf, g))
+	decoded, err := ReadEdgeList(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, g.nodes, decoded.nodes)
+	assert.Equal(t, g.states, decoded.states)
+	assert.Equal(t, g.edges, decoded.edges)
+}
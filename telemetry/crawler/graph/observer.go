@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+)
+
+var _ (crawler.Observer) = (*Observer)(nil)
+
+// Builds the graph of each crawl and hands it to a callback when the crawl ends
+type Observer struct {
+	mu    sync.Mutex
+	graph *Graph
+	fn    func(*Graph)
+}
+
+func NewObserver(fn func(*Graph)) *Observer {
+	return &Observer{
+		graph: New(),
+		fn:    fn,
+	}
+}
+
+// CrawlBegin implements crawler.Observer
+func (o *Observer) CrawlBegin() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.graph = New()
+}
+
+// CrawlEnd implements crawler.Observer
+func (o *Observer) CrawlEnd() {
+	o.mu.Lock()
+	g := o.graph
+	o.graph = New()
+	o.mu.Unlock()
+	o.fn(g)
+}
+
+// ObservePeer implements crawler.Observer
+func (o *Observer) ObservePeer(p *walker.Peer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.graph.AddPeer(p)
+}
+
+// ObserveError implements crawler.Observer
+func (o *Observer) ObserveError(e *walker.Error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.graph.AddUnreachable(e.ID)
+}
+
+// ObserveEvent implements crawler.Observer
+func (o *Observer) ObserveEvent(*crawler.Event) {
+}
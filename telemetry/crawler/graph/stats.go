@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"slices"
+)
+
+type Stats struct {
+	Nodes       int `json:"nodes"`
+	Edges       int `json:"edges"`
+	Reachable   int `json:"reachable"`
+	Unreachable int `json:"unreachable"`
+	// Peers found in routing tables that were never queried
+	Unknown int `json:"unknown"`
+	// Routing table entries pointing at peers that are not reachable
+	StaleEntries int `json:"stale_entries"`
+	// Number of routing table entries of each reachable peer
+	OutDegree Distribution `json:"out_degree"`
+	// Number of routing tables each peer is in
+	InDegree Distribution `json:"in_degree"`
+	// Number of routing tables each peer that is not reachable is in
+	UnreachableInDegree Distribution `json:"unreachable_in_degree"`
+}
+
+type Distribution struct {
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	// Number of nodes with each degree
+	Histogram map[int]int `json:"histogram"`
+}
+
+func ComputeStats(g *Graph) Stats {
+	in := make([]int, len(g.nodes))
+	out := make([]int, len(g.nodes))
+	stats := Stats{
+		Nodes: len(g.nodes),
+		Edges: len(g.edges),
+	}
+	for _, edge := range g.edges {
+		out[edge.From] += 1
+		in[edge.To] += 1
+		if g.states[edge.To] != NodeReachable {
+			stats.StaleEntries += 1
+		}
+	}
+
+	reachableOut := make([]int, 0, len(g.nodes))
+	unreachableIn := make([]int, 0)
+	for i, state := range g.states {
+		switch state {
+		case NodeReachable:
+			stats.Reachable += 1
+			reachableOut = append(reachableOut, out[i])
+		case NodeUnreachable:
+			stats.Unreachable += 1
+		default:
+			stats.Unknown += 1
+		}
+		if state != NodeReachable {
+			unreachableIn = append(unreachableIn, in[i])
+		}
+	}
+
+	stats.OutDegree = newDistribution(reachableOut)
+	stats.InDegree = newDistribution(in)
+	stats.UnreachableInDegree = newDistribution(unreachableIn)
+	return stats
+}
+
+func newDistribution(values []int) Distribution {
+	d := Distribution{Histogram: make(map[int]int)}
+	if len(values) == 0 {
+		return d
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	d.Min = sorted[0]
+	d.Max = sorted[len(sorted)-1]
+
+	total := 0
+	for _, v := range sorted {
+		total += v
+		d.Histogram[v] += 1
+	}
+	d.Mean = float64(total) / float64(len(sorted))
+	if n := len(sorted); n%2 == 0 {
+		d.Median = float64(sorted[n/2-1]+sorted[n/2]) / 2
+	} else {
+		d.Median = float64(sorted[n/2])
+	}
+	return d
+}
@@ -13,7 +13,13 @@ var (
 		Version: "0.0.0",
 	}
 
-	unitCount = "{count}"
+	AttrReason    = "reason"
+	AttrStage     = "stage"
+	AttrTransport = "transport"
+
+	unitCount   = "{count}"
+	unitSeconds = "s"
+	unitPerHour = "{count}/h"
 )
 
 type Metrics struct {
@@ -26,6 +32,16 @@ type Metrics struct {
 	ErrorsCurrentCrawl         metric.Int64ObservableGauge
 	ErrorsLastCrawl            metric.Int64ObservableGauge
 	CompletedCrawls            metric.Int64ObservableGauge
+	Failures                   metric.Int64Counter
+	DialFailures               metric.Int64Counter
+
+	// Continuous mode
+	LivePeers      metric.Int64ObservableGauge
+	ChurnRate      metric.Float64ObservableGauge
+	PeerJoins      metric.Int64Counter
+	PeerLeaves     metric.Int64Counter
+	AddressChanges metric.Int64Counter
+	SessionLength  metric.Float64Histogram
 }
 
 func New(meterProvider metric.MeterProvider) (*Metrics, error) {
@@ -94,6 +110,79 @@ func New(meterProvider metric.MeterProvider) (*Metrics, error) {
 		return nil, err
 	}
 
+	Failures, err := m.Int64Counter(
+		"crawler.failures",
+		metric.WithDescription("Number of peers that could not be walked, by stage and failure reason"),
+		metric.WithUnit(unitCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	DialFailures, err := m.Int64Counter(
+		"crawler.dial_failures",
+		metric.WithDescription("Number of failed address dials, by transport and failure reason"),
+		metric.WithUnit(unitCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	LivePeers, err := m.Int64ObservableGauge(
+		"crawler.live_peers",
+		metric.WithDescription("Number of peers currently in the live peer set, in continuous mode"),
+		metric.WithUnit(unitCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ChurnRate, err := m.Float64ObservableGauge(
+		"crawler.churn_rate",
+		metric.WithDescription("Number of peer joins and leaves per hour over the last hour, in continuous mode"),
+		metric.WithUnit(unitPerHour),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	PeerJoins, err := m.Int64Counter(
+		"crawler.peer_joins",
+		metric.WithDescription("Number of peers that joined the live peer set"),
+		metric.WithUnit(unitCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	PeerLeaves, err := m.Int64Counter(
+		"crawler.peer_leaves",
+		metric.WithDescription("Number of peers that left the live peer set"),
+		metric.WithUnit(unitCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	AddressChanges, err := m.Int64Counter(
+		"crawler.address_changes",
+		metric.WithDescription("Number of times a live peer changed its addresses"),
+		metric.WithUnit(unitCount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	SessionLength, err := m.Float64Histogram(
+		"crawler.session_length",
+		metric.WithDescription("Time between a peer joining and leaving the live peer set"),
+		metric.WithUnit(unitSeconds),
+		metric.WithExplicitBucketBoundaries(60, 300, 900, 1800, 3600, 2*3600, 6*3600, 12*3600, 24*3600, 3*24*3600, 7*24*3600),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Metrics{
 		m: m,
 
@@ -104,6 +193,15 @@ func New(meterProvider metric.MeterProvider) (*Metrics, error) {
 		ErrorsCurrentCrawl:         ErrorsCurrentCrawl,
 		ErrorsLastCrawl:            ErrorsLastCrawl,
 		CompletedCrawls:            CompletedCrawls,
+		Failures:                   Failures,
+		DialFailures:               DialFailures,
+
+		LivePeers:      LivePeers,
+		ChurnRate:      ChurnRate,
+		PeerJoins:      PeerJoins,
+		PeerLeaves:     PeerLeaves,
+		AddressChanges: AddressChanges,
+		SessionLength:  SessionLength,
 	}, nil
 }
 
@@ -116,6 +214,8 @@ func (m *Metrics) RegisterCallback(cb func(context.Context, metric.Observer) err
 		m.ErrorsCurrentCrawl,
 		m.ErrorsLastCrawl,
 		m.CompletedCrawls,
+		m.LivePeers,
+		m.ChurnRate,
 	)
 	return err
 }
@@ -1,11 +1,39 @@
 package crawler
 
-import "github.com/diogo464/telemetry/walker"
+import (
+	"time"
+
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
 
 type Observer interface {
 	walker.Observer
 	CrawlBegin()
 	CrawlEnd()
+	// Changes to the live peer set, only emitted in continuous mode
+	ObserveEvent(*Event)
+}
+
+type EventKind string
+
+const (
+	EventJoin          EventKind = "join"
+	EventLeave         EventKind = "leave"
+	EventAddressChange EventKind = "address_change"
+)
+
+type Event struct {
+	Kind      EventKind             `json:"kind"`
+	ID        peer.ID               `json:"id"`
+	Network   string                `json:"network"`
+	Time      time.Time             `json:"time"`
+	Addresses []multiaddr.Multiaddr `json:"addresses"`
+	// Addresses before the change, only set for address changes
+	PreviousAddresses []multiaddr.Multiaddr `json:"previous_addresses,omitempty"`
+	// Time between the peer joining and when it was last seen, only set for leaves
+	SessionLength time.Duration `json:"session_length,omitempty"`
 }
 
 var _ (Observer) = (*walkerObserverBridge)(nil)
@@ -20,8 +48,14 @@ func (o *walkerObserverBridge) ObservePeer(p *walker.Peer) {
 func (o *walkerObserverBridge) ObserveError(e *walker.Error) {
 	o.observer.ObserveError(e)
 }
-func (o *walkerObserverBridge) CrawlBegin() {}
-func (o *walkerObserverBridge) CrawlEnd()   {}
+func (o *walkerObserverBridge) CrawlBegin()         {}
+func (o *walkerObserverBridge) CrawlEnd()           {}
+func (o *walkerObserverBridge) ObserveEvent(*Event) {}
 func newWalkerObserverBridge(observer walker.Observer) *walkerObserverBridge {
 	return &walkerObserverBridge{observer}
 }
+
+// Adapt a walker.Observer to an Observer that ignores crawl boundaries and events
+func FromWalkerObserver(observer walker.Observer) Observer {
+	return newWalkerObserverBridge(observer)
+}
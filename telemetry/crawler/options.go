@@ -1,12 +1,22 @@
 package crawler
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/diogo464/telemetry/walker"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/noop"
 	"go.uber.org/zap"
 )
 
+const (
+	DEFAULT_REQUERY_INTERVAL       = 15 * time.Minute
+	DEFAULT_RETRY_INTERVAL         = 2 * time.Minute
+	DEFAULT_LEAVE_AFTER            = 3
+	DEFAULT_CONTINUOUS_CONCURRENCY = 64
+)
+
 type Option func(*options) error
 
 type options struct {
@@ -14,6 +24,13 @@ type options struct {
 	meterProvider metric.MeterProvider
 	observers     []Observer
 	walkerOpts    []walker.Option
+	networks      []*walker.Network
+
+	continuous            bool
+	requeryInterval       time.Duration
+	retryInterval         time.Duration
+	leaveAfter            int
+	continuousConcurrency int
 }
 
 func WithLogger(l *zap.Logger) Option {
@@ -51,12 +68,84 @@ func WithWalkerOption(walkerOpt ...walker.Option) Option {
 	}
 }
 
+// Crawl the given network, can be used multiple times to crawl several networks at the same time.
+// Each network gets its own walker, created with the walker options and the network.
+func WithNetwork(network walker.Network) Option {
+	return func(o *options) error {
+		for _, n := range o.networks {
+			if n.Name == network.Name {
+				return fmt.Errorf("duplicate network %q", network.Name)
+			}
+		}
+		o.networks = append(o.networks, &network)
+		return nil
+	}
+}
+
+// Keep a live peer set and re-query peers on a per-peer schedule instead of doing back-to-back full crawls
+func WithContinuous(continuous bool) Option {
+	return func(o *options) error {
+		o.continuous = continuous
+		return nil
+	}
+}
+
+// How long to wait before querying a live peer again, in continuous mode
+func WithRequeryInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return fmt.Errorf("requery interval must be positive")
+		}
+		o.requeryInterval = interval
+		return nil
+	}
+}
+
+// How long to wait before querying a peer again after a failed query, in continuous mode
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return fmt.Errorf("retry interval must be positive")
+		}
+		o.retryInterval = interval
+		return nil
+	}
+}
+
+// Number of consecutive failed queries after which a peer is considered to have left the network, in continuous mode
+func WithLeaveAfter(failures int) Option {
+	return func(o *options) error {
+		if failures <= 0 {
+			return fmt.Errorf("leave after must be positive")
+		}
+		o.leaveAfter = failures
+		return nil
+	}
+}
+
+// Maximum number of peers queried at the same time, in continuous mode
+func WithContinuousConcurrency(concurrency int) Option {
+	return func(o *options) error {
+		if concurrency <= 0 {
+			return fmt.Errorf("continuous concurrency must be positive")
+		}
+		o.continuousConcurrency = concurrency
+		return nil
+	}
+}
+
 func defaults() *options {
 	return &options{
 		logger:        zap.NewNop(),
 		meterProvider: noop.NewMeterProvider(),
 		observers:     []Observer{},
 		walkerOpts:    []walker.Option{},
+
+		continuous:            false,
+		requeryInterval:       DEFAULT_REQUERY_INTERVAL,
+		retryInterval:         DEFAULT_RETRY_INTERVAL,
+		leaveAfter:            DEFAULT_LEAVE_AFTER,
+		continuousConcurrency: DEFAULT_CONTINUOUS_CONCURRENCY,
 	}
 }
 
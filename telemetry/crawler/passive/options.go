@@ -0,0 +1,117 @@
+package passive
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/multiformats/go-multiaddr"
+	"go.uber.org/zap"
+)
+
+type Option func(*options) error
+
+type options struct {
+	logger      *zap.Logger
+	host        host.Host
+	listenAddrs []multiaddr.Multiaddr
+	network     walker.Network
+	observers   []crawler.Observer
+	round       time.Duration
+	serveDHT    bool
+}
+
+func WithLogger(l *zap.Logger) Option {
+	return func(o *options) error {
+		o.logger = l
+		return nil
+	}
+}
+
+// Host to listen on, ex: the host of a local kubo node. A new host is created if none is provided.
+func WithHost(h host.Host) Option {
+	return func(o *options) error {
+		o.host = h
+		return nil
+	}
+}
+
+// Addresses the created host listens on, ignored if a host is provided
+func WithListenAddrs(addrs ...multiaddr.Multiaddr) Option {
+	return func(o *options) error {
+		o.listenAddrs = addrs
+		return nil
+	}
+}
+
+// The dht network to join, its first protocol is served and its seeds are used to bootstrap
+func WithNetwork(network walker.Network) Option {
+	return func(o *options) error {
+		if len(network.Protocols) == 0 {
+			return fmt.Errorf("network %q has no dht protocols", network.Name)
+		}
+		o.network = network
+		return nil
+	}
+}
+
+func WithObserver(observer crawler.Observer) Option {
+	return func(o *options) error {
+		o.observers = append(o.observers, observer)
+		return nil
+	}
+}
+
+func WithWalkerObserver(observer walker.Observer) Option {
+	return func(o *options) error {
+		o.observers = append(o.observers, crawler.FromWalkerObserver(observer))
+		return nil
+	}
+}
+
+// How often the peers seen are reported to the observers, each report is a crawl
+func WithRoundInterval(interval time.Duration) Option {
+	return func(o *options) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid round interval %v", interval)
+		}
+		o.round = interval
+		return nil
+	}
+}
+
+// Run a dht server on the host, enabled by default.
+// When disabled peers are only recorded from the host's identify events, use it when the host already runs a dht.
+func WithServeDHT(enabled bool) Option {
+	return func(o *options) error {
+		o.serveDHT = enabled
+		return nil
+	}
+}
+
+func defaults() *options {
+	return &options{
+		logger: zap.NewNop(),
+		listenAddrs: []multiaddr.Multiaddr{
+			multiaddr.StringCast("/ip4/0.0.0.0/tcp/4001"),
+			multiaddr.StringCast("/ip4/0.0.0.0/udp/4001/quic-v1"),
+			multiaddr.StringCast("/ip6/::/tcp/4001"),
+			multiaddr.StringCast("/ip6/::/udp/4001/quic-v1"),
+		},
+		network:   walker.NetworkAmino(),
+		observers: []crawler.Observer{},
+		round:     30 * time.Minute,
+		serveDHT:  true,
+	}
+}
+
+func apply(opts *options, o ...Option) error {
+	for _, opt := range o {
+		if err := opt(opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
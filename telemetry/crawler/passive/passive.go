@@ -0,0 +1,246 @@
+// Package passive discovers peers by running a dht server node and recording every peer that contacts it.
+// Unlike the walker it also finds peers that are not reachable, like peers behind a NAT.
+package passive
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+	"go.uber.org/zap"
+)
+
+type seenPeer struct {
+	first    time.Time
+	last     time.Time
+	addrs    []multiaddr.Multiaddr
+	identify *walker.Identify
+	messages map[string]uint64
+}
+
+type Node struct {
+	l         *zap.Logger
+	opts      *options
+	h         host.Host
+	ownsHost  bool
+	dht       *dht.IpfsDHT
+	sub       event.Subscription
+	lastRound time.Time
+
+	mu    sync.Mutex
+	peers map[peer.ID]*seenPeer
+}
+
+func New(ctx context.Context, o ...Option) (*Node, error) {
+	opts := defaults()
+	if err := apply(opts, o...); err != nil {
+		return nil, err
+	}
+
+	n := &Node{
+		l:         opts.logger,
+		opts:      opts,
+		h:         opts.host,
+		lastRound: time.Now(),
+		peers:     make(map[peer.ID]*seenPeer),
+	}
+	if n.h == nil {
+		h, err := libp2p.New(libp2p.ListenAddrs(opts.listenAddrs...))
+		if err != nil {
+			return nil, err
+		}
+		n.h = h
+		n.ownsHost = true
+	}
+
+	sub, err := n.h.EventBus().Subscribe(new(event.EvtPeerIdentificationCompleted))
+	if err != nil {
+		n.Close()
+		return nil, err
+	}
+	n.sub = sub
+
+	if opts.serveDHT {
+		d, err := dht.New(ctx, n.h,
+			dht.Mode(dht.ModeServer),
+			dht.V1ProtocolOverride(opts.network.Protocols[0]),
+			dht.BootstrapPeers(opts.network.Seeds...),
+			dht.OnRequestHook(n.onRequest),
+		)
+		if err != nil {
+			n.Close()
+			return nil, err
+		}
+		n.dht = d
+	}
+	return n, nil
+}
+
+func (n *Node) Host() host.Host {
+	return n.h
+}
+
+// Record peers until the context is canceled, the peers seen are reported to the observers every round
+func (n *Node) Run(ctx context.Context) error {
+	if n.dht != nil {
+		if err := n.dht.Bootstrap(ctx); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(n.opts.round)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-n.sub.Out():
+			if !ok {
+				return nil
+			}
+			n.onIdentify(e.(event.EvtPeerIdentificationCompleted))
+		case <-ticker.C:
+			n.Report()
+		}
+	}
+}
+
+func (n *Node) Close() {
+	if n.dht != nil {
+		n.dht.Close()
+	}
+	if n.sub != nil {
+		n.sub.Close()
+	}
+	if n.ownsHost {
+		n.h.Close()
+	}
+}
+
+// Report the peers seen since the last report and the peers still connected as a single crawl
+func (n *Node) Report() {
+	now := time.Now()
+	peers := make([]*walker.Peer, 0)
+
+	n.mu.Lock()
+	for pid, seen := range n.peers {
+		connected := n.h.Network().Connectedness(pid) == network.Connected
+		if seen.last.Before(n.lastRound) && !connected {
+			delete(n.peers, pid)
+			continue
+		}
+		peers = append(peers, n.toWalkerPeer(pid, seen))
+		seen.messages = make(map[string]uint64)
+	}
+	n.lastRound = now
+	n.mu.Unlock()
+
+	n.l.Info("reporting passive peers", zap.Int("peers", len(peers)))
+	for _, observer := range n.opts.observers {
+		observer.CrawlBegin()
+	}
+	for _, p := range peers {
+		for _, observer := range n.opts.observers {
+			observer.ObservePeer(p)
+		}
+	}
+	for _, observer := range n.opts.observers {
+		observer.CrawlEnd()
+	}
+}
+
+// Number of peers recorded since the last report or still connected
+func (n *Node) NumPeers() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.peers)
+}
+
+func (n *Node) onRequest(ctx context.Context, s network.Stream, req *pb.Message) {
+	pid := s.Conn().RemotePeer()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	seen := n.seen(pid)
+	seen.messages[req.GetType().String()] += 1
+	if addr := s.Conn().RemoteMultiaddr(); !slices.ContainsFunc(seen.addrs, addr.Equal) {
+		seen.addrs = append(seen.addrs, addr)
+	}
+}
+
+func (n *Node) onIdentify(evt event.EvtPeerIdentificationCompleted) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// peers we connect to ourselves did not contact us, they are only recorded once they send a request
+	seen, ok := n.peers[evt.Peer]
+	if !ok && (evt.Conn == nil || evt.Conn.Stat().Direction != network.DirInbound) {
+		return
+	}
+	if !ok {
+		seen = n.seen(evt.Peer)
+	}
+	seen.identify = walker.IdentifyFromEvent(evt)
+}
+
+// Must be called with the lock held
+func (n *Node) seen(pid peer.ID) *seenPeer {
+	now := time.Now()
+	seen, ok := n.peers[pid]
+	if !ok {
+		seen = &seenPeer{first: now, messages: make(map[string]uint64)}
+		n.peers[pid] = seen
+	}
+	seen.last = now
+	return seen
+}
+
+// Must be called with the lock held
+func (n *Node) toWalkerPeer(pid peer.ID, seen *seenPeer) *walker.Peer {
+	addrs := seen.addrs
+	agent := ""
+	protocols := []protocol.ID{}
+	if seen.identify != nil {
+		if len(seen.identify.ListenAddrs) > 0 {
+			addrs = seen.identify.ListenAddrs
+		}
+		agent = seen.identify.AgentVersion
+		protocols = seen.identify.Protocols
+	} else {
+		if v, err := n.h.Peerstore().Get(pid, "AgentVersion"); err == nil {
+			agent, _ = v.(string)
+		}
+		if ps, err := n.h.Peerstore().GetProtocols(pid); err == nil {
+			protocols = ps
+		}
+	}
+
+	messages := make(map[string]uint64, len(seen.messages))
+	for kind, count := range seen.messages {
+		messages[kind] = count
+	}
+	return &walker.Peer{
+		ID:           pid,
+		Network:      n.opts.network.Name,
+		Addresses:    addrs,
+		Agent:        agent,
+		Protocols:    protocols,
+		Buckets:      []walker.BucketEntry{},
+		Requests:     []walker.Request{},
+		ConnectStart: seen.first,
+		Identify:     seen.identify,
+		Fingerprint:  walker.Classify(agent, protocols),
+		Messages:     messages,
+	}
+}
@@ -0,0 +1,83 @@
+package passive
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	pb "github.com/libp2p/go-libp2p-kad-dht/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	crawls int
+	peers  map[peer.ID]*walker.Peer
+}
+
+func (r *recorder) ObservePeer(p *walker.Peer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers[p.ID] = p
+}
+func (r *recorder) ObserveError(*walker.Error)  {}
+func (r *recorder) ObserveEvent(*crawler.Event) {}
+func (r *recorder) CrawlBegin()                 { r.peers = make(map[peer.ID]*walker.Peer) }
+func (r *recorder) CrawlEnd()                   { r.crawls += 1 }
+
+func TestPassive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	mn, err := mocknet.FullMeshLinked(2)
+	require.NoError(t, err)
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	network := walker.NewNetwork("test", walker.NetworkAmino().Protocols, nil)
+	r := &recorder{}
+	node, err := New(ctx, WithHost(hosts[0]), WithNetwork(network), WithObserver(r), WithRoundInterval(time.Hour))
+	require.NoError(t, err)
+	defer node.Close()
+	go node.Run(ctx)
+
+	// the second host contacts the passive node
+	require.NoError(t, hosts[1].Connect(ctx, peer.AddrInfo{ID: hosts[0].ID(), Addrs: hosts[0].Addrs()}))
+	messenger, err := pb.NewProtocolMessenger(walker.NewMessageSender(hosts[1], network.Protocols...))
+	require.NoError(t, err)
+	_, err = messenger.GetClosestPeers(ctx, hosts[0].ID(), hosts[1].ID())
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		seen, ok := node.peers[hosts[1].ID()]
+		return ok && seen.identify != nil
+	}, 10*time.Second, 10*time.Millisecond)
+
+	node.Report()
+	require.Equal(t, 1, r.crawls)
+	require.Contains(t, r.peers, hosts[1].ID())
+	p := r.peers[hosts[1].ID()]
+	assert.Equal(t, "test", p.Network)
+	assert.Equal(t, uint64(1), p.Messages[pb.Message_FIND_NODE.String()])
+	assert.NotEmpty(t, p.Agent)
+	assert.NotEmpty(t, p.Protocols)
+	assert.NotNil(t, p.Identify)
+
+	// message counts are per round, connected peers are reported every round
+	node.Report()
+	require.Contains(t, r.peers, hosts[1].ID())
+	assert.Empty(t, r.peers[hosts[1].ID()].Messages)
+
+	// disconnected peers are dropped after a round without contact
+	require.NoError(t, mn.DisconnectPeers(hosts[0].ID(), hosts[1].ID()))
+	node.Report()
+	assert.NotContains(t, r.peers, hosts[1].ID())
+	assert.Equal(t, 0, node.NumPeers())
+}
@@ -22,6 +22,7 @@ type Exporter interface {
 	Properties(peer.ID, telemetry.Session, []telemetry.Property)
 	Events(peer.ID, telemetry.Session, telemetry.EventDescriptor, []telemetry.Event)
 	Bandwidth(peer.ID, telemetry.Bandwidth)
+	Probe(peer.ID, Probe)
 }
 
 type noOpExporter struct{}
@@ -62,6 +63,10 @@ func (*noOpExporter) Metrics(peer.ID, telemetry.Session, telemetry.Metrics) {
 func (*noOpExporter) Bandwidth(peer.ID, telemetry.Bandwidth) {
 }
 
+// Probe implements Exporter
+func (*noOpExporter) Probe(peer.ID, Probe) {
+}
+
 type observableExporter struct {
 	m *metrics.ExporterMetrics
 	e Exporter
@@ -88,6 +93,12 @@ func (e *observableExporter) Bandwidth(p peer.ID, b telemetry.Bandwidth) {
 	e.e.Bandwidth(p, b)
 }
 
+// Probe implements Exporter
+func (e *observableExporter) Probe(p peer.ID, pr Probe) {
+	e.m.Exports.Add(context.Background(), 1, metric.WithAttributes(metrics.AttrExportKindProbe))
+	e.e.Probe(p, pr)
+}
+
 // Events implements Exporter
 func (e *observableExporter) Events(p peer.ID, s telemetry.Session, d telemetry.EventDescriptor, ev []telemetry.Event) {
 	e.m.Exports.Add(context.Background(), 1, metric.WithAttributes(metrics.AttrExportKindEvents))
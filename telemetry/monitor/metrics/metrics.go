@@ -29,6 +29,7 @@ var (
 	AttrExportKindEvents     = KeyExportKind.String("events")
 	AttrExportKindMetrics    = KeyExportKind.String("metrics")
 	AttrExportKindProperties = KeyExportKind.String("properties")
+	AttrExportKindProbe      = KeyExportKind.String("probe")
 	AttrExportKindSession    = KeyExportKind.String("session")
 
 	histogramBucketsMs = []float64{0.01, 0.05, 0.1, 0.3, 0.6, 0.8, 1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 5000, 10000, 20000, 50000, 100000}
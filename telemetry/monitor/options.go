@@ -2,9 +2,11 @@ package monitor
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"time"
 
+	"github.com/diogo464/telemetry"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/host"
 	"go.opentelemetry.io/otel/metric"
@@ -21,6 +23,12 @@ const (
 	DEFAULT_BANDWIDTH_ENABLED   = true
 	DEFAULT_BANDWIDTH_PERIOD    = time.Minute * 30
 	DEFAULT_BANDWIDTH_TIMEOUT   = time.Minute * 5
+	DEFAULT_BANDWIDTH_STREAMS   = telemetry.DEFAULT_BANDWIDTH_STREAMS
+	DEFAULT_BANDWIDTH_DURATION  = telemetry.DEFAULT_BANDWIDTH_DURATION
+	DEFAULT_PROBE_ENABLED       = true
+	DEFAULT_PROBE_PERIOD        = time.Minute * 5
+	DEFAULT_PROBE_TIMEOUT       = time.Minute
+	DEFAULT_PROBE_PINGS         = 3
 )
 
 type Option func(*options) error
@@ -38,26 +46,40 @@ type options struct {
 	BandwidthEnabled bool
 	BandwidthPeriod  time.Duration
 	BandwidthTimeout time.Duration
-	Host             host.Host
-	Exporter         Exporter
-	Listener         net.Listener
-	Logger           *zap.Logger
-	MeterProvider    metric.MeterProvider
+	// Number of parallel streams and duration of each direction of a bandwidth test
+	BandwidthStreams  uint32
+	BandwidthDuration time.Duration
+	// Periodic ping, per-transport dial and identify probes
+	ProbeEnabled  bool
+	ProbePeriod   time.Duration
+	ProbeTimeout  time.Duration
+	ProbePings    int
+	Host          host.Host
+	Exporter      Exporter
+	Listener      net.Listener
+	Logger        *zap.Logger
+	MeterProvider metric.MeterProvider
 }
 
 func defaults() *options {
 	return &options{
-		MaxFailedAttemps: DEFAULT_MAX_FAILED_ATTEMPTS,
-		RetryInterval:    DEFAULT_RETRY_INTERVAL,
-		CollectEnabled:   DEFAULT_COLLECT_ENABLED,
-		CollectPeriod:    DEFAULT_COLLECT_PERIOD,
-		CollectTimeout:   DEFAULT_COLLECT_TIMEOUT,
-		BandwidthEnabled: DEFAULT_BANDWIDTH_ENABLED,
-		BandwidthPeriod:  DEFAULT_BANDWIDTH_PERIOD,
-		BandwidthTimeout: DEFAULT_BANDWIDTH_TIMEOUT,
-		Listener:         nil,
-		Logger:           zap.NewNop(),
-		MeterProvider:    noop.NewMeterProvider(),
+		MaxFailedAttemps:  DEFAULT_MAX_FAILED_ATTEMPTS,
+		RetryInterval:     DEFAULT_RETRY_INTERVAL,
+		CollectEnabled:    DEFAULT_COLLECT_ENABLED,
+		CollectPeriod:     DEFAULT_COLLECT_PERIOD,
+		CollectTimeout:    DEFAULT_COLLECT_TIMEOUT,
+		BandwidthEnabled:  DEFAULT_BANDWIDTH_ENABLED,
+		BandwidthPeriod:   DEFAULT_BANDWIDTH_PERIOD,
+		BandwidthTimeout:  DEFAULT_BANDWIDTH_TIMEOUT,
+		BandwidthStreams:  DEFAULT_BANDWIDTH_STREAMS,
+		BandwidthDuration: DEFAULT_BANDWIDTH_DURATION,
+		ProbeEnabled:      DEFAULT_PROBE_ENABLED,
+		ProbePeriod:       DEFAULT_PROBE_PERIOD,
+		ProbeTimeout:      DEFAULT_PROBE_TIMEOUT,
+		ProbePings:        DEFAULT_PROBE_PINGS,
+		Listener:          nil,
+		Logger:            zap.NewNop(),
+		MeterProvider:     noop.NewMeterProvider(),
 	}
 }
 
@@ -126,6 +148,61 @@ func WithBandwidthTimeout(timeout time.Duration) Option {
 	}
 }
 
+func WithBandwidthStreams(streams uint32) Option {
+	return func(o *options) error {
+		if streams == 0 || streams > telemetry.MAX_BANDWIDTH_STREAMS {
+			return fmt.Errorf("bandwidth streams must be between 1 and %d, got %d", telemetry.MAX_BANDWIDTH_STREAMS, streams)
+		}
+		o.BandwidthStreams = streams
+		return nil
+	}
+}
+
+func WithBandwidthDuration(duration time.Duration) Option {
+	return func(o *options) error {
+		// tests are sampled with the default interval, which must fit in the duration
+		if duration < telemetry.DEFAULT_BANDWIDTH_SAMPLE_INTERVAL || duration > telemetry.MAX_BANDWIDTH_DURATION {
+			return fmt.Errorf("bandwidth duration must be between %v and %v, got %v", telemetry.DEFAULT_BANDWIDTH_SAMPLE_INTERVAL, telemetry.MAX_BANDWIDTH_DURATION, duration)
+		}
+		o.BandwidthDuration = duration
+		return nil
+	}
+}
+
+func WithProbeEnabled(enabled bool) Option {
+	return func(o *options) error {
+		o.ProbeEnabled = enabled
+		return nil
+	}
+}
+
+func WithProbePeriod(period time.Duration) Option {
+	return func(o *options) error {
+		if period <= 0 {
+			return fmt.Errorf("probe period must be positive, got %v", period)
+		}
+		o.ProbePeriod = period
+		return nil
+	}
+}
+
+func WithProbeTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.ProbeTimeout = timeout
+		return nil
+	}
+}
+
+func WithProbePings(pings int) Option {
+	return func(o *options) error {
+		if pings < 1 {
+			return fmt.Errorf("probe pings must be at least 1, got %v", pings)
+		}
+		o.ProbePings = pings
+		return nil
+	}
+}
+
 func WithHost(h host.Host) Option {
 	return func(o *options) error {
 		o.Host = h
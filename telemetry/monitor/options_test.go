@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diogo464/telemetry"
+)
+
+func TestBandwidthDurationRange(t *testing.T) {
+	for _, duration := range []time.Duration{0, time.Millisecond, telemetry.MAX_BANDWIDTH_DURATION + time.Second} {
+		if err := apply(defaults(), WithBandwidthDuration(duration)); err == nil {
+			t.Fatalf("expected bandwidth duration %v to be rejected", duration)
+		}
+	}
+	if err := apply(defaults(), WithBandwidthDuration(telemetry.MAX_BANDWIDTH_DURATION)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProbeOptions(t *testing.T) {
+	if err := apply(defaults(), WithProbePeriod(0)); err == nil {
+		t.Fatalf("expected a zero probe period to be rejected")
+	}
+	if err := apply(defaults(), WithProbePings(0)); err == nil {
+		t.Fatalf("expected zero probe pings to be rejected")
+	}
+	if err := apply(defaults(), WithProbePeriod(time.Minute), WithProbePings(1)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -41,7 +41,10 @@ type peerTask struct {
 	command_receiver   <-chan peerCommand
 	collect_ticker     *time.Ticker
 	bandwidth_ticker   *time.Ticker
+	probe_ticker       *time.Ticker
 	client_state       *telemetry.ClientState
+	// session of the last successful collection, InvalidSession until then
+	session telemetry.Session
 }
 
 func newPeerTask(pid peer.ID, host host.Host, opts *options, exporter Exporter, monitor *Monitor, logger *zap.Logger, m *metrics.PeerTaskMetrics) *peerTask {
@@ -63,7 +66,12 @@ func newPeerTask(pid peer.ID, host host.Host, opts *options, exporter Exporter,
 		command_receiver:   command_channel,
 		collect_ticker:     time.NewTicker(opts.CollectPeriod),
 		bandwidth_ticker:   time.NewTicker(opts.BandwidthPeriod),
+		probe_ticker:       time.NewTicker(opts.ProbePeriod),
 		client_state:       nil,
+		session:            telemetry.InvalidSession,
+	}
+	if !opts.ProbeEnabled {
+		pt.probe_ticker.Stop()
 	}
 	go pt.run(ctx)
 	return pt
@@ -82,6 +90,8 @@ LOOP:
 			p.collectTelemetry(ctx)
 		case <-p.bandwidth_ticker.C:
 			p.bandwidthTest(ctx)
+		case <-p.probe_ticker.C:
+			p.probe(ctx)
 		}
 	}
 
@@ -125,6 +135,7 @@ func (p *peerTask) tryCollectTelemetry(ctx context.Context) error {
 		p.metrics.RecordCollectFailure(ctx, "get session")
 		return err
 	}
+	p.session = sess
 
 	p.logger.Info("exporting session", zap.Any("session", sess))
 	if err := p.tryExportSession(ctx, client, sess); err != nil {
@@ -229,19 +240,46 @@ func (p *peerTask) tryBandwidthTest(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	defer client.Close()
+
+	sess, err := client.GetSession(ctx)
+	if err != nil {
+		p.logger.Warn("failed to get session")
+		return err
+	}
+	p.session = sess
 
 	p.logger.Info("starting bandwidth test")
-	result, err := client.Bandwidth(ctx, telemetry.DEFAULT_BANDWIDTH_PAYLOAD_SIZE)
+	result, err := client.Bandwidth(ctx,
+		telemetry.WithBandwidthStreams(p.opts.BandwidthStreams),
+		telemetry.WithBandwidthDuration(p.opts.BandwidthDuration))
 	if err != nil {
 		return err
 	}
 
 	p.logger.Info("exporting bandwidth test result", zap.Any("result", result))
+	p.exporter.Session(p.pid, sess)
 	p.exporter.Bandwidth(p.pid, result)
 
 	return nil
 }
 
+func (p *peerTask) probe(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.ProbeTimeout)
+	defer cancel()
+
+	// probe failures are part of the result and do not count as peer failures
+	p.exporter.PeerBegin(p.pid)
+	result := probePeer(ctx, p.host, p.pid, p.opts.ProbePings)
+	p.logger.Info("exporting probe result", zap.Any("result", result))
+	if p.session != telemetry.InvalidSession {
+		// probes do not use the telemetry protocol, the session is the one seen by the last collection
+		p.exporter.Session(p.pid, p.session)
+	}
+	p.exporter.Probe(p.pid, result)
+	p.exporter.PeerSuccess(p.pid)
+}
+
 func (p *peerTask) createClient(ctx context.Context) (*telemetry.Client, error) {
 	p.logger.Info("creating telemetry client", zap.Any("state", p.client_state))
 
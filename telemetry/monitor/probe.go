@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+const (
+	// Maximum number of addresses tried for each transport
+	probeMaxDialsPerTransport = 3
+)
+
+type Transport string
+
+const (
+	TransportTcp          Transport = "tcp"
+	TransportQuic         Transport = "quic"
+	TransportWebTransport Transport = "webtransport"
+	TransportRelay        Transport = "relay"
+)
+
+var probeTransports = []Transport{TransportTcp, TransportQuic, TransportWebTransport, TransportRelay}
+
+var ErrNoAddresses = fmt.Errorf("peer has no addresses for transport")
+var ErrDialUnsupported = fmt.Errorf("host does not support dialing individual transports")
+
+// Result of a lightweight reachability probe of a peer
+type Probe struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Round trip times of each successful ping
+	PingRtts  []time.Duration `json:"ping_rtts"`
+	PingError string          `json:"ping_error,omitempty"`
+	Dials     []DialProbe     `json:"dials"`
+	// Addresses known for the peer after identify
+	IdentifyAddrs []multiaddr.Multiaddr `json:"identify_addrs"`
+}
+
+// Result of dialing a peer using a single transport
+type DialProbe struct {
+	Transport Transport `json:"transport"`
+	// Number of addresses the peer has for this transport
+	Addresses int  `json:"addresses"`
+	Success   bool `json:"success"`
+	// Address of the successful dial
+	Address  multiaddr.Multiaddr `json:"address,omitempty"`
+	Duration time.Duration       `json:"duration"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// Transport used to dial an address, empty if it is not one of the probed transports
+func TransportOf(addr multiaddr.Multiaddr) Transport {
+	has := func(code int) bool {
+		_, err := addr.ValueForProtocol(code)
+		return err == nil
+	}
+	switch {
+	case has(multiaddr.P_CIRCUIT):
+		return TransportRelay
+	case has(multiaddr.P_WEBTRANSPORT):
+		return TransportWebTransport
+	case has(multiaddr.P_QUIC_V1):
+		return TransportQuic
+	case has(multiaddr.P_WS) || has(multiaddr.P_WSS):
+		return ""
+	case has(multiaddr.P_TCP):
+		return TransportTcp
+	default:
+		return ""
+	}
+}
+
+func probePeer(ctx context.Context, h host.Host, pid peer.ID, pings int) Probe {
+	probe := Probe{
+		Timestamp: time.Now(),
+		PingRtts:  make([]time.Duration, 0, pings),
+	}
+
+	pingCtx, cancel := context.WithCancel(ctx)
+	for result := range ping.Ping(pingCtx, h, pid) {
+		if result.Error != nil {
+			probe.PingError = result.Error.Error()
+			break
+		}
+		probe.PingRtts = append(probe.PingRtts, result.RTT)
+		if len(probe.PingRtts) >= pings {
+			break
+		}
+	}
+	cancel()
+
+	probe.IdentifyAddrs = identifyAddrs(ctx, h, pid)
+
+	addrs := h.Peerstore().Addrs(pid)
+	for _, transport := range probeTransports {
+		probe.Dials = append(probe.Dials, probeDial(ctx, h, pid, transport, addrs))
+	}
+
+	return probe
+}
+
+// Wait for identify to complete on an existing connection and return the addresses of the peer
+func identifyAddrs(ctx context.Context, h host.Host, pid peer.ID) []multiaddr.Multiaddr {
+	conns := h.Network().ConnsToPeer(pid)
+	if ids, ok := h.(interface{ IDService() identify.IDService }); ok && len(conns) > 0 {
+		select {
+		case <-ids.IDService().IdentifyWait(conns[0]):
+		case <-ctx.Done():
+		}
+	}
+	return h.Peerstore().Addrs(pid)
+}
+
+// Dial the peer using only the given transport. The connection is not added to the host and is closed immediately.
+func probeDial(ctx context.Context, h host.Host, pid peer.ID, transport Transport, addrs []multiaddr.Multiaddr) DialProbe {
+	candidates := make([]multiaddr.Multiaddr, 0)
+	for _, addr := range addrs {
+		if TransportOf(addr) != transport {
+			continue
+		}
+		if transport != TransportRelay && !manet.IsPublicAddr(addr) {
+			continue
+		}
+		candidates = append(candidates, addr)
+	}
+
+	result := DialProbe{
+		Transport: transport,
+		Addresses: len(candidates),
+	}
+	if len(candidates) == 0 {
+		result.Error = ErrNoAddresses.Error()
+		return result
+	}
+
+	sw, ok := h.Network().(*swarm.Swarm)
+	if !ok {
+		result.Error = ErrDialUnsupported.Error()
+		return result
+	}
+
+	start := time.Now()
+	for i, addr := range candidates {
+		if i >= probeMaxDialsPerTransport {
+			break
+		}
+		tpt := sw.TransportForDialing(addr)
+		if tpt == nil {
+			result.Error = fmt.Sprintf("no transport for %v", addr)
+			continue
+		}
+		conn, err := tpt.Dial(network.WithForceDirectDial(ctx, "probe"), addr, pid)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		_ = conn.Close()
+		result.Success = true
+		result.Address = addr
+		result.Error = ""
+		break
+	}
+	result.Duration = time.Since(start)
+	return result
+}
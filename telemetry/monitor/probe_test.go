@@ -0,0 +1,27 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestTransportOf(t *testing.T) {
+	cases := map[string]Transport{
+		"/ip4/1.2.3.4/tcp/4001":                      TransportTcp,
+		"/ip4/1.2.3.4/tcp/4001/ws":                   "",
+		"/ip4/1.2.3.4/udp/4001/quic-v1":              TransportQuic,
+		"/ip4/1.2.3.4/udp/4001/quic-v1/webtransport": TransportWebTransport,
+		"/ip4/1.2.3.4/udp/4001/quic-v1/webtransport/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g": TransportWebTransport,
+		"/ip4/1.2.3.4/tcp/4001/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit":          TransportRelay,
+	}
+	for addr, expected := range cases {
+		maddr, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transport := TransportOf(maddr); transport != expected {
+			t.Fatalf("expected transport %q for %v, got %q", expected, addr, transport)
+		}
+	}
+}
@@ -46,6 +46,7 @@ type Service struct {
 
 	downloadBlocker *requestBlocker
 	uploadBlocker   *requestBlocker
+	bandwidthTests  *serviceBandwidthTests
 
 	smetrics *metrics.Metrics
 }
@@ -84,11 +85,15 @@ func NewService(h host.Host, os ...ServiceOption) (*Service, MeterProvider, erro
 
 		downloadBlocker: newRequestBlocker(),
 		uploadBlocker:   newRequestBlocker(),
+		bandwidthTests:  newServiceBandwidthTests(),
 	}
 
 	if opts.enableBandwidth {
+		h.SetStreamHandler(ID_BANDWIDTH, t.bandwidthHandler)
 		h.SetStreamHandler(ID_UPLOAD, t.uploadHandler)
 		h.SetStreamHandler(ID_DOWNLOAD, t.downloadHandler)
+		h.SetStreamHandler(ID_UPLOAD_LEGACY, t.legacyUploadHandler)
+		h.SetStreamHandler(ID_DOWNLOAD_LEGACY, t.legacyDownloadHandler)
 	}
 
 	exporter := otlp_exporter.New(t.metrics.stream)
@@ -162,6 +167,11 @@ func NewService(h host.Host, os ...ServiceOption) (*Service, MeterProvider, erro
 	return t, t.meter_provider, nil
 }
 
+// Current session of the service
+func (s *Service) Session() Session {
+	return s.session
+}
+
 func (s *Service) Context() context.Context {
 	return s.ctx
 }
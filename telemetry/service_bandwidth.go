@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A bandwidth test accepted on a control stream. Data streams join it using its token.
+type serviceBandwidthTest struct {
+	direction uint32
+	streams   uint32
+	opened    atomic.Uint32
+	start     time.Time
+	deadline  time.Time
+	counter   bandwidthCounter
+}
+
+type serviceBandwidthTests struct {
+	mu    sync.Mutex
+	tests map[uint64]*serviceBandwidthTest
+}
+
+func newServiceBandwidthTests() *serviceBandwidthTests {
+	return &serviceBandwidthTests{
+		tests: make(map[uint64]*serviceBandwidthTest),
+	}
+}
+
+func (s *serviceBandwidthTests) register(test *serviceBandwidthTest) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf := make([]byte, 8)
+	for {
+		_, _ = rand.Read(buf)
+		token := binary.BigEndian.Uint64(buf)
+		if _, ok := s.tests[token]; !ok {
+			s.tests[token] = test
+			return token
+		}
+	}
+}
+
+func (s *serviceBandwidthTests) remove(token uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tests, token)
+}
+
+// Join a test as one of its data streams.
+// Returns nil if the token is unknown, the direction does not match, the test ended or all of its streams are already open.
+func (s *serviceBandwidthTests) join(token uint64, direction uint32) *serviceBandwidthTest {
+	s.mu.Lock()
+	test, ok := s.tests[token]
+	s.mu.Unlock()
+
+	if !ok || test.direction != direction || time.Now().After(test.deadline) {
+		return nil
+	}
+	if test.opened.Add(1) > test.streams {
+		return nil
+	}
+	return test
+}
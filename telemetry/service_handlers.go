@@ -9,9 +9,150 @@ import (
 	"github.com/multiformats/go-multiaddr"
 )
 
+func (s *Service) bandwidthHandler(stream network.Stream) {
+	defer stream.Close()
+
+	if !s.serviceAcl.isAllowed(stream.Conn().RemotePeer()) {
+		return
+	}
+
+	_ = stream.SetDeadline(time.Now().Add(MAX_BANDWIDTH_DURATION + 2*BANDWIDTH_GRACE_PERIOD))
+
+	direction, err := utils.ReadU32(stream)
+	if err != nil {
+		return
+	}
+	streams, err := utils.ReadU32(stream)
+	if err != nil {
+		return
+	}
+	duration, err := utils.ReadU64(stream)
+	if err != nil {
+		return
+	}
+	interval, err := utils.ReadU64(stream)
+	if err != nil {
+		return
+	}
+
+	if !validBandwidthRequest(direction, streams, time.Duration(duration), time.Duration(interval)) {
+		_ = utils.WriteU32(stream, bandwidthStatusInvalid)
+		return
+	}
+
+	blocker := s.downloadBlocker
+	if direction == bandwidthDirectionUpload {
+		blocker = s.uploadBlocker
+	}
+	if publicIp, err := utils.GetFirstPublicAddressFromMultiaddrs([]multiaddr.Multiaddr{stream.Conn().RemoteMultiaddr()}); err == nil {
+		if blocker.isBlocked(publicIp) {
+			_ = utils.WriteU32(stream, bandwidthStatusBlocked)
+			return
+		}
+		blocker.block(publicIp, BLOCK_DURATION_BANDWIDTH)
+	}
+
+	if err := utils.WriteU32(stream, bandwidthStatusOk); err != nil {
+		return
+	}
+
+	// echo the client's pings so it can measure rtt before any data is transferred
+	pings, err := utils.ReadU32(stream)
+	if err != nil || pings > MAX_BANDWIDTH_PINGS {
+		return
+	}
+	for i := uint32(0); i < pings; i++ {
+		v, err := utils.ReadU64(stream)
+		if err != nil {
+			return
+		}
+		if err := utils.WriteU64(stream, v); err != nil {
+			return
+		}
+	}
+
+	start := time.Now()
+	test := &serviceBandwidthTest{
+		direction: direction,
+		streams:   streams,
+		start:     start,
+		deadline:  start.Add(time.Duration(duration)),
+	}
+	token := s.bandwidthTests.register(test)
+	defer s.bandwidthTests.remove(token)
+
+	if err := utils.WriteU64(stream, token); err != nil {
+		return
+	}
+
+	if direction == bandwidthDirectionUpload {
+		// the client measures what it receives
+		select {
+		case <-time.After(time.Until(test.deadline)):
+		case <-s.ctx.Done():
+		}
+		return
+	}
+
+	samples := sampleBandwidth(s.ctx, &test.counter, test.start, time.Duration(interval), time.Duration(duration))
+	if err := utils.WriteU32(stream, uint32(len(samples))); err != nil {
+		return
+	}
+	for _, sample := range samples {
+		if err := utils.WriteU64(stream, uint64(sample.Elapsed)); err != nil {
+			return
+		}
+		if err := utils.WriteU64(stream, sample.Bytes); err != nil {
+			return
+		}
+	}
+}
+
 func (s *Service) uploadHandler(stream network.Stream) {
 	defer stream.Close()
 
+	test := s.joinBandwidthTest(stream, bandwidthDirectionUpload)
+	if test == nil {
+		return
+	}
+
+	_ = stream.SetWriteDeadline(test.deadline)
+	copyUntil(stream, utils.NullReader{}, test.deadline)
+}
+
+func (s *Service) downloadHandler(stream network.Stream) {
+	defer stream.Close()
+
+	test := s.joinBandwidthTest(stream, bandwidthDirectionDownload)
+	if test == nil {
+		return
+	}
+
+	// a blocked read is only interrupted by the stream deadline, reset the stream if that is not supported
+	reset := time.AfterFunc(time.Until(test.deadline.Add(BANDWIDTH_GRACE_PERIOD)), func() { _ = stream.Reset() })
+	defer reset.Stop()
+
+	_ = stream.SetReadDeadline(test.deadline)
+	copyUntil(&test.counter, stream, test.deadline)
+}
+
+func (s *Service) joinBandwidthTest(stream network.Stream, direction uint32) *serviceBandwidthTest {
+	if !s.serviceAcl.isAllowed(stream.Conn().RemotePeer()) {
+		return nil
+	}
+
+	_ = stream.SetReadDeadline(time.Now().Add(BANDWIDTH_GRACE_PERIOD))
+	token, err := utils.ReadU64(stream)
+	if err != nil {
+		return nil
+	}
+	return s.bandwidthTests.join(token, direction)
+}
+
+// Sends the requested payload and replies with the rate, used by clients that predate ID_BANDWIDTH
+func (s *Service) legacyUploadHandler(stream network.Stream) {
+	defer stream.Close()
+
 	if !s.serviceAcl.isAllowed(stream.Conn().RemotePeer()) {
 		return
 	}
@@ -24,25 +165,22 @@ func (s *Service) uploadHandler(stream network.Stream) {
 		s.uploadBlocker.block(publicIp, BLOCK_DURATION_BANDWIDTH)
 	}
 
-	requested_payload, err := utils.ReadU32(stream)
-	if err != nil || requested_payload > DEFAULT_MAX_BANDWIDTH_PAYLOAD_SIZE {
+	payload, err := utils.ReadU32(stream)
+	if err != nil || payload > MAX_LEGACY_BANDWIDTH_PAYLOAD_SIZE {
 		return
 	}
 
-	upload_start := time.Now()
-	n, err := io.Copy(stream, io.LimitReader(utils.NullReader{}, int64(requested_payload)))
+	start := time.Now()
+	n, err := io.Copy(stream, io.LimitReader(utils.NullReader{}, int64(payload)))
 	if err != nil {
 		return
 	}
-	elapsed := time.Since(upload_start)
-	if err != nil {
-		return
-	}
-	rate := uint32(float64(n) / elapsed.Seconds())
+	rate := uint32(float64(n) / time.Since(start).Seconds())
 	_ = utils.WriteU32(stream, rate)
 }
 
-func (s *Service) downloadHandler(stream network.Stream) {
+// Receives the announced payload and replies with the rate, used by clients that predate ID_BANDWIDTH
+func (s *Service) legacyDownloadHandler(stream network.Stream) {
 	defer stream.Close()
 
 	if !s.serviceAcl.isAllowed(stream.Conn().RemotePeer()) {
@@ -57,17 +195,16 @@ func (s *Service) downloadHandler(stream network.Stream) {
 		s.downloadBlocker.block(publicIp, BLOCK_DURATION_BANDWIDTH)
 	}
 
-	expected_payload, err := utils.ReadU32(stream)
-	if err != nil || expected_payload > DEFAULT_MAX_BANDWIDTH_PAYLOAD_SIZE {
+	payload, err := utils.ReadU32(stream)
+	if err != nil || payload > MAX_LEGACY_BANDWIDTH_PAYLOAD_SIZE {
 		return
 	}
 
-	download_start := time.Now()
-	n, err := io.Copy(io.Discard, io.LimitReader(stream, int64(expected_payload)))
-	elapsed := time.Since(download_start)
+	start := time.Now()
+	n, err := io.Copy(io.Discard, io.LimitReader(stream, int64(payload)))
 	if err != nil {
 		return
 	}
-	rate := uint32(float64(n) / elapsed.Seconds())
+	rate := uint32(float64(n) / time.Since(start).Seconds())
 	_ = utils.WriteU32(stream, rate)
 }
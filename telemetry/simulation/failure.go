@@ -0,0 +1,175 @@
+package simulation
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Take a node offline, it is disconnected and unlinked from every other host.
+// Other nodes may keep it in their routing tables so it can still be discovered but never walked.
+func (s *Simulation) Stop(i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop(s.nodes[i])
+}
+
+// Bring an offline node back, it is linked and connected to every online node
+func (s *Simulation) Start(ctx context.Context, i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.start(ctx, s.nodes[i])
+}
+
+// Make a node unreachable from the simulation host while it stays part of the dht
+func (s *Simulation) Unreachable(i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node := s.nodes[i]
+	if !node.reachable {
+		return nil
+	}
+	node.reachable = false
+	if !node.online {
+		return nil
+	}
+	return s.unlink(s.host.ID(), node.Host.ID())
+}
+
+// Undo Unreachable
+func (s *Simulation) Reachable(i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node := s.nodes[i]
+	if node.reachable {
+		return nil
+	}
+	node.reachable = true
+	if !node.online {
+		return nil
+	}
+	_, err := s.mn.LinkPeers(s.host.ID(), node.Host.ID())
+	return err
+}
+
+// Flip the state of a fraction of the nodes, online nodes go offline and offline nodes come back.
+// The nodes are picked using the simulation seed. Returns the indices of the flipped nodes.
+func (s *Simulation) Churn(ctx context.Context, fraction float64) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := int(fraction * float64(len(s.nodes)))
+	flipped := s.rng.Perm(len(s.nodes))[:count]
+	for _, i := range flipped {
+		node := s.nodes[i]
+		var err error
+		if node.online {
+			err = s.stop(node)
+		} else {
+			err = s.start(ctx, node)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return flipped, nil
+}
+
+// Churn the network every interval until the context is canceled
+func (s *Simulation) RunChurn(ctx context.Context, interval time.Duration, fraction float64) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.Churn(ctx, fraction); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Simulation) stop(node *Node) error {
+	if !node.online {
+		return nil
+	}
+	node.online = false
+
+	peers := []peer.ID{}
+	if node.reachable {
+		peers = append(peers, s.host.ID())
+	}
+	for _, other := range s.nodes {
+		if other != node && other.online {
+			peers = append(peers, other.Host.ID())
+		}
+	}
+	for _, p := range peers {
+		if err := s.unlink(node.Host.ID(), p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Simulation) start(ctx context.Context, node *Node) error {
+	if node.online {
+		return nil
+	}
+	node.online = true
+
+	if node.reachable {
+		if _, err := s.mn.LinkPeers(s.host.ID(), node.Host.ID()); err != nil {
+			return err
+		}
+	}
+	for _, other := range s.nodes {
+		if other == node || !other.online {
+			continue
+		}
+		if _, err := s.mn.LinkPeers(node.Host.ID(), other.Host.ID()); err != nil {
+			return err
+		}
+	}
+	return s.connect(ctx, node)
+}
+
+func (s *Simulation) unlink(p1, p2 peer.ID) error {
+	if err := s.mn.DisconnectPeers(p1, p2); err != nil {
+		return err
+	}
+	return s.mn.UnlinkPeers(p1, p2)
+}
+
+// Nodes that are online
+func (s *Simulation) OnlineIDs() []peer.ID {
+	return s.ids(func(n *Node) bool { return n.online })
+}
+
+// Nodes that are online and reachable from the simulation host, a complete crawl finds exactly these peers
+func (s *Simulation) ReachableIDs() []peer.ID {
+	return s.ids(func(n *Node) bool { return n.online && n.reachable })
+}
+
+// Reachable nodes running a telemetry service
+func (s *Simulation) TelemetryIDs() []peer.ID {
+	return s.ids(func(n *Node) bool { return n.online && n.reachable && n.Service != nil })
+}
+
+func (s *Simulation) ids(filter func(*Node) bool) []peer.ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := []peer.ID{}
+	for _, node := range s.nodes {
+		if filter(node) {
+			ids = append(ids, node.Host.ID())
+		}
+	}
+	return ids
+}
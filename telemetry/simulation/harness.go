@@ -0,0 +1,237 @@
+package simulation
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diogo464/telemetry"
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/monitor"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+var _ (crawler.Observer) = (*CrawlResult)(nil)
+var _ (monitor.Exporter) = (*Collector)(nil)
+
+// Peers and errors observed during a single crawl
+type CrawlResult struct {
+	mu     sync.Mutex
+	done   chan struct{}
+	Peers  map[peer.ID]*walker.Peer
+	Errors map[peer.ID]*walker.Error
+}
+
+func newCrawlResult() *CrawlResult {
+	return &CrawlResult{
+		done:   make(chan struct{}),
+		Peers:  make(map[peer.ID]*walker.Peer),
+		Errors: make(map[peer.ID]*walker.Error),
+	}
+}
+
+// ObservePeer implements crawler.Observer
+func (r *CrawlResult) ObservePeer(p *walker.Peer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Peers[p.ID] = p
+}
+
+// ObserveError implements crawler.Observer
+func (r *CrawlResult) ObserveError(e *walker.Error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Errors[e.ID] = e
+}
+
+// CrawlBegin implements crawler.Observer
+func (r *CrawlResult) CrawlBegin() {}
+
+// CrawlEnd implements crawler.Observer
+func (r *CrawlResult) CrawlEnd() {
+	close(r.done)
+}
+
+// ObserveEvent implements crawler.Observer
+func (r *CrawlResult) ObserveEvent(*crawler.Event) {}
+
+// IDs of the peers that were walked successfully
+func (r *CrawlResult) PeerIDs() []peer.ID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]peer.ID, 0, len(r.Peers))
+	for id := range r.Peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Run a single crawl of the simulated network
+func (s *Simulation) Crawl(ctx context.Context, o ...crawler.Option) (*CrawlResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := newCrawlResult()
+	opts := []crawler.Option{crawler.WithWalkerOption(s.WalkerOptions()...)}
+	opts = append(opts, o...)
+	opts = append(opts, crawler.WithObserver(result))
+	c, err := crawler.NewCrawler(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cerr := make(chan error, 1)
+	go func() { cerr <- c.Run(ctx) }()
+	select {
+	case <-result.done:
+		cancel()
+		<-cerr
+		return result, nil
+	case err := <-cerr:
+		return nil, err
+	}
+}
+
+// Data collected by a monitor, per peer
+type Collector struct {
+	mu             sync.Mutex
+	PeerSessions   map[peer.ID]telemetry.Session
+	PeerProperties map[peer.ID][]telemetry.Property
+	PeerFailures   map[peer.ID]int
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		PeerSessions:   make(map[peer.ID]telemetry.Session),
+		PeerProperties: make(map[peer.ID][]telemetry.Property),
+		PeerFailures:   make(map[peer.ID]int),
+	}
+}
+
+// PeerBegin implements monitor.Exporter
+func (*Collector) PeerBegin(peer.ID) {}
+
+// PeerSuccess implements monitor.Exporter
+func (*Collector) PeerSuccess(peer.ID) {}
+
+// PeerFailure implements monitor.Exporter
+func (c *Collector) PeerFailure(p peer.ID, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.PeerFailures[p] += 1
+}
+
+// Session implements monitor.Exporter
+func (c *Collector) Session(p peer.ID, sess telemetry.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.PeerSessions[p] = sess
+}
+
+// Properties implements monitor.Exporter
+func (c *Collector) Properties(p peer.ID, _ telemetry.Session, props []telemetry.Property) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.PeerProperties[p] = props
+}
+
+// Metrics implements monitor.Exporter
+func (*Collector) Metrics(peer.ID, telemetry.Session, telemetry.Metrics) {}
+
+// Events implements monitor.Exporter
+func (*Collector) Events(peer.ID, telemetry.Session, telemetry.EventDescriptor, []telemetry.Event) {}
+
+// Bandwidth implements monitor.Exporter
+func (*Collector) Bandwidth(peer.ID, telemetry.Bandwidth) {}
+
+// Probe implements monitor.Exporter
+func (*Collector) Probe(peer.ID, monitor.Probe) {}
+
+// Start a monitor on the simulation host that exports to the collector and discover every telemetry node
+func (s *Simulation) Monitor(ctx context.Context, collector *Collector, o ...monitor.Option) (*monitor.Monitor, error) {
+	opts := []monitor.Option{
+		monitor.WithHost(s.host),
+		monitor.WithExporter(collector),
+		monitor.WithBandwidthEnabled(false),
+		monitor.WithProbeEnabled(false),
+		monitor.WithCollectPeriod(time.Millisecond * 100),
+	}
+	opts = append(opts, o...)
+	m, err := monitor.Start(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range s.TelemetryIDs() {
+		m.Discover(ctx, id)
+	}
+	return m, nil
+}
+
+// Fail the test unless the crawl walked exactly the reachable online nodes
+func (s *Simulation) AssertDiscovered(t testing.TB, result *CrawlResult) {
+	t.Helper()
+	expected := s.ReachableIDs()
+	found := result.PeerIDs()
+	for _, id := range expected {
+		if !slices.Contains(found, id) {
+			t.Errorf("reachable peer %v was not walked", id)
+		}
+	}
+	for _, id := range found {
+		if !slices.Contains(expected, id) {
+			t.Errorf("walked peer %v is not reachable", id)
+		}
+	}
+
+	telemetryIDs := s.TelemetryIDs()
+	for id, p := range result.Peers {
+		hasTelemetry := p.ContainsProtocol(telemetry.ID_TELEMETRY)
+		if hasTelemetry != slices.Contains(telemetryIDs, id) {
+			t.Errorf("peer %v telemetry protocol support is %v", id, hasTelemetry)
+		}
+	}
+}
+
+// Wait until the collector has the session and properties of every telemetry node, fail the test on timeout
+func (s *Simulation) AssertCollected(t testing.TB, collector *Collector, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		missing := s.missingCollected(collector)
+		if len(missing) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("telemetry was not collected from %v", missing)
+			return
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+}
+
+func (s *Simulation) missingCollected(collector *Collector) []peer.ID {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	missing := []peer.ID{}
+	for _, node := range s.nodes {
+		id := node.Host.ID()
+		if node.Service == nil || !slices.Contains(s.TelemetryIDs(), id) {
+			continue
+		}
+		sess, ok := collector.PeerSessions[id]
+		if !ok || sess != node.Service.Session() || !hasNodeProperty(collector.PeerProperties[id], node.Index) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func hasNodeProperty(props []telemetry.Property, index int) bool {
+	return slices.ContainsFunc(props, func(p telemetry.Property) bool {
+		return p.Name == PropertyNode && p.Value.GetInteger() == int64(index)
+	})
+}
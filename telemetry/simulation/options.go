@@ -0,0 +1,108 @@
+package simulation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diogo464/telemetry"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+type Option func(*options) error
+
+type options struct {
+	peers          int
+	telemetryPeers int
+	seed           int64
+	protocolPrefix protocol.ID
+	link           mocknet.LinkOptions
+	serviceOpts    []telemetry.ServiceOption
+	setupTimeout   time.Duration
+}
+
+// Number of dht peers in the network
+func WithPeers(n int) Option {
+	return func(o *options) error {
+		if n < 2 {
+			return fmt.Errorf("a simulation needs at least 2 peers")
+		}
+		o.peers = n
+		return nil
+	}
+}
+
+// Number of dht peers that also run a telemetry service, the first n peers are used
+func WithTelemetryPeers(n int) Option {
+	return func(o *options) error {
+		if n < 0 {
+			return fmt.Errorf("invalid number of telemetry peers %v", n)
+		}
+		o.telemetryPeers = n
+		return nil
+	}
+}
+
+// Seed used to generate peer keys and churn decisions, the same seed always produces the same peer ids
+func WithSeed(seed int64) Option {
+	return func(o *options) error {
+		o.seed = seed
+		return nil
+	}
+}
+
+// Protocol prefix of the dht, ex: /ipfs or /ipfs/lan
+func WithProtocolPrefix(prefix protocol.ID) Option {
+	return func(o *options) error {
+		o.protocolPrefix = prefix
+		return nil
+	}
+}
+
+// Latency and bandwidth of every link in the network
+func WithLinkOptions(link mocknet.LinkOptions) Option {
+	return func(o *options) error {
+		o.link = link
+		return nil
+	}
+}
+
+// Options of every telemetry service
+func WithServiceOptions(opts ...telemetry.ServiceOption) Option {
+	return func(o *options) error {
+		o.serviceOpts = append(o.serviceOpts, opts...)
+		return nil
+	}
+}
+
+// How long to wait for the routing tables to fill up when the simulation is created
+func WithSetupTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.setupTimeout = timeout
+		return nil
+	}
+}
+
+func defaults() *options {
+	return &options{
+		peers:          10,
+		telemetryPeers: 0,
+		seed:           1,
+		protocolPrefix: "/ipfs",
+		link:           mocknet.LinkOptions{},
+		serviceOpts:    []telemetry.ServiceOption{},
+		setupTimeout:   time.Second * 10,
+	}
+}
+
+func apply(opts *options, o ...Option) error {
+	for _, opt := range o {
+		if err := opt(opts); err != nil {
+			return err
+		}
+	}
+	if opts.telemetryPeers > opts.peers {
+		return fmt.Errorf("more telemetry peers (%v) than peers (%v)", opts.telemetryPeers, opts.peers)
+	}
+	return nil
+}
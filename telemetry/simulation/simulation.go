@@ -0,0 +1,238 @@
+// Package simulation runs an in-process dht network on top of mocknet so that the walker,
+// crawler and monitor can be tested end to end without the live ipfs network.
+//
+// Peer keys and churn decisions are derived from a seed, the same options always produce
+// the same network.
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/diogo464/telemetry"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/diogo464/telemetry/walker/preimage"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p-kad-dht/amino"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// Name of the network used by the walker options of a simulation
+const NetworkName = "simulation"
+
+// Name of the property every telemetry service exposes, its value is the index of the node
+const PropertyNode = "simulation.node"
+
+// Number of bits of the preimage table used by the walker, small networks do not need more
+const preimageBits = 8
+
+var (
+	preimageOnce  sync.Once
+	preimageTable *preimage.Table
+)
+
+type Node struct {
+	Index   int
+	Host    host.Host
+	DHT     *dht.IpfsDHT
+	Service *telemetry.Service
+
+	online    bool
+	reachable bool
+}
+
+type Simulation struct {
+	opts *options
+	mn   mocknet.Mocknet
+	rng  *rand.Rand
+	host host.Host
+
+	mu    sync.Mutex
+	nodes []*Node
+}
+
+func New(ctx context.Context, o ...Option) (*Simulation, error) {
+	opts := defaults()
+	if err := apply(opts, o...); err != nil {
+		return nil, err
+	}
+
+	mn := mocknet.New()
+	mn.SetLinkDefaults(opts.link)
+	s := &Simulation{
+		opts:  opts,
+		mn:    mn,
+		rng:   rand.New(rand.NewSource(opts.seed)),
+		nodes: make([]*Node, 0, opts.peers),
+	}
+
+	var err error
+	if s.host, err = s.addHost(opts.peers); err != nil {
+		s.Close()
+		return nil, err
+	}
+	for i := 0; i < opts.peers; i++ {
+		node, err := s.newNode(ctx, i)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.nodes = append(s.nodes, node)
+	}
+
+	if err := mn.LinkAll(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	for _, node := range s.nodes {
+		if err := s.connect(ctx, node); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	if err := s.waitRoutingTables(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create a host with a key derived from the seed and a deterministic address
+func (s *Simulation) addHost(index int) (host.Host, error) {
+	sk, _, err := crypto.GenerateEd25519Key(s.rng)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip4/10.%d.%d.%d/tcp/4001", (index>>16)&0xff, (index>>8)&0xff, index&0xff))
+	if err != nil {
+		return nil, err
+	}
+	return s.mn.AddPeer(sk, addr)
+}
+
+func (s *Simulation) newNode(ctx context.Context, index int) (*Node, error) {
+	h, err := s.addHost(index)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := dht.New(ctx, h, dht.Mode(dht.ModeServer), dht.ProtocolPrefix(s.opts.protocolPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{Index: index, Host: h, DHT: d, online: true, reachable: true}
+	if index < s.opts.telemetryPeers {
+		service, mp, err := telemetry.NewService(h, s.opts.serviceOpts...)
+		if err != nil {
+			return nil, err
+		}
+		mp.TelemetryMeter("simulation").Property(PropertyNode, telemetry.NewPropertyValueInteger(int64(index)))
+		node.Service = service
+	}
+	return node, nil
+}
+
+// Connect a node to every other online node and let the simulation host know its addresses
+func (s *Simulation) connect(ctx context.Context, node *Node) error {
+	s.host.Peerstore().AddAddrs(node.Host.ID(), node.Host.Addrs(), peerstore.PermanentAddrTTL)
+	for _, other := range s.nodes {
+		if other == node || !other.online {
+			continue
+		}
+		if err := node.Host.Connect(ctx, peer.AddrInfo{ID: other.Host.ID(), Addrs: other.Host.Addrs()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait until every routing table is as full as the network allows
+func (s *Simulation) waitRoutingTables(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.setupTimeout)
+	defer cancel()
+
+	expected := min(len(s.nodes)-1, amino.DefaultBucketSize)
+	ticker := time.NewTicker(time.Millisecond * 20)
+	defer ticker.Stop()
+	for {
+		ready := true
+		for _, node := range s.nodes {
+			if node.DHT.RoutingTable().Size() < expected {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("routing tables did not fill up: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Simulation) Close() {
+	for _, node := range s.nodes {
+		if node.Service != nil {
+			node.Service.Close()
+		}
+		node.DHT.Close()
+	}
+	s.mn.Close()
+}
+
+// Host outside of the dht, used by the walker, crawler and monitor under test
+func (s *Simulation) Host() host.Host {
+	return s.host
+}
+
+func (s *Simulation) Nodes() []*Node {
+	return s.nodes
+}
+
+func (s *Simulation) Node(i int) *Node {
+	return s.nodes[i]
+}
+
+// Network to walk, seeded with the first online node
+func (s *Simulation) Network() walker.Network {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seeds := []peer.AddrInfo{}
+	for _, node := range s.nodes {
+		if node.online && node.reachable {
+			seeds = append(seeds, peer.AddrInfo{ID: node.Host.ID(), Addrs: node.Host.Addrs()})
+			break
+		}
+	}
+	return walker.NewNetwork(NetworkName, []protocol.ID{s.opts.protocolPrefix}, seeds)
+}
+
+// Walker options required to walk the simulated network from the simulation host
+func (s *Simulation) WalkerOptions() []walker.Option {
+	preimageOnce.Do(func() {
+		preimageTable = preimage.GenerateWithBits(preimageBits)
+	})
+	return []walker.Option{
+		walker.WithHost(s.host),
+		walker.WithNetwork(s.Network()),
+		walker.WithAddressFilter(walker.AddressFilterAllowAll),
+		walker.WithPreimageTable(preimageTable),
+		walker.WithConnectTimeout(time.Second),
+		walker.WithRequestTimeout(time.Second * 2),
+		walker.WithInterval(time.Millisecond),
+	}
+}
@@ -0,0 +1,114 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diogo464/telemetry/crawler"
+	"github.com/diogo464/telemetry/walker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministic(t *testing.T) {
+	ctx := context.Background()
+	s1, err := New(ctx, WithPeers(4), WithSeed(7))
+	require.NoError(t, err)
+	defer s1.Close()
+	s2, err := New(ctx, WithPeers(4), WithSeed(7))
+	require.NoError(t, err)
+	defer s2.Close()
+
+	assert.Equal(t, s1.OnlineIDs(), s2.OnlineIDs())
+	assert.Equal(t, s1.Host().ID(), s2.Host().ID())
+}
+
+func TestCrawl(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	s, err := New(ctx, WithPeers(12), WithTelemetryPeers(3))
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Unreachable(5))
+	require.NoError(t, s.Stop(8))
+
+	result, err := s.Crawl(ctx)
+	require.NoError(t, err)
+	s.AssertDiscovered(t, result)
+	assert.Len(t, result.Peers, 10)
+
+	require.Contains(t, result.Errors, s.Node(5).Host.ID())
+	assert.Equal(t, walker.StageConnect, result.Errors[s.Node(5).Host.ID()].Stage)
+}
+
+func TestCrawlChurn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	s, err := New(ctx, WithPeers(10))
+	require.NoError(t, err)
+	defer s.Close()
+
+	flipped, err := s.Churn(ctx, 0.3)
+	require.NoError(t, err)
+	assert.Len(t, flipped, 3)
+	assert.Len(t, s.OnlineIDs(), 7)
+
+	result, err := s.Crawl(ctx)
+	require.NoError(t, err)
+	s.AssertDiscovered(t, result)
+
+	for _, i := range flipped {
+		require.NoError(t, s.Start(ctx, i))
+	}
+	result, err = s.Crawl(ctx)
+	require.NoError(t, err)
+	s.AssertDiscovered(t, result)
+	assert.Len(t, result.Peers, 10)
+}
+
+func TestMonitor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	s, err := New(ctx, WithPeers(6), WithTelemetryPeers(2))
+	require.NoError(t, err)
+	defer s.Close()
+
+	collector := NewCollector()
+	_, err = s.Monitor(ctx, collector)
+	require.NoError(t, err)
+	s.AssertCollected(t, collector, time.Second*20)
+}
+
+func TestCrawlPoliteness(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	s, err := New(ctx, WithPeers(8))
+	require.NoError(t, err)
+	defer s.Close()
+	require.NoError(t, s.Unreachable(3))
+
+	// every simulated peer is in the same /24
+	cache := walker.NewDialCache(time.Hour, 100)
+	opts := crawler.WithWalkerOption(
+		walker.WithGroupLimit(walker.GroupByPrefix(24, 48), 1),
+		walker.WithConnectionBudget(walker.NewConnectionBudget(2)),
+		walker.WithDialCache(cache),
+	)
+	result, err := s.Crawl(ctx, opts)
+	require.NoError(t, err)
+	s.AssertDiscovered(t, result)
+	assert.Equal(t, 1, cache.Len())
+
+	// the unreachable peer is not dialed again
+	require.NoError(t, s.Reachable(3))
+	result, err = s.Crawl(ctx, opts)
+	require.NoError(t, err)
+	require.Contains(t, result.Errors, s.Node(3).Host.ID())
+	assert.Len(t, result.Peers, 7)
+}
@@ -11,11 +11,28 @@ var log = logging.Logger("telemetry")
 
 const (
 	ID_TELEMETRY protocol.ID = "/telemetry/telemetry/0.6.0"
-	ID_UPLOAD    protocol.ID = "/telemetry/upload/0.6.0"
-	ID_DOWNLOAD  protocol.ID = "/telemetry/download/0.6.0"
+	ID_BANDWIDTH protocol.ID = "/telemetry/bandwidth/0.7.0"
+	ID_UPLOAD    protocol.ID = "/telemetry/upload/0.7.0"
+	ID_DOWNLOAD  protocol.ID = "/telemetry/download/0.7.0"
+	// Single stream bandwidth protocols of peers that predate ID_BANDWIDTH
+	ID_UPLOAD_LEGACY   protocol.ID = "/telemetry/upload/0.6.0"
+	ID_DOWNLOAD_LEGACY protocol.ID = "/telemetry/download/0.6.0"
 
-	DEFAULT_BANDWIDTH_PAYLOAD_SIZE     = 32 * 1024 * 1024
-	DEFAULT_MAX_BANDWIDTH_PAYLOAD_SIZE = 128 * 1024 * 1024
+	DEFAULT_BANDWIDTH_STREAMS         = 4
+	DEFAULT_BANDWIDTH_DURATION        = time.Second * 10
+	DEFAULT_BANDWIDTH_SAMPLE_INTERVAL = time.Millisecond * 500
+	DEFAULT_BANDWIDTH_RAMP_UP         = time.Second * 2
+	DEFAULT_BANDWIDTH_PINGS           = 10
+
+	MAX_BANDWIDTH_STREAMS         = 16
+	MAX_BANDWIDTH_DURATION        = time.Second * 30
+	MAX_BANDWIDTH_PINGS           = 64
+	MIN_BANDWIDTH_SAMPLE_INTERVAL = time.Millisecond * 100
+	// Extra time given to streams after a test ends before they are reset
+	BANDWIDTH_GRACE_PERIOD = time.Second * 5
+
+	DEFAULT_LEGACY_BANDWIDTH_PAYLOAD_SIZE = 32 * 1024 * 1024
+	MAX_LEGACY_BANDWIDTH_PAYLOAD_SIZE     = 128 * 1024 * 1024
 
 	BLOCK_DURATION_BANDWIDTH = time.Minute * 5
 	BLOCK_DURATION_STREAM    = time.Minute * 5
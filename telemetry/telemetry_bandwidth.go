@@ -1,6 +1,252 @@
 package telemetry
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+var ErrBandwidthBlocked = fmt.Errorf("bandwidth test blocked, try again later")
+var ErrBandwidthInvalidRequest = fmt.Errorf("invalid bandwidth test request")
+
+const (
+	bandwidthDirectionDownload uint32 = 0
+	bandwidthDirectionUpload   uint32 = 1
+
+	bandwidthStatusOk      uint32 = 0
+	bandwidthStatusBlocked uint32 = 1
+	bandwidthStatusInvalid uint32 = 2
+)
+
 type Bandwidth struct {
-	UploadRate   uint32 `json:"upload_rate"`
-	DownloadRate uint32 `json:"download_rate"`
+	// Average rates excluding the ramp-up period, in bytes per second
+	UploadRate   uint64 `json:"upload_rate"`
+	DownloadRate uint64 `json:"download_rate"`
+	// Round trip time and jitter measured before the transfers
+	Rtt    time.Duration `json:"rtt"`
+	Jitter time.Duration `json:"jitter"`
+
+	Upload   BandwidthMeasurement `json:"upload"`
+	Download BandwidthMeasurement `json:"download"`
+}
+
+type BandwidthMeasurement struct {
+	Streams  uint32        `json:"streams"`
+	Duration time.Duration `json:"duration"`
+	// Total number of bytes transferred across all streams
+	Bytes uint64 `json:"bytes"`
+	// Average rate excluding the ramp-up period, in bytes per second
+	Rate uint64 `json:"rate"`
+	// Highest rate of any sample, in bytes per second
+	PeakRate uint64            `json:"peak_rate"`
+	Samples  []BandwidthSample `json:"samples"`
+	Rtt      time.Duration     `json:"rtt"`
+	Jitter   time.Duration     `json:"jitter"`
+}
+
+type BandwidthSample struct {
+	// Time since the start of the test at which the sample was taken
+	Elapsed time.Duration `json:"elapsed"`
+	// Bytes transferred since the previous sample
+	Bytes uint64 `json:"bytes"`
+	// Rate since the previous sample, in bytes per second
+	Rate uint64 `json:"rate"`
+}
+
+type BandwidthOption = func(*bandwidthOptions)
+
+type bandwidthOptions struct {
+	streams        uint32
+	duration       time.Duration
+	sampleInterval time.Duration
+	rampUp         time.Duration
+	pings          uint32
+}
+
+func bandwidthDefaults() *bandwidthOptions {
+	return &bandwidthOptions{
+		streams:        DEFAULT_BANDWIDTH_STREAMS,
+		duration:       DEFAULT_BANDWIDTH_DURATION,
+		sampleInterval: DEFAULT_BANDWIDTH_SAMPLE_INTERVAL,
+		rampUp:         DEFAULT_BANDWIDTH_RAMP_UP,
+		pings:          DEFAULT_BANDWIDTH_PINGS,
+	}
+}
+
+// Number of parallel streams used to transfer data, between 1 and MAX_BANDWIDTH_STREAMS.
+// Tests with any other number of streams fail with ErrBandwidthInvalidRequest before contacting the peer.
+func WithBandwidthStreams(streams uint32) BandwidthOption {
+	return func(o *bandwidthOptions) {
+		o.streams = streams
+	}
+}
+
+// How long data is transferred for, in each direction, at most MAX_BANDWIDTH_DURATION and at least the sample interval.
+// Tests with any other duration fail with ErrBandwidthInvalidRequest before contacting the peer.
+func WithBandwidthDuration(duration time.Duration) BandwidthOption {
+	return func(o *bandwidthOptions) {
+		o.duration = duration
+	}
+}
+
+// How often throughput samples are taken
+func WithBandwidthSampleInterval(interval time.Duration) BandwidthOption {
+	return func(o *bandwidthOptions) {
+		o.sampleInterval = interval
+	}
+}
+
+// Samples taken during the ramp-up period are not used to compute the average rate
+func WithBandwidthRampUp(rampUp time.Duration) BandwidthOption {
+	return func(o *bandwidthOptions) {
+		o.rampUp = rampUp
+	}
+}
+
+// Number of round trips used to measure rtt and jitter
+func WithBandwidthPings(pings uint32) BandwidthOption {
+	return func(o *bandwidthOptions) {
+		o.pings = pings
+	}
+}
+
+func validBandwidthRequest(direction, streams uint32, duration, interval time.Duration) bool {
+	return (direction == bandwidthDirectionDownload || direction == bandwidthDirectionUpload) &&
+		streams > 0 && streams <= MAX_BANDWIDTH_STREAMS &&
+		duration > 0 && duration <= MAX_BANDWIDTH_DURATION &&
+		interval >= MIN_BANDWIDTH_SAMPLE_INTERVAL && interval <= duration
+}
+
+// Upper bound on the number of samples of a test, used to validate responses
+func maxBandwidthSamples(duration, interval time.Duration) int {
+	return int(duration/interval) + 2
+}
+
+// Counts bytes written to it, safe for concurrent use
+type bandwidthCounter struct {
+	bytes atomic.Uint64
+}
+
+func (c *bandwidthCounter) Write(p []byte) (int, error) {
+	c.bytes.Add(uint64(len(p)))
+	return len(p), nil
+}
+
+// Copy from src to dst until src is exhausted, an error occurs or the deadline is reached.
+// The deadline is checked between reads so it also applies to transports that do not support stream deadlines.
+func copyUntil(dst io.Writer, src io.Reader, deadline time.Time) {
+	buf := make([]byte, 32*1024)
+	for time.Now().Before(deadline) {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Sample the counter every interval until start+duration or the context is cancelled
+func sampleBandwidth(ctx context.Context, counter *bandwidthCounter, start time.Time, interval, duration time.Duration) []BandwidthSample {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(time.Until(start.Add(duration)))
+	defer deadline.Stop()
+
+	samples := make([]BandwidthSample, 0, maxBandwidthSamples(duration, interval))
+	var prevBytes uint64
+	var prevElapsed time.Duration
+	take := func(now time.Time) {
+		bytes := counter.bytes.Load()
+		elapsed := now.Sub(start)
+		if elapsed <= prevElapsed {
+			return
+		}
+		samples = append(samples, newBandwidthSample(elapsed, elapsed-prevElapsed, bytes-prevBytes))
+		prevBytes = bytes
+		prevElapsed = elapsed
+	}
+
+	for {
+		select {
+		case now := <-ticker.C:
+			take(now)
+		case now := <-deadline.C:
+			take(now)
+			return samples
+		case <-ctx.Done():
+			return samples
+		}
+	}
+}
+
+func newBandwidthSample(elapsed, span time.Duration, bytes uint64) BandwidthSample {
+	return BandwidthSample{
+		Elapsed: elapsed,
+		Bytes:   bytes,
+		Rate:    uint64(float64(bytes) / span.Seconds()),
+	}
+}
+
+func newBandwidthMeasurement(opts *bandwidthOptions, samples []BandwidthSample, rtts []time.Duration) BandwidthMeasurement {
+	m := BandwidthMeasurement{
+		Streams:  opts.streams,
+		Duration: opts.duration,
+		Samples:  samples,
+	}
+
+	var steadyBytes uint64
+	var steadySpan time.Duration
+	var prevElapsed time.Duration
+	for _, sample := range samples {
+		m.Bytes += sample.Bytes
+		m.PeakRate = max(m.PeakRate, sample.Rate)
+		if prevElapsed >= opts.rampUp {
+			steadyBytes += sample.Bytes
+			steadySpan += sample.Elapsed - prevElapsed
+		}
+		prevElapsed = sample.Elapsed
+	}
+	if steadySpan == 0 && prevElapsed > 0 {
+		// the test was shorter than the ramp-up period, use every sample
+		steadyBytes = m.Bytes
+		steadySpan = prevElapsed
+	}
+	if steadySpan > 0 {
+		m.Rate = uint64(float64(steadyBytes) / steadySpan.Seconds())
+	}
+
+	m.Rtt, m.Jitter = rttAndJitter(rtts)
+	return m
+}
+
+// Mean round trip time and mean difference between consecutive round trip times
+func rttAndJitter(rtts []time.Duration) (time.Duration, time.Duration) {
+	if len(rtts) == 0 {
+		return 0, 0
+	}
+
+	var total time.Duration
+	var variation time.Duration
+	for i, rtt := range rtts {
+		total += rtt
+		if i > 0 {
+			diff := rtt - rtts[i-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			variation += diff
+		}
+	}
+
+	rtt := total / time.Duration(len(rtts))
+	if len(rtts) == 1 {
+		return rtt, 0
+	}
+	return rtt, variation / time.Duration(len(rtts)-1)
 }
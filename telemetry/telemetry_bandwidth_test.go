@@ -0,0 +1,95 @@
+package telemetry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diogo464/telemetry"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+func TestBandwidth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	service, _, err := telemetry.NewService(hosts[0], telemetry.WithServiceBandwidth(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer service.Close()
+
+	client, err := telemetry.NewClient(ctx, telemetry.WithClientLibp2pDial(hosts[1], hosts[0].ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	result, err := client.Bandwidth(ctx,
+		telemetry.WithBandwidthStreams(2),
+		telemetry.WithBandwidthDuration(time.Second),
+		telemetry.WithBandwidthSampleInterval(time.Millisecond*200),
+		telemetry.WithBandwidthRampUp(time.Millisecond*200))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, m := range map[string]telemetry.BandwidthMeasurement{"upload": result.Upload, "download": result.Download} {
+		if m.Streams != 2 || m.Bytes == 0 || m.Rate == 0 || m.PeakRate < m.Rate {
+			t.Fatalf("unexpected %v measurement: %+v", name, m)
+		}
+		if len(m.Samples) < 3 {
+			t.Fatalf("expected at least 3 %v samples, got %v", name, len(m.Samples))
+		}
+	}
+	if result.UploadRate != result.Upload.Rate || result.DownloadRate != result.Download.Rate {
+		t.Fatalf("rates do not match measurements: %+v", result)
+	}
+
+	if _, err := client.Download(ctx); err != telemetry.ErrBandwidthBlocked && err != nil {
+		t.Fatalf("unexpected error on repeated test: %v", err)
+	}
+}
+
+func TestBandwidthLegacyFallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	service, _, err := telemetry.NewService(hosts[0], telemetry.WithServiceBandwidth(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer service.Close()
+	// a peer that only speaks the 0.6.0 protocols
+	hosts[0].RemoveStreamHandler(telemetry.ID_BANDWIDTH)
+
+	client, err := telemetry.NewClient(ctx, telemetry.WithClientLibp2pDial(hosts[1], hosts[0].ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	result, err := client.Bandwidth(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, m := range map[string]telemetry.BandwidthMeasurement{"upload": result.Upload, "download": result.Download} {
+		if m.Streams != 1 || m.Bytes != telemetry.DEFAULT_LEGACY_BANDWIDTH_PAYLOAD_SIZE || m.Rate == 0 {
+			t.Fatalf("unexpected %v measurement: %+v", name, m)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package telemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/diogo464/telemetry/internal/pb"
 	"github.com/diogo464/telemetry/internal/stream"
@@ -14,6 +18,8 @@ import (
 	gostream "github.com/libp2p/go-libp2p-gostream"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	msmux "github.com/multiformats/go-multistream"
 	"go.opentelemetry.io/otel/sdk/instrumentation"
 	v1 "go.opentelemetry.io/proto/otlp/metrics/v1"
 	"google.golang.org/grpc"
@@ -358,71 +364,258 @@ func (c *Client) GetEvents(ctx context.Context, eventId uint32) ([]Event, error)
 	return events, nil
 }
 
-func (c *Client) Download(ctx context.Context, payload uint32) (uint32, error) {
+// Measure how fast the peer can download, that is, how fast we can send data to it
+func (c *Client) Download(ctx context.Context, opts ...BandwidthOption) (BandwidthMeasurement, error) {
+	return c.bandwidthTest(ctx, bandwidthDirectionDownload, opts...)
+}
+
+// Measure how fast the peer can upload, that is, how fast we can receive data from it
+func (c *Client) Upload(ctx context.Context, opts ...BandwidthOption) (BandwidthMeasurement, error) {
+	return c.bandwidthTest(ctx, bandwidthDirectionUpload, opts...)
+}
+
+func (c *Client) Bandwidth(ctx context.Context, opts ...BandwidthOption) (Bandwidth, error) {
+	download, err := c.Download(ctx, opts...)
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	upload, err := c.Upload(ctx, opts...)
+	if err != nil {
+		return Bandwidth{}, err
+	}
+	return Bandwidth{
+		UploadRate:   upload.Rate,
+		DownloadRate: download.Rate,
+		Rtt:          (upload.Rtt + download.Rtt) / 2,
+		Jitter:       (upload.Jitter + download.Jitter) / 2,
+		Upload:       upload,
+		Download:     download,
+	}, nil
+}
+
+func (c *Client) bandwidthTest(ctx context.Context, direction uint32, opts ...BandwidthOption) (BandwidthMeasurement, error) {
 	if c.h == nil {
-		return 0, ErrNotUsingLibp2p
+		return BandwidthMeasurement{}, ErrNotUsingLibp2p
 	}
 
-	stream, err := c.h.NewStream(ctx, c.p, ID_DOWNLOAD)
+	options := bandwidthDefaults()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if !validBandwidthRequest(direction, options.streams, options.duration, options.sampleInterval) || options.pings > MAX_BANDWIDTH_PINGS {
+		return BandwidthMeasurement{}, ErrBandwidthInvalidRequest
+	}
+
+	control, err := c.h.NewStream(ctx, c.p, ID_BANDWIDTH)
+	if errors.Is(err, msmux.ErrNotSupported[protocol.ID]{}) {
+		return c.legacyBandwidthTest(ctx, direction)
+	}
 	if err != nil {
-		return 0, err
+		return BandwidthMeasurement{}, err
+	}
+	defer control.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = control.SetDeadline(deadline)
 	}
 
-	if err := utils.WriteU32(stream, payload); err != nil {
-		return 0, err
+	if err := utils.WriteU32(control, direction); err != nil {
+		return BandwidthMeasurement{}, err
+	}
+	if err := utils.WriteU32(control, options.streams); err != nil {
+		return BandwidthMeasurement{}, err
+	}
+	if err := utils.WriteU64(control, uint64(options.duration)); err != nil {
+		return BandwidthMeasurement{}, err
+	}
+	if err := utils.WriteU64(control, uint64(options.sampleInterval)); err != nil {
+		return BandwidthMeasurement{}, err
 	}
 
-	if _, err := io.Copy(stream, io.LimitReader(utils.NullReader{}, int64(payload))); err != nil {
-		return 0, err
+	status, err := utils.ReadU32(control)
+	if err != nil {
+		return BandwidthMeasurement{}, err
+	}
+	switch status {
+	case bandwidthStatusOk:
+	case bandwidthStatusBlocked:
+		return BandwidthMeasurement{}, ErrBandwidthBlocked
+	case bandwidthStatusInvalid:
+		return BandwidthMeasurement{}, ErrBandwidthInvalidRequest
+	default:
+		return BandwidthMeasurement{}, ErrInvalidResponse
 	}
 
-	rate, err := utils.ReadU32(stream)
+	if err := utils.WriteU32(control, options.pings); err != nil {
+		return BandwidthMeasurement{}, err
+	}
+	rtts := make([]time.Duration, 0, options.pings)
+	for i := uint32(0); i < options.pings; i++ {
+		sent := time.Now()
+		if err := utils.WriteU64(control, uint64(i)); err != nil {
+			return BandwidthMeasurement{}, err
+		}
+		echo, err := utils.ReadU64(control)
+		if err != nil {
+			return BandwidthMeasurement{}, err
+		}
+		if echo != uint64(i) {
+			return BandwidthMeasurement{}, ErrInvalidResponse
+		}
+		rtts = append(rtts, time.Since(sent))
+	}
+
+	token, err := utils.ReadU64(control)
 	if err != nil {
-		return 0, err
+		return BandwidthMeasurement{}, err
+	}
+	start := time.Now()
+	deadline := start.Add(options.duration)
+
+	protocolId := ID_DOWNLOAD
+	if direction == bandwidthDirectionUpload {
+		protocolId = ID_UPLOAD
+	}
+
+	var counter bandwidthCounter
+	var opened atomic.Uint32
+	var openErr error
+	var openErrOnce sync.Once
+	wg := sync.WaitGroup{}
+	for i := uint32(0); i < options.streams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			stream, err := c.h.NewStream(ctx, c.p, protocolId)
+			if err == nil {
+				err = utils.WriteU64(stream, token)
+			}
+			if err != nil {
+				openErrOnce.Do(func() { openErr = err })
+				if stream != nil {
+					_ = stream.Reset()
+				}
+				return
+			}
+			defer stream.Close()
+			opened.Add(1)
+
+			// errors once the transfer started are expected, either side may reset the stream when the deadline is reached
+			if direction == bandwidthDirectionDownload {
+				_ = stream.SetWriteDeadline(deadline)
+				copyUntil(stream, utils.NullReader{}, deadline)
+			} else {
+				reset := time.AfterFunc(time.Until(deadline.Add(BANDWIDTH_GRACE_PERIOD)), func() { _ = stream.Reset() })
+				defer reset.Stop()
+
+				_ = stream.CloseWrite()
+				_ = stream.SetReadDeadline(deadline.Add(BANDWIDTH_GRACE_PERIOD))
+				copyUntil(&counter, stream, deadline.Add(BANDWIDTH_GRACE_PERIOD))
+			}
+		}()
+	}
+
+	var samples []BandwidthSample
+	if direction == bandwidthDirectionUpload {
+		samples = sampleBandwidth(ctx, &counter, start, options.sampleInterval, options.duration)
+	}
+	wg.Wait()
+
+	if opened.Load() == 0 {
+		return BandwidthMeasurement{}, openErr
+	}
+	if err := ctx.Err(); err != nil {
+		return BandwidthMeasurement{}, err
+	}
+
+	if direction == bandwidthDirectionDownload {
+		samples, err = readBandwidthSamples(control, options)
+		if err != nil {
+			return BandwidthMeasurement{}, err
+		}
 	}
 
-	return rate, nil
+	return newBandwidthMeasurement(options, samples, rtts), nil
 }
 
-func (c *Client) Upload(ctx context.Context, payload uint32) (uint32, error) {
-	if c.h == nil {
-		return 0, ErrNotUsingLibp2p
+// Single stream test with a fixed payload against peers that only support the 0.6.0 protocols.
+// The peer only reports the average rate so the measurement has no samples, rtt or jitter.
+func (c *Client) legacyBandwidthTest(ctx context.Context, direction uint32) (BandwidthMeasurement, error) {
+	protocolId := ID_DOWNLOAD_LEGACY
+	if direction == bandwidthDirectionUpload {
+		protocolId = ID_UPLOAD_LEGACY
 	}
 
-	stream, err := c.h.NewStream(ctx, c.p, ID_UPLOAD)
+	stream, err := c.h.NewStream(ctx, c.p, protocolId)
 	if err != nil {
-		return 0, err
+		return BandwidthMeasurement{}, err
+	}
+	defer stream.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = stream.SetDeadline(deadline)
 	}
 
+	payload := uint32(DEFAULT_LEGACY_BANDWIDTH_PAYLOAD_SIZE)
 	if err := utils.WriteU32(stream, payload); err != nil {
-		return 0, err
+		return BandwidthMeasurement{}, err
 	}
 
-	if _, err := io.Copy(io.Discard, io.LimitReader(stream, int64(payload))); err != nil {
-		return 0, err
+	start := time.Now()
+	var n int64
+	if direction == bandwidthDirectionDownload {
+		n, err = io.Copy(stream, io.LimitReader(utils.NullReader{}, int64(payload)))
+	} else {
+		n, err = io.Copy(io.Discard, io.LimitReader(stream, int64(payload)))
 	}
-
-	rate, err := utils.ReadU32(stream)
 	if err != nil {
-		return 0, err
+		return BandwidthMeasurement{}, err
+	}
+	elapsed := time.Since(start)
+	if n != int64(payload) {
+		// the peer replies with a zero rate instead of the payload when the test is blocked
+		return BandwidthMeasurement{}, ErrBandwidthBlocked
 	}
 
-	return rate, nil
+	rate, err := utils.ReadU32(stream)
+	if err != nil {
+		return BandwidthMeasurement{}, err
+	}
+	return BandwidthMeasurement{
+		Streams:  1,
+		Duration: elapsed,
+		Bytes:    uint64(n),
+		Rate:     uint64(rate),
+		PeakRate: uint64(rate),
+	}, nil
 }
 
-func (c *Client) Bandwidth(ctx context.Context, payload uint32) (Bandwidth, error) {
-	download, err := c.Download(ctx, payload)
+func readBandwidthSamples(r io.Reader, opts *bandwidthOptions) ([]BandwidthSample, error) {
+	n, err := utils.ReadU32(r)
 	if err != nil {
-		return Bandwidth{}, err
+		return nil, err
 	}
-	upload, err := c.Upload(ctx, payload)
-	if err != nil {
-		return Bandwidth{}, err
+	if int(n) > maxBandwidthSamples(opts.duration, opts.sampleInterval) {
+		return nil, ErrInvalidResponse
 	}
-	return Bandwidth{
-		UploadRate:   upload,
-		DownloadRate: download,
-	}, nil
+
+	samples := make([]BandwidthSample, 0, n)
+	var prevElapsed time.Duration
+	for i := uint32(0); i < n; i++ {
+		elapsed, err := utils.ReadU64(r)
+		if err != nil {
+			return nil, err
+		}
+		bytes, err := utils.ReadU64(r)
+		if err != nil {
+			return nil, err
+		}
+		if time.Duration(elapsed) <= prevElapsed {
+			return nil, ErrInvalidResponse
+		}
+		samples = append(samples, newBandwidthSample(time.Duration(elapsed), time.Duration(elapsed)-prevElapsed, bytes))
+		prevElapsed = time.Duration(elapsed)
+	}
+	return samples, nil
 }
 
 func (c *Client) newGrpcClient() (pb.TelemetryClient, error) {
@@ -0,0 +1,170 @@
+package walker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Step of a peer walk that failed
+type Stage string
+
+const (
+	StageConnect Stage = "connect"
+	StageRequest Stage = "request"
+)
+
+type FailureReason string
+
+const (
+	FailureTimeout              FailureReason = "timeout"
+	FailureConnectionRefused    FailureReason = "connection_refused"
+	FailureNoAddresses          FailureReason = "no_addresses"
+	FailureHandshake            FailureReason = "handshake"
+	FailureResourceLimit        FailureReason = "resource_limit"
+	FailureProtocolNotSupported FailureReason = "protocol_not_supported"
+	// The peer is only reachable through relays or only has private addresses
+	FailureRelayOnly   FailureReason = "relay_only"
+	FailureUnreachable FailureReason = "unreachable"
+	FailureCanceled    FailureReason = "canceled"
+	FailureOther       FailureReason = "other"
+)
+
+// Failure to dial a single address
+type DialFailure struct {
+	Address   multiaddr.Multiaddr `json:"address"`
+	Transport string              `json:"transport"`
+	Reason    FailureReason       `json:"reason"`
+	Error     string              `json:"error"`
+}
+
+// Classify the cause of a failed walk and the failure of each dialed address
+func classifyFailure(stage Stage, err error, addrs []multiaddr.Multiaddr) (FailureReason, []DialFailure) {
+	var dials []DialFailure
+	var dialErr *swarm.DialError
+	if errors.As(err, &dialErr) {
+		dials = make([]DialFailure, 0, len(dialErr.DialErrors))
+		for _, terr := range dialErr.DialErrors {
+			dials = append(dials, DialFailure{
+				Address:   terr.Address,
+				Transport: transportOf(terr.Address),
+				Reason:    ClassifyError(terr.Cause),
+				Error:     terr.Cause.Error(),
+			})
+		}
+	}
+
+	if stage == StageConnect {
+		if errors.Is(err, swarm.ErrNoAddresses) || errors.Is(err, swarm.ErrNoGoodAddresses) {
+			if relayOnly(addrs) {
+				return FailureRelayOnly, dials
+			}
+			return FailureNoAddresses, dials
+		}
+		if len(dials) > 0 {
+			return dominantReason(dials), dials
+		}
+	}
+	return ClassifyError(err), dials
+}
+
+// Classify a single error
+func ClassifyError(err error) FailureReason {
+	if err == nil {
+		return FailureOther
+	}
+
+	msg := strings.ToLower(err.Error())
+	var timeout interface{ Timeout() bool }
+	var mismatch sec.ErrPeerIDMismatch
+	switch {
+	case errors.Is(err, context.Canceled):
+		return FailureCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, swarm.ErrDialTimeout), os.IsTimeout(err),
+		errors.As(err, &timeout) && timeout.Timeout(),
+		strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return FailureTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), strings.Contains(msg, "connection refused"):
+		return FailureConnectionRefused
+	case errors.Is(err, swarm.ErrNoAddresses), errors.Is(err, swarm.ErrNoGoodAddresses):
+		return FailureNoAddresses
+	case errors.Is(err, network.ErrResourceLimitExceeded), errors.Is(err, network.ErrResourceScopeClosed),
+		strings.Contains(msg, "resource limit exceeded"):
+		return FailureResourceLimit
+	case strings.Contains(msg, "protocols not supported"), strings.Contains(msg, "protocol not supported"):
+		return FailureProtocolNotSupported
+	case errors.As(err, &mismatch), strings.Contains(msg, "handshake"),
+		strings.Contains(msg, "security protocol"), strings.Contains(msg, "peer id mismatch"):
+		return FailureHandshake
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH),
+		strings.Contains(msg, "no route to host"), strings.Contains(msg, "network is unreachable"):
+		return FailureUnreachable
+	default:
+		return FailureOther
+	}
+}
+
+// Most common reason among the dials, ties are broken by the first dial
+func dominantReason(dials []DialFailure) FailureReason {
+	counts := make(map[FailureReason]int)
+	best := dials[0].Reason
+	for _, dial := range dials {
+		counts[dial.Reason] += 1
+		if counts[dial.Reason] > counts[best] {
+			best = dial.Reason
+		}
+	}
+	return best
+}
+
+// True if the peer has addresses but none of them is a public direct address
+func relayOnly(addrs []multiaddr.Multiaddr) bool {
+	if len(addrs) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if transportOf(addr) != "p2p-circuit" && manet.IsPublicAddr(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON implements json.Marshaler, the error is serialized as its message
+func (e Error) MarshalJSON() ([]byte, error) {
+	type alias Error
+	msg := ""
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return json.Marshal(&struct {
+		alias
+		Err string `json:"error"`
+	}{alias(e), msg})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (e *Error) UnmarshalJSON(data []byte) error {
+	type alias Error
+	v := struct {
+		*alias
+		Err string `json:"error"`
+	}{alias: (*alias)(e)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	e.Err = nil
+	if v.Err != "" {
+		e.Err = errors.New(v.Err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package walker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/test"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := map[FailureReason]error{
+		FailureTimeout:              fmt.Errorf("dial: %w", context.DeadlineExceeded),
+		FailureCanceled:             context.Canceled,
+		FailureConnectionRefused:    fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED),
+		FailureNoAddresses:          swarm.ErrNoAddresses,
+		FailureResourceLimit:        network.ErrResourceLimitExceeded,
+		FailureProtocolNotSupported: fmt.Errorf("failed to negotiate protocol: protocols not supported: [/ipfs/kad/1.0.0]"),
+		FailureHandshake:            fmt.Errorf("failed to negotiate security protocol: EOF"),
+		FailureUnreachable:          fmt.Errorf("dial udp: %w", syscall.ENETUNREACH),
+		FailureOther:                fmt.Errorf("something else"),
+	}
+	for reason, err := range cases {
+		assert.Equal(t, reason, ClassifyError(err), err.Error())
+	}
+}
+
+func TestClassifyConnectFailure(t *testing.T) {
+	tcp := multiaddr.StringCast("/ip4/1.1.1.1/tcp/4001")
+	quic := multiaddr.StringCast("/ip4/1.1.1.1/udp/4001/quic-v1")
+	dialErr := &swarm.DialError{
+		DialErrors: []swarm.TransportError{
+			{Address: tcp, Cause: syscall.ECONNREFUSED},
+			{Address: quic, Cause: context.DeadlineExceeded},
+			{Address: quic, Cause: context.DeadlineExceeded},
+		},
+	}
+	reason, dials := classifyFailure(StageConnect, fmt.Errorf("failed to dial: %w", dialErr), nil)
+	assert.Equal(t, FailureTimeout, reason)
+	require.Len(t, dials, 3)
+	assert.Equal(t, "tcp", dials[0].Transport)
+	assert.Equal(t, FailureConnectionRefused, dials[0].Reason)
+
+	relay := multiaddr.StringCast("/ip4/1.1.1.1/tcp/4001/p2p/12D3KooWMeRfth7YpbCmy89YyMRmgjXRuCW9QzjvjuG6JSfoaqHC/p2p-circuit")
+	private := multiaddr.StringCast("/ip4/192.168.1.1/tcp/4001")
+	reason, _ = classifyFailure(StageConnect, swarm.ErrNoGoodAddresses, []multiaddr.Multiaddr{relay, private})
+	assert.Equal(t, FailureRelayOnly, reason)
+}
+
+func TestErrorJSON(t *testing.T) {
+	pid, err := test.RandPeerID()
+	require.NoError(t, err)
+	e := &Error{
+		ID:     pid,
+		Err:    fmt.Errorf("Connect: %w", context.DeadlineExceeded),
+		Stage:  StageConnect,
+		Reason: FailureTimeout,
+	}
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error":"Connect: context deadline exceeded"`)
+
+	decoded := new(Error)
+	require.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, e.Err.Error(), decoded.Err.Error())
+	assert.Equal(t, e.Reason, decoded.Reason)
+	assert.Equal(t, e.ID, decoded.ID)
+}
@@ -0,0 +1,87 @@
+package walker
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+type Implementation string
+
+const (
+	ImplementationUnknown    Implementation = "unknown"
+	ImplementationKubo       Implementation = "kubo"
+	ImplementationBoxo       Implementation = "boxo"
+	ImplementationGoLibp2p   Implementation = "go-libp2p"
+	ImplementationRustLibp2p Implementation = "rust-libp2p"
+	ImplementationJsLibp2p   Implementation = "js-libp2p"
+	ImplementationNimLibp2p  Implementation = "nim-libp2p"
+	ImplementationHydra      Implementation = "hydra-booster"
+)
+
+// Applications built on top of boxo that do not identify as kubo
+var boxoAgents = []string{"boxo", "rainbow", "someguy"}
+
+// Classification of the software a peer runs
+type Fingerprint struct {
+	Implementation Implementation `json:"implementation"`
+	// Name and version of the agent, ex: kubo and 0.25.0
+	Agent   string `json:"agent"`
+	Version string `json:"version"`
+}
+
+// Classify a peer using its agent version and, when that is not enough, its supported protocols
+func Classify(agent string, protocols []protocol.ID) Fingerprint {
+	name, version := splitAgent(agent)
+	fp := Fingerprint{
+		Implementation: ImplementationUnknown,
+		Agent:          name,
+		Version:        version,
+	}
+
+	lower := strings.ToLower(agent)
+	switch {
+	case name == "kubo" || name == "go-ipfs":
+		fp.Implementation = ImplementationKubo
+	case name == "hydra-booster":
+		fp.Implementation = ImplementationHydra
+	case slices.Contains(boxoAgents, name):
+		fp.Implementation = ImplementationBoxo
+	case strings.Contains(lower, "rust-libp2p"):
+		fp.Implementation = ImplementationRustLibp2p
+	case strings.Contains(lower, "js-libp2p") || name == "helia":
+		fp.Implementation = ImplementationJsLibp2p
+	case strings.Contains(lower, "nim-libp2p"):
+		fp.Implementation = ImplementationNimLibp2p
+	case strings.Contains(lower, "go-libp2p"):
+		fp.Implementation = ImplementationGoLibp2p
+	case agent == "":
+		fp.Implementation = classifyProtocols(protocols)
+	}
+	return fp
+}
+
+// Split an agent version like kubo/0.25.0/abcdef into its name and version
+func splitAgent(agent string) (string, string) {
+	parts := strings.Split(agent, "/")
+	if len(parts) >= 2 && parts[0] != "" && !strings.Contains(parts[0], ".") {
+		return parts[0], strings.TrimPrefix(parts[1], "v")
+	}
+	return agent, ""
+}
+
+// Best effort classification of peers without an agent version
+func classifyProtocols(protocols []protocol.ID) Implementation {
+	has := func(prefix string) bool {
+		return slices.ContainsFunc(protocols, func(p protocol.ID) bool { return strings.HasPrefix(string(p), prefix) })
+	}
+	switch {
+	case has("/ipfs/bitswap") && has("/libp2p/autonat"):
+		return ImplementationBoxo
+	case has("/libp2p/circuit/relay/0.2.0/stop") && has("/ipfs/id/push"):
+		return ImplementationGoLibp2p
+	default:
+		return ImplementationUnknown
+	}
+}
@@ -0,0 +1,56 @@
+package walker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		agent string
+		impl  Implementation
+		name  string
+		ver   string
+	}{
+		{"kubo/0.25.0/413a52d", ImplementationKubo, "kubo", "0.25.0"},
+		{"go-ipfs/0.12.2/", ImplementationKubo, "go-ipfs", "0.12.2"},
+		{"rainbow/1.2.0", ImplementationBoxo, "rainbow", "1.2.0"},
+		{"rust-libp2p/0.44.0", ImplementationRustLibp2p, "rust-libp2p", "0.44.0"},
+		{"helia/4.0.0 js-libp2p/1.2.0", ImplementationJsLibp2p, "helia", "4.0.0 js-libp2p"},
+		{"github.com/libp2p/go-libp2p", ImplementationGoLibp2p, "github.com/libp2p/go-libp2p", ""},
+		{"something-else", ImplementationUnknown, "something-else", ""},
+	}
+	for _, c := range cases {
+		fp := Classify(c.agent, nil)
+		assert.Equal(t, c.impl, fp.Implementation, c.agent)
+		assert.Equal(t, c.name, fp.Agent, c.agent)
+		assert.Equal(t, c.ver, fp.Version, c.agent)
+	}
+}
+
+func TestIdentifyTracker(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	require.NoError(t, err)
+	defer mn.Close()
+	h1, h2 := mn.Hosts()[0], mn.Hosts()[1]
+
+	tracker, err := newIdentifyTracker(h1)
+	require.NoError(t, err)
+	w := tracker.watch(h2.ID())
+	defer tracker.forget(h2.ID(), w)
+	require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id := w.wait(ctx)
+	require.NotNil(t, id)
+	assert.NotEmpty(t, id.AgentVersion)
+	assert.NotEmpty(t, id.Protocols)
+	assert.Equal(t, h2.Addrs(), id.ListenAddrs)
+}
@@ -0,0 +1,184 @@
+package walker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// How long to wait for the identify event of a peer once its dht requests are done.
+// The host identifies a connection before opening streams on it so the event is usually already published.
+const identifyEventTimeout = time.Second
+
+// Identify payload sent by a peer and the state of the connection it was received on
+type Identify struct {
+	ProtocolVersion string                `json:"protocol_version"`
+	AgentVersion    string                `json:"agent_version"`
+	ListenAddrs     []multiaddr.Multiaddr `json:"listen_addrs"`
+	// Our address as observed by the peer
+	ObservedAddr multiaddr.Multiaddr `json:"observed_addr,omitempty"`
+	Protocols    []protocol.ID       `json:"protocols"`
+	// Nil if the peer did not send a signed peer record or it was invalid
+	SignedRecord *SignedRecord `json:"signed_record,omitempty"`
+	// Transports of the listen addresses, ex: tcp, quic-v1, webtransport
+	Transports []string `json:"transports"`
+
+	// Negotiated on the connection used to identify the peer
+	Transport string      `json:"transport"`
+	Security  protocol.ID `json:"security"`
+	Muxer     protocol.ID `json:"muxer"`
+}
+
+type SignedRecord struct {
+	Seq   uint64                `json:"seq"`
+	Addrs []multiaddr.Multiaddr `json:"addrs"`
+}
+
+// Identify payloads published on the host's event bus for the peers being walked
+type identifyTracker struct {
+	mu      sync.Mutex
+	waiting map[peer.ID]*identifyWait
+}
+
+type identifyWait struct {
+	done     chan struct{}
+	identify *Identify
+}
+
+// Subscribe to the identify events of the host, the subscription lives as long as the host
+func newIdentifyTracker(h host.Host) (*identifyTracker, error) {
+	sub, err := h.EventBus().Subscribe([]interface{}{
+		new(event.EvtPeerIdentificationCompleted),
+		new(event.EvtPeerIdentificationFailed),
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := &identifyTracker{waiting: make(map[peer.ID]*identifyWait)}
+	go func() {
+		for e := range sub.Out() {
+			switch evt := e.(type) {
+			case event.EvtPeerIdentificationCompleted:
+				t.done(evt.Peer, IdentifyFromEvent(evt))
+			case event.EvtPeerIdentificationFailed:
+				t.done(evt.Peer, nil)
+			}
+		}
+	}()
+	return t, nil
+}
+
+// Start recording the identify payload of a peer, must be called before connecting to it
+func (t *identifyTracker) watch(pid peer.ID) *identifyWait {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := &identifyWait{done: make(chan struct{})}
+	t.waiting[pid] = w
+	return w
+}
+
+// Stop recording the identify payload of a peer
+func (t *identifyTracker) forget(pid peer.ID, w *identifyWait) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.waiting[pid] == w {
+		delete(t.waiting, pid)
+	}
+}
+
+func (t *identifyTracker) done(pid peer.ID, identify *Identify) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := t.waiting[pid]
+	if w == nil {
+		return
+	}
+	select {
+	case <-w.done:
+		// only the first identification of the walk is kept
+	default:
+		w.identify = identify
+		close(w.done)
+	}
+}
+
+// Identify payload of the peer, nil if the host failed to identify it before the context is done
+func (w *identifyWait) wait(ctx context.Context) *Identify {
+	select {
+	case <-w.done:
+		return w.identify
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// Identify payload of a peer identified by the host, as published on the host's event bus
+func IdentifyFromEvent(evt event.EvtPeerIdentificationCompleted) *Identify {
+	id := &Identify{
+		ProtocolVersion: evt.ProtocolVersion,
+		AgentVersion:    evt.AgentVersion,
+		ListenAddrs:     evt.ListenAddrs,
+		ObservedAddr:    evt.ObservedAddr,
+		Protocols:       evt.Protocols,
+		Transports:      transportsOf(evt.ListenAddrs),
+	}
+	if evt.SignedPeerRecord != nil {
+		if rec, err := evt.SignedPeerRecord.Record(); err == nil {
+			if prec, ok := rec.(*peer.PeerRecord); ok && prec.PeerID == evt.Peer {
+				id.SignedRecord = &SignedRecord{Seq: prec.Seq, Addrs: prec.Addrs}
+			}
+		}
+	}
+	if evt.Conn != nil {
+		state := evt.Conn.ConnState()
+		id.Transport = state.Transport
+		id.Security = state.Security
+		id.Muxer = state.StreamMultiplexer
+	}
+	return id
+}
+
+// Name of the transport of each address, without duplicates
+func transportsOf(addrs []multiaddr.Multiaddr) []string {
+	transports := make([]string, 0)
+	for _, addr := range addrs {
+		transport := transportOf(addr)
+		if transport != "" && !slices.Contains(transports, transport) {
+			transports = append(transports, transport)
+		}
+	}
+	slices.Sort(transports)
+	return transports
+}
+
+func transportOf(addr multiaddr.Multiaddr) string {
+	has := func(code int) bool {
+		_, err := addr.ValueForProtocol(code)
+		return err == nil
+	}
+	switch {
+	case has(multiaddr.P_CIRCUIT):
+		return "p2p-circuit"
+	case has(multiaddr.P_WEBTRANSPORT):
+		return "webtransport"
+	case has(multiaddr.P_WEBRTC_DIRECT):
+		return "webrtc-direct"
+	case has(multiaddr.P_QUIC_V1):
+		return "quic-v1"
+	case has(multiaddr.P_QUIC):
+		return "quic"
+	case has(multiaddr.P_WS) || has(multiaddr.P_WSS):
+		return "websocket"
+	case has(multiaddr.P_TCP):
+		return "tcp"
+	default:
+		return ""
+	}
+}
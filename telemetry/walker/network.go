@@ -0,0 +1,54 @@
+package walker
+
+import (
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// Kad protocol suffix appended to network protocol prefixes
+const kadProtocolSuffix = "/kad/1.0.0"
+
+const (
+	NetworkNameAmino = "amino"
+	NetworkNameLan   = "lan"
+)
+
+// A dht network, identified by the protocols its peers speak
+type Network struct {
+	Name      string          `json:"name"`
+	Protocols []protocol.ID   `json:"protocols"`
+	Seeds     []peer.AddrInfo `json:"seeds"`
+}
+
+// Create a network whose protocols are the kad protocol under each of the given prefixes
+func NewNetwork(name string, prefixes []protocol.ID, seeds []peer.AddrInfo) Network {
+	protocols := make([]protocol.ID, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		protocols = append(protocols, KadProtocol(prefix))
+	}
+	return Network{
+		Name:      name,
+		Protocols: protocols,
+		Seeds:     seeds,
+	}
+}
+
+// The kad dht protocol under the given prefix, ex: /ipfs -> /ipfs/kad/1.0.0
+func KadProtocol(prefix protocol.ID) protocol.ID {
+	return prefix + kadProtocolSuffix
+}
+
+// The public ipfs dht
+func NetworkAmino() Network {
+	return Network{
+		Name:      NetworkNameAmino,
+		Protocols: dht.DefaultProtocols,
+		Seeds:     dht.GetDefaultBootstrapPeerAddrInfos(),
+	}
+}
+
+// The ipfs lan dht. It has no well known seeds, they must be provided.
+func NetworkLan(seeds []peer.AddrInfo) Network {
+	return NewNetwork(NetworkNameLan, []protocol.ID{"/ipfs/lan"}, seeds)
+}
@@ -0,0 +1,14 @@
+package walker
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNetwork(t *testing.T) {
+	network := NewNetwork("test", []protocol.ID{"/ipfs/lan", "/custom"}, nil)
+	assert.Equal(t, []protocol.ID{"/ipfs/lan/kad/1.0.0", "/custom/kad/1.0.0"}, network.Protocols)
+	assert.Equal(t, []protocol.ID{"/ipfs/lan/kad/1.0.0"}, NetworkLan(nil).Protocols)
+}
@@ -16,7 +16,9 @@ type BucketEntry struct {
 }
 
 type Peer struct {
-	ID              peer.ID               `json:"id"`
+	ID peer.ID `json:"id"`
+	// Name of the network the peer was found in
+	Network         string                `json:"network"`
 	Addresses       []multiaddr.Multiaddr `json:"addresses"`
 	Agent           string                `json:"agent"`
 	Protocols       []protocol.ID         `json:"protocols"`
@@ -24,6 +26,11 @@ type Peer struct {
 	Requests        []Request             `json:"requests"`
 	ConnectStart    time.Time             `json:"connect_start"`
 	ConnectDuration time.Duration         `json:"connect_duration"`
+	// Nil if identify was disabled or failed
+	Identify    *Identify   `json:"identify,omitempty"`
+	Fingerprint Fingerprint `json:"fingerprint"`
+	// Number of dht messages of each type received from the peer, only set by passive discovery
+	Messages map[string]uint64 `json:"messages,omitempty"`
 }
 
 func (p *Peer) ContainsProtocol(id protocol.ID) bool {
@@ -37,9 +44,14 @@ type Request struct {
 
 type Error struct {
 	ID        peer.ID               `json:"id"`
+	Network   string                `json:"network"`
 	Addresses []multiaddr.Multiaddr `json:"addresses"`
 	Time      time.Time             `json:"time"`
 	Err       error                 `json:"error"`
+	Stage     Stage                 `json:"stage"`
+	Reason    FailureReason         `json:"reason"`
+	// Failure of each dialed address, only set for connect failures
+	Dials []DialFailure `json:"dials,omitempty"`
 }
 
 type Observer interface {
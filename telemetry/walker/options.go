@@ -1,15 +1,24 @@
 package walker
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/diogo464/telemetry/walker/preimage"
 	"github.com/libp2p/go-libp2p"
-	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 )
 
+const (
+	DEFAULT_CONNECT_TIMEOUT = time.Second * 5
+	DEFAULT_REQUEST_TIMEOUT = time.Second * 25
+	DEFAULT_INTERVAL        = time.Millisecond * 20
+	DEFAULT_CONCURRENCY     = 128
+)
+
 type Option func(*options) error
 
 type options struct {
@@ -18,9 +27,15 @@ type options struct {
 	requestTimeout time.Duration
 	interval       time.Duration
 	concurrency    uint
-	seeds          []peer.AddrInfo
+	network        Network
 	observer       Observer
 	addrFilter     AddressFilter
+	preimageTable  *preimage.Table
+	identify       bool
+	maxInterval    time.Duration
+	groupLimits    []groupLimit
+	budget         *ConnectionBudget
+	dialCache      *DialCache
 }
 
 func WithHost(h host.Host) Option {
@@ -64,7 +79,32 @@ func WithConcurrency(concurrency uint) Option {
 
 func WithSeeds(seeds []peer.AddrInfo) Option {
 	return func(c *options) error {
-		c.seeds = seeds
+		c.network.Seeds = seeds
+		return nil
+	}
+}
+
+// Dht protocols used to query peers, tried in order
+func WithProtocols(protocols ...protocol.ID) Option {
+	return func(c *options) error {
+		if len(protocols) == 0 {
+			return fmt.Errorf("at least one dht protocol is required")
+		}
+		c.network.Protocols = protocols
+		return nil
+	}
+}
+
+// The network to crawl. Replaces the name, protocols and seeds.
+func WithNetwork(network Network) Option {
+	return func(c *options) error {
+		if network.Name == "" {
+			return fmt.Errorf("network name is required")
+		}
+		if len(network.Protocols) == 0 {
+			return fmt.Errorf("network %q has no dht protocols", network.Name)
+		}
+		c.network = network
 		return nil
 	}
 }
@@ -83,14 +123,76 @@ func WithAddressFilter(filter AddressFilter) Option {
 	}
 }
 
+// Table used to generate the keys that cover every bucket of a peer's routing table.
+// A table with the default number of bits is generated when the walker is created if none is provided.
+func WithPreimageTable(table *preimage.Table) Option {
+	return func(c *options) error {
+		if table == nil {
+			return fmt.Errorf("preimage table is required")
+		}
+		if err := table.Verify(); err != nil {
+			return err
+		}
+		c.preimageTable = table
+		return nil
+	}
+}
+
+// Record the identify payload of every peer from the identification done by the host when connecting, enabled by default
+func WithIdentify(enabled bool) Option {
+	return func(c *options) error {
+		c.identify = enabled
+		return nil
+	}
+}
+
+// Adapt the interval between peer requests to request timeouts, the interval never exceeds max.
+// Disabled by default.
+func WithAdaptivePacing(max time.Duration) Option {
+	return func(c *options) error {
+		c.maxInterval = max
+		return nil
+	}
+}
+
+// Walk at most limit peers of the same group at the same time, ex: of the same autonomous system.
+// Can be used more than once, a peer is only walked when none of its groups are at the limit.
+func WithGroupLimit(group GroupFunc, limit int) Option {
+	return func(c *options) error {
+		if limit < 1 {
+			return fmt.Errorf("invalid group limit %v", limit)
+		}
+		c.groupLimits = append(c.groupLimits, groupLimit{group: group, limit: limit})
+		return nil
+	}
+}
+
+// Limit the number of open connections. The default host is also created with this limit.
+// Peers wait for a free slot before their connect timeout starts.
+func WithConnectionBudget(budget *ConnectionBudget) Option {
+	return func(c *options) error {
+		c.budget = budget
+		return nil
+	}
+}
+
+// Skip peers whose connection failed recently, disabled by default
+func WithDialCache(cache *DialCache) Option {
+	return func(c *options) error {
+		c.dialCache = cache
+		return nil
+	}
+}
+
 func defaults(c *options) {
-	c.connectTimeout = time.Second * 5
-	c.requestTimeout = time.Second * 25
-	c.interval = time.Millisecond * 20
-	c.concurrency = 128
-	c.seeds = dht.GetDefaultBootstrapPeerAddrInfos()
+	c.connectTimeout = DEFAULT_CONNECT_TIMEOUT
+	c.requestTimeout = DEFAULT_REQUEST_TIMEOUT
+	c.interval = DEFAULT_INTERVAL
+	c.concurrency = DEFAULT_CONCURRENCY
+	c.network = NetworkAmino()
 	c.observer = &NullObserver{}
 	c.addrFilter = AddressFilterPublic
+	c.identify = true
 }
 
 func apply(c *options, opts ...Option) error {
@@ -102,8 +204,13 @@ func apply(c *options, opts ...Option) error {
 	return nil
 }
 
-func newDefaultHost() (host.Host, error) {
+func newDefaultHost(budget *ConnectionBudget) (host.Host, error) {
 	limits := rcmgr.InfiniteLimits
+	if budget != nil {
+		limits = rcmgr.PartialLimitConfig{
+			System: rcmgr.ResourceLimits{ConnsOutbound: rcmgr.LimitVal(budget.Size())},
+		}.Build(rcmgr.InfiniteLimits)
+	}
 	limiter := rcmgr.NewFixedLimiter(limits)
 	rm, err := rcmgr.NewResourceManager(limiter)
 	if err != nil {
@@ -0,0 +1,261 @@
+package walker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Adaptive interval between peer requests.
+// Every timeout doubles the interval up to max, every success lowers it by the base interval until it is back to base.
+// Only request timeouts are counted, connect timeouts are common for unreachable peers and say nothing about load.
+type pacer struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func newPacer(base, max time.Duration) *pacer {
+	return &pacer{base: base, max: max, current: base}
+}
+
+func (p *pacer) interval() time.Duration {
+	return p.current
+}
+
+func (p *pacer) observe(err *Error) {
+	if p.max <= p.base {
+		return
+	}
+	if err != nil && err.Stage == StageRequest && err.Reason == FailureTimeout {
+		p.current = min(p.max, p.current*2)
+	} else {
+		p.current = max(p.base, p.current-p.base)
+	}
+}
+
+// Maps an ip to the group it belongs to, ex: its autonomous system or network prefix
+type GroupFunc func(ip net.IP) (string, bool)
+
+// Group ips by their network prefix
+func GroupByPrefix(v4Bits, v6Bits int) GroupFunc {
+	v4Mask := net.CIDRMask(v4Bits, 32)
+	v6Mask := net.CIDRMask(v6Bits, 128)
+	return func(ip net.IP) (string, bool) {
+		if ip4 := ip.To4(); ip4 != nil {
+			return (&net.IPNet{IP: ip4.Mask(v4Mask), Mask: v4Mask}).String(), true
+		}
+		return (&net.IPNet{IP: ip.Mask(v6Mask), Mask: v6Mask}).String(), true
+	}
+}
+
+// Group ips by their autonomous system
+func GroupByASN(lookup func(ip net.IP) (uint, bool)) GroupFunc {
+	return func(ip net.IP) (string, bool) {
+		asn, ok := lookup(ip)
+		if !ok || asn == 0 {
+			return "", false
+		}
+		return "AS" + strconv.FormatUint(uint64(asn), 10), true
+	}
+}
+
+type groupLimit struct {
+	group GroupFunc
+	limit int
+}
+
+// Number of peers being walked in each group
+type groupCounter struct {
+	limits []groupLimit
+	counts map[string]int
+}
+
+func newGroupCounter(limits []groupLimit) *groupCounter {
+	return &groupCounter{limits: limits, counts: make(map[string]int)}
+}
+
+// Groups of every ip a peer will be dialed on, keys are prefixed with the index of their limit
+func (g *groupCounter) groupsOf(addrs []multiaddr.Multiaddr) []string {
+	if len(g.limits) == 0 {
+		return nil
+	}
+	groups := make([]string, 0)
+	for _, addr := range addrs {
+		if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err == nil {
+			continue
+		}
+		ip, err := manet.ToIP(addr)
+		if err != nil {
+			continue
+		}
+		for i, limit := range g.limits {
+			if group, ok := limit.group(ip); ok {
+				key := strconv.Itoa(i) + "/" + group
+				if !slices.Contains(groups, key) {
+					groups = append(groups, key)
+				}
+			}
+		}
+	}
+	return groups
+}
+
+func (g *groupCounter) allowed(groups []string) bool {
+	for _, key := range groups {
+		if g.counts[key] >= g.limitOf(key) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *groupCounter) acquire(groups []string) {
+	for _, key := range groups {
+		g.counts[key] += 1
+	}
+}
+
+func (g *groupCounter) release(groups []string) {
+	for _, key := range groups {
+		if g.counts[key] -= 1; g.counts[key] <= 0 {
+			delete(g.counts, key)
+		}
+	}
+}
+
+func (g *groupCounter) limitOf(key string) int {
+	prefix, _, _ := strings.Cut(key, "/")
+	idx, _ := strconv.Atoi(prefix)
+	return g.limits[idx].limit
+}
+
+// Maximum number of connections the walker opens at the same time.
+// A budget can be shared by several walkers using the same host.
+type ConnectionBudget struct {
+	slots chan struct{}
+}
+
+func NewConnectionBudget(connections int) *ConnectionBudget {
+	return &ConnectionBudget{slots: make(chan struct{}, connections)}
+}
+
+func (b *ConnectionBudget) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *ConnectionBudget) release() {
+	<-b.slots
+}
+
+// Size of the budget
+func (b *ConnectionBudget) Size() int {
+	return cap(b.slots)
+}
+
+// Caches connection failures so that unreachable peers are not dialed again by the next crawls.
+// A cache can be shared by several walkers and outlives a single walk.
+type DialCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[peer.ID]*dialCacheEntry
+}
+
+type dialCacheEntry struct {
+	time time.Time
+	err  *Error
+}
+
+func NewDialCache(ttl time.Duration, maxEntries int) *DialCache {
+	return &DialCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[peer.ID]*dialCacheEntry),
+	}
+}
+
+// Cached connection failure of a peer, reported as a failure in the given network. Nil if the peer has not failed recently.
+func (c *DialCache) get(pid peer.ID, network string, now time.Time) *Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[pid]
+	if !ok {
+		return nil
+	}
+	if now.Sub(entry.time) > c.ttl {
+		delete(c.entries, pid)
+		return nil
+	}
+	cached := *entry.err
+	cached.Network = network
+	cached.Time = now
+	cached.Err = fmt.Errorf("dial failure cached at %v: %w", entry.time.Format(time.RFC3339), entry.err.Err)
+	return &cached
+}
+
+// Record the outcome of a connection attempt, only failures that indicate the peer is unreachable are cached
+func (c *DialCache) put(pid peer.ID, err *Error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil || err.Stage != StageConnect || !cacheableReason(err.Reason) {
+		delete(c.entries, pid)
+		return
+	}
+	if len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[pid] = &dialCacheEntry{time: now, err: err}
+}
+
+// Remove expired entries, or the oldest entry if none expired
+func (c *DialCache) evict(now time.Time) {
+	var oldest peer.ID
+	var oldestTime time.Time
+	for pid, entry := range c.entries {
+		if now.Sub(entry.time) > c.ttl {
+			delete(c.entries, pid)
+			continue
+		}
+		if oldestTime.IsZero() || entry.time.Before(oldestTime) {
+			oldest, oldestTime = pid, entry.time
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// Number of cached failures
+func (c *DialCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Local failures, like resource limits or canceled walks, say nothing about the peer
+func cacheableReason(reason FailureReason) bool {
+	switch reason {
+	case FailureResourceLimit, FailureCanceled:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,140 @@
+package walker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/test"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacer(t *testing.T) {
+	p := newPacer(10*time.Millisecond, 50*time.Millisecond)
+	timeout := &Error{Stage: StageRequest, Reason: FailureTimeout}
+
+	p.observe(timeout)
+	assert.Equal(t, 20*time.Millisecond, p.interval())
+	p.observe(timeout)
+	p.observe(timeout)
+	assert.Equal(t, 50*time.Millisecond, p.interval())
+
+	// connect timeouts do not slow down the walk
+	p.observe(&Error{Stage: StageConnect, Reason: FailureTimeout})
+	assert.Equal(t, 40*time.Millisecond, p.interval())
+	for i := 0; i < 10; i++ {
+		p.observe(nil)
+	}
+	assert.Equal(t, 10*time.Millisecond, p.interval())
+
+	disabled := newPacer(10*time.Millisecond, 0)
+	disabled.observe(timeout)
+	assert.Equal(t, 10*time.Millisecond, disabled.interval())
+}
+
+func TestGroupLimits(t *testing.T) {
+	asns := map[string]uint{"1.1.1.1": 13335, "1.0.0.1": 13335}
+	counter := newGroupCounter([]groupLimit{
+		{group: GroupByPrefix(24, 48), limit: 2},
+		{group: GroupByASN(func(ip net.IP) (uint, bool) { asn, ok := asns[ip.String()]; return asn, ok }), limit: 1},
+	})
+
+	a := counter.groupsOf([]multiaddr.Multiaddr{multiaddr.StringCast("/ip4/1.1.1.1/tcp/4001"), multiaddr.StringCast("/ip4/1.1.1.1/udp/4001/quic-v1")})
+	assert.Equal(t, []string{"0/1.1.1.0/24", "1/AS13335"}, a)
+	b := counter.groupsOf([]multiaddr.Multiaddr{multiaddr.StringCast("/ip4/1.0.0.1/tcp/4001")})
+	c := counter.groupsOf([]multiaddr.Multiaddr{multiaddr.StringCast("/ip4/1.1.1.2/tcp/4001")})
+	d := counter.groupsOf([]multiaddr.Multiaddr{multiaddr.StringCast("/ip6/2001:db8::1/tcp/4001")})
+	assert.Equal(t, []string{"0/2001:db8::/48"}, d)
+
+	require.True(t, counter.allowed(a))
+	counter.acquire(a)
+	assert.False(t, counter.allowed(b), "same autonomous system")
+	assert.True(t, counter.allowed(c))
+	counter.acquire(c)
+	assert.False(t, counter.allowed(c), "prefix at its limit")
+	counter.release(a)
+	assert.True(t, counter.allowed(b))
+	assert.True(t, counter.allowed(c))
+}
+
+func TestDialCache(t *testing.T) {
+	cache := NewDialCache(time.Minute, 2)
+	now := time.Now()
+	p1, p2, p3 := test.RandPeerIDFatal(t), test.RandPeerIDFatal(t), test.RandPeerIDFatal(t)
+	failure := func() *Error {
+		return &Error{Stage: StageConnect, Reason: FailureConnectionRefused, Err: errors.New("connection refused")}
+	}
+
+	cache.put(p1, failure(), now)
+	cached := cache.get(p1, NetworkNameLan, now.Add(time.Second))
+	require.NotNil(t, cached)
+	assert.Equal(t, FailureConnectionRefused, cached.Reason)
+	assert.Equal(t, NetworkNameLan, cached.Network)
+	assert.Nil(t, cache.get(p1, NetworkNameLan, now.Add(2*time.Minute)))
+
+	cache.put(p1, failure(), now)
+	cache.put(p1, nil, now)
+	assert.Nil(t, cache.get(p1, NetworkNameLan, now), "a successful connection clears the failure")
+	cache.put(p1, &Error{Stage: StageConnect, Reason: FailureResourceLimit}, now)
+	assert.Equal(t, 0, cache.Len(), "local failures are not cached")
+
+	cache.put(p1, failure(), now)
+	cache.put(p2, failure(), now.Add(time.Second))
+	cache.put(p3, failure(), now.Add(2*time.Second))
+	assert.Equal(t, 2, cache.Len())
+	assert.Nil(t, cache.get(p1, NetworkNameLan, now.Add(2*time.Second)), "oldest entry is evicted")
+}
+
+func TestConnectionBudget(t *testing.T) {
+	budget := NewConnectionBudget(1)
+	require.NoError(t, budget.acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, budget.acquire(ctx), context.DeadlineExceeded)
+
+	budget.release()
+	require.NoError(t, budget.acquire(context.Background()))
+}
+
+func TestWalkPeerWaitsForBudget(t *testing.T) {
+	mn := mocknet.New()
+	defer mn.Close()
+	h, err := mn.GenPeer()
+	require.NoError(t, err)
+
+	budget := NewConnectionBudget(1)
+	opts := new(options)
+	defaults(opts)
+	require.NoError(t, apply(opts, WithConnectTimeout(10*time.Millisecond), WithConnectionBudget(budget)))
+	w := &implWalker{h: h, opts: opts}
+	pid, err := test.RandPeerID()
+	require.NoError(t, err)
+
+	// the peer waits for a slot longer than the connect timeout without failing
+	require.NoError(t, budget.acquire(context.Background()))
+	cresult := make(chan walkResult, 1)
+	go func() { cresult <- w.walkPeerTask(context.Background(), pendingPeer{peer: pid}) }()
+	select {
+	case <-cresult:
+		t.Fatal("peer failed while waiting for a connection slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	budget.release()
+	result := <-cresult
+	require.NotNil(t, result.err)
+	assert.NotEqual(t, FailureResourceLimit, result.err.Reason)
+
+	// waiting only fails when the walk is cancelled
+	require.NoError(t, budget.acquire(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result = w.walkPeerTask(ctx, pendingPeer{peer: pid})
+	require.NotNil(t, result.err)
+	assert.Equal(t, FailureCanceled, result.err.Reason)
+}
@@ -0,0 +1,66 @@
+package preimage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Read and verify a table written by WriteFile
+func ReadFile(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := new(Table)
+	if err := t.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTable, err)
+	}
+	if err := t.Verify(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Write the table to path, replacing any existing file atomically
+func WriteFile(path string, t *Table) error {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read the table cached at path. If the file does not exist, is invalid or has a different number of bits
+// a new table is generated and written to path.
+func LoadOrGenerate(path string, bits int) (*Table, error) {
+	t, err := ReadFile(path)
+	if err == nil && t.Bits() == bits {
+		return t, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, ErrInvalidTable) {
+		return nil, err
+	}
+
+	t = GenerateWithBits(bits)
+	if err := WriteFile(path, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"io"
 	"runtime"
 
@@ -20,6 +21,8 @@ const (
 	invalidPeerID = peer.ID("")
 )
 
+var ErrInvalidTable = fmt.Errorf("invalid preimage table")
+
 type prefixedPeerID struct {
 	ID     peer.ID
 	Prefix int
@@ -65,7 +68,8 @@ func (t *Table) MarshalJSON() ([]byte, error) {
 func (t *Table) UnmarshalBinary(compressed []byte) error {
 	freader := flate.NewReader(bytes.NewReader(compressed))
 	marshaled, err := io.ReadAll(freader)
-	if err != nil {
+	// tables used to be written without the final flate block, the json decoder catches truncated data
+	if err != nil && err != io.ErrUnexpectedEOF {
 		return err
 	}
 	return json.Unmarshal(marshaled, t)
@@ -85,13 +89,37 @@ func (t *Table) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = fwriter.Flush()
+	err = fwriter.Close()
 	if err != nil {
 		return nil, err
 	}
 	return writer.Bytes(), nil
 }
 
+// Number of prefix bits covered by the table
+func (t *Table) Bits() int {
+	return t.bits
+}
+
+// Check that the table has an entry for every prefix and that every entry hashes to its prefix
+func (t *Table) Verify() error {
+	if t.bits < 1 || t.bits > MaxBits {
+		return fmt.Errorf("%w: invalid number of bits %v", ErrInvalidTable, t.bits)
+	}
+	if len(t.pids) != 1<<t.bits {
+		return fmt.Errorf("%w: expected %v entries, got %v", ErrInvalidTable, 1<<t.bits, len(t.pids))
+	}
+	for i, pid := range t.pids {
+		if pid == invalidPeerID {
+			return fmt.Errorf("%w: missing entry for prefix %v", ErrInvalidTable, i)
+		}
+		if prefix := prefixFromHash(t.bits, sha256.Sum256([]byte(pid))); prefix != i {
+			return fmt.Errorf("%w: entry %v has prefix %v", ErrInvalidTable, i, prefix)
+		}
+	}
+	return nil
+}
+
 func (t *Table) GetIDsForPeer(p peer.ID) []peer.ID {
 	ids := make([]peer.ID, 0, t.bits)
 	hash := sha256.Sum256([]byte(p))
@@ -112,7 +140,13 @@ func Generate() *Table {
 	return GenerateWithBits(DefaultBits)
 }
 
+// Generate a table with an entry for every prefix of the given number of bits.
+// Panics if bits is not in [1, MaxBits].
 func GenerateWithBits(bits int) *Table {
+	if bits < 1 || bits > MaxBits {
+		panic(fmt.Sprintf("invalid number of preimage bits %v, must be between 1 and %v", bits, MaxBits))
+	}
+
 	t := make([]peer.ID, 1<<bits)
 	for i := range t {
 		t[i] = invalidPeerID
@@ -125,19 +159,16 @@ func GenerateWithBits(bits int) *Table {
 	// worker goroutines
 	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
 		go func(ctx context.Context) {
-		LOOP:
 			for {
 				p := generateRandomPeerID()
 				hash := sha256.Sum256([]byte(p))
-				prefix := int(hash[0])<<8 | int(hash[1])
-				work <- prefixedPeerID{ID: p, Prefix: prefix}
+				prefix := prefixFromHash(bits, hash)
 				select {
+				case work <- prefixedPeerID{ID: p, Prefix: prefix}:
 				case <-ctx.Done():
-					break LOOP
-				default:
+					return
 				}
 			}
-
 		}(ctx)
 	}
 
@@ -1,6 +1,8 @@
 package preimage
 
 import (
+	"errors"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -78,3 +80,50 @@ func TestBucketPlacement(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateWithBits(t *testing.T) {
+	for _, bits := range []int{1, 4, 9, 17} {
+		table := GenerateWithBits(bits)
+		if table.Bits() != bits {
+			t.Fatalf("expected %v bits, got %v", bits, table.Bits())
+		}
+		if err := table.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preimage.bin")
+
+	generated, err := LoadOrGenerate(path, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Bits() != 8 {
+		t.Fatalf("expected 8 bits, got %v", loaded.Bits())
+	}
+	for i := range generated.pids {
+		if generated.pids[i] != loaded.pids[i] {
+			t.Fatalf("entry %v differs after reading the table back", i)
+		}
+	}
+
+	// a cached table with a different number of bits is replaced
+	regenerated, err := LoadOrGenerate(path, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regenerated.Bits() != 6 {
+		t.Fatalf("expected 6 bits, got %v", regenerated.Bits())
+	}
+
+	loaded.pids[3] = loaded.pids[4]
+	if err := loaded.Verify(); !errors.Is(err, ErrInvalidTable) {
+		t.Fatalf("expected invalid table error, got %v", err)
+	}
+}
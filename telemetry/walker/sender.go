@@ -8,19 +8,25 @@ import (
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-msgio/pbio"
 )
 
 type MessageSender struct {
-	h host.Host
+	h         host.Host
+	protocols []protocol.ID
 }
 
-func NewMessageSender(h host.Host) *MessageSender {
-	return &MessageSender{h}
+// Create a message sender for the given dht protocols, the amino dht protocols are used if none are given
+func NewMessageSender(h host.Host, protocols ...protocol.ID) *MessageSender {
+	if len(protocols) == 0 {
+		protocols = dht.DefaultProtocols
+	}
+	return &MessageSender{h, protocols}
 }
 
 func (ms *MessageSender) SendRequest(ctx context.Context, p peer.ID, pmes *pb.Message) (*pb.Message, error) {
-	stream, err := ms.h.NewStream(ctx, p, dht.DefaultProtocols...)
+	stream, err := ms.h.NewStream(ctx, p, ms.protocols...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +47,7 @@ func (ms *MessageSender) SendRequest(ctx context.Context, p peer.ID, pmes *pb.Me
 }
 
 func (ms *MessageSender) SendMessage(ctx context.Context, p peer.ID, pmes *pb.Message) error {
-	stream, err := ms.h.NewStream(ctx, p, dht.DefaultProtocols...)
+	stream, err := ms.h.NewStream(ctx, p, ms.protocols...)
 	if err != nil {
 		return err
 	}
@@ -20,6 +20,8 @@ import (
 
 type Walker interface {
 	Walk(ctx context.Context) error
+	// Query a single peer without following its buckets or notifying the observer
+	WalkPeer(ctx context.Context, p peer.AddrInfo) (*Peer, *Error)
 }
 
 func New(opts ...Option) (Walker, error) {
@@ -29,11 +31,14 @@ func New(opts ...Option) (Walker, error) {
 type pendingPeer struct {
 	peer      peer.ID
 	realAddrs []multiaddr.Multiaddr
+	// groups counted against the group limits while the peer is walked
+	groups []string
 }
 
 type walkResult struct {
-	ok  *Peer
-	err *Error
+	ok     *Peer
+	err    *Error
+	groups []string
 }
 
 type implWalker struct {
@@ -41,7 +46,9 @@ type implWalker struct {
 	opts      *options
 	messenger *pb.ProtocolMessenger
 	table     *preimage.Table
-	wg        sync.WaitGroup
+	// nil if the identify payloads are not recorded
+	identified *identifyTracker
+	wg         sync.WaitGroup
 }
 
 func newImplWalker(opts ...Option) (*implWalker, error) {
@@ -51,16 +58,14 @@ func newImplWalker(opts ...Option) (*implWalker, error) {
 		return nil, err
 	}
 	if c.host == nil {
-		h, err := newDefaultHost()
+		h, err := newDefaultHost(c.budget)
 		if err != nil {
 			return nil, err
 		}
 		c.host = h
 	}
 
-	messenger, err := pb.NewProtocolMessenger(&MessageSender{
-		h: c.host,
-	})
+	messenger, err := pb.NewProtocolMessenger(NewMessageSender(c.host, c.network.Protocols...))
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +74,17 @@ func newImplWalker(opts ...Option) (*implWalker, error) {
 		h:         c.host,
 		opts:      c,
 		messenger: messenger,
-		table:     preimage.Generate(),
+		table:     c.preimageTable,
+	}
+	if walker.table == nil {
+		walker.table = preimage.Generate()
+	}
+	if c.identify {
+		identified, err := newIdentifyTracker(c.host)
+		if err != nil {
+			return nil, err
+		}
+		walker.identified = identified
 	}
 
 	return walker, nil
@@ -84,23 +99,31 @@ func (c *implWalker) Walk(ctx context.Context) error {
 	inprogress := 0
 	pending := vecdeque.New[pendingPeer]()
 	queried := make(map[peer.ID]struct{})
-	interval := time.NewTicker(c.opts.interval)
-
-	for _, addr := range c.opts.seeds {
+	pacer := newPacer(c.opts.interval, c.opts.maxInterval)
+	groups := newGroupCounter(c.opts.groupLimits)
+	// every pending peer is in a group at its limit, wait for a walk to finish
+	blocked := false
+	interval := time.NewTimer(pacer.interval())
+	defer interval.Stop()
+
+	for _, addr := range c.opts.network.Seeds {
 		c.h.Peerstore().AddAddrs(addr.ID, addr.Addrs, peerstore.PermanentAddrTTL)
-		pending.PushBack(pendingPeer{addr.ID, addr.Addrs})
+		pending.PushBack(pendingPeer{peer: addr.ID, realAddrs: addr.Addrs})
 		queried[addr.ID] = struct{}{}
 	}
 
 LOOP:
 	for pending.Len() > 0 || inprogress > 0 {
 		var intervalChan <-chan time.Time
-		if pending.Len() > 0 && inprogress < int(c.opts.concurrency) {
+		if pending.Len() > 0 && inprogress < int(c.opts.concurrency) && !blocked {
 			intervalChan = interval.C
 		}
 
 		select {
 		case result := <-cresult:
+			groups.release(result.groups)
+			blocked = false
+			pacer.observe(result.err)
 			if result.ok != nil {
 				c.opts.observer.ObservePeer(result.ok)
 				for _, addrinfo := range result.ok.Buckets {
@@ -114,7 +137,7 @@ LOOP:
 
 						c.h.Peerstore().AddAddrs(addrinfo.ID, addrs, peerstore.PermanentAddrTTL)
 						queried[addrinfo.ID] = struct{}{}
-						pending.PushBack(pendingPeer{addrinfo.ID, addrinfo.Addrs})
+						pending.PushBack(pendingPeer{peer: addrinfo.ID, realAddrs: addrinfo.Addrs})
 					}
 				}
 			} else {
@@ -122,8 +145,14 @@ LOOP:
 			}
 			inprogress -= 1
 		case <-intervalChan:
+			interval.Reset(pacer.interval())
+			pp, ok := c.nextPeer(pending, groups)
+			if !ok {
+				blocked = true
+				continue
+			}
+			groups.acquire(pp.groups)
 			inprogress += 1
-			pp := pending.PopFront()
 			c.walkPeer(ctx, cresult, pp)
 		case <-ctx.Done():
 			err = ctx.Err()
@@ -136,27 +165,87 @@ LOOP:
 	return err
 }
 
-func (c *implWalker) walkPeerTask(ctx context.Context, pp pendingPeer) walkResult {
-	connCtx, connCancel := context.WithTimeout(ctx, c.opts.connectTimeout)
-	defer connCancel()
+// Pop the first pending peer whose groups are below their limits, peers that can not be walked yet are moved to the back
+func (c *implWalker) nextPeer(pending *vecdeque.VecDeque[pendingPeer], groups *groupCounter) (pendingPeer, bool) {
+	for i := pending.Len(); i > 0; i-- {
+		pp := pending.PopFront()
+		if pp.groups == nil {
+			pp.groups = groups.groupsOf(c.h.Peerstore().Addrs(pp.peer))
+		}
+		if groups.allowed(pp.groups) {
+			return pp, true
+		}
+		pending.PushBack(pp)
+	}
+	return pendingPeer{}, false
+}
+
+// WalkPeer implements Walker
+func (c *implWalker) WalkPeer(ctx context.Context, p peer.AddrInfo) (*Peer, *Error) {
+	addrs := make([]multiaddr.Multiaddr, 0, len(p.Addrs))
+	for _, addr := range p.Addrs {
+		if c.opts.addrFilter(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	c.h.Peerstore().AddAddrs(p.ID, addrs, peerstore.PermanentAddrTTL)
+
+	result := c.walkPeerTask(ctx, pendingPeer{peer: p.ID, realAddrs: addrs})
+	return result.ok, result.err
+}
 
+func (c *implWalker) walkPeerTask(ctx context.Context, pp pendingPeer) walkResult {
 	pid := pp.peer
 	addrs := pp.realAddrs
 	walkStart := time.Now()
 	walkError := &Error{
 		ID:        pid,
+		Network:   c.opts.network.Name,
 		Addresses: addrs,
 		Time:      walkStart,
 		Err:       nil,
 	}
 
+	if c.opts.dialCache != nil {
+		if cached := c.opts.dialCache.get(pid, c.opts.network.Name, walkStart); cached != nil {
+			return walkResult{err: cached}
+		}
+	}
+	if c.opts.budget != nil {
+		// waiting for a slot is not part of the connect timeout, it only fails when the walk is cancelled
+		if err := c.opts.budget.acquire(ctx); err != nil {
+			walkError.Err = errors.Wrap(err, "Connection budget")
+			walkError.Stage = StageConnect
+			walkError.Reason = ClassifyError(err)
+			return walkResult{err: walkError}
+		}
+		defer c.opts.budget.release()
+	}
+
+	connCtx, connCancel := context.WithTimeout(ctx, c.opts.connectTimeout)
+	defer connCancel()
+
+	var identifying *identifyWait
+	if c.identified != nil {
+		identifying = c.identified.watch(pid)
+		defer c.identified.forget(pid, identifying)
+	}
+
 	connectStart := time.Now()
 	if err := c.h.Connect(connCtx, c.h.Peerstore().PeerInfo(pid)); err != nil {
 		walkError.Err = errors.Wrap(err, "Connect")
+		walkError.Stage = StageConnect
+		walkError.Reason, walkError.Dials = classifyFailure(StageConnect, err, addrs)
+		if c.opts.dialCache != nil {
+			c.opts.dialCache.put(pid, walkError, walkStart)
+		}
 		return walkResult{err: walkError}
 	}
 	connectDuration := time.Since(connectStart)
 	defer func() { _ = c.h.Network().ClosePeer(pid) }()
+	if c.opts.dialCache != nil {
+		c.opts.dialCache.put(pid, nil, walkStart)
+	}
 
 	reqCtx, reqCancel := context.WithTimeout(ctx, c.opts.requestTimeout)
 	defer reqCancel()
@@ -171,6 +260,8 @@ func (c *implWalker) walkPeerTask(ctx context.Context, pp pendingPeer) walkResul
 
 		if err != nil {
 			walkError.Err = errors.Wrap(err, "GetClosestPeers")
+			walkError.Stage = StageRequest
+			walkError.Reason, _ = classifyFailure(StageRequest, err, nil)
 			return walkResult{err: walkError}
 		}
 
@@ -197,6 +288,8 @@ func (c *implWalker) walkPeerTask(ctx context.Context, pp pendingPeer) walkResul
 		select {
 		case <-reqCtx.Done():
 			walkError.Err = errors.Wrap(reqCtx.Err(), "Context Done")
+			walkError.Stage = StageRequest
+			walkError.Reason = ClassifyError(reqCtx.Err())
 			return walkResult{err: walkError}
 		default:
 		}
@@ -210,8 +303,25 @@ func (c *implWalker) walkPeerTask(ctx context.Context, pp pendingPeer) walkResul
 	if err != nil {
 		protocols = []protocol.ID{}
 	}
+
+	// identify failures are not fatal, the peer already answered the dht requests
+	var identify *Identify
+	if identifying != nil {
+		identifyCtx, identifyCancel := context.WithTimeout(reqCtx, identifyEventTimeout)
+		identify = identifying.wait(identifyCtx)
+		identifyCancel()
+	}
+	if identify != nil {
+		if identify.AgentVersion != "" {
+			agent = identify.AgentVersion
+		}
+		if len(identify.Protocols) > 0 {
+			protocols = identify.Protocols
+		}
+	}
 	walkOk := &Peer{
 		ID:              pid,
+		Network:         c.opts.network.Name,
 		Addresses:       c.h.Peerstore().Addrs(pid),
 		Agent:           agent.(string),
 		Protocols:       protocols,
@@ -219,6 +329,8 @@ func (c *implWalker) walkPeerTask(ctx context.Context, pp pendingPeer) walkResul
 		Requests:        requests,
 		ConnectStart:    connectStart,
 		ConnectDuration: connectDuration,
+		Identify:        identify,
+		Fingerprint:     Classify(agent.(string), protocols),
 	}
 	return walkResult{ok: walkOk}
 }
@@ -227,6 +339,7 @@ func (c *implWalker) walkPeer(ctx context.Context, cresult chan<- walkResult, pp
 	c.wg.Add(1)
 	go func() {
 		res := c.walkPeerTask(ctx, pp)
+		res.groups = pp.groups
 		select {
 		case cresult <- res:
 		case <-ctx.Done():